@@ -1,14 +1,58 @@
 package configs
 
+import "net"
+
 type Config struct {
-	AppHost            string `env:"HOST" envDefault:"127.0.0.1"`
-	AppPort            string `env:"PORT" envDefault:"9090"`
-	DatabaseDsn        string `env:"DATABASE_DSN" envDefault:"host=localhost user=postgres password=password dbname=postgres port=5432 sslmode=disable"`
-	KafkaNetwork       string `env:"KAFKA_NETWORK" envDefault:"tcp"`
-	KafkaHost          string `env:"KAFKA_HOST" envDefault:"localhost"`
-	KafkaPort          string `env:"KAFKA_PORT" envDefault:"9092"`
-	KafkaTopic         string `env:"KAFKA_TOPIC" envDefault:"company-api"`
-	KafkaGroupId       string `env:"KAFKA_GROUP" envDefault:"compamy_api_group"`
-	KafkaWriteDeadline int    `env:"KAFKA_WRITE_DEADLINE" envDefault:"8"`
-	AccessTokenTTL     string `env:"ACCESS_TOKEN_TTL" envDefault:"120s"`
+	AppHost              string   `env:"HOST" envDefault:"127.0.0.1"`
+	AppPort              string   `env:"PORT" envDefault:"9090"`
+	DatabaseDsn          string   `env:"DATABASE_DSN" envDefault:"host=localhost user=postgres password=password dbname=postgres port=5432 sslmode=disable"`
+	KafkaNetwork         string   `env:"KAFKA_NETWORK" envDefault:"tcp"`
+	KafkaHost            string   `env:"KAFKA_HOST" envDefault:"localhost"`
+	KafkaPort            string   `env:"KAFKA_PORT" envDefault:"9092"`
+	KafkaTopic           string   `env:"KAFKA_TOPIC" envDefault:"company-api"`
+	KafkaCommandTopic    string   `env:"KAFKA_COMMAND_TOPIC" envDefault:"company-api-commands"`
+	KafkaDLQTopic        string   `env:"KAFKA_DLQ_TOPIC" envDefault:"company-api-commands-dlq"`
+	KafkaGroupId         string   `env:"KAFKA_GROUP" envDefault:"compamy_api_group"`
+	KafkaWriteDeadline   int      `env:"KAFKA_WRITE_DEADLINE" envDefault:"8"`
+	AccessTokenTTL       string   `env:"ACCESS_TOKEN_TTL" envDefault:"120s"`
+	RefreshTokenTTL      string   `env:"REFRESH_TOKEN_TTL" envDefault:"720h"`
+	KeysReloadInterval   string   `env:"JWT_KEYS_RELOAD_INTERVAL" envDefault:"1m"`
+	LoginFreeAttempts    int      `env:"LOGIN_FREE_ATTEMPTS" envDefault:"3"`
+	LoginMaxAttempts     int      `env:"LOGIN_MAX_ATTEMPTS" envDefault:"10"`
+	LoginMaxIPAttempts   int      `env:"LOGIN_MAX_IP_ATTEMPTS" envDefault:"50"`
+	LoginLockout         string   `env:"LOGIN_LOCKOUT" envDefault:"15m"`
+	ClientIPHeader       string   `env:"CLIENT_IP_HEADER"`
+	PasswordMinLength    int      `env:"PASSWORD_MIN_LENGTH" envDefault:"8"`
+	PasswordMinClasses   int      `env:"PASSWORD_MIN_CHARACTER_CLASSES" envDefault:"2"`
+	PasswordBreachedFile string   `env:"PASSWORD_BREACHED_FILE"`
+	PasswordResetTTL     string   `env:"PASSWORD_RESET_TTL" envDefault:"1h"`
+	NotifyFile           string   `env:"NOTIFY_FILE"`
+	MFAIssuer            string   `env:"MFA_ISSUER" envDefault:"company-api"`
+	MFAChallengeTTL      string   `env:"MFA_CHALLENGE_TTL" envDefault:"5m"`
+	OutboxPollInterval   string   `env:"OUTBOX_POLL_INTERVAL" envDefault:"1s"`
+	OutboxBatchSize      int      `env:"OUTBOX_BATCH_SIZE" envDefault:"100"`
+	WebhookInterval      string   `env:"WEBHOOK_POLL_INTERVAL" envDefault:"1s"`
+	WebhookTimeout       string   `env:"WEBHOOK_TIMEOUT" envDefault:"10s"`
+	WebhookMaxAttempts   int      `env:"WEBHOOK_MAX_ATTEMPTS" envDefault:"8"`
+	EventsBufferSize     int      `env:"EVENTS_BUFFER_SIZE" envDefault:"1000"`
+	CompanyRetention     string   `env:"COMPANY_RETENTION" envDefault:"720h"`
+	CompanyPurgeInterval string   `env:"COMPANY_PURGE_INTERVAL" envDefault:"1h"`
+	IdempotencyTTL       string   `env:"IDEMPOTENCY_TTL" envDefault:"24h"`
+	ImportBatchSize      int      `env:"IMPORT_BATCH_SIZE" envDefault:"500"`
+	DefaultUserRole      string   `env:"DEFAULT_USER_ROLE" envDefault:"viewer"`
+	AdminUserIds         []string `env:"ADMIN_USER_IDS" envSeparator:","`
+}
+
+// KafkaAddr returns the address of the Kafka broker on the configured network.
+func (c *Config) KafkaAddr() net.Addr {
+	return kafkaAddr{network: c.KafkaNetwork, address: net.JoinHostPort(c.KafkaHost, c.KafkaPort)}
 }
+
+type kafkaAddr struct {
+	network string
+	address string
+}
+
+func (a kafkaAddr) Network() string { return a.network }
+
+func (a kafkaAddr) String() string { return a.address }
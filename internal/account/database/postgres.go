@@ -0,0 +1,84 @@
+package database
+
+import (
+	"errors"
+	"githib.com/dkischenko/company-api/internal/account"
+	uerrors "githib.com/dkischenko/company-api/internal/errors"
+	"githib.com/dkischenko/company-api/models"
+	"githib.com/dkischenko/company-api/pkg/logger"
+	"gorm.io/gorm"
+	"time"
+)
+
+type postgres struct {
+	logger *logger.Logger
+	db     *gorm.DB
+}
+
+func NewStorage(db *gorm.DB, logger *logger.Logger) account.Repository {
+	return &postgres{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (p postgres) Transaction(fn func(r account.Repository) error) error {
+	return p.db.Transaction(func(tx *gorm.DB) error {
+		return fn(postgres{db: tx, logger: p.logger})
+	})
+}
+
+func (p postgres) GetUser(userId uint) (u models.User, err error) {
+	err = p.db.Where("id = ?", userId).First(&u).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return u, uerrors.ErrGetUser
+	}
+	return
+}
+
+func (p postgres) FindUser(name string) (u models.User, err error) {
+	err = p.db.Where("name = ?", name).First(&u).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return u, uerrors.ErrGetUser
+	}
+	return
+}
+
+func (p postgres) UpdatePassword(userId uint, passwordHash string) (err error) {
+	res := p.db.Model(&models.User{}).Where("id = ?", userId).Update("password_hash", passwordHash)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return uerrors.ErrGetUser
+	}
+	return nil
+}
+
+func (p postgres) CreateResetToken(t *models.PasswordResetToken) (err error) {
+	return p.db.Create(t).Error
+}
+
+func (p postgres) GetResetToken(hash string) (t models.PasswordResetToken, err error) {
+	err = p.db.Where("token_hash = ?", hash).First(&t).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return t, uerrors.ErrInvalidResetToken
+	}
+	return
+}
+
+func (p postgres) UseResetToken(t models.PasswordResetToken, at time.Time) (err error) {
+	// the token is only marked by the first of concurrent resets
+	res := p.db.Model(&models.PasswordResetToken{}).
+		Where("id = ? AND used_at IS NULL", t.Id).
+		Update("used_at", at)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return uerrors.ErrInvalidResetToken
+	}
+	return p.db.Model(&models.PasswordResetToken{}).
+		Where("user_id = ? AND used_at IS NULL", t.UserId).
+		Update("used_at", at).Error
+}
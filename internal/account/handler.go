@@ -0,0 +1,133 @@
+package account
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	uerrors "githib.com/dkischenko/company-api/internal/errors"
+	"githib.com/dkischenko/company-api/internal/middleware"
+	"githib.com/dkischenko/company-api/models"
+	"githib.com/dkischenko/company-api/pkg/logger"
+	"githib.com/dkischenko/company-api/pkg/password"
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+	"net/http"
+)
+
+const (
+	userPassword           = "/v1/users/me/password"
+	passwordResetRequest   = "/v1/password/reset-request"
+	passwordReset          = "/v1/password/reset"
+	headerContentType      = "Content-Type"
+	headerValueContentType = "application/json"
+)
+
+type handler struct {
+	logger  *logger.Logger
+	service IService
+}
+
+func NewHandler(logger *logger.Logger, service IService) *handler {
+	return &handler{
+		logger:  logger,
+		service: service,
+	}
+}
+
+// Register adds the routes of passwords. Changing the password has no scope,
+// so it can't be done with API keys.
+func (h handler) Register(router *mux.Router) {
+	middleware.HandleRoutes(router, []middleware.Route{
+		{Method: http.MethodPut, Path: userPassword, Role: models.RoleViewer, Handler: h.ChangePasswordHandler},
+		{Method: http.MethodPost, Path: passwordResetRequest, Handler: h.RequestPasswordResetHandler},
+		{Method: http.MethodPost, Path: passwordReset, Handler: h.ResetPasswordHandler},
+	})
+}
+
+func (h handler) ChangePasswordHandler(w http.ResponseWriter, r *http.Request) {
+	req := PasswordChangeRequest{}
+	if !h.decode(w, r, &req) {
+		return
+	}
+
+	err := h.service.ChangePassword(r.Context(), req.CurrentPassword, req.NewPassword)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RequestPasswordResetHandler is accepted for unknown users too,
+// so the answer doesn't tell which users exist.
+func (h handler) RequestPasswordResetHandler(w http.ResponseWriter, r *http.Request) {
+	req := ResetRequest{}
+	if !h.decode(w, r, &req) {
+		return
+	}
+
+	if err := h.service.RequestPasswordReset(r.Context(), req.Name); err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (h handler) ResetPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	req := PasswordResetRequest{}
+	if !h.decode(w, r, &req) {
+		return
+	}
+
+	if err := h.service.ResetPassword(r.Context(), req.Token, req.NewPassword); err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// decode reads the request into req and writes the error when it's malformed or invalid.
+func (h handler) decode(w http.ResponseWriter, r *http.Request, req interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		h.logger.Entry.Error("wrong json format")
+		h.writeError(w, http.StatusBadRequest, "wrong json format")
+		return false
+	}
+	if err := validator.New().Struct(req); err != nil {
+		h.logger.Entry.Errorf("got wrong password data: %+v", err)
+		h.writeError(w, http.StatusBadRequest, fmt.Sprintf("got wrong password data: %+v", err))
+		return false
+	}
+	return true
+}
+
+func (h handler) writeServiceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, password.ErrWeakPassword):
+		h.writeError(w, http.StatusBadRequest, fmt.Sprintf("got wrong password data: %s", errors.Unwrap(err)))
+	case errors.Is(err, uerrors.ErrInvalidResetToken):
+		h.writeError(w, http.StatusBadRequest, uerrors.ErrInvalidResetToken.Error())
+	case errors.Is(err, uerrors.ErrCheckUserPasswordHash):
+		h.writeError(w, http.StatusForbidden, "current password is wrong")
+	case errors.Is(err, uerrors.ErrGetUser):
+		h.writeError(w, http.StatusUnauthorized, "user not found")
+	default:
+		h.logger.Entry.Errorf("password request failed: %+v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func (h handler) writeError(w http.ResponseWriter, code int, message string) {
+	h.writeJSON(w, code, uerrors.ErrorResponse{Code: code, Message: message})
+}
+
+func (h handler) writeJSON(w http.ResponseWriter, code int, body interface{}) {
+	w.Header().Add(headerContentType, headerValueContentType)
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		h.logger.Entry.Errorf("problems with encoding data: %+v", err)
+	}
+}
@@ -0,0 +1,24 @@
+package account
+
+import (
+	"githib.com/dkischenko/company-api/models"
+	"time"
+)
+
+//go:generate mockgen -source=repository.go -destination=mocks/repository_mock.go
+type Repository interface {
+	// Transaction runs fn with a Repository bound to a single database transaction,
+	// which is committed when fn returns nil and rolled back otherwise.
+	Transaction(fn func(r Repository) error) error
+	// GetUser returns uerrors.ErrGetUser for unknown users.
+	GetUser(userId uint) (u models.User, err error)
+	// FindUser returns uerrors.ErrGetUser for unknown users.
+	FindUser(name string) (u models.User, err error)
+	UpdatePassword(userId uint, passwordHash string) (err error)
+	CreateResetToken(t *models.PasswordResetToken) (err error)
+	// GetResetToken returns uerrors.ErrInvalidResetToken for unknown hashes.
+	GetResetToken(hash string) (t models.PasswordResetToken, err error)
+	// UseResetToken marks the token and all other unused tokens of its user as used,
+	// it returns uerrors.ErrInvalidResetToken when the token was used already.
+	UseResetToken(t models.PasswordResetToken, at time.Time) (err error)
+}
@@ -0,0 +1,15 @@
+package account
+
+type PasswordChangeRequest struct {
+	CurrentPassword string `json:"currentPassword" validate:"required"`
+	NewPassword     string `json:"newPassword" validate:"required"`
+}
+
+type ResetRequest struct {
+	Name string `json:"name" validate:"required"`
+}
+
+type PasswordResetRequest struct {
+	Token       string `json:"token" validate:"required"`
+	NewPassword string `json:"newPassword" validate:"required"`
+}
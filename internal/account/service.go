@@ -0,0 +1,163 @@
+package account
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	uerrors "githib.com/dkischenko/company-api/internal/errors"
+	"githib.com/dkischenko/company-api/internal/notify"
+	"githib.com/dkischenko/company-api/models"
+	"githib.com/dkischenko/company-api/pkg/auth"
+	"githib.com/dkischenko/company-api/pkg/hasher"
+	"githib.com/dkischenko/company-api/pkg/logger"
+	"githib.com/dkischenko/company-api/pkg/password"
+	"strconv"
+	"time"
+)
+
+// resetTokenSize is the number of random bytes of a password reset token.
+const resetTokenSize = 32
+
+type Service struct {
+	logger    *logger.Logger
+	storage   Repository
+	notifier  notify.Notifier
+	passwords *password.Policy
+	resetTTL  time.Duration
+}
+
+//go:generate mockgen -source=service.go -destination=mocks/service_mock.go
+type IService interface {
+	// ChangePassword sets the password of the user of the request,
+	// who has to know the current password.
+	ChangePassword(ctx context.Context, currentPassword, newPassword string) (err error)
+	// RequestPasswordReset sends a reset token to the user with the name. Unknown
+	// names are ignored, so the request doesn't tell which users exist.
+	RequestPasswordReset(ctx context.Context, name string) (err error)
+	// ResetPassword sets the password of the user of the reset token, the token
+	// and all other tokens of the user can't be used again.
+	ResetPassword(ctx context.Context, resetToken, newPassword string) (err error)
+}
+
+// NewService creates the service of accounts, which checks new passwords by
+// the policy and sends reset tokens valid for resetTTL by the notifier.
+func NewService(logger *logger.Logger, storage Repository, notifier notify.Notifier, passwords *password.Policy,
+	resetTTL time.Duration) IService {
+	return &Service{
+		logger:    logger,
+		storage:   storage,
+		notifier:  notifier,
+		passwords: passwords,
+		resetTTL:  resetTTL,
+	}
+}
+
+func (s Service) ChangePassword(ctx context.Context, currentPassword, newPassword string) (err error) {
+	userId, err := strconv.ParseUint(auth.UserIdFromContext(ctx), 10, 32)
+	if err != nil {
+		return fmt.Errorf("error occurs: %w", uerrors.ErrGetUser)
+	}
+	u, err := s.storage.GetUser(uint(userId))
+	if errors.Is(err, uerrors.ErrGetUser) {
+		return fmt.Errorf("error occurs: %w", err)
+	}
+	if err != nil {
+		s.logger.Entry.Errorf("failed to get user %d: %s", userId, err)
+		return fmt.Errorf("error occurs: %w", uerrors.ErrChangePassword)
+	}
+	if !hasher.CheckPasswordHash(u.PasswordHash, currentPassword) {
+		return fmt.Errorf("error occurs: %w", uerrors.ErrCheckUserPasswordHash)
+	}
+
+	return s.setPassword(s.storage, u.Id, newPassword, uerrors.ErrChangePassword)
+}
+
+func (s Service) RequestPasswordReset(ctx context.Context, name string) (err error) {
+	u, err := s.storage.FindUser(name)
+	if errors.Is(err, uerrors.ErrGetUser) {
+		return nil
+	}
+	if err != nil {
+		s.logger.Entry.Errorf("failed to find user: %s", err)
+		return fmt.Errorf("error occurs: %w", uerrors.ErrResetPassword)
+	}
+
+	b := make([]byte, resetTokenSize)
+	if _, err = rand.Read(b); err != nil {
+		s.logger.Entry.Errorf("failed to generate password reset token: %s", err)
+		return fmt.Errorf("error occurs: %w", uerrors.ErrResetPassword)
+	}
+	resetToken := base64.RawURLEncoding.EncodeToString(b)
+
+	t := &models.PasswordResetToken{
+		UserId:    u.Id,
+		TokenHash: hasher.HashToken(resetToken),
+		ExpiresAt: time.Now().Add(s.resetTTL),
+	}
+	if err = s.storage.CreateResetToken(t); err != nil {
+		s.logger.Entry.Errorf("failed to create password reset token: %s", err)
+		return fmt.Errorf("error occurs: %w", uerrors.ErrResetPassword)
+	}
+
+	err = s.notifier.Notify(ctx, notify.Message{
+		UserId:   u.Id,
+		UserName: u.Name,
+		Subject:  "Password reset",
+		Text: fmt.Sprintf("Use the token %s to set a new password until %s.",
+			resetToken, t.ExpiresAt.UTC().Format(time.RFC1123)),
+	})
+	if err != nil {
+		s.logger.Entry.Errorf("failed to send password reset token to user %d: %s", u.Id, err)
+		return fmt.Errorf("error occurs: %w", uerrors.ErrResetPassword)
+	}
+	return nil
+}
+
+func (s Service) ResetPassword(ctx context.Context, resetToken, newPassword string) (err error) {
+	// the policy is checked first, so a weak password doesn't use up the token
+	if err = s.passwords.Check(newPassword); err != nil {
+		return fmt.Errorf("error occurs: %w", err)
+	}
+
+	now := time.Now()
+	err = s.storage.Transaction(func(r Repository) error {
+		t, err := r.GetResetToken(hasher.HashToken(resetToken))
+		if err != nil {
+			return err
+		}
+		if t.UsedAt != nil || !t.ExpiresAt.After(now) {
+			return uerrors.ErrInvalidResetToken
+		}
+		if err = r.UseResetToken(t, now); err != nil {
+			return err
+		}
+		return s.setPassword(r, t.UserId, newPassword, uerrors.ErrResetPassword)
+	})
+	if errors.Is(err, uerrors.ErrInvalidResetToken) {
+		return fmt.Errorf("error occurs: %w", err)
+	}
+	if err != nil && !errors.Is(err, uerrors.ErrResetPassword) {
+		s.logger.Entry.Errorf("failed to reset password: %s", err)
+		return fmt.Errorf("error occurs: %w", uerrors.ErrResetPassword)
+	}
+	return err
+}
+
+// setPassword checks the password by the policy and stores its hash,
+// failures of the storage are reported as failed.
+func (s Service) setPassword(r Repository, userId uint, newPassword string, failed error) error {
+	if err := s.passwords.Check(newPassword); err != nil {
+		return fmt.Errorf("error occurs: %w", err)
+	}
+	hash, err := hasher.HashPassword(newPassword)
+	if err == nil {
+		err = r.UpdatePassword(userId, hash)
+	}
+	if err != nil {
+		s.logger.Entry.Errorf("failed to set password of user %d: %s", userId, err)
+		return fmt.Errorf("error occurs: %w", failed)
+	}
+	return nil
+}
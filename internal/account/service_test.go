@@ -0,0 +1,179 @@
+package account_test
+
+import (
+	"context"
+	"errors"
+	"githib.com/dkischenko/company-api/internal/account"
+	mock_account "githib.com/dkischenko/company-api/internal/account/mocks"
+	uerrors "githib.com/dkischenko/company-api/internal/errors"
+	"githib.com/dkischenko/company-api/internal/notify"
+	"githib.com/dkischenko/company-api/models"
+	"githib.com/dkischenko/company-api/pkg/auth"
+	"githib.com/dkischenko/company-api/pkg/hasher"
+	"githib.com/dkischenko/company-api/pkg/logger"
+	"githib.com/dkischenko/company-api/pkg/password"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+// notifier keeps the messages instead of delivering them.
+type notifier []notify.Message
+
+func (n *notifier) Notify(ctx context.Context, m notify.Message) error {
+	*n = append(*n, m)
+	return nil
+}
+
+func policy(t *testing.T) *password.Policy {
+	p, err := password.NewPolicy(8, 2, "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	return p
+}
+
+func TestService_ChangePassword(t *testing.T) {
+	hash, err := hasher.HashPassword("old-password")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	tests := []struct {
+		name        string
+		current     string
+		newPassword string
+		updated     bool
+		wantErr     error
+	}{
+		{name: "Changed", current: "old-password", newPassword: "new-password1", updated: true},
+		{name: "Wrong current password", current: "guess", newPassword: "new-password1", wantErr: uerrors.ErrCheckUserPasswordHash},
+		{name: "Weak new password", current: "old-password", newPassword: "short", wantErr: password.ErrWeakPassword},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mock_account.NewMockRepository(ctrl)
+			mockRepo.EXPECT().GetUser(uint(7)).Return(models.User{Id: 7, Name: "Bob", PasswordHash: hash}, nil)
+			if tc.updated {
+				mockRepo.EXPECT().UpdatePassword(uint(7), gomock.Any()).DoAndReturn(func(userId uint, h string) error {
+					assert.True(t, hasher.CheckPasswordHash(h, tc.newPassword))
+					return nil
+				})
+			}
+
+			l, _ := logger.GetLogger()
+			s := account.NewService(l, mockRepo, &notifier{}, policy(t), time.Hour)
+			err := s.ChangePassword(auth.WithUserId(context.Background(), "7"), tc.current, tc.newPassword)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestService_RequestPasswordReset(t *testing.T) {
+	t.Run("Known user", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		var stored models.PasswordResetToken
+		mockRepo := mock_account.NewMockRepository(ctrl)
+		mockRepo.EXPECT().FindUser("Bob").Return(models.User{Id: 7, Name: "Bob"}, nil)
+		mockRepo.EXPECT().CreateResetToken(gomock.Any()).DoAndReturn(func(rt *models.PasswordResetToken) error {
+			stored = *rt
+			return nil
+		})
+
+		l, _ := logger.GetLogger()
+		sent := &notifier{}
+		s := account.NewService(l, mockRepo, sent, policy(t), time.Hour)
+		assert.NoError(t, s.RequestPasswordReset(context.Background(), "Bob"))
+		assert.Len(t, *sent, 1)
+		assert.Equal(t, uint(7), (*sent)[0].UserId)
+		assert.Equal(t, uint(7), stored.UserId)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), stored.ExpiresAt, time.Minute)
+
+		// the message carries the token, only its hash is stored
+		resetToken := strings.Fields((*sent)[0].Text)[3]
+		assert.Equal(t, hasher.HashToken(resetToken), stored.TokenHash)
+	})
+
+	t.Run("Unknown user", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mock_account.NewMockRepository(ctrl)
+		mockRepo.EXPECT().FindUser("Eve").Return(models.User{}, uerrors.ErrGetUser)
+
+		l, _ := logger.GetLogger()
+		sent := &notifier{}
+		s := account.NewService(l, mockRepo, sent, policy(t), time.Hour)
+		assert.NoError(t, s.RequestPasswordReset(context.Background(), "Eve"))
+		assert.Empty(t, *sent)
+	})
+}
+
+func TestService_ResetPassword(t *testing.T) {
+	past, future := time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+	tests := []struct {
+		name        string
+		stored      models.PasswordResetToken
+		getErr      error
+		useErr      error
+		newPassword string
+		used        bool
+		wantErr     error
+	}{
+		{name: "Reset", stored: models.PasswordResetToken{UserId: 7, ExpiresAt: future}, newPassword: "new-password1", used: true},
+		{name: "Unknown token", getErr: uerrors.ErrInvalidResetToken, newPassword: "new-password1", wantErr: uerrors.ErrInvalidResetToken},
+		{name: "Expired token", stored: models.PasswordResetToken{UserId: 7, ExpiresAt: past}, newPassword: "new-password1", wantErr: uerrors.ErrInvalidResetToken},
+		{name: "Used token", stored: models.PasswordResetToken{UserId: 7, ExpiresAt: future, UsedAt: &past}, newPassword: "new-password1", wantErr: uerrors.ErrInvalidResetToken},
+		{name: "Used concurrently", stored: models.PasswordResetToken{UserId: 7, ExpiresAt: future}, useErr: uerrors.ErrInvalidResetToken, newPassword: "new-password1", used: true, wantErr: uerrors.ErrInvalidResetToken},
+		{name: "Database error", getErr: errors.New("connection refused"), newPassword: "new-password1", wantErr: uerrors.ErrResetPassword},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mock_account.NewMockRepository(ctrl)
+			mockRepo.EXPECT().Transaction(gomock.Any()).DoAndReturn(func(fn func(r account.Repository) error) error {
+				return fn(mockRepo)
+			})
+			mockRepo.EXPECT().GetResetToken(hasher.HashToken("reset-token")).Return(tc.stored, tc.getErr)
+			if tc.used {
+				mockRepo.EXPECT().UseResetToken(tc.stored, gomock.Any()).Return(tc.useErr)
+			}
+			if tc.used && tc.useErr == nil {
+				mockRepo.EXPECT().UpdatePassword(uint(7), gomock.Any()).Return(nil)
+			}
+
+			l, _ := logger.GetLogger()
+			s := account.NewService(l, mockRepo, &notifier{}, policy(t), time.Hour)
+			err := s.ResetPassword(context.Background(), "reset-token", tc.newPassword)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+
+	t.Run("Weak password keeps the token", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mock_account.NewMockRepository(ctrl)
+		l, _ := logger.GetLogger()
+		s := account.NewService(l, mockRepo, &notifier{}, policy(t), time.Hour)
+		assert.ErrorIs(t, s.ResetPassword(context.Background(), "reset-token", "short"), password.ErrWeakPassword)
+	})
+}
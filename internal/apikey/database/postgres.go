@@ -0,0 +1,68 @@
+package database
+
+import (
+	"errors"
+	"githib.com/dkischenko/company-api/internal/apikey"
+	uerrors "githib.com/dkischenko/company-api/internal/errors"
+	"githib.com/dkischenko/company-api/models"
+	"githib.com/dkischenko/company-api/pkg/logger"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"time"
+)
+
+type postgres struct {
+	logger *logger.Logger
+	db     *gorm.DB
+}
+
+func NewStorage(db *gorm.DB, logger *logger.Logger) apikey.Repository {
+	return &postgres{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (p postgres) Create(key *models.APIKey) (err error) {
+	return p.db.Create(key).Error
+}
+
+func (p postgres) List(userId uint) (keys []models.APIKey, err error) {
+	err = p.db.Where("user_id = ?", userId).Order("created_at DESC").Find(&keys).Error
+	return
+}
+
+func (p postgres) GetByHash(hash string) (key models.APIKey, err error) {
+	err = p.db.Where("key_hash = ?", hash).First(&key).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return key, uerrors.ErrInvalidAPIKey
+	}
+	return
+}
+
+func (p postgres) Revoke(userId uint, id uuid.UUID, at time.Time) (key models.APIKey, err error) {
+	err = p.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("id = ? AND user_id = ?", id, userId).First(&key).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return uerrors.ErrAPIKeyNotFound
+		}
+		if err != nil || key.RevokedAt != nil {
+			return err
+		}
+		key.RevokedAt = &at
+		return tx.Model(&key).Update("revoked_at", at).Error
+	})
+	return
+}
+
+func (p postgres) TouchLastUsed(id uuid.UUID, at time.Time) (err error) {
+	return p.db.Model(&models.APIKey{}).Where("id = ?", id).Update("last_used_at", at).Error
+}
+
+func (p postgres) GetUser(userId uint) (u models.User, err error) {
+	err = p.db.Where("id = ?", userId).First(&u).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return u, uerrors.ErrGetUser
+	}
+	return
+}
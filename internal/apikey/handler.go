@@ -0,0 +1,120 @@
+package apikey
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	uerrors "githib.com/dkischenko/company-api/internal/errors"
+	"githib.com/dkischenko/company-api/internal/middleware"
+	"githib.com/dkischenko/company-api/models"
+	"githib.com/dkischenko/company-api/pkg/logger"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"net/http"
+)
+
+const (
+	apiKeys                = "/v1/apikeys"
+	apiKeyWithId           = "/v1/apikeys/{id}"
+	headerContentType      = "Content-Type"
+	headerValueContentType = "application/json"
+)
+
+type handler struct {
+	logger  *logger.Logger
+	service IService
+}
+
+func NewHandler(logger *logger.Logger, service IService) *handler {
+	return &handler{
+		logger:  logger,
+		service: service,
+	}
+}
+
+// Register adds the routes of API keys. They have no scope, so keys can't be
+// managed with API keys.
+func (h handler) Register(router *mux.Router) {
+	middleware.HandleRoutes(router, []middleware.Route{
+		{Method: http.MethodPost, Path: apiKeys, Role: models.RoleViewer, Handler: h.CreateKeyHandler},
+		{Method: http.MethodGet, Path: apiKeys, Role: models.RoleViewer, Handler: h.ListKeysHandler},
+		{Method: http.MethodDelete, Path: apiKeyWithId, Role: models.RoleViewer, Handler: h.RevokeKeyHandler},
+	})
+}
+
+func (h handler) CreateKeyHandler(w http.ResponseWriter, r *http.Request) {
+	req := KeyRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Entry.Error("wrong json format")
+		h.writeError(w, http.StatusBadRequest, "wrong json format")
+		return
+	}
+	if err := validator.New().Struct(req); err != nil {
+		h.logger.Entry.Errorf("got wrong API key data: %+v", err)
+		h.writeError(w, http.StatusBadRequest, fmt.Sprintf("got wrong API key data: %+v", err))
+		return
+	}
+
+	k, key, err := h.service.CreateKey(r.Context(), req)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusCreated, KeyCreateResponse{APIKey: k, Key: key})
+}
+
+func (h handler) ListKeysHandler(w http.ResponseWriter, r *http.Request) {
+	keys, err := h.service.ListKeys(r.Context())
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+	if keys == nil {
+		keys = []models.APIKey{}
+	}
+
+	h.writeJSON(w, http.StatusOK, keys)
+}
+
+// RevokeKeyHandler revokes the key, which stays listed with the time it was revoked at.
+func (h handler) RevokeKeyHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.logger.Entry.Errorf("can't parse UUID: %+v", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if _, err = h.service.RevokeKey(r.Context(), id); err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h handler) writeServiceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, uerrors.ErrAPIKeyNotFound):
+		h.writeError(w, http.StatusNotFound, uerrors.ErrAPIKeyNotFound.Error())
+	case errors.Is(err, uerrors.ErrInvalidAPIKeyRequest):
+		h.writeError(w, http.StatusBadRequest, "expiresAt must be in the future")
+	default:
+		h.logger.Entry.Errorf("API key request failed: %+v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func (h handler) writeError(w http.ResponseWriter, code int, message string) {
+	h.writeJSON(w, code, uerrors.ErrorResponse{Code: code, Message: message})
+}
+
+func (h handler) writeJSON(w http.ResponseWriter, code int, body interface{}) {
+	w.Header().Add(headerContentType, headerValueContentType)
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		h.logger.Entry.Errorf("problems with encoding data: %+v", err)
+	}
+}
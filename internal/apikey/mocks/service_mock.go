@@ -0,0 +1,100 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: service.go
+
+// Package mock_apikey is a generated GoMock package.
+package mock_apikey
+
+import (
+	context "context"
+	reflect "reflect"
+
+	apikey "githib.com/dkischenko/company-api/internal/apikey"
+	models "githib.com/dkischenko/company-api/models"
+	gomock "github.com/golang/mock/gomock"
+	uuid "github.com/google/uuid"
+)
+
+// MockIService is a mock of IService interface.
+type MockIService struct {
+	ctrl     *gomock.Controller
+	recorder *MockIServiceMockRecorder
+}
+
+// MockIServiceMockRecorder is the mock recorder for MockIService.
+type MockIServiceMockRecorder struct {
+	mock *MockIService
+}
+
+// NewMockIService creates a new mock instance.
+func NewMockIService(ctrl *gomock.Controller) *MockIService {
+	mock := &MockIService{ctrl: ctrl}
+	mock.recorder = &MockIServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIService) EXPECT() *MockIServiceMockRecorder {
+	return m.recorder
+}
+
+// CreateKey mocks base method.
+func (m *MockIService) CreateKey(ctx context.Context, req apikey.KeyRequest) (models.APIKey, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateKey", ctx, req)
+	ret0, _ := ret[0].(models.APIKey)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// CreateKey indicates an expected call of CreateKey.
+func (mr *MockIServiceMockRecorder) CreateKey(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateKey", reflect.TypeOf((*MockIService)(nil).CreateKey), ctx, req)
+}
+
+// ListKeys mocks base method.
+func (m *MockIService) ListKeys(ctx context.Context) ([]models.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListKeys", ctx)
+	ret0, _ := ret[0].([]models.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListKeys indicates an expected call of ListKeys.
+func (mr *MockIServiceMockRecorder) ListKeys(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListKeys", reflect.TypeOf((*MockIService)(nil).ListKeys), ctx)
+}
+
+// RevokeKey mocks base method.
+func (m *MockIService) RevokeKey(ctx context.Context, id uuid.UUID) (models.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeKey", ctx, id)
+	ret0, _ := ret[0].(models.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevokeKey indicates an expected call of RevokeKey.
+func (mr *MockIServiceMockRecorder) RevokeKey(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeKey", reflect.TypeOf((*MockIService)(nil).RevokeKey), ctx, id)
+}
+
+// VerifyAPIKey mocks base method.
+func (m *MockIService) VerifyAPIKey(ctx context.Context, key string) (models.APIKey, models.Role, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyAPIKey", ctx, key)
+	ret0, _ := ret[0].(models.APIKey)
+	ret1, _ := ret[1].(models.Role)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// VerifyAPIKey indicates an expected call of VerifyAPIKey.
+func (mr *MockIServiceMockRecorder) VerifyAPIKey(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyAPIKey", reflect.TypeOf((*MockIService)(nil).VerifyAPIKey), ctx, key)
+}
@@ -0,0 +1,21 @@
+package apikey
+
+import (
+	"githib.com/dkischenko/company-api/models"
+	"github.com/google/uuid"
+	"time"
+)
+
+//go:generate mockgen -source=repository.go -destination=mocks/repository_mock.go
+type Repository interface {
+	Create(key *models.APIKey) (err error)
+	List(userId uint) (keys []models.APIKey, err error)
+	// GetByHash returns uerrors.ErrInvalidAPIKey for unknown hashes.
+	GetByHash(hash string) (key models.APIKey, err error)
+	// Revoke returns uerrors.ErrAPIKeyNotFound unless the user has the key,
+	// revoking a revoked key keeps the time it was revoked at.
+	Revoke(userId uint, id uuid.UUID, at time.Time) (key models.APIKey, err error)
+	TouchLastUsed(id uuid.UUID, at time.Time) (err error)
+	// GetUser returns uerrors.ErrGetUser for unknown users.
+	GetUser(userId uint) (u models.User, err error)
+}
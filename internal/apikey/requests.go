@@ -0,0 +1,18 @@
+package apikey
+
+import (
+	"githib.com/dkischenko/company-api/models"
+	"time"
+)
+
+type KeyRequest struct {
+	Name      string         `json:"name" validate:"required,max=255"`
+	Scopes    []models.Scope `json:"scopes" validate:"required,min=1,dive,oneof=companies:read companies:write webhooks admin"`
+	ExpiresAt *time.Time     `json:"expiresAt"`
+}
+
+// KeyCreateResponse reveals the key, it's the only time the key is returned by the API.
+type KeyCreateResponse struct {
+	models.APIKey
+	Key string `json:"key"`
+}
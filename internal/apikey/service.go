@@ -0,0 +1,154 @@
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	uerrors "githib.com/dkischenko/company-api/internal/errors"
+	"githib.com/dkischenko/company-api/models"
+	"githib.com/dkischenko/company-api/pkg/auth"
+	"githib.com/dkischenko/company-api/pkg/hasher"
+	"githib.com/dkischenko/company-api/pkg/logger"
+	"github.com/google/uuid"
+	"strconv"
+	"time"
+)
+
+const (
+	// keyPrefix marks API keys, so leaked keys are easy to find by scanners.
+	keyPrefix = "cak_"
+	keySize   = 32
+	// shownPrefixLength is the length of the start of a key kept to tell keys apart.
+	shownPrefixLength = len(keyPrefix) + 8
+	// lastUsedPrecision limits the writes of the last use of a key to one a minute.
+	lastUsedPrecision = time.Minute
+)
+
+type Service struct {
+	logger  *logger.Logger
+	storage Repository
+}
+
+//go:generate mockgen -source=service.go -destination=mocks/service_mock.go
+type IService interface {
+	// CreateKey creates the key of the user of the request, the key itself
+	// is returned only here.
+	CreateKey(ctx context.Context, req KeyRequest) (k models.APIKey, key string, err error)
+	ListKeys(ctx context.Context) (keys []models.APIKey, err error)
+	RevokeKey(ctx context.Context, id uuid.UUID) (k models.APIKey, err error)
+	// VerifyAPIKey returns the key and the role its user has now.
+	VerifyAPIKey(ctx context.Context, key string) (k models.APIKey, role models.Role, err error)
+}
+
+func NewService(logger *logger.Logger, storage Repository) IService {
+	return &Service{
+		logger:  logger,
+		storage: storage,
+	}
+}
+
+func (s Service) CreateKey(ctx context.Context, req KeyRequest) (k models.APIKey, key string, err error) {
+	userId, err := userIdFromContext(ctx)
+	if err != nil {
+		return k, "", err
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return k, "", fmt.Errorf("error occurs: %w", uerrors.ErrInvalidAPIKeyRequest)
+	}
+
+	b := make([]byte, keySize)
+	if _, err = rand.Read(b); err != nil {
+		s.logger.Entry.Errorf("failed to generate API key: %s", err)
+		return k, "", fmt.Errorf("error occurs: %w", uerrors.ErrCreateAPIKey)
+	}
+	key = keyPrefix + base64.RawURLEncoding.EncodeToString(b)
+
+	k = models.APIKey{
+		UserId:    userId,
+		Name:      req.Name,
+		Prefix:    key[:shownPrefixLength],
+		KeyHash:   hasher.HashToken(key),
+		Scopes:    req.Scopes,
+		ExpiresAt: req.ExpiresAt,
+	}
+	if err = s.storage.Create(&k); err != nil {
+		s.logger.Entry.Errorf("failed to create API key: %s", err)
+		return models.APIKey{}, "", fmt.Errorf("error occurs: %w", uerrors.ErrCreateAPIKey)
+	}
+	return k, key, nil
+}
+
+func (s Service) ListKeys(ctx context.Context) (keys []models.APIKey, err error) {
+	userId, err := userIdFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	keys, err = s.storage.List(userId)
+	if err != nil {
+		s.logger.Entry.Errorf("failed to list API keys: %s", err)
+		return nil, fmt.Errorf("error occurs: %w", uerrors.ErrListAPIKeys)
+	}
+	return
+}
+
+// RevokeKey revokes the key if it belongs to the user of the request.
+func (s Service) RevokeKey(ctx context.Context, id uuid.UUID) (k models.APIKey, err error) {
+	userId, err := userIdFromContext(ctx)
+	if err != nil {
+		return k, err
+	}
+	k, err = s.storage.Revoke(userId, id, time.Now())
+	if errors.Is(err, uerrors.ErrAPIKeyNotFound) {
+		return k, fmt.Errorf("error occurs: %w", err)
+	}
+	if err != nil {
+		s.logger.Entry.Errorf("failed to revoke API key %s: %s", id, err)
+		return k, fmt.Errorf("error occurs: %w", uerrors.ErrRevokeAPIKey)
+	}
+	return
+}
+
+// VerifyAPIKey rejects keys which are unknown, revoked or expired. The last use
+// of the key is tracked with a precision of a minute, failing to track it
+// doesn't fail the request.
+func (s Service) VerifyAPIKey(ctx context.Context, key string) (k models.APIKey, role models.Role, err error) {
+	now := time.Now()
+	k, err = s.storage.GetByHash(hasher.HashToken(key))
+	if errors.Is(err, uerrors.ErrInvalidAPIKey) {
+		return k, "", fmt.Errorf("error occurs: %w", err)
+	}
+	if err != nil {
+		return k, "", err
+	}
+	if k.RevokedAt != nil || (k.ExpiresAt != nil && !k.ExpiresAt.After(now)) {
+		return models.APIKey{}, "", fmt.Errorf("error occurs: %w", uerrors.ErrInvalidAPIKey)
+	}
+
+	u, err := s.storage.GetUser(k.UserId)
+	if errors.Is(err, uerrors.ErrGetUser) {
+		return models.APIKey{}, "", fmt.Errorf("error occurs: %w", uerrors.ErrInvalidAPIKey)
+	}
+	if err != nil {
+		return models.APIKey{}, "", err
+	}
+	if u.Disabled() {
+		return models.APIKey{}, "", fmt.Errorf("error occurs: %w", uerrors.ErrInvalidAPIKey)
+	}
+
+	if k.LastUsedAt == nil || now.Sub(*k.LastUsedAt) >= lastUsedPrecision {
+		if err := s.storage.TouchLastUsed(k.Id, now); err != nil {
+			s.logger.Entry.Errorf("failed to track use of API key %s: %s", k.Id, err)
+		}
+	}
+	return k, u.Role, nil
+}
+
+func userIdFromContext(ctx context.Context) (uint, error) {
+	userId, err := strconv.ParseUint(auth.UserIdFromContext(ctx), 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("error occurs: %w", uerrors.ErrGetUser)
+	}
+	return uint(userId), nil
+}
@@ -0,0 +1,124 @@
+package apikey_test
+
+import (
+	"context"
+	"errors"
+	"githib.com/dkischenko/company-api/internal/apikey"
+	mock_apikey "githib.com/dkischenko/company-api/internal/apikey/mocks"
+	uerrors "githib.com/dkischenko/company-api/internal/errors"
+	"githib.com/dkischenko/company-api/models"
+	"githib.com/dkischenko/company-api/pkg/auth"
+	"githib.com/dkischenko/company-api/pkg/hasher"
+	"githib.com/dkischenko/company-api/pkg/logger"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestService_CreateKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var stored models.APIKey
+	mockRepo := mock_apikey.NewMockRepository(ctrl)
+	mockRepo.EXPECT().Create(gomock.Any()).DoAndReturn(func(k *models.APIKey) error {
+		stored = *k
+		return nil
+	})
+
+	l, _ := logger.GetLogger()
+	s := apikey.NewService(l, mockRepo)
+	ctx := auth.WithUserId(context.Background(), "7")
+	req := apikey.KeyRequest{Name: "nightly sync", Scopes: []models.Scope{models.ScopeCompaniesRead}}
+	k, key, err := s.CreateKey(ctx, req)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(key, k.Prefix))
+	assert.Equal(t, uint(7), stored.UserId)
+	assert.Equal(t, hasher.HashToken(key), stored.KeyHash)
+	assert.Equal(t, req.Scopes, stored.Scopes)
+
+	past := time.Now().Add(-time.Hour)
+	req.ExpiresAt = &past
+	_, _, err = s.CreateKey(ctx, req)
+	assert.ErrorIs(t, err, uerrors.ErrInvalidAPIKeyRequest)
+}
+
+func TestService_VerifyAPIKey(t *testing.T) {
+	past, future := time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+	recently := time.Now().Add(-time.Second)
+	tests := []struct {
+		name     string
+		stored   models.APIKey
+		getErr   error
+		getUser  bool
+		userErr  error
+		disabled bool
+		touched  bool
+		wantErr  error
+	}{
+		{name: "Valid key", stored: models.APIKey{Id: uuid.New(), UserId: 7, ExpiresAt: &future}, getUser: true, touched: true},
+		{name: "Used recently", stored: models.APIKey{Id: uuid.New(), UserId: 7, LastUsedAt: &recently}, getUser: true},
+		{name: "Unknown key", getErr: uerrors.ErrInvalidAPIKey, wantErr: uerrors.ErrInvalidAPIKey},
+		{name: "Revoked key", stored: models.APIKey{Id: uuid.New(), UserId: 7, RevokedAt: &past}, wantErr: uerrors.ErrInvalidAPIKey},
+		{name: "Expired key", stored: models.APIKey{Id: uuid.New(), UserId: 7, ExpiresAt: &past}, wantErr: uerrors.ErrInvalidAPIKey},
+		{name: "Deleted user", stored: models.APIKey{Id: uuid.New(), UserId: 7}, getUser: true, userErr: uerrors.ErrGetUser, wantErr: uerrors.ErrInvalidAPIKey},
+		{name: "Disabled user", stored: models.APIKey{Id: uuid.New(), UserId: 7}, getUser: true, disabled: true, wantErr: uerrors.ErrInvalidAPIKey},
+		{name: "Database error", getErr: errors.New("connection refused")},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mock_apikey.NewMockRepository(ctrl)
+			mockRepo.EXPECT().GetByHash(hasher.HashToken("cak_key")).Return(tc.stored, tc.getErr)
+			if tc.getUser {
+				u := models.User{Id: 7, Role: models.RoleViewer}
+				if tc.disabled {
+					u.DisabledAt = &past
+				}
+				mockRepo.EXPECT().GetUser(uint(7)).Return(u, tc.userErr)
+			}
+			if tc.touched {
+				mockRepo.EXPECT().TouchLastUsed(tc.stored.Id, gomock.Any()).Return(nil)
+			}
+
+			l, _ := logger.GetLogger()
+			s := apikey.NewService(l, mockRepo)
+			k, role, err := s.VerifyAPIKey(context.Background(), "cak_key")
+			switch {
+			case tc.wantErr != nil:
+				assert.ErrorIs(t, err, tc.wantErr)
+			case tc.getErr != nil:
+				assert.Error(t, err)
+				assert.NotErrorIs(t, err, uerrors.ErrInvalidAPIKey)
+			default:
+				assert.NoError(t, err)
+				assert.Equal(t, tc.stored.Id, k.Id)
+				assert.Equal(t, models.RoleViewer, role)
+			}
+		})
+	}
+}
+
+func TestService_RevokeKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	id := uuid.New()
+	mockRepo := mock_apikey.NewMockRepository(ctrl)
+	mockRepo.EXPECT().Revoke(uint(7), id, gomock.Any()).Return(models.APIKey{Id: id}, nil)
+	mockRepo.EXPECT().Revoke(uint(8), id, gomock.Any()).Return(models.APIKey{}, uerrors.ErrAPIKeyNotFound)
+
+	l, _ := logger.GetLogger()
+	s := apikey.NewService(l, mockRepo)
+
+	_, err := s.RevokeKey(auth.WithUserId(context.Background(), "7"), id)
+	assert.NoError(t, err)
+	_, err = s.RevokeKey(auth.WithUserId(context.Background(), "8"), id)
+	assert.ErrorIs(t, err, uerrors.ErrAPIKeyNotFound)
+}
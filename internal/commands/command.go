@@ -0,0 +1,88 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"githib.com/dkischenko/company-api/internal/company"
+	uerrors "githib.com/dkischenko/company-api/internal/errors"
+	"githib.com/dkischenko/company-api/models"
+	"githib.com/dkischenko/company-api/pkg/auth"
+	"github.com/google/uuid"
+)
+
+type Type string
+
+const (
+	CreateCompany Type = "create"
+	UpdateCompany Type = "update"
+	DeleteCompany Type = "delete"
+)
+
+// Command asks to change a company on behalf of an upstream system.
+// Company is required by create and update commands, CompanyId by delete ones.
+// Updates and deletes are only applied to the expected version of the company,
+// given by the version of Company or by Version, when it's set.
+type Command struct {
+	Id        string          `json:"id"`
+	Type      Type            `json:"type"`
+	ActorId   string          `json:"actorId"`
+	CompanyId uuid.UUID       `json:"companyId"`
+	Version   int             `json:"version,omitempty"`
+	Company   *models.Company `json:"company"`
+}
+
+// ErrInvalidCommand marks commands which will never succeed, so retrying them is useless.
+var ErrInvalidCommand = errors.New("invalid command")
+
+// Decode parses and validates the command from a message value.
+func Decode(value []byte) (cmd Command, err error) {
+	if err = json.Unmarshal(value, &cmd); err != nil {
+		return cmd, fmt.Errorf("%w: wrong json format: %s", ErrInvalidCommand, err)
+	}
+
+	switch cmd.Type {
+	case CreateCompany, UpdateCompany:
+		if cmd.Company == nil {
+			return cmd, fmt.Errorf("%w: %s command without company", ErrInvalidCommand, cmd.Type)
+		}
+		if cmd.Type == UpdateCompany && cmd.Company.Id == uuid.Nil {
+			return cmd, fmt.Errorf("%w: update command without company id", ErrInvalidCommand)
+		}
+		if err = company.ValidateCompany(cmd.Company); err != nil {
+			return cmd, fmt.Errorf("%w: got wrong company data: %s", ErrInvalidCommand, err)
+		}
+	case DeleteCompany:
+		if cmd.CompanyId == uuid.Nil {
+			return cmd, fmt.Errorf("%w: delete command without company id", ErrInvalidCommand)
+		}
+	default:
+		return cmd, fmt.Errorf("%w: unknown command type %q", ErrInvalidCommand, cmd.Type)
+	}
+
+	return cmd, nil
+}
+
+// Apply executes the command through the service as the actor of the command.
+func Apply(ctx context.Context, service company.IService, cmd Command) (err error) {
+	ctx = auth.WithUserId(ctx, cmd.ActorId)
+
+	switch cmd.Type {
+	case CreateCompany:
+		_, err = service.CreateCompany(ctx, *cmd.Company)
+	case UpdateCompany:
+		err = service.UpdateCompany(ctx, cmd.Company)
+	case DeleteCompany:
+		err = service.DeleteCompany(ctx, cmd.CompanyId, cmd.Version)
+	default:
+		err = fmt.Errorf("%w: unknown command type %q", ErrInvalidCommand, cmd.Type)
+	}
+	// the company was changed by someone else or the actor may not change it,
+	// the command won't ever apply
+	if errors.Is(err, uerrors.ErrVersionMismatch) || errors.Is(err, uerrors.ErrCompanyForbidden) {
+		err = fmt.Errorf("%w: %s", ErrInvalidCommand, err)
+	}
+
+	return
+}
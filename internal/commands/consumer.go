@@ -0,0 +1,187 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"githib.com/dkischenko/company-api/configs"
+	"githib.com/dkischenko/company-api/internal/company"
+	"githib.com/dkischenko/company-api/pkg/logger"
+	"github.com/segmentio/kafka-go"
+	"strconv"
+	"time"
+)
+
+const (
+	maxAttempts  = 3
+	retryBackoff = time.Second
+	// maxHandleBackoff limits the delay between attempts to handle a message
+	// which could neither be applied nor written to the dead-letter topic.
+	maxHandleBackoff = time.Minute
+
+	headerError          = "error"
+	headerOriginalTopic  = "original-topic"
+	headerOriginalOffset = "original-offset"
+)
+
+// MessageReader is the part of kafka.Reader the consumer relies on.
+type MessageReader interface {
+	FetchMessage(ctx context.Context) (kafka.Message, error)
+	CommitMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+// MessageWriter is the part of kafka.Writer the consumer relies on.
+type MessageWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+// Consumer applies company commands read from Kafka. A command is committed
+// once it's applied or written to the dead-letter topic, invalid commands go
+// there at once and others after maxAttempts failed attempts. A command is
+// handled until that succeeds before the next one is fetched, as committing
+// a later offset would skip it.
+type Consumer struct {
+	logger     *logger.Logger
+	service    company.IService
+	reader     MessageReader
+	deadLetter MessageWriter
+	backoff    time.Duration
+}
+
+func NewConsumer(logger *logger.Logger, service company.IService, reader MessageReader, deadLetter MessageWriter) *Consumer {
+	return &Consumer{
+		logger:     logger,
+		service:    service,
+		reader:     reader,
+		deadLetter: deadLetter,
+		backoff:    retryBackoff,
+	}
+}
+
+// NewKafkaConsumer creates a consumer of the configured command topic
+// within the configured consumer group.
+func NewKafkaConsumer(logger *logger.Logger, service company.IService, cfg *configs.Config) *Consumer {
+	deadline := time.Duration(cfg.KafkaWriteDeadline) * time.Second
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers: []string{cfg.KafkaAddr().String()},
+		Dialer:  &kafka.Dialer{Timeout: deadline},
+		GroupID: cfg.KafkaGroupId,
+		Topic:   cfg.KafkaCommandTopic,
+	})
+	deadLetter := &kafka.Writer{
+		Addr:         cfg.KafkaAddr(),
+		Topic:        cfg.KafkaDLQTopic,
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: kafka.RequireAll,
+		WriteTimeout: deadline,
+	}
+
+	return NewConsumer(logger, service, reader, deadLetter)
+}
+
+// SetRetryBackoff changes the delay between attempts to apply a command,
+// the delay grows linearly with the number of the attempt.
+func (c *Consumer) SetRetryBackoff(d time.Duration) {
+	c.backoff = d
+}
+
+// Run consumes commands until ctx is done.
+func (c *Consumer) Run(ctx context.Context) {
+	defer func() {
+		if err := c.reader.Close(); err != nil {
+			c.logger.Entry.Errorf("failed to close command reader: %s", err)
+		}
+		if err := c.deadLetter.Close(); err != nil {
+			c.logger.Entry.Errorf("failed to close dead-letter writer: %s", err)
+		}
+	}()
+
+	for {
+		m, err := c.reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			c.logger.Entry.Errorf("failed to fetch command: %s", err)
+			continue
+		}
+
+		if err := c.handleUntilDone(ctx, m); err != nil {
+			// not committed, the command is redelivered after restart or rebalance
+			return
+		}
+		if err := c.reader.CommitMessages(ctx, m); err != nil {
+			c.logger.Entry.Errorf("failed to commit command at offset %d: %s", m.Offset, err)
+		}
+	}
+}
+
+// Handle applies the command of the message or writes the message to the dead-letter topic.
+// It returns an error only when neither succeeded.
+func (c *Consumer) Handle(ctx context.Context, m kafka.Message) error {
+	cmd, err := Decode(m.Value)
+	if err == nil {
+		err = c.apply(ctx, cmd)
+	}
+	if err == nil {
+		c.logger.Entry.Infof("applied %s command %s", cmd.Type, cmd.Id)
+		return nil
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	c.logger.Entry.Errorf("sending command at offset %d to dead-letter topic: %s", m.Offset, err)
+	headers := append([]kafka.Header{}, m.Headers...)
+	headers = append(headers,
+		kafka.Header{Key: headerError, Value: []byte(err.Error())},
+		kafka.Header{Key: headerOriginalTopic, Value: []byte(m.Topic)},
+		kafka.Header{Key: headerOriginalOffset, Value: []byte(strconv.FormatInt(m.Offset, 10))},
+	)
+
+	return c.deadLetter.WriteMessages(ctx, kafka.Message{
+		Key:     m.Key,
+		Value:   m.Value,
+		Headers: headers,
+	})
+}
+
+// handleUntilDone handles the message again with a growing delay until it succeeds
+// or ctx is done.
+func (c *Consumer) handleUntilDone(ctx context.Context, m kafka.Message) error {
+	for attempt := 1; ; attempt++ {
+		err := c.Handle(ctx, m)
+		if err == nil || ctx.Err() != nil {
+			return err
+		}
+
+		delay := c.backoff * time.Duration(attempt)
+		if delay > maxHandleBackoff {
+			delay = maxHandleBackoff
+		}
+		c.logger.Entry.Errorf("failed to handle command at offset %d (attempt %d), retrying: %s", m.Offset, attempt, err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// apply retries the command with a growing delay unless the command is invalid.
+func (c *Consumer) apply(ctx context.Context, cmd Command) (err error) {
+	for attempt := 1; ; attempt++ {
+		err = Apply(ctx, c.service, cmd)
+		if err == nil || errors.Is(err, ErrInvalidCommand) || attempt == maxAttempts {
+			return err
+		}
+
+		c.logger.Entry.Warningf("failed to apply %s command %s (attempt %d): %s", cmd.Type, cmd.Id, attempt, err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(c.backoff * time.Duration(attempt)):
+		}
+	}
+}
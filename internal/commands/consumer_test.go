@@ -0,0 +1,182 @@
+package commands_test
+
+import (
+	"context"
+	"errors"
+	"githib.com/dkischenko/company-api/internal/commands"
+	mock_company "githib.com/dkischenko/company-api/internal/company/mocks"
+	"githib.com/dkischenko/company-api/models"
+	"githib.com/dkischenko/company-api/pkg/auth"
+	"githib.com/dkischenko/company-api/pkg/logger"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+type memoryWriter struct {
+	messages []kafka.Message
+	// failures is the number of writes failing before the writer accepts messages
+	failures int
+}
+
+func (w *memoryWriter) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	if w.failures > 0 {
+		w.failures--
+		return errors.New("broker is not available")
+	}
+	w.messages = append(w.messages, msgs...)
+	return nil
+}
+
+func (w *memoryWriter) Close() error {
+	return nil
+}
+
+// memoryReader returns its messages and stops the consumer once they are fetched.
+type memoryReader struct {
+	messages  []kafka.Message
+	committed []int64
+	stop      context.CancelFunc
+}
+
+func (r *memoryReader) FetchMessage(ctx context.Context) (kafka.Message, error) {
+	if len(r.messages) == 0 {
+		r.stop()
+		return kafka.Message{}, ctx.Err()
+	}
+	m := r.messages[0]
+	r.messages = r.messages[1:]
+	return m, nil
+}
+
+func (r *memoryReader) CommitMessages(ctx context.Context, msgs ...kafka.Message) error {
+	for _, m := range msgs {
+		r.committed = append(r.committed, m.Offset)
+	}
+	return nil
+}
+
+func (r *memoryReader) Close() error {
+	return nil
+}
+
+func header(m kafka.Message, key string) string {
+	for _, h := range m.Headers {
+		if h.Key == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+func TestConsumer_Handle(t *testing.T) {
+	companyId := uuid.New()
+	testCases := []struct {
+		name           string
+		value          string
+		expect         func(s *mock_company.MockIService)
+		wantDeadLetter string
+	}{
+		{
+			name: "Create command",
+			value: `{"id": "1", "type": "create", "actorId": "upstream",
+				"company": {"name": "Big company", "amountOfEmployees": 10, "type": "NonProfit"}}`,
+			expect: func(s *mock_company.MockIService) {
+				s.EXPECT().CreateCompany(gomock.Any(), models.Company{
+					Name:              "Big company",
+					AmountOfEmployees: 10,
+					Type:              models.NonProfit,
+				}).DoAndReturn(func(ctx context.Context, c models.Company) (models.Company, error) {
+					assert.Equal(t, "upstream", auth.UserIdFromContext(ctx))
+					return c, nil
+				})
+			},
+		},
+		{
+			name:   "Delete command",
+			value:  `{"id": "2", "type": "delete", "companyId": "` + companyId.String() + `"}`,
+			expect: func(s *mock_company.MockIService) { s.EXPECT().DeleteCompany(gomock.Any(), companyId, 0).Return(nil) },
+		},
+		{
+			name:           "Wrong json",
+			value:          `{"id": "3", "type": `,
+			wantDeadLetter: "invalid command: wrong json format",
+		},
+		{
+			name:           "Company fails validation",
+			value:          `{"id": "4", "type": "create", "company": {"name": "Big company", "type": "Unknown"}}`,
+			wantDeadLetter: "invalid command: got wrong company data",
+		},
+		{
+			name:           "Update without id",
+			value:          `{"id": "5", "type": "update", "company": {"name": "Big company", "type": "NonProfit"}}`,
+			wantDeadLetter: "invalid command: update command without company id",
+		},
+		{
+			name:  "Service keeps failing",
+			value: `{"id": "6", "type": "delete", "companyId": "` + companyId.String() + `"}`,
+			expect: func(s *mock_company.MockIService) {
+				s.EXPECT().DeleteCompany(gomock.Any(), companyId, 0).Return(errors.New("database is down")).Times(3)
+			},
+			wantDeadLetter: "database is down",
+		},
+	}
+
+	for _, tcase := range testCases {
+		t.Run(tcase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			l, _ := logger.GetLogger()
+			mockService := mock_company.NewMockIService(ctrl)
+			if tcase.expect != nil {
+				tcase.expect(mockService)
+			}
+			deadLetter := &memoryWriter{}
+			c := commands.NewConsumer(l, mockService, nil, deadLetter)
+			c.SetRetryBackoff(0)
+
+			m := kafka.Message{Topic: "company-api-commands", Offset: 42, Value: []byte(tcase.value)}
+			assert.NoError(t, c.Handle(context.Background(), m))
+
+			if tcase.wantDeadLetter == "" {
+				assert.Empty(t, deadLetter.messages)
+				return
+			}
+			if assert.Len(t, deadLetter.messages, 1) {
+				dl := deadLetter.messages[0]
+				assert.Equal(t, m.Value, dl.Value)
+				assert.Contains(t, header(dl, "error"), tcase.wantDeadLetter)
+				assert.Equal(t, "42", header(dl, "original-offset"))
+			}
+		})
+	}
+}
+
+func TestConsumer_RunDoesNotSkipFailedCommands(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	reader := &memoryReader{stop: cancel, messages: []kafka.Message{
+		{Offset: 1, Value: []byte(`{"id": "1", "type": "unknown"}`)},
+		{Offset: 2, Value: []byte(`{"id": "2", "type": "unknown"}`)},
+	}}
+	deadLetter := &memoryWriter{failures: 2}
+
+	l, _ := logger.GetLogger()
+	c := commands.NewConsumer(l, mock_company.NewMockIService(ctrl), reader, deadLetter)
+	c.SetRetryBackoff(time.Millisecond)
+	c.Run(ctx)
+
+	// the first command is committed only after the dead-letter topic took it
+	assert.Equal(t, []int64{1, 2}, reader.committed)
+	if assert.Len(t, deadLetter.messages, 2) {
+		assert.Equal(t, "1", header(deadLetter.messages[0], "original-offset"))
+		assert.Equal(t, "2", header(deadLetter.messages[1], "original-offset"))
+	}
+}
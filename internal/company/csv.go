@@ -0,0 +1,72 @@
+package company
+
+import (
+	"fmt"
+	"githib.com/dkischenko/company-api/models"
+	"github.com/google/uuid"
+	"strconv"
+)
+
+// CSVColumns are the columns of companies in CSV, named after the JSON fields
+// of models.Company and kept in their order.
+var CSVColumns = []string{"id", "name", "description", "amountOfEmployees", "registered", "type", "ownerId", "version"}
+
+// CSVRecord returns the fields of the company in the order of CSVColumns.
+func CSVRecord(c models.Company) []string {
+	var ownerId string
+	if c.OwnerId != nil {
+		ownerId = strconv.FormatUint(uint64(*c.OwnerId), 10)
+	}
+	return []string{
+		c.Id.String(),
+		c.Name,
+		c.Description,
+		strconv.Itoa(c.AmountOfEmployees),
+		strconv.FormatBool(c.Registered),
+		string(c.Type),
+		ownerId,
+		strconv.Itoa(c.Version),
+	}
+}
+
+// SetCSVField sets the field of the company in the column to the value read from CSV.
+func SetCSVField(c *models.Company, column, value string) (err error) {
+	switch column {
+	case "id":
+		if value != "" {
+			c.Id, err = uuid.Parse(value)
+		}
+	case "name":
+		c.Name = value
+	case "description":
+		c.Description = value
+	case "amountOfEmployees":
+		if value != "" {
+			c.AmountOfEmployees, err = strconv.Atoi(value)
+		}
+	case "registered":
+		if value != "" {
+			c.Registered, err = strconv.ParseBool(value)
+		}
+	case "type":
+		c.Type = models.TypeAllowed(value)
+	case "ownerId":
+		if value != "" {
+			var id uint64
+			if id, err = strconv.ParseUint(value, 10, 32); err == nil {
+				ownerId := uint(id)
+				c.OwnerId = &ownerId
+			}
+		}
+	case "version":
+		if value != "" {
+			c.Version, err = strconv.Atoi(value)
+		}
+	default:
+		return fmt.Errorf("unknown column %s", column)
+	}
+	if err != nil {
+		return fmt.Errorf("wrong value of %s: %q", column, value)
+	}
+	return nil
+}
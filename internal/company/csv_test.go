@@ -0,0 +1,46 @@
+package company_test
+
+import (
+	"githib.com/dkischenko/company-api/internal/company"
+	"githib.com/dkischenko/company-api/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCSVColumns(t *testing.T) {
+	// columns follow the JSON fields of the company in their order
+	var fields []string
+	typ := reflect.TypeOf(models.Company{})
+	for i := 0; i < typ.NumField(); i++ {
+		if name := strings.Split(typ.Field(i).Tag.Get("json"), ",")[0]; name != "-" {
+			fields = append(fields, name)
+		}
+	}
+	assert.Equal(t, fields, company.CSVColumns)
+}
+
+func TestCSVRecord(t *testing.T) {
+	ownerId := uint(7)
+	c := models.Company{
+		Id:                uuid.New(),
+		Name:              "Green Energy",
+		Description:       "Solar panels",
+		AmountOfEmployees: 12,
+		Registered:        true,
+		Type:              models.Sole_Proprietorship,
+		OwnerId:           &ownerId,
+		Version:           3,
+	}
+
+	restored := models.Company{}
+	for i, value := range company.CSVRecord(c) {
+		assert.NoError(t, company.SetCSVField(&restored, company.CSVColumns[i], value))
+	}
+	assert.Equal(t, c, restored)
+
+	assert.Error(t, company.SetCSVField(&restored, "amountOfEmployees", "many"))
+	assert.Error(t, company.SetCSVField(&restored, "founded", "1990"))
+}
@@ -0,0 +1,36 @@
+package database
+
+import (
+	"fmt"
+	"gorm.io/gorm"
+)
+
+// postgresMigrations hold the schema changes AutoMigrate can't express.
+// Every statement must be safe to run on each start of the application.
+var postgresMigrations = []string{
+	`ALTER TABLE companies ADD COLUMN IF NOT EXISTS search_vector tsvector
+		GENERATED ALWAYS AS (
+			setweight(to_tsvector('english', coalesce(name, '')), 'A') ||
+			setweight(to_tsvector('english', coalesce(description, '')), 'B')
+		) STORED`,
+	`CREATE INDEX IF NOT EXISTS idx_companies_search_vector ON companies USING GIN (search_vector)`,
+	// names are unique among companies which are not deleted,
+	// so a deleted company does not block its name until it's purged
+	`ALTER TABLE companies DROP CONSTRAINT IF EXISTS companies_name_key`,
+	`CREATE UNIQUE INDEX IF NOT EXISTS idx_companies_name_alive ON companies (name) WHERE deleted_at IS NULL`,
+}
+
+// Migrate applies postgres specific schema changes on top of AutoMigrate.
+func Migrate(db *gorm.DB) error {
+	if db.Dialector.Name() != "postgres" {
+		return nil
+	}
+
+	for _, m := range postgresMigrations {
+		if err := db.Exec(m).Error; err != nil {
+			return fmt.Errorf("cannot apply migration %q: %w", m, err)
+		}
+	}
+
+	return nil
+}
@@ -1,15 +1,35 @@
 package database
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
 	"githib.com/dkischenko/company-api/internal/company"
 	uerrors "githib.com/dkischenko/company-api/internal/errors"
+	"githib.com/dkischenko/company-api/internal/events"
+	"githib.com/dkischenko/company-api/internal/lockout"
 	"githib.com/dkischenko/company-api/models"
 	"githib.com/dkischenko/company-api/pkg/logger"
 	"github.com/google/uuid"
+	"github.com/jackc/pgconn"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"strconv"
+	"time"
 )
 
+// uniqueViolation is the SQLSTATE code of a unique constraint violation.
+const uniqueViolation = "23505"
+
+// sortColumns maps sortable JSON fields of models.Company to their columns.
+var sortColumns = map[string]string{
+	"id":                "id",
+	"name":              "name",
+	"type":              "type",
+	"registered":        "registered",
+	"amountOfEmployees": "amount_of_employees",
+}
+
 type postgres struct {
 	logger *logger.Logger
 	db     *gorm.DB
@@ -22,6 +42,12 @@ func NewStorage(db *gorm.DB, logger *logger.Logger) company.Repository {
 	}
 }
 
+func (p postgres) Transaction(fn func(r company.Repository) error) error {
+	return p.db.Transaction(func(tx *gorm.DB) error {
+		return fn(postgres{db: tx, logger: p.logger})
+	})
+}
+
 func (p postgres) Create(company models.Company) (models.Company, error) {
 	err := p.db.Create(&company).Error
 	return company, err
@@ -36,17 +62,276 @@ func (p postgres) Get(companyId uuid.UUID) (company models.Company, err error) {
 }
 
 func (p postgres) Update(company *models.Company) (err error) {
-	return p.db.Model(&company).Updates(&models.Company{
-		Name:              company.Name,
-		Description:       company.Description,
-		AmountOfEmployees: company.AmountOfEmployees,
-		Registered:        company.Registered,
-		Type:              company.Type,
+	// fields are selected explicitly, so zero values are written as well
+	res := p.db.Model(&models.Company{}).
+		Where("id = ? AND version = ?", company.Id, company.Version).
+		Select("name", "description", "amount_of_employees", "registered", "type", "version").
+		Updates(&models.Company{
+			Name:              company.Name,
+			Description:       company.Description,
+			AmountOfEmployees: company.AmountOfEmployees,
+			Registered:        company.Registered,
+			Type:              company.Type,
+			Version:           company.Version + 1,
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return uerrors.ErrVersionMismatch
+	}
+	return nil
+}
+
+func (p postgres) Delete(id uuid.UUID, version int) (err error) {
+	res := p.db.Where("version = ?", version).Delete(&models.Company{Id: id})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return uerrors.ErrVersionMismatch
+	}
+	return nil
+}
+
+func (p postgres) Restore(id uuid.UUID) (err error) {
+	res := p.db.Unscoped().Model(&models.Company{}).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Update("deleted_at", nil)
+	if pgErr := (&pgconn.PgError{}); errors.As(res.Error, &pgErr) && pgErr.Code == uniqueViolation {
+		return uerrors.ErrCompanyNameTaken
+	}
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return uerrors.ErrGetCompany
+	}
+	return nil
+}
+
+func (p postgres) Purge(deletedBefore time.Time) (purged int64, err error) {
+	err = p.db.Transaction(func(tx *gorm.DB) error {
+		// the companies are locked, so none of them is restored halfway through the purge
+		var ids []uuid.UUID
+		err := tx.Unscoped().Model(&models.Company{}).
+			Where("deleted_at < ?", deletedBefore).
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Pluck("id", &ids).Error
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+
+		for _, m := range []interface{}{&models.CompanyRevision{}, &models.ACLEntry{}} {
+			if err = tx.Where("company_id IN ?", ids).Delete(m).Error; err != nil {
+				return err
+			}
+		}
+		res := tx.Unscoped().Where("id IN ?", ids).Delete(&models.Company{})
+		purged = res.RowsAffected
+		return res.Error
+	})
+	return
+}
+
+func (p postgres) List(filter company.CompanyFilter) (companies []models.Company, total int64, err error) {
+	q := p.filtered(filter)
+	if err = q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	column, ok := sortColumns[filter.Sort]
+	if !ok {
+		column = sortColumns["name"]
+	}
+	// rows before the keyset are scanned in the reversed order
+	desc := filter.Desc
+	if filter.Keyset != nil && filter.Keyset.Backward {
+		desc = !desc
+	}
+	if filter.Keyset != nil {
+		op := ">"
+		if desc {
+			op = "<"
+		}
+		if column == "id" {
+			q = q.Where("id "+op+" ?", filter.Keyset.Id)
+		} else {
+			q = q.Where(fmt.Sprintf("(%s, id) %s (?, ?)", column, op), filter.Keyset.Value, filter.Keyset.Id)
+		}
+	}
+
+	err = ordered(q, column, desc).Limit(filter.Limit).Offset(filter.Offset).Find(&companies).Error
+	return
+}
+
+// Export reads the rows one by one from the database cursor,
+// so the companies are never loaded into memory all at once.
+func (p postgres) Export(filter company.CompanyFilter, fn func(c models.Company) error) (err error) {
+	column, ok := sortColumns[filter.Sort]
+	if !ok {
+		column = sortColumns["name"]
+	}
+	rows, err := ordered(p.filtered(filter), column, filter.Desc).Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var c models.Company
+		if err = p.db.ScanRows(rows, &c); err != nil {
+			return err
+		}
+		if err = fn(c); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// filtered returns the query of companies matching the filter.
+func (p postgres) filtered(filter company.CompanyFilter) *gorm.DB {
+	q := p.db.Model(&models.Company{})
+	if filter.Name != "" {
+		q = q.Where("LOWER(name) LIKE LOWER(?) ESCAPE '\\'", escapeLike(filter.Name)+"%")
+	}
+	if filter.Type != "" {
+		q = q.Where("type = ?", filter.Type)
+	}
+	if filter.Registered != nil {
+		q = q.Where("registered = ?", *filter.Registered)
+	}
+	if filter.MinEmployees != nil {
+		q = q.Where("amount_of_employees >= ?", *filter.MinEmployees)
+	}
+	if filter.MaxEmployees != nil {
+		q = q.Where("amount_of_employees <= ?", *filter.MaxEmployees)
+	}
+	return q
+}
+
+// ordered orders the query by the column with id as a tiebreaker.
+func ordered(q *gorm.DB, column string, desc bool) *gorm.DB {
+	direction := " ASC"
+	if desc {
+		direction = " DESC"
+	}
+	q = q.Order(column + direction)
+	if column != "id" {
+		q = q.Order("id" + direction)
+	}
+	return q
+}
+
+func (p postgres) TakenNames(names []string) (taken []string, err error) {
+	err = p.db.Model(&models.Company{}).Where("name IN ?", names).Pluck("name", &taken).Error
+	return
+}
+
+func (p postgres) CreateOutboxEvent(e events.Event) (err error) {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	return p.db.Create(&models.OutboxEvent{
+		EventId:       e.Id,
+		CompanyId:     e.CompanyId,
+		Type:          string(e.Type),
+		Payload:       payload,
+		CreatedAt:     e.OccurredAt,
+		NextAttemptAt: e.OccurredAt,
 	}).Error
 }
 
-func (p postgres) Delete(id uuid.UUID) (err error) {
-	return p.db.Delete(&models.Company{Id: id}).Error
+// CreateRevision relies on the change of the company being made earlier in the same
+// transaction: the row lock it takes serializes revisions of the company.
+func (p postgres) CreateRevision(rev *models.CompanyRevision) (err error) {
+	var last int
+	err = p.db.Model(&models.CompanyRevision{}).
+		Select("COALESCE(MAX(revision), 0)").
+		Where("company_id = ?", rev.CompanyId).
+		Scan(&last).Error
+	if err != nil {
+		return err
+	}
+
+	rev.Revision = last + 1
+	return p.db.Create(rev).Error
+}
+
+func (p postgres) ListRevisions(companyId uuid.UUID, limit, offset int) (revisions []models.CompanyRevision, total int64, err error) {
+	q := p.db.Model(&models.CompanyRevision{}).Where("company_id = ?", companyId)
+	if err = q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err = q.Order("revision DESC").Limit(limit).Offset(offset).Find(&revisions).Error
+	return
+}
+
+func (p postgres) GetRevision(companyId uuid.UUID, revision int) (rev models.CompanyRevision, err error) {
+	err = p.db.Where("company_id = ? AND revision = ?", companyId, revision).First(&rev).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return rev, uerrors.ErrRevisionNotFound
+	}
+	return
+}
+
+func (p postgres) Grants(companyId uuid.UUID, userId uint) (permissions []models.Permission, err error) {
+	groups := p.db.Model(&models.GroupMember{}).Select("group_id").Where("user_id = ?", userId)
+	err = p.db.Model(&models.ACLEntry{}).
+		Where("company_id = ?", companyId).
+		Where(p.db.Where("user_id = ?", userId).Or("group_id IN (?)", groups)).
+		Pluck("permission", &permissions).Error
+	return
+}
+
+func (p postgres) ListACL(companyId uuid.UUID) (entries []models.ACLEntry, err error) {
+	err = p.db.Where("company_id = ?", companyId).Order("created_at").Find(&entries).Error
+	return
+}
+
+func (p postgres) SaveACL(entry *models.ACLEntry) (err error) {
+	q := p.db.Where("company_id = ?", entry.CompanyId)
+	if entry.UserId != nil {
+		q = q.Where("user_id = ?", *entry.UserId)
+	} else {
+		q = q.Where("group_id = ?", *entry.GroupId)
+	}
+	var stored models.ACLEntry
+	err = q.First(&stored).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return p.db.Create(entry).Error
+	}
+	if err != nil {
+		return err
+	}
+	if err = p.db.Model(&stored).Update("permission", entry.Permission).Error; err != nil {
+		return err
+	}
+	stored.Permission = entry.Permission
+	*entry = stored
+	return nil
+}
+
+func (p postgres) DeleteACL(companyId, entryId uuid.UUID) (err error) {
+	res := p.db.Where("id = ? AND company_id = ?", entryId, companyId).Delete(&models.ACLEntry{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return uerrors.ErrACLEntryNotFound
+	}
+	return nil
+}
+
+func (p postgres) GetGroup(groupId uuid.UUID) (g models.Group, err error) {
+	err = p.db.Where("id = ?", groupId).First(&g).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return g, uerrors.ErrGroupNotFound
+	}
+	return
 }
 
 func (p postgres) CreateUser(user *models.User) (u models.User, err error) {
@@ -64,3 +349,87 @@ func (p postgres) FindOneUser(name string) (u models.User, err error) {
 	}
 	return
 }
+
+func (p postgres) SetUserRole(userId uint, role models.Role) (u models.User, err error) {
+	res := p.db.Model(&models.User{}).Where("id = ?", userId).Update("role", role)
+	if res.Error != nil {
+		return u, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return u, uerrors.ErrGetUser
+	}
+	err = p.db.Where("id = ?", userId).First(&u).Error
+	return
+}
+
+func (p postgres) ListUsers(limit, offset int) (users []models.User, total int64, err error) {
+	q := p.db.Model(&models.User{})
+	if err = q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err = q.Order("id").Limit(limit).Offset(offset).Find(&users).Error
+	return
+}
+
+func (p postgres) GetUser(userId uint) (u models.User, err error) {
+	err = p.db.Where("id = ?", userId).First(&u).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return u, uerrors.ErrGetUser
+	}
+	return
+}
+
+func (p postgres) SetUserDisabled(userId uint, disabledAt *time.Time) (u models.User, err error) {
+	res := p.db.Model(&models.User{}).Where("id = ?", userId).Update("disabled_at", disabledAt)
+	if res.Error != nil {
+		return u, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return u, uerrors.ErrGetUser
+	}
+	err = p.db.Where("id = ?", userId).First(&u).Error
+	return
+}
+
+// userData are the models of the data owned by a user, deleted along with it.
+// Data keyed by the id of the user as text or by its name is deleted by DeleteUser.
+var userData = []interface{}{
+	&models.RefreshToken{}, &models.APIKey{}, &models.PasswordResetToken{},
+	&models.MFASecret{}, &models.RecoveryCode{}, &models.MFAChallenge{}, &models.OAuthClient{},
+	&models.GroupMember{}, &models.ACLEntry{},
+}
+
+func (p postgres) DeleteUser(userId uint) (err error) {
+	return p.db.Transaction(func(tx *gorm.DB) error {
+		var u models.User
+		err := tx.Where("id = ?", userId).First(&u).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return uerrors.ErrGetUser
+		}
+		if err != nil {
+			return err
+		}
+		if err = tx.Delete(&u).Error; err != nil {
+			return err
+		}
+		for _, m := range userData {
+			if err = tx.Where("user_id = ?", userId).Delete(m).Error; err != nil {
+				return err
+			}
+		}
+
+		id := strconv.FormatUint(uint64(userId), 10)
+		// deliveries of the webhooks are deleted by the database along with them
+		if err = tx.Where("owner_id = ?", id).Delete(&models.WebhookSubscription{}).Error; err != nil {
+			return err
+		}
+		if err = tx.Where("user_id = ?", id).Delete(&models.IdempotencyKey{}).Error; err != nil {
+			return err
+		}
+		if err = tx.Where("key = ?", lockout.UserKey(u.Name)).Delete(&models.LoginFailure{}).Error; err != nil {
+			return err
+		}
+		// companies of the user are left without owner, deleted ones included
+		return tx.Unscoped().Model(&models.Company{}).Where("owner_id = ?", userId).Update("owner_id", nil).Error
+	})
+}
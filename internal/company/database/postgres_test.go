@@ -0,0 +1,105 @@
+package database_test
+
+import (
+	"githib.com/dkischenko/company-api/internal/company"
+	"githib.com/dkischenko/company-api/internal/company/database"
+	"githib.com/dkischenko/company-api/pkg/logger"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"regexp"
+	"testing"
+	"time"
+)
+
+// newStorage returns the storage on a mocked database, which fails the test
+// when the expected statements weren't run.
+func newStorage(t *testing.T) (company.Repository, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	gdb, err := gorm.Open(postgres.New(postgres.Config{Conn: db}), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	t.Cleanup(func() {
+		assert.NoError(t, mock.ExpectationsWereMet())
+		_ = db.Close()
+	})
+	l, _ := logger.GetLogger()
+	return database.NewStorage(gdb, l), mock
+}
+
+func TestPostgres_ListByNamePrefix(t *testing.T) {
+	s, mock := newStorage(t)
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "companies" WHERE LOWER(name) LIKE LOWER($1) ESCAPE '\'`)).
+		WithArgs(`big\_co\%%`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery(regexp.QuoteMeta(`LOWER(name) LIKE LOWER($1) ESCAPE '\'`)).
+		WithArgs(`big\_co\%%`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	_, total, err := s.List(company.CompanyFilter{Name: "big_co%", Limit: 10})
+	assert.NoError(t, err)
+	assert.Zero(t, total)
+}
+
+func TestPostgres_Purge(t *testing.T) {
+	s, mock := newStorage(t)
+	deletedBefore := time.Now().Add(-time.Hour)
+	first, second := uuid.New(), uuid.New()
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT "id" FROM "companies" WHERE deleted_at < $1 FOR UPDATE`)).
+		WithArgs(deletedBefore).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(first).AddRow(second))
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "company_revisions" WHERE company_id IN ($1,$2)`)).
+		WithArgs(first, second).
+		WillReturnResult(sqlmock.NewResult(0, 5))
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "acl_entries" WHERE company_id IN ($1,$2)`)).
+		WithArgs(first, second).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "companies" WHERE id IN ($1,$2)`)).
+		WithArgs(first, second).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	purged, err := s.Purge(deletedBefore)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), purged)
+}
+
+func TestPostgres_DeleteUser(t *testing.T) {
+	s, mock := newStorage(t)
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users" WHERE id = $1`)).
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(5, "bill"))
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "users" WHERE "users"."id" = $1`)).
+		WithArgs(5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	for _, table := range []string{"refresh_tokens", "api_keys", "password_reset_tokens", "mfa_secrets",
+		"recovery_codes", "mfa_challenges", "o_auth_clients", "group_members", "acl_entries"} {
+		mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "` + table + `" WHERE user_id = $1`)).
+			WithArgs(5).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	// webhooks and idempotency keys are keyed by the id as text, login failures by the name
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "webhook_subscriptions" WHERE owner_id = $1`)).
+		WithArgs("5").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "idempotency_keys" WHERE user_id = $1`)).
+		WithArgs("5").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "login_failures" WHERE key = $1`)).
+		WithArgs("user:bill").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "companies" SET "owner_id"=$1 WHERE owner_id = $2`)).
+		WithArgs(nil, 5).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectCommit()
+
+	assert.NoError(t, s.DeleteUser(5))
+}
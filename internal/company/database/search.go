@@ -0,0 +1,72 @@
+package database
+
+import (
+	"githib.com/dkischenko/company-api/internal/company"
+	"githib.com/dkischenko/company-api/models"
+	"strings"
+)
+
+const (
+	searchConfig = "english"
+	// headlineOptions configure ts_headline to mark terms the same way company.Highlight does
+	headlineOptions = "StartSel=" + company.HighlightStart + ", StopSel=" + company.HighlightStop +
+		", MaxWords=35, MinWords=15, MaxFragments=2"
+)
+
+func (p postgres) Search(query company.SearchQuery) (results []company.SearchResult, total int64, err error) {
+	if p.db.Dialector.Name() != "postgres" {
+		return p.substringSearch(query)
+	}
+
+	match := p.db.Model(&models.Company{}).
+		Where("search_vector @@ websearch_to_tsquery(?, ?)", searchConfig, query.Query)
+	if err = match.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	rows := []struct {
+		models.Company
+		Rank    float64
+		Snippet string
+	}{}
+	err = p.db.Model(&models.Company{}).
+		Select("companies.*, ts_rank(search_vector, q) AS rank, ts_headline(?, coalesce(description, ''), q, ?) AS snippet",
+			searchConfig, headlineOptions).
+		Joins("CROSS JOIN websearch_to_tsquery(?, ?) AS q", searchConfig, query.Query).
+		Where("search_vector @@ q").
+		Order("rank DESC").Order("id").
+		Limit(query.Limit).Offset(query.Offset).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	results = make([]company.SearchResult, 0, len(rows))
+	for _, r := range rows {
+		results = append(results, company.SearchResult{Company: r.Company, Rank: r.Rank, Snippet: r.Snippet})
+	}
+
+	return results, total, nil
+}
+
+// substringSearch narrows companies down with LIKE and leaves ranking
+// to company.SubstringSearch, for databases without full-text search.
+func (p postgres) substringSearch(query company.SearchQuery) ([]company.SearchResult, int64, error) {
+	q := p.db.Model(&models.Company{})
+	for _, t := range company.SearchTerms(query.Query) {
+		pattern := "%" + escapeLike(t) + "%"
+		q = q.Where("(LOWER(name) LIKE ? ESCAPE '\\' OR LOWER(description) LIKE ? ESCAPE '\\')", pattern, pattern)
+	}
+
+	var companies []models.Company
+	if err := q.Find(&companies).Error; err != nil {
+		return nil, 0, err
+	}
+
+	results, total := company.SubstringSearch(companies, query)
+	return results, total, nil
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
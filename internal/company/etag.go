@@ -0,0 +1,68 @@
+package company
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	headerETag        = "ETag"
+	headerIfMatch     = "If-Match"
+	headerIfNoneMatch = "If-None-Match"
+)
+
+// etag returns the entity tag of the version of a company.
+func etag(version int) string {
+	return strconv.Quote(strconv.Itoa(version))
+}
+
+// ifMatchVersion returns the version required by the If-Match header of the request
+// or zero for "*", which matches any version. Tags which aren't issued by etag
+// can't match any version.
+func ifMatchVersion(r *http.Request) (version int, err error) {
+	v := strings.TrimSpace(r.Header.Get(headerIfMatch))
+	if v == "*" {
+		return 0, nil
+	}
+	tag, err := strconv.Unquote(v)
+	if err == nil {
+		version, err = strconv.Atoi(tag)
+	}
+	if err != nil || version < 1 {
+		return 0, fmt.Errorf("%s %s does not match any version", headerIfMatch, v)
+	}
+	return version, nil
+}
+
+// noneMatch tells whether the If-None-Match header of the request lists
+// none of the tags of the version, weak tags are compared as strong ones.
+func noneMatch(r *http.Request, version int) bool {
+	v := r.Header.Get(headerIfNoneMatch)
+	if v == "" {
+		return true
+	}
+	for _, tag := range strings.Split(v, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == etag(version) {
+			return false
+		}
+	}
+	return true
+}
+
+// requireIfMatch returns the version required by the request
+// and writes the response itself when the precondition is missing or can't hold.
+func (h handler) requireIfMatch(w http.ResponseWriter, r *http.Request) (version int, ok bool) {
+	if r.Header.Get(headerIfMatch) == "" {
+		h.writeError(w, http.StatusPreconditionRequired, fmt.Sprintf("%s header is required", headerIfMatch))
+		return 0, false
+	}
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		h.writeError(w, http.StatusPreconditionFailed, err.Error())
+		return 0, false
+	}
+	return version, true
+}
@@ -0,0 +1,152 @@
+package company
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"githib.com/dkischenko/company-api/models"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	queryFormat              = "format"
+	headerAccept             = "Accept"
+	headerContentDisposition = "Content-Disposition"
+
+	formatCSV    = "csv"
+	formatNDJSON = "ndjson"
+
+	// exportFlushRows is the number of rows sent to the client at once.
+	exportFlushRows = 500
+	// exportWriteTimeout is the time the export has to send the next rows to the client.
+	// It replaces the write timeout of the server, which would cut large exports short.
+	exportWriteTimeout = 15 * time.Second
+)
+
+// exportTypes maps formats of exports to their media types.
+var exportTypes = map[string]string{
+	formatCSV:    "text/csv",
+	formatNDJSON: "application/x-ndjson",
+}
+
+// exportFormat picks the format of the export by the format parameter, or by
+// the first media type of the Accept header it supports. CSV is the default.
+func exportFormat(r *http.Request) (format string, code int, err error) {
+	if format = r.URL.Query().Get(queryFormat); format != "" {
+		if _, ok := exportTypes[format]; !ok {
+			return "", http.StatusBadRequest, fmt.Errorf("unknown %s %s, use %s or %s", queryFormat, format, formatCSV, formatNDJSON)
+		}
+		return format, 0, nil
+	}
+
+	accept := r.Header.Get(headerAccept)
+	if accept == "" {
+		return formatCSV, 0, nil
+	}
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		switch mediaType {
+		case "*/*", "text/*", "text/csv":
+			return formatCSV, 0, nil
+		case "application/x-ndjson", "application/ndjson":
+			return formatNDJSON, 0, nil
+		}
+	}
+	return "", http.StatusNotAcceptable, fmt.Errorf("companies are exported as text/csv or application/x-ndjson")
+}
+
+// ExportCompaniesHandler streams all companies matching the filters of the listing
+// as CSV with the header of CSVColumns, or as NDJSON. Paging parameters are ignored.
+// Once the first row is sent a failure can only cut the export short.
+// The write deadline is extended whenever rows are sent, so an export
+// takes as long as the client keeps reading.
+func (h handler) ExportCompaniesHandler(w http.ResponseWriter, r *http.Request) {
+	format, code, err := exportFormat(r)
+	if err != nil {
+		h.writeError(w, code, err.Error())
+		return
+	}
+	filter, err := parseCompanyFilter(r)
+	if err != nil {
+		h.logger.Entry.Errorf("got wrong filter: %s", err)
+		h.writeError(w, http.StatusBadRequest, fmt.Sprintf("got wrong filter: %s", err))
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	extendDeadline := func() {
+		err := rc.SetWriteDeadline(time.Now().Add(exportWriteTimeout))
+		if err != nil && !errors.Is(err, http.ErrNotSupported) {
+			h.logger.Entry.Errorf("can't extend the write deadline of the export: %s", err)
+		}
+	}
+	extendDeadline()
+
+	cw := csv.NewWriter(w)
+	encoder := json.NewEncoder(w)
+	rows, started := 0, false
+	start := func() error {
+		started = true
+		w.Header().Set(headerContentType, exportTypes[format])
+		w.Header().Set(headerContentDisposition, fmt.Sprintf("attachment; filename=\"companies.%s\"", format))
+		w.WriteHeader(http.StatusOK)
+		if format == formatCSV {
+			return cw.Write(CSVColumns)
+		}
+		return nil
+	}
+	flush := func() error {
+		cw.Flush()
+		if err := cw.Error(); err != nil {
+			return err
+		}
+		if err := rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			return err
+		}
+		extendDeadline()
+		return nil
+	}
+
+	err = h.service.ExportCompanies(r.Context(), filter, func(c models.Company) (err error) {
+		if !started {
+			if err = start(); err != nil {
+				return err
+			}
+		}
+		if format == formatCSV {
+			err = cw.Write(CSVRecord(c))
+		} else {
+			err = encoder.Encode(c)
+		}
+		if rows++; err == nil && rows%exportFlushRows == 0 {
+			err = flush()
+		}
+		return err
+	})
+	if err != nil && !started {
+		h.logger.Entry.Errorf("can't export companies: %+v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if err != nil {
+		h.logger.Entry.Errorf("export of companies was cut short after %d rows: %+v", rows, err)
+		return
+	}
+
+	if !started {
+		if err = start(); err != nil {
+			h.logger.Entry.Errorf("can't export companies: %+v", err)
+			return
+		}
+	}
+	if err = flush(); err != nil {
+		h.logger.Entry.Errorf("can't export companies: %+v", err)
+	}
+}
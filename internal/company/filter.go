@@ -0,0 +1,191 @@
+package company
+
+import (
+	"fmt"
+	"githib.com/dkischenko/company-api/models"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultListLimit = 20
+	maxListLimit     = 100
+
+	queryLimit        = "limit"
+	queryOffset       = "offset"
+	queryName         = "name"
+	queryType         = "type"
+	queryRegistered   = "registered"
+	queryMinEmployees = "amountOfEmployeesMin"
+	queryMaxEmployees = "amountOfEmployeesMax"
+	querySort         = "sort"
+	queryCursor       = "cursor"
+)
+
+// CompanyFilter describes which companies should be returned by a listing
+// and which page of the result set is requested.
+// A page is addressed either by Offset or by the opaque Cursor,
+// which the service resolves into Keyset before querying the storage.
+type CompanyFilter struct {
+	Name         string
+	Type         models.TypeAllowed
+	Registered   *bool
+	MinEmployees *int
+	MaxEmployees *int
+	Sort         string
+	Desc         bool
+	Limit        int
+	Offset       int
+	Cursor       string
+	Keyset       *Keyset
+}
+
+var allowedTypes = map[models.TypeAllowed]struct{}{
+	models.Corporations:        {},
+	models.NonProfit:           {},
+	models.Cooperative:         {},
+	models.Sole_Proprietorship: {},
+}
+
+// parseCompanyFilter builds CompanyFilter from the query string of the request.
+func parseCompanyFilter(r *http.Request) (f CompanyFilter, err error) {
+	q := r.URL.Query()
+
+	if f.Limit, f.Offset, err = parsePage(q); err != nil {
+		return f, err
+	}
+
+	f.Sort = defaultSortField
+	if v := q.Get(querySort); v != "" {
+		f.Desc = strings.HasPrefix(v, "-")
+		f.Sort = strings.TrimPrefix(v, "-")
+		if _, ok := sortFields[f.Sort]; !ok {
+			return f, fmt.Errorf("companies can't be sorted by %s", f.Sort)
+		}
+	}
+
+	if f.Cursor = q.Get(queryCursor); f.Cursor != "" && q.Get(queryOffset) != "" {
+		return f, fmt.Errorf("%s and %s can't be used together", queryCursor, queryOffset)
+	}
+
+	f.Name = q.Get(queryName)
+
+	if v := q.Get(queryType); v != "" {
+		f.Type = models.TypeAllowed(v)
+		if _, ok := allowedTypes[f.Type]; !ok {
+			return f, fmt.Errorf("unknown company %s: %s", queryType, v)
+		}
+	}
+
+	if v := q.Get(queryRegistered); v != "" {
+		registered, err := strconv.ParseBool(v)
+		if err != nil {
+			return f, fmt.Errorf("%s must be a boolean", queryRegistered)
+		}
+		f.Registered = &registered
+	}
+
+	if f.MinEmployees, err = parseIntParam(q, queryMinEmployees); err != nil {
+		return f, err
+	}
+	if f.MaxEmployees, err = parseIntParam(q, queryMaxEmployees); err != nil {
+		return f, err
+	}
+	if f.MinEmployees != nil && f.MaxEmployees != nil && *f.MinEmployees > *f.MaxEmployees {
+		return f, fmt.Errorf("%s can't be greater than %s", queryMinEmployees, queryMaxEmployees)
+	}
+
+	return f, nil
+}
+
+// parsePage returns the limit and the offset of the requested page.
+func parsePage(q url.Values) (limit int, offset int, err error) {
+	limit = defaultListLimit
+	if v := q.Get(queryLimit); v != "" {
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxListLimit {
+			return 0, 0, fmt.Errorf("%s must be an integer between 1 and %d", queryLimit, maxListLimit)
+		}
+	}
+
+	if v := q.Get(queryOffset); v != "" {
+		offset, err = strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			return 0, 0, fmt.Errorf("%s must be a non-negative integer", queryOffset)
+		}
+	}
+
+	return limit, offset, nil
+}
+
+func parseIntParam(q url.Values, name string) (*int, error) {
+	v := q.Get(name)
+	if v == "" {
+		return nil, nil
+	}
+	i, err := strconv.Atoi(v)
+	if err != nil || i < 0 {
+		return nil, fmt.Errorf("%s must be a non-negative integer", name)
+	}
+	return &i, nil
+}
+
+// paginationLinks returns links to the neighbour pages of the listing
+// keeping all other query parameters of the request untouched.
+// Pages requested by cursor are linked by cursors, all others by offsets.
+func paginationLinks(u *url.URL, f CompanyFilter, page CompanyPage) PaginationLinks {
+	link := func(set map[string]string) string {
+		q := u.Query()
+		q.Del(queryOffset)
+		q.Del(queryCursor)
+		q.Set(queryLimit, strconv.Itoa(f.Limit))
+		for k, v := range set {
+			q.Set(k, v)
+		}
+		return (&url.URL{Path: u.Path, RawQuery: q.Encode()}).String()
+	}
+	offsetLink := func(offset int) string {
+		return link(map[string]string{queryOffset: strconv.Itoa(offset)})
+	}
+	cursorLink := func(cursor string) string {
+		return link(map[string]string{queryCursor: cursor})
+	}
+
+	if f.Cursor != "" {
+		links := PaginationLinks{
+			Self:  cursorLink(f.Cursor),
+			First: link(nil),
+		}
+		if page.PrevCursor != "" {
+			links.Prev = cursorLink(page.PrevCursor)
+		}
+		if page.NextCursor != "" {
+			links.Next = cursorLink(page.NextCursor)
+		}
+		return links
+	}
+
+	links := PaginationLinks{
+		Self:  offsetLink(f.Offset),
+		First: offsetLink(0),
+	}
+	if f.Offset > 0 {
+		prev := f.Offset - f.Limit
+		if prev < 0 {
+			prev = 0
+		}
+		links.Prev = offsetLink(prev)
+	}
+	if int64(f.Offset+f.Limit) < page.Total {
+		links.Next = offsetLink(f.Offset + f.Limit)
+	}
+	if page.Total > 0 {
+		links.Last = offsetLink(int((page.Total - 1) / int64(f.Limit) * int64(f.Limit)))
+	} else {
+		links.Last = links.First
+	}
+
+	return links
+}
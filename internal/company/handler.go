@@ -2,15 +2,25 @@ package company
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"githib.com/dkischenko/company-api/configs"
+	"githib.com/dkischenko/company-api/internal/apikey"
 	uerrors "githib.com/dkischenko/company-api/internal/errors"
+	"githib.com/dkischenko/company-api/internal/idempotency"
+	"githib.com/dkischenko/company-api/internal/lockout"
 	"githib.com/dkischenko/company-api/internal/middleware"
+	"githib.com/dkischenko/company-api/internal/token"
 	"githib.com/dkischenko/company-api/models"
+	"githib.com/dkischenko/company-api/pkg/auth"
 	"githib.com/dkischenko/company-api/pkg/logger"
+	"githib.com/dkischenko/company-api/pkg/password"
+	"githib.com/dkischenko/company-api/pkg/patch"
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
+	"io"
+	"mime"
 	"net/http"
 	"strconv"
 	"time"
@@ -20,35 +30,120 @@ const (
 	company                = "/v1/companies"
 	users                  = "/v1/users"
 	usersLogin             = "/v1/login"
+	usersLoginMFA          = "/v1/login/mfa"
 	companyWithId          = "/v1/companies/{id}"
+	companySearch          = "/v1/companies/search"
+	companyEvents          = "/v1/companies/events"
+	companyExport          = "/v1/companies/export"
+	companyRestore         = "/v1/companies/{id}/restore"
+	companyHistory         = "/v1/companies/{id}/history"
+	companyRevision        = "/v1/companies/{id}/history/{rev}"
+	companyACL             = "/v1/companies/{id}/acl"
+	companyACLEntry        = "/v1/companies/{id}/acl/{entryId}"
+	companyPurge           = "/v1/admin/companies/purge"
+	usersMe                = "/v1/users/me"
+	adminUsers             = "/v1/admin/users"
+	adminUser              = "/v1/admin/users/{id}"
+	userRole               = "/v1/admin/users/{id}/role"
+	userDisable            = "/v1/admin/users/{id}/disable"
+	userEnable             = "/v1/admin/users/{id}/enable"
+	queryOlderThan         = "olderThan"
 	headerContentType      = "Content-Type"
 	headerValueContentType = "application/json"
 	headerAuthorization    = "Authorization"
 	headerXExpiresAfter    = "X-Expires-After"
+	headerRetryAfter       = "Retry-After"
+	headerLastEventId      = "Last-Event-ID"
+	headerCacheControl     = "Cache-Control"
+	headerAcceptPatch      = "Accept-Patch"
+	headerValueEventStream = "text/event-stream"
 )
 
 type handler struct {
-	logger  *logger.Logger
-	service IService
-	config  *configs.Config
+	logger      *logger.Logger
+	service     IService
+	config      *configs.Config
+	idempotency *idempotency.Keeper
+	tokens      token.IService
+	apiKeys     apikey.IService
+	lockout     *lockout.Guard
 }
 
-func NewHandler(logger *logger.Logger, service IService, cfg *configs.Config) *handler {
+// NewHandler creates the handler of companies and users. Creation of them is safe
+// to retry with the Idempotency-Key header when the keeper is given.
+// With the service of tokens login issues refresh tokens and revoked
+// access tokens are rejected, with the service of API keys requests
+// can be authorized by the X-API-Key header. Failed logins are throttled by the guard.
+func NewHandler(logger *logger.Logger, service IService, cfg *configs.Config, keeper *idempotency.Keeper,
+	tokens token.IService, apiKeys apikey.IService, guard *lockout.Guard) *handler {
 	return &handler{
-		logger:  logger,
-		service: service,
-		config:  cfg,
+		logger:      logger,
+		service:     service,
+		config:      cfg,
+		idempotency: keeper,
+		tokens:      tokens,
+		apiKeys:     apiKeys,
+		lockout:     guard,
 	}
 }
 
 func (h handler) Register(router *mux.Router) {
-	router.HandleFunc(companyWithId, h.GetCompanyHandler).Methods(http.MethodGet)
-	router.HandleFunc(company, h.CreateCompanyHandler).Methods(http.MethodPost)
-	router.HandleFunc(company, h.UpdateCompanyHandler).Methods(http.MethodPut)
-	router.HandleFunc(companyWithId, h.DeleteCompanyHandler).Methods(http.MethodDelete)
-	router.HandleFunc(users, h.CreateUser).Methods(http.MethodPost)
-	router.HandleFunc(usersLogin, h.LoginUser).Methods(http.MethodPost)
-	router.Use(middleware.PanicAndRecover, middleware.Logging, middleware.IsAuthorized)
+	middleware.HandleRoutes(router, []middleware.Route{
+		{Method: http.MethodGet, Path: company, Handler: h.ListCompaniesHandler},
+		{Method: http.MethodGet, Path: companySearch, Handler: h.SearchCompaniesHandler},
+		{Method: http.MethodGet, Path: companyEvents, Role: models.RoleEditor, Scope: models.ScopeCompaniesWrite,
+			Handler: h.CompanyEventsHandler},
+		{Method: http.MethodGet, Path: companyExport, Handler: h.ExportCompaniesHandler},
+		{Method: http.MethodGet, Path: companyWithId, Handler: h.GetCompanyHandler},
+		{Method: http.MethodGet, Path: companyHistory, Handler: h.CompanyHistoryHandler},
+		{Method: http.MethodGet, Path: companyRevision, Handler: h.CompanyRevisionHandler},
+		{Method: http.MethodPost, Path: company, Role: models.RoleEditor, Scope: models.ScopeCompaniesWrite,
+			Handler: h.idempotency.Middleware(http.HandlerFunc(h.CreateCompanyHandler)).ServeHTTP},
+		{Method: http.MethodPut, Path: company, Role: models.RoleEditor, Scope: models.ScopeCompaniesWrite,
+			Handler: h.UpdateCompanyHandler},
+		{Method: http.MethodPut, Path: companyWithId, Role: models.RoleEditor, Scope: models.ScopeCompaniesWrite,
+			Handler: h.ReplaceCompanyHandler},
+		{Method: http.MethodPatch, Path: companyWithId, Role: models.RoleEditor, Scope: models.ScopeCompaniesWrite,
+			Handler: h.PatchCompanyHandler},
+		{Method: http.MethodDelete, Path: companyWithId, Role: models.RoleEditor, Scope: models.ScopeCompaniesWrite,
+			Handler: h.DeleteCompanyHandler},
+		{Method: http.MethodPost, Path: companyRestore, Role: models.RoleEditor, Scope: models.ScopeCompaniesWrite,
+			Handler: h.RestoreCompanyHandler},
+		{Method: http.MethodGet, Path: companyACL, Role: models.RoleViewer, Scope: models.ScopeCompaniesRead,
+			Handler: h.CompanyACLHandler},
+		{Method: http.MethodPost, Path: companyACL, Role: models.RoleEditor, Scope: models.ScopeCompaniesWrite,
+			Handler: h.ShareCompanyHandler},
+		{Method: http.MethodDelete, Path: companyACLEntry, Role: models.RoleEditor, Scope: models.ScopeCompaniesWrite,
+			Handler: h.UnshareCompanyHandler},
+		{Method: http.MethodPost, Path: companyPurge, Role: models.RoleAdmin, Scope: models.ScopeAdmin,
+			Handler: h.PurgeCompaniesHandler},
+		{Method: http.MethodPost, Path: users,
+			Handler: h.idempotency.Middleware(http.HandlerFunc(h.CreateUser)).ServeHTTP},
+		{Method: http.MethodPost, Path: usersLogin, Handler: h.LoginUser},
+		{Method: http.MethodPost, Path: usersLoginMFA, Handler: h.LoginMFA},
+		{Method: http.MethodGet, Path: usersMe, Role: models.RoleViewer, Handler: h.CurrentUserHandler},
+		{Method: http.MethodGet, Path: adminUsers, Role: models.RoleAdmin, Scope: models.ScopeAdmin,
+			Handler: h.ListUsersHandler},
+		{Method: http.MethodGet, Path: adminUser, Role: models.RoleAdmin, Scope: models.ScopeAdmin,
+			Handler: h.GetUserHandler},
+		{Method: http.MethodDelete, Path: adminUser, Role: models.RoleAdmin, Scope: models.ScopeAdmin,
+			Handler: h.DeleteUserHandler},
+		{Method: http.MethodPut, Path: userRole, Role: models.RoleAdmin, Scope: models.ScopeAdmin,
+			Handler: h.SetUserRoleHandler},
+		{Method: http.MethodPost, Path: userDisable, Role: models.RoleAdmin, Scope: models.ScopeAdmin,
+			Handler: h.setUserDisabledHandler(true)},
+		{Method: http.MethodPost, Path: userEnable, Role: models.RoleAdmin, Scope: models.ScopeAdmin,
+			Handler: h.setUserDisabledHandler(false)},
+	})
+	var revoked middleware.RevocationList
+	if h.tokens != nil {
+		revoked = h.tokens
+	}
+	var verifier middleware.APIKeyVerifier
+	if h.apiKeys != nil {
+		verifier = h.apiKeys
+	}
+	router.Use(middleware.PanicAndRecover, middleware.Logging, middleware.Authenticate(revoked, verifier))
 	router.Methods(http.MethodPost).Subrouter()
 }
 
@@ -78,13 +173,96 @@ func (h handler) LoginUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	usr, err := h.service.Login(r.Context(), u)
+	ip := lockout.ClientIP(r, h.config.ClientIPHeader)
+	if retryAfter, err := h.lockout.Check(r.Context(), u.Name, ip); err != nil {
+		setRetryAfter(w, retryAfter)
+		h.writeError(w, http.StatusTooManyRequests, "too many failed logins, try again later")
+		return
+	}
+
+	usr, challenge, err := h.service.Login(r.Context(), u)
+	if errors.Is(err, uerrors.ErrFindOneUser) || errors.Is(err, uerrors.ErrCheckUserPasswordHash) {
+		h.logger.Entry.Errorf("error with user login: %v", err)
+		setRetryAfter(w, h.lockout.Failed(r.Context(), u.Name, ip))
+		h.writeError(w, http.StatusUnauthorized, "wrong user name or password")
+		return
+	}
+	if errors.Is(err, uerrors.ErrUserDisabled) {
+		h.writeError(w, http.StatusForbidden, "user is disabled")
+		return
+	}
 	if err != nil {
 		h.logger.Entry.Errorf("error with user login: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	// failures are only forgotten once the code of the challenge is verified
+	if challenge != "" {
+		w.Header().Add(headerContentType, headerValueContentType)
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(MFAChallengeResponse{MFARequired: true, ChallengeToken: challenge}); err != nil {
+			h.logger.Entry.Errorf("Failed to login user: %+v", err)
+		}
+		return
+	}
+	h.lockout.Succeeded(r.Context(), u.Name)
+
+	h.writeLogin(w, r, usr)
+}
+
+// LoginMFA exchanges the challenge of the login of a user with MFA and a TOTP
+// or recovery code for the tokens. Wrong codes count as failed logins of the user.
+func (h handler) LoginMFA(w http.ResponseWriter, r *http.Request) {
+	req := &MFALoginRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		h.logger.Entry.Error("wrong json format")
+		h.writeError(w, http.StatusBadRequest, "wrong json format")
+		return
+	}
+	if err := validator.New().Struct(req); err != nil {
+		h.logger.Entry.Errorf("got wrong MFA data: %+v", err)
+		h.writeError(w, http.StatusBadRequest, fmt.Sprintf("got wrong MFA data: %+v", err))
+		return
+	}
+
+	usr, err := h.service.VerifyMFA(r.Context(), req.ChallengeToken, req.Code)
+	if errors.Is(err, uerrors.ErrInvalidMFACode) {
+		h.logger.Entry.Errorf("error with MFA login: %v", err)
+		setRetryAfter(w, h.lockout.Failed(r.Context(), usr.Name, lockout.ClientIP(r, h.config.ClientIPHeader)))
+		h.writeError(w, http.StatusUnauthorized, "wrong MFA code")
+		return
+	}
+	if errors.Is(err, uerrors.ErrInvalidMFAChallenge) {
+		h.writeError(w, http.StatusUnauthorized, "MFA challenge is unknown or expired, log in again")
+		return
+	}
+	if errors.Is(err, uerrors.ErrUserDisabled) {
+		h.writeError(w, http.StatusForbidden, "user is disabled")
+		return
 	}
-	hash, err := h.service.CreateToken(strconv.FormatUint(uint64(usr.Id), 10))
+	if err != nil {
+		h.logger.Entry.Errorf("error with MFA login: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	h.lockout.Succeeded(r.Context(), usr.Name)
+
+	h.writeLogin(w, r, usr)
+}
+
+// writeLogin answers a successful login with the tokens of the user.
+func (h handler) writeLogin(w http.ResponseWriter, r *http.Request, usr models.User) {
+	hash, err := h.service.CreateToken(strconv.FormatUint(uint64(usr.Id), 10), usr.Role)
 	if err != nil {
 		h.logger.Entry.Errorf("error with create token: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	var refreshToken string
+	if h.tokens != nil {
+		if refreshToken, err = h.tokens.IssueRefreshToken(r.Context(), usr.Id); err != nil {
+			h.logger.Entry.Errorf("error with issue refresh token: %v", err)
+		}
 	}
 
 	accessTokenTTL, err := time.ParseDuration(h.config.AccessTokenTTL)
@@ -96,7 +274,8 @@ func (h handler) LoginUser(w http.ResponseWriter, r *http.Request) {
 	w.Header().Add(headerContentType, headerValueContentType)
 	w.WriteHeader(http.StatusOK)
 	responseBody := UserLoginResponse{
-		Hash: hash,
+		Hash:         hash,
+		RefreshToken: refreshToken,
 	}
 	if err := json.NewEncoder(w).Encode(responseBody); err != nil {
 		h.logger.Entry.Errorf("Failed to login user: %+v", err)
@@ -131,7 +310,11 @@ func (h handler) CreateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := h.service.CreateUser(u)
+	user, err := h.service.CreateUser(u, models.Role(h.config.DefaultUserRole))
+	if errors.Is(err, password.ErrWeakPassword) {
+		h.writeError(w, http.StatusBadRequest, fmt.Sprintf("got wrong user data: %s", errors.Unwrap(err)))
+		return
+	}
 	if err != nil {
 		h.logger.Entry.Errorf("can't create user: %+v", err)
 		w.WriteHeader(http.StatusInternalServerError)
@@ -143,6 +326,7 @@ func (h handler) CreateUser(w http.ResponseWriter, r *http.Request) {
 	responseBody := UserCreateResponse{
 		ID:   user.Id,
 		Name: user.Name,
+		Role: user.Role,
 	}
 
 	if err := json.NewEncoder(w).Encode(responseBody); err != nil {
@@ -152,6 +336,168 @@ func (h handler) CreateUser(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// SetUserRoleHandler assigns the role to the user. The role applies
+// to the tokens issued to the user afterwards.
+func (h handler) SetUserRoleHandler(w http.ResponseWriter, r *http.Request) {
+	userId, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 32)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, "user id must be a positive integer")
+		return
+	}
+	req := RoleRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, "wrong json format")
+		return
+	}
+	if err := validator.New().Struct(req); err != nil {
+		h.writeError(w, http.StatusBadRequest, fmt.Sprintf("got wrong role: %+v", err))
+		return
+	}
+
+	user, err := h.service.SetUserRole(r.Context(), uint(userId), req.Role)
+	switch {
+	case errors.Is(err, uerrors.ErrGetUser):
+		h.writeError(w, http.StatusNotFound, uerrors.ErrGetUser.Error())
+		return
+	case errors.Is(err, uerrors.ErrChangeOwnRole):
+		h.writeError(w, http.StatusConflict, uerrors.ErrChangeOwnRole.Error())
+		return
+	case err != nil:
+		h.logger.Entry.Errorf("can't set role of user: %+v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add(headerContentType, headerValueContentType)
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(UserCreateResponse{ID: user.Id, Name: user.Name, Role: user.Role}); err != nil {
+		h.logger.Entry.Errorf("problems with encoding data: %+v", err)
+	}
+}
+
+// CurrentUserHandler returns the user the request is authenticated as.
+func (h handler) CurrentUserHandler(w http.ResponseWriter, r *http.Request) {
+	userId, err := strconv.ParseUint(auth.UserIdFromContext(r.Context()), 10, 32)
+	if err != nil {
+		h.writeError(w, http.StatusUnauthorized, uerrors.ErrGetUser.Error())
+		return
+	}
+	h.writeUser(w, r, uint(userId))
+}
+
+func (h handler) GetUserHandler(w http.ResponseWriter, r *http.Request) {
+	userId, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 32)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, "user id must be a positive integer")
+		return
+	}
+	h.writeUser(w, r, uint(userId))
+}
+
+func (h handler) writeUser(w http.ResponseWriter, r *http.Request, userId uint) {
+	user, err := h.service.GetUser(r.Context(), userId)
+	if errors.Is(err, uerrors.ErrGetUser) {
+		h.writeError(w, http.StatusNotFound, uerrors.ErrGetUser.Error())
+		return
+	}
+	if err != nil {
+		h.logger.Entry.Errorf("can't get user: %+v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add(headerContentType, headerValueContentType)
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(user); err != nil {
+		h.logger.Entry.Errorf("problems with encoding data: %+v", err)
+	}
+}
+
+func (h handler) ListUsersHandler(w http.ResponseWriter, r *http.Request) {
+	limit, offset, err := parsePage(r.URL.Query())
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, fmt.Sprintf("got wrong page: %s", err))
+		return
+	}
+
+	users, total, err := h.service.ListUsers(r.Context(), limit, offset)
+	if err != nil {
+		h.logger.Entry.Errorf("can't list users: %+v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if users == nil {
+		users = []models.User{}
+	}
+
+	w.Header().Add(headerContentType, headerValueContentType)
+	w.WriteHeader(http.StatusOK)
+	responseBody := UserListResponse{
+		Data:   users,
+		Total:  total,
+		Limit:  limit,
+		Offset: offset,
+	}
+	if err := json.NewEncoder(w).Encode(responseBody); err != nil {
+		h.logger.Entry.Errorf("can't list users: %+v", err)
+	}
+}
+
+// setUserDisabledHandler disables or enables the user. The tokens of disabled
+// users are rejected right away, not only after they expire.
+func (h handler) setUserDisabledHandler(disabled bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userId, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 32)
+		if err != nil {
+			h.writeError(w, http.StatusBadRequest, "user id must be a positive integer")
+			return
+		}
+
+		user, err := h.service.SetUserDisabled(r.Context(), uint(userId), disabled)
+		switch {
+		case errors.Is(err, uerrors.ErrGetUser):
+			h.writeError(w, http.StatusNotFound, uerrors.ErrGetUser.Error())
+			return
+		case errors.Is(err, uerrors.ErrChangeOwnAccount):
+			h.writeError(w, http.StatusConflict, uerrors.ErrChangeOwnAccount.Error())
+			return
+		case err != nil:
+			h.logger.Entry.Errorf("can't update user: %+v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Add(headerContentType, headerValueContentType)
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(user); err != nil {
+			h.logger.Entry.Errorf("problems with encoding data: %+v", err)
+		}
+	}
+}
+
+func (h handler) DeleteUserHandler(w http.ResponseWriter, r *http.Request) {
+	userId, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 32)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, "user id must be a positive integer")
+		return
+	}
+
+	err = h.service.DeleteUser(r.Context(), uint(userId))
+	switch {
+	case errors.Is(err, uerrors.ErrGetUser):
+		h.writeError(w, http.StatusNotFound, uerrors.ErrGetUser.Error())
+		return
+	case errors.Is(err, uerrors.ErrChangeOwnAccount):
+		h.writeError(w, http.StatusConflict, uerrors.ErrChangeOwnAccount.Error())
+		return
+	case err != nil:
+		h.logger.Entry.Errorf("can't delete user: %+v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
 func (h handler) GetCompanyHandler(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	cId, err := uuid.Parse(params["id"])
@@ -168,6 +514,11 @@ func (h handler) GetCompanyHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	w.Header().Set(headerETag, etag(c.Version))
+	if !noneMatch(r, c.Version) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
 	w.Header().Add(headerContentType, headerValueContentType)
 	w.WriteHeader(http.StatusOK)
 	if err := json.NewEncoder(w).Encode(c); err != nil {
@@ -177,6 +528,103 @@ func (h handler) GetCompanyHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func (h handler) ListCompaniesHandler(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseCompanyFilter(r)
+	if err != nil {
+		w.Header().Add(headerContentType, headerValueContentType)
+		w.WriteHeader(http.StatusBadRequest)
+		responseBody := uerrors.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: fmt.Sprintf("got wrong filter: %s", err),
+		}
+		if err := json.NewEncoder(w).Encode(responseBody); err != nil {
+			h.logger.Entry.Errorf("problems with encoding data: %+v", err)
+		}
+		h.logger.Entry.Errorf("got wrong filter: %s", err)
+		return
+	}
+
+	page, err := h.service.ListCompanies(r.Context(), filter)
+	if errors.Is(err, uerrors.ErrInvalidCursor) {
+		w.Header().Add(headerContentType, headerValueContentType)
+		w.WriteHeader(http.StatusBadRequest)
+		responseBody := uerrors.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: uerrors.ErrInvalidCursor.Error(),
+		}
+		if err := json.NewEncoder(w).Encode(responseBody); err != nil {
+			h.logger.Entry.Errorf("problems with encoding data: %+v", err)
+		}
+		return
+	}
+	if err != nil {
+		h.logger.Entry.Errorf("can't list companies: %+v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if page.Companies == nil {
+		page.Companies = []models.Company{}
+	}
+
+	w.Header().Add(headerContentType, headerValueContentType)
+	w.WriteHeader(http.StatusOK)
+	responseBody := CompanyListResponse{
+		Data:       page.Companies,
+		Total:      page.Total,
+		Limit:      filter.Limit,
+		Offset:     filter.Offset,
+		NextCursor: page.NextCursor,
+		PrevCursor: page.PrevCursor,
+		Links:      paginationLinks(r.URL, filter, page),
+	}
+	if err := json.NewEncoder(w).Encode(responseBody); err != nil {
+		h.logger.Entry.Errorf("can't list companies: %+v", err)
+		return
+	}
+}
+
+func (h handler) SearchCompaniesHandler(w http.ResponseWriter, r *http.Request) {
+	query, err := parseSearchQuery(r)
+	if err != nil {
+		w.Header().Add(headerContentType, headerValueContentType)
+		w.WriteHeader(http.StatusBadRequest)
+		responseBody := uerrors.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: fmt.Sprintf("got wrong search query: %s", err),
+		}
+		if err := json.NewEncoder(w).Encode(responseBody); err != nil {
+			h.logger.Entry.Errorf("problems with encoding data: %+v", err)
+		}
+		h.logger.Entry.Errorf("got wrong search query: %s", err)
+		return
+	}
+
+	results, total, err := h.service.SearchCompanies(r.Context(), query)
+	if err != nil {
+		h.logger.Entry.Errorf("can't search companies: %+v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if results == nil {
+		results = []SearchResult{}
+	}
+
+	w.Header().Add(headerContentType, headerValueContentType)
+	w.WriteHeader(http.StatusOK)
+	responseBody := CompanySearchResponse{
+		Data:   results,
+		Total:  total,
+		Limit:  query.Limit,
+		Offset: query.Offset,
+		Links: paginationLinks(r.URL, CompanyFilter{Limit: query.Limit, Offset: query.Offset},
+			CompanyPage{Total: total}),
+	}
+	if err := json.NewEncoder(w).Encode(responseBody); err != nil {
+		h.logger.Entry.Errorf("can't search companies: %+v", err)
+		return
+	}
+}
+
 func (h handler) CreateCompanyHandler(w http.ResponseWriter, r *http.Request) {
 	companyData := &models.Company{}
 	err := json.NewDecoder(r.Body).Decode(&companyData)
@@ -186,8 +634,7 @@ func (h handler) CreateCompanyHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	v := validator.New()
-	if err := v.Struct(companyData); err != nil {
+	if err := ValidateCompany(companyData); err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		responseBody := uerrors.ErrorResponse{
 			Code:    http.StatusBadRequest,
@@ -209,6 +656,7 @@ func (h handler) CreateCompanyHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	w.Header().Set(headerETag, etag(c.Version))
 	w.Header().Add(headerContentType, headerValueContentType)
 	w.WriteHeader(http.StatusOK)
 
@@ -219,23 +667,138 @@ func (h handler) CreateCompanyHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// UpdateCompanyHandler replaces the company identified by the id in the request body.
 func (h handler) UpdateCompanyHandler(w http.ResponseWriter, r *http.Request) {
+	h.replaceCompany(w, r, uuid.Nil)
+}
+
+func (h handler) ReplaceCompanyHandler(w http.ResponseWriter, r *http.Request) {
+	cId, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.logger.Entry.Errorf("can't parse UUID: %+v", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	h.replaceCompany(w, r, cId)
+}
+
+// replaceCompany writes all fields of the company from the request body, zero values included.
+// The company is identified by companyId or by the id in the body when companyId is nil.
+func (h handler) replaceCompany(w http.ResponseWriter, r *http.Request, companyId uuid.UUID) {
+	version, ok := h.requireIfMatch(w, r)
+	if !ok {
+		return
+	}
 	companyData := &models.Company{}
 	err := json.NewDecoder(r.Body).Decode(companyData)
 	if err != nil {
 		h.logger.Entry.Error("wrong json format")
-		w.WriteHeader(http.StatusInternalServerError)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if companyId != uuid.Nil {
+		if companyData.Id != uuid.Nil && companyData.Id != companyId {
+			h.writeError(w, http.StatusBadRequest, "company id in the body does not match the path")
+			return
+		}
+		companyData.Id = companyId
+	}
+	if err := ValidateCompany(companyData); err != nil {
+		h.logger.Entry.Errorf("got wrong company data: %+v", err)
+		h.writeError(w, http.StatusBadRequest, fmt.Sprintf("got wrong company data: %+v", err))
 		return
 	}
+
+	companyData.Version = version
 	err = h.service.UpdateCompany(r.Context(), companyData)
+	if errors.Is(err, uerrors.ErrVersionMismatch) {
+		h.writeError(w, http.StatusPreconditionFailed, uerrors.ErrVersionMismatch.Error())
+		return
+	}
+	if errors.Is(err, uerrors.ErrCompanyForbidden) {
+		h.writeError(w, http.StatusForbidden, uerrors.ErrCompanyForbidden.Error())
+		return
+	}
 	if err != nil {
 		h.logger.Entry.Errorf("can't update company: %+v", err)
 		w.WriteHeader(http.StatusNotFound)
 		return
 	}
 
+	w.Header().Set(headerETag, etag(companyData.Version))
+	w.Header().Add(headerContentType, headerValueContentType)
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(companyData); err != nil {
+		h.logger.Entry.Errorf("can't update company: %+v", err)
+		return
+	}
+}
+
+// PatchCompanyHandler applies JSON Merge Patch or JSON Patch, depending on
+// the content type of the request, to the company.
+func (h handler) PatchCompanyHandler(w http.ResponseWriter, r *http.Request) {
+	cId, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.logger.Entry.Errorf("can't parse UUID: %+v", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	version, ok := h.requireIfMatch(w, r)
+	if !ok {
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		h.logger.Entry.Errorf("can't read patch: %+v", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var p patch.Patch
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get(headerContentType))
+	switch mediaType {
+	case patch.MergePatchContentType:
+		p, err = patch.ParseMergePatch(body)
+	case patch.JSONPatchContentType:
+		p, err = patch.ParseJSONPatch(body)
+	default:
+		w.Header().Set(headerAcceptPatch, patch.MergePatchContentType+", "+patch.JSONPatchContentType)
+		h.writeError(w, http.StatusUnsupportedMediaType, fmt.Sprintf("patch must be either %s or %s",
+			patch.MergePatchContentType, patch.JSONPatchContentType))
+		return
+	}
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	c, err := h.service.PatchCompany(r.Context(), cId, version, p)
+	switch {
+	case errors.Is(err, uerrors.ErrVersionMismatch):
+		h.writeError(w, http.StatusPreconditionFailed, uerrors.ErrVersionMismatch.Error())
+		return
+	case errors.Is(err, uerrors.ErrInvalidPatch):
+		h.writeError(w, http.StatusUnprocessableEntity, fmt.Sprintf("got wrong company data: %s", errors.Unwrap(err)))
+		return
+	case errors.Is(err, uerrors.ErrCompanyForbidden):
+		h.writeError(w, http.StatusForbidden, uerrors.ErrCompanyForbidden.Error())
+		return
+	case errors.Is(err, uerrors.ErrGetCompany):
+		w.WriteHeader(http.StatusNotFound)
+		return
+	case err != nil:
+		h.logger.Entry.Errorf("can't patch company: %+v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set(headerETag, etag(c.Version))
 	w.Header().Add(headerContentType, headerValueContentType)
 	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(c); err != nil {
+		h.logger.Entry.Errorf("can't patch company: %+v", err)
+		return
+	}
 }
 
 func (h handler) DeleteCompanyHandler(w http.ResponseWriter, r *http.Request) {
@@ -246,9 +809,24 @@ func (h handler) DeleteCompanyHandler(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
+	version, ok := h.requireIfMatch(w, r)
+	if !ok {
+		return
+	}
 
-	err = h.service.DeleteCompany(cId)
-
+	err = h.service.DeleteCompany(r.Context(), cId, version)
+	if errors.Is(err, uerrors.ErrGetCompany) {
+		h.writeError(w, http.StatusNotFound, "company not found")
+		return
+	}
+	if errors.Is(err, uerrors.ErrVersionMismatch) {
+		h.writeError(w, http.StatusPreconditionFailed, uerrors.ErrVersionMismatch.Error())
+		return
+	}
+	if errors.Is(err, uerrors.ErrCompanyForbidden) {
+		h.writeError(w, http.StatusForbidden, uerrors.ErrCompanyForbidden.Error())
+		return
+	}
 	if err != nil {
 		h.logger.Entry.Errorf("can't delete company: %+v", err)
 		w.WriteHeader(http.StatusInternalServerError)
@@ -258,3 +836,253 @@ func (h handler) DeleteCompanyHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Add(headerContentType, headerValueContentType)
 	w.WriteHeader(http.StatusOK)
 }
+
+func (h handler) RestoreCompanyHandler(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	cId, err := uuid.Parse(params["id"])
+	if err != nil {
+		h.logger.Entry.Errorf("can't parse UUID: %+v", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	c, err := h.service.RestoreCompany(r.Context(), cId)
+	switch {
+	case errors.Is(err, uerrors.ErrGetCompany):
+		h.writeError(w, http.StatusNotFound, "deleted company not found")
+		return
+	case errors.Is(err, uerrors.ErrCompanyNameTaken):
+		h.writeError(w, http.StatusConflict, uerrors.ErrCompanyNameTaken.Error())
+		return
+	case errors.Is(err, uerrors.ErrCompanyForbidden):
+		h.writeError(w, http.StatusForbidden, uerrors.ErrCompanyForbidden.Error())
+		return
+	case err != nil:
+		h.logger.Entry.Errorf("can't restore company: %+v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set(headerETag, etag(c.Version))
+	w.Header().Add(headerContentType, headerValueContentType)
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(c); err != nil {
+		h.logger.Entry.Errorf("can't restore company: %+v", err)
+		return
+	}
+}
+
+func (h handler) CompanyACLHandler(w http.ResponseWriter, r *http.Request) {
+	cId, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.logger.Entry.Errorf("can't parse UUID: %+v", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	ownerId, entries, err := h.service.CompanyACL(r.Context(), cId)
+	if err != nil {
+		h.writeACLError(w, err)
+		return
+	}
+	if entries == nil {
+		entries = []models.ACLEntry{}
+	}
+
+	w.Header().Add(headerContentType, headerValueContentType)
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(CompanyACLResponse{OwnerId: ownerId, Data: entries}); err != nil {
+		h.logger.Entry.Errorf("problems with encoding data: %+v", err)
+	}
+}
+
+// ShareCompanyHandler grants the permission on the company to the user or group
+// of the request, replacing the permission it had before.
+func (h handler) ShareCompanyHandler(w http.ResponseWriter, r *http.Request) {
+	cId, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.logger.Entry.Errorf("can't parse UUID: %+v", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	req := ShareRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, "wrong json format")
+		return
+	}
+	if err := validator.New().Struct(req); err != nil {
+		h.writeError(w, http.StatusBadRequest, fmt.Sprintf("got wrong ACL entry: %+v", err))
+		return
+	}
+
+	entry, err := h.service.ShareCompany(r.Context(), cId, req)
+	if err != nil {
+		h.writeACLError(w, err)
+		return
+	}
+
+	w.Header().Add(headerContentType, headerValueContentType)
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(entry); err != nil {
+		h.logger.Entry.Errorf("problems with encoding data: %+v", err)
+	}
+}
+
+func (h handler) UnshareCompanyHandler(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	cId, err := uuid.Parse(params["id"])
+	if err != nil {
+		h.logger.Entry.Errorf("can't parse UUID: %+v", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	entryId, err := uuid.Parse(params["entryId"])
+	if err != nil {
+		h.logger.Entry.Errorf("can't parse UUID: %+v", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err = h.service.UnshareCompany(r.Context(), cId, entryId); err != nil {
+		h.writeACLError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h handler) writeACLError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, uerrors.ErrGetCompany):
+		h.writeError(w, http.StatusNotFound, "company not found")
+	case errors.Is(err, uerrors.ErrCompanyForbidden):
+		h.writeError(w, http.StatusForbidden, uerrors.ErrCompanyForbidden.Error())
+	case errors.Is(err, uerrors.ErrACLEntryNotFound):
+		h.writeError(w, http.StatusNotFound, uerrors.ErrACLEntryNotFound.Error())
+	case errors.Is(err, uerrors.ErrGetUser):
+		h.writeError(w, http.StatusUnprocessableEntity, "user not found")
+	case errors.Is(err, uerrors.ErrGroupNotFound):
+		h.writeError(w, http.StatusUnprocessableEntity, uerrors.ErrGroupNotFound.Error())
+	case errors.Is(err, uerrors.ErrInvalidACLEntry):
+		h.writeError(w, http.StatusBadRequest, "either userId or groupId must be given with a known permission")
+	default:
+		h.logger.Entry.Errorf("ACL request failed: %+v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// PurgeCompaniesHandler permanently removes companies deleted longer ago
+// than the olderThan duration, or the configured retention period by default.
+func (h handler) PurgeCompaniesHandler(w http.ResponseWriter, r *http.Request) {
+	olderThan := r.URL.Query().Get(queryOlderThan)
+	if olderThan == "" {
+		olderThan = h.config.CompanyRetention
+	}
+	retention, err := time.ParseDuration(olderThan)
+	if err != nil || retention < 0 {
+		h.writeError(w, http.StatusBadRequest, fmt.Sprintf("%s must be a non-negative duration", queryOlderThan))
+		return
+	}
+
+	purged, err := h.service.PurgeCompanies(r.Context(), time.Now().Add(-retention))
+	if err != nil {
+		h.logger.Entry.Errorf("can't purge companies: %+v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add(headerContentType, headerValueContentType)
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(PurgeResponse{Purged: purged}); err != nil {
+		h.logger.Entry.Errorf("can't purge companies: %+v", err)
+		return
+	}
+}
+
+func (h handler) CompanyHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	cId, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.logger.Entry.Errorf("can't parse UUID: %+v", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	limit, offset, err := parsePage(r.URL.Query())
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, fmt.Sprintf("got wrong page: %s", err))
+		return
+	}
+
+	revisions, total, err := h.service.CompanyHistory(r.Context(), cId, limit, offset)
+	if errors.Is(err, uerrors.ErrRevisionNotFound) {
+		h.writeError(w, http.StatusNotFound, "company history not found")
+		return
+	}
+	if err != nil {
+		h.logger.Entry.Errorf("can't get company history: %+v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if revisions == nil {
+		revisions = []models.CompanyRevision{}
+	}
+
+	w.Header().Add(headerContentType, headerValueContentType)
+	w.WriteHeader(http.StatusOK)
+	responseBody := CompanyHistoryResponse{
+		Data:   revisions,
+		Total:  total,
+		Limit:  limit,
+		Offset: offset,
+	}
+	if err := json.NewEncoder(w).Encode(responseBody); err != nil {
+		h.logger.Entry.Errorf("can't get company history: %+v", err)
+		return
+	}
+}
+
+func (h handler) CompanyRevisionHandler(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	cId, err := uuid.Parse(params["id"])
+	if err != nil {
+		h.logger.Entry.Errorf("can't parse UUID: %+v", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	rev, err := strconv.Atoi(params["rev"])
+	if err != nil || rev < 1 {
+		h.writeError(w, http.StatusBadRequest, "revision must be a positive integer")
+		return
+	}
+
+	revision, err := h.service.CompanyRevision(r.Context(), cId, rev)
+	if errors.Is(err, uerrors.ErrRevisionNotFound) {
+		h.writeError(w, http.StatusNotFound, uerrors.ErrRevisionNotFound.Error())
+		return
+	}
+	if err != nil {
+		h.logger.Entry.Errorf("can't get company revision: %+v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add(headerContentType, headerValueContentType)
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(revision); err != nil {
+		h.logger.Entry.Errorf("can't get company revision: %+v", err)
+		return
+	}
+}
+
+// setRetryAfter tells the client the seconds to wait, rounded up, if it has to wait at all.
+func setRetryAfter(w http.ResponseWriter, retryAfter time.Duration) {
+	if retryAfter > 0 {
+		w.Header().Set(headerRetryAfter, strconv.Itoa(int((retryAfter+time.Second-1)/time.Second)))
+	}
+}
+
+func (h handler) writeError(w http.ResponseWriter, code int, message string) {
+	w.Header().Add(headerContentType, headerValueContentType)
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(uerrors.ErrorResponse{Code: code, Message: message}); err != nil {
+		h.logger.Entry.Errorf("problems with encoding data: %+v", err)
+	}
+}
@@ -1,20 +1,28 @@
 package company_test
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
 	"githib.com/dkischenko/company-api/configs"
 	"githib.com/dkischenko/company-api/internal/company"
 	mock_company "githib.com/dkischenko/company-api/internal/company/mocks"
+	uerrors "githib.com/dkischenko/company-api/internal/errors"
 	"githib.com/dkischenko/company-api/models"
+	"githib.com/dkischenko/company-api/pkg/auth"
 	"githib.com/dkischenko/company-api/pkg/hasher"
 	"githib.com/dkischenko/company-api/pkg/logger"
 	"github.com/caarlos0/env"
 	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestHandler_RegisterOk(t *testing.T) {
@@ -41,15 +49,1237 @@ func TestHandler_RegisterOk(t *testing.T) {
 		l, _ := logger.GetLogger()
 		mockService := mock_company.NewMockIService(ctrl)
 		hash, _ := hasher.HashPassword("password")
-		mockService.EXPECT().CreateUser(&uDTO).Return(models.User{
+		mockService.EXPECT().CreateUser(&uDTO, models.RoleViewer).Return(models.User{
 			Id:           1,
 			Name:         uDTO.Name,
 			PasswordHash: hash,
 		}, nil).AnyTimes()
-		h := company.NewHandler(l, mockService, &cfg)
+		h := company.NewHandler(l, mockService, &cfg, nil, nil, nil, nil)
 		router := mux.NewRouter()
 		h.Register(router)
 		h.CreateUser(w, req)
 		assert.Equal(t, w.Code, http.StatusOK)
 	})
 }
+
+func TestHandler_ListCompanies(t *testing.T) {
+	testCases := []struct {
+		name     string
+		target   string
+		filter   *company.CompanyFilter
+		next     string
+		total    int64
+		wantCode int
+		wantNext string
+	}{
+		{
+			name:     "Default page",
+			target:   "/v1/companies",
+			filter:   &company.CompanyFilter{Sort: "name", Limit: 20},
+			total:    45,
+			wantCode: http.StatusOK,
+			wantNext: "/v1/companies?limit=20&offset=20",
+		},
+		{
+			name:     "Filtered page",
+			target:   "/v1/companies?type=NonProfit&limit=10&offset=10&sort=-amountOfEmployees",
+			filter:   &company.CompanyFilter{Type: models.NonProfit, Sort: "amountOfEmployees", Desc: true, Limit: 10, Offset: 10},
+			total:    15,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "Wrong limit",
+			target:   "/v1/companies?limit=1000",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Cursor page",
+			target:   "/v1/companies?cursor=abc.def",
+			filter:   &company.CompanyFilter{Sort: "name", Limit: 20, Cursor: "abc.def"},
+			next:     "ghi.jkl",
+			total:    45,
+			wantCode: http.StatusOK,
+			wantNext: "/v1/companies?cursor=ghi.jkl&limit=20",
+		},
+		{
+			name:     "Cursor with offset",
+			target:   "/v1/companies?cursor=abc.def&offset=20",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Wrong sort",
+			target:   "/v1/companies?sort=description",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Wrong type",
+			target:   "/v1/companies?type=Unknown",
+			wantCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tcase := range testCases {
+		t.Run(tcase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			cfg := configs.Config{}
+			_ = env.Parse(&cfg)
+			l, _ := logger.GetLogger()
+			mockService := mock_company.NewMockIService(ctrl)
+			if tcase.filter != nil {
+				mockService.EXPECT().ListCompanies(gomock.Any(), *tcase.filter).
+					Return(company.CompanyPage{
+						Companies:  []models.Company{{Name: "Big company"}},
+						Total:      tcase.total,
+						NextCursor: tcase.next,
+					}, nil)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, tcase.target, nil)
+			w := httptest.NewRecorder()
+			h := company.NewHandler(l, mockService, &cfg, nil, nil, nil, nil)
+			h.ListCompaniesHandler(w, req)
+			assert.Equal(t, tcase.wantCode, w.Code)
+			if tcase.wantCode != http.StatusOK {
+				return
+			}
+
+			resp := company.CompanyListResponse{}
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			assert.Equal(t, tcase.total, resp.Total)
+			assert.Len(t, resp.Data, 1)
+			assert.Equal(t, tcase.wantNext, resp.Links.Next)
+		})
+	}
+}
+
+func TestHandler_SearchCompanies(t *testing.T) {
+	t.Run("Search route is not shadowed by company id", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		cfg := configs.Config{}
+		_ = env.Parse(&cfg)
+		l, _ := logger.GetLogger()
+		mockService := mock_company.NewMockIService(ctrl)
+		mockService.EXPECT().SearchCompanies(gomock.Any(), company.SearchQuery{Query: "solar panels", Limit: 20}).
+			Return([]company.SearchResult{{
+				Company: models.Company{Name: "Solar Systems"},
+				Rank:    0.6,
+				Snippet: "We install <mark>solar</mark> <mark>panels</mark>",
+			}}, int64(1), nil)
+
+		h := company.NewHandler(l, mockService, &cfg, nil, nil, nil, nil)
+		router := mux.NewRouter()
+		h.Register(router)
+		req := httptest.NewRequest(http.MethodGet, "/v1/companies/search?q=solar+panels", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		resp := company.CompanySearchResponse{}
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		assert.Equal(t, int64(1), resp.Total)
+		assert.Equal(t, "Solar Systems", resp.Data[0].Name)
+	})
+
+	t.Run("Empty query", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		cfg := configs.Config{}
+		_ = env.Parse(&cfg)
+		l, _ := logger.GetLogger()
+		h := company.NewHandler(l, mock_company.NewMockIService(ctrl), &cfg, nil, nil, nil, nil)
+		req := httptest.NewRequest(http.MethodGet, "/v1/companies/search?q=", nil)
+		w := httptest.NewRecorder()
+		h.SearchCompaniesHandler(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestHandler_CreateCompanyValidation(t *testing.T) {
+	testCases := []struct {
+		name    string
+		payload string
+	}{
+		{
+			name:    "Missing name",
+			payload: `{"amountOfEmployees": 10, "type": "NonProfit"}`,
+		},
+		{
+			name:    "Unknown type",
+			payload: `{"name": "Big company", "amountOfEmployees": 10, "type": "Partnership"}`,
+		},
+		{
+			name:    "Negative amount of employees",
+			payload: `{"name": "Big company", "amountOfEmployees": -1, "type": "NonProfit"}`,
+		},
+	}
+
+	for _, tcase := range testCases {
+		t.Run(tcase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			cfg := configs.Config{}
+			_ = env.Parse(&cfg)
+			l, _ := logger.GetLogger()
+			h := company.NewHandler(l, mock_company.NewMockIService(ctrl), &cfg, nil, nil, nil, nil)
+			req := httptest.NewRequest(http.MethodPost, "/v1/companies", strings.NewReader(tcase.payload))
+			w := httptest.NewRecorder()
+			h.CreateCompanyHandler(w, req)
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+		})
+	}
+}
+
+func TestHandler_RestoreCompany(t *testing.T) {
+	testCases := []struct {
+		name string
+		err  error
+		code int
+	}{
+		{
+			name: "Restored",
+			code: http.StatusOK,
+		},
+		{
+			name: "Not deleted",
+			err:  fmt.Errorf("error occurs: %w", uerrors.ErrGetCompany),
+			code: http.StatusNotFound,
+		},
+		{
+			name: "Name taken",
+			err:  fmt.Errorf("error occurs: %w", uerrors.ErrCompanyNameTaken),
+			code: http.StatusConflict,
+		},
+	}
+
+	for _, tcase := range testCases {
+		t.Run(tcase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			cfg := configs.Config{}
+			_ = env.Parse(&cfg)
+			l, _ := logger.GetLogger()
+			id := uuid.New()
+			mockService := mock_company.NewMockIService(ctrl)
+			mockService.EXPECT().RestoreCompany(gomock.Any(), id).Return(models.Company{Id: id}, tcase.err)
+
+			h := company.NewHandler(l, mockService, &cfg, nil, nil, nil, nil)
+			req := mux.SetURLVars(httptest.NewRequest(http.MethodPost, "/v1/companies/"+id.String()+"/restore", nil),
+				map[string]string{"id": id.String()})
+			w := httptest.NewRecorder()
+			h.RestoreCompanyHandler(w, req)
+			assert.Equal(t, tcase.code, w.Code)
+		})
+	}
+}
+
+func TestHandler_DeleteCompanyNotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cfg := configs.Config{}
+	l, _ := logger.GetLogger()
+	id := uuid.New()
+	mockService := mock_company.NewMockIService(ctrl)
+	mockService.EXPECT().DeleteCompany(gomock.Any(), id, 0).
+		Return(fmt.Errorf("error occurs: %w", fmt.Errorf("cannot get company before delete: %w", uerrors.ErrGetCompany)))
+
+	h := company.NewHandler(l, mockService, &cfg, nil, nil, nil, nil)
+	req := mux.SetURLVars(httptest.NewRequest(http.MethodDelete, "/v1/companies/"+id.String(), nil),
+		map[string]string{"id": id.String()})
+	req.Header.Set("If-Match", "*")
+	w := httptest.NewRecorder()
+	h.DeleteCompanyHandler(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestHandler_PurgeCompanies(t *testing.T) {
+	t.Run("Purged", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		cfg := configs.Config{}
+		_ = env.Parse(&cfg)
+		l, _ := logger.GetLogger()
+		mockService := mock_company.NewMockIService(ctrl)
+		mockService.EXPECT().PurgeCompanies(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, deletedBefore time.Time) (int64, error) {
+				assert.WithinDuration(t, time.Now().Add(-24*time.Hour), deletedBefore, time.Minute)
+				return 3, nil
+			})
+
+		h := company.NewHandler(l, mockService, &cfg, nil, nil, nil, nil)
+		req := httptest.NewRequest(http.MethodPost, "/v1/admin/companies/purge?olderThan=24h", nil)
+		w := httptest.NewRecorder()
+		h.PurgeCompaniesHandler(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		resp := company.PurgeResponse{}
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		assert.Equal(t, int64(3), resp.Purged)
+	})
+
+	t.Run("Wrong duration", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		cfg := configs.Config{}
+		_ = env.Parse(&cfg)
+		l, _ := logger.GetLogger()
+		h := company.NewHandler(l, mock_company.NewMockIService(ctrl), &cfg, nil, nil, nil, nil)
+		req := httptest.NewRequest(http.MethodPost, "/v1/admin/companies/purge?olderThan=month", nil)
+		w := httptest.NewRecorder()
+		h.PurgeCompaniesHandler(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Admins only", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		cfg := configs.Config{}
+		l, _ := logger.GetLogger()
+		h := company.NewHandler(l, mock_company.NewMockIService(ctrl), &cfg, nil, nil, nil, nil)
+		router := mux.NewRouter()
+		h.Register(router)
+		req := httptest.NewRequest(http.MethodPost, "/v1/admin/companies/purge", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+		req = httptest.NewRequest(http.MethodPost, "/v1/admin/companies/purge", nil)
+		req.Header.Set("Authorization", "Bearer "+token(t, "1", models.RoleEditor))
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+}
+
+func TestHandler_CompanyEventsEditorsOnly(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cfg := configs.Config{}
+	l, _ := logger.GetLogger()
+	h := company.NewHandler(l, mock_company.NewMockIService(ctrl), &cfg, nil, nil, nil, nil)
+	router := mux.NewRouter()
+	h.Register(router)
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/companies/events", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/v1/companies/events", nil)
+	req.Header.Set("Authorization", "Bearer "+token(t, "1", models.RoleViewer))
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestHandler_CompanyHistory(t *testing.T) {
+	t.Run("History of a company", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		cfg := configs.Config{}
+		_ = env.Parse(&cfg)
+		l, _ := logger.GetLogger()
+		id := uuid.New()
+		mockService := mock_company.NewMockIService(ctrl)
+		mockService.EXPECT().CompanyHistory(gomock.Any(), id, 20, 0).Return([]models.CompanyRevision{
+			{CompanyId: id, Revision: 2, Action: "company.updated"},
+			{CompanyId: id, Revision: 1, Action: "company.created"},
+		}, int64(2), nil)
+
+		h := company.NewHandler(l, mockService, &cfg, nil, nil, nil, nil)
+		router := mux.NewRouter()
+		h.Register(router)
+		req := httptest.NewRequest(http.MethodGet, "/v1/companies/"+id.String()+"/history", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		resp := company.CompanyHistoryResponse{}
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		assert.Equal(t, int64(2), resp.Total)
+		assert.Equal(t, 2, resp.Data[0].Revision)
+	})
+
+	t.Run("Unknown revision", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		cfg := configs.Config{}
+		_ = env.Parse(&cfg)
+		l, _ := logger.GetLogger()
+		id := uuid.New()
+		mockService := mock_company.NewMockIService(ctrl)
+		mockService.EXPECT().CompanyRevision(gomock.Any(), id, 7).
+			Return(models.CompanyRevision{}, fmt.Errorf("error occurs: %w", uerrors.ErrRevisionNotFound))
+
+		h := company.NewHandler(l, mockService, &cfg, nil, nil, nil, nil)
+		router := mux.NewRouter()
+		h.Register(router)
+		req := httptest.NewRequest(http.MethodGet, "/v1/companies/"+id.String()+"/history/7", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestHandler_GetCompanyETag(t *testing.T) {
+	testCases := []struct {
+		name        string
+		ifNoneMatch string
+		code        int
+	}{
+		{
+			name: "No precondition",
+			code: http.StatusOK,
+		},
+		{
+			name:        "Not modified",
+			ifNoneMatch: `"1", W/"3"`,
+			code:        http.StatusNotModified,
+		},
+		{
+			name:        "Modified",
+			ifNoneMatch: `"2"`,
+			code:        http.StatusOK,
+		},
+	}
+
+	for _, tcase := range testCases {
+		t.Run(tcase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			cfg := configs.Config{}
+			_ = env.Parse(&cfg)
+			l, _ := logger.GetLogger()
+			id := uuid.New()
+			mockService := mock_company.NewMockIService(ctrl)
+			mockService.EXPECT().GetCompany(gomock.Any(), id).Return(models.Company{Id: id, Version: 3}, nil)
+
+			h := company.NewHandler(l, mockService, &cfg, nil, nil, nil, nil)
+			router := mux.NewRouter()
+			h.Register(router)
+			req := httptest.NewRequest(http.MethodGet, "/v1/companies/"+id.String(), nil)
+			if tcase.ifNoneMatch != "" {
+				req.Header.Set("If-None-Match", tcase.ifNoneMatch)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tcase.code, w.Code)
+			assert.Equal(t, `"3"`, w.Header().Get("ETag"))
+		})
+	}
+}
+
+func TestHandler_UpdateCompanyIfMatch(t *testing.T) {
+	payload := `{"id": "af056d5a-0f61-4635-a174-cfddf4b1b01e", "name": "Big company", "type": "NonProfit"}`
+	testCases := []struct {
+		name    string
+		ifMatch string
+		expect  func(s *mock_company.MockIService)
+		code    int
+	}{
+		{
+			name: "Missing If-Match",
+			code: http.StatusPreconditionRequired,
+		},
+		{
+			name:    "Malformed If-Match",
+			ifMatch: "3",
+			code:    http.StatusPreconditionFailed,
+		},
+		{
+			name:    "Stale version",
+			ifMatch: `"3"`,
+			expect: func(s *mock_company.MockIService) {
+				s.EXPECT().UpdateCompany(gomock.Any(), gomock.Any()).
+					Return(fmt.Errorf("error occurs: %w", uerrors.ErrVersionMismatch))
+			},
+			code: http.StatusPreconditionFailed,
+		},
+		{
+			name:    "Updated",
+			ifMatch: `"3"`,
+			expect: func(s *mock_company.MockIService) {
+				s.EXPECT().UpdateCompany(gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, c *models.Company) error {
+						assert.Equal(t, 3, c.Version)
+						c.Version = 4
+						return nil
+					})
+			},
+			code: http.StatusOK,
+		},
+	}
+
+	for _, tcase := range testCases {
+		t.Run(tcase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			cfg := configs.Config{}
+			_ = env.Parse(&cfg)
+			l, _ := logger.GetLogger()
+			mockService := mock_company.NewMockIService(ctrl)
+			if tcase.expect != nil {
+				tcase.expect(mockService)
+			}
+
+			h := company.NewHandler(l, mockService, &cfg, nil, nil, nil, nil)
+			req := httptest.NewRequest(http.MethodPut, "/v1/companies", strings.NewReader(payload))
+			if tcase.ifMatch != "" {
+				req.Header.Set("If-Match", tcase.ifMatch)
+			}
+			w := httptest.NewRecorder()
+			h.UpdateCompanyHandler(w, req)
+			assert.Equal(t, tcase.code, w.Code)
+			if tcase.code == http.StatusOK {
+				assert.Equal(t, `"4"`, w.Header().Get("ETag"))
+			}
+		})
+	}
+}
+
+func TestHandler_PatchCompany(t *testing.T) {
+	testCases := []struct {
+		name        string
+		contentType string
+		payload     string
+		expect      func(s *mock_company.MockIService)
+		code        int
+	}{
+		{
+			name:        "Merge patch",
+			contentType: "application/merge-patch+json",
+			payload:     `{"registered": false}`,
+			expect: func(s *mock_company.MockIService) {
+				s.EXPECT().PatchCompany(gomock.Any(), gomock.Any(), 3, gomock.Any()).
+					Return(models.Company{Version: 4}, nil)
+			},
+			code: http.StatusOK,
+		},
+		{
+			name:        "JSON patch",
+			contentType: "application/json-patch+json; charset=utf-8",
+			payload:     `[{"op": "replace", "path": "/registered", "value": false}]`,
+			expect: func(s *mock_company.MockIService) {
+				s.EXPECT().PatchCompany(gomock.Any(), gomock.Any(), 3, gomock.Any()).
+					Return(models.Company{Version: 4}, nil)
+			},
+			code: http.StatusOK,
+		},
+		{
+			name:        "Unsupported content type",
+			contentType: "application/json",
+			payload:     `{"registered": false}`,
+			code:        http.StatusUnsupportedMediaType,
+		},
+		{
+			name:        "Malformed patch",
+			contentType: "application/json-patch+json",
+			payload:     `[{"op": "rename", "path": "/name"}]`,
+			code:        http.StatusBadRequest,
+		},
+		{
+			name:        "Invalid result",
+			contentType: "application/merge-patch+json",
+			payload:     `{"name": null}`,
+			expect: func(s *mock_company.MockIService) {
+				s.EXPECT().PatchCompany(gomock.Any(), gomock.Any(), 3, gomock.Any()).
+					Return(models.Company{}, fmt.Errorf("error occurs: %w", uerrors.ErrInvalidPatch))
+			},
+			code: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tcase := range testCases {
+		t.Run(tcase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			cfg := configs.Config{}
+			_ = env.Parse(&cfg)
+			l, _ := logger.GetLogger()
+			mockService := mock_company.NewMockIService(ctrl)
+			if tcase.expect != nil {
+				tcase.expect(mockService)
+			}
+
+			h := company.NewHandler(l, mockService, &cfg, nil, nil, nil, nil)
+			id := uuid.New()
+			req := mux.SetURLVars(httptest.NewRequest(http.MethodPatch, "/v1/companies/"+id.String(),
+				strings.NewReader(tcase.payload)), map[string]string{"id": id.String()})
+			req.Header.Set("Content-Type", tcase.contentType)
+			req.Header.Set("If-Match", `"3"`)
+			w := httptest.NewRecorder()
+			h.PatchCompanyHandler(w, req)
+			assert.Equal(t, tcase.code, w.Code)
+		})
+	}
+}
+
+func TestHandler_ReplaceCompany(t *testing.T) {
+	t.Run("Id mismatch", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		cfg := configs.Config{}
+		_ = env.Parse(&cfg)
+		l, _ := logger.GetLogger()
+		h := company.NewHandler(l, mock_company.NewMockIService(ctrl), &cfg, nil, nil, nil, nil)
+		id := uuid.New()
+		payload := `{"id": "af056d5a-0f61-4635-a174-cfddf4b1b01e", "name": "Big company", "type": "NonProfit"}`
+		req := mux.SetURLVars(httptest.NewRequest(http.MethodPut, "/v1/companies/"+id.String(),
+			strings.NewReader(payload)), map[string]string{"id": id.String()})
+		req.Header.Set("If-Match", `"3"`)
+		w := httptest.NewRecorder()
+		h.ReplaceCompanyHandler(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Zero values are replaced", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		cfg := configs.Config{}
+		_ = env.Parse(&cfg)
+		l, _ := logger.GetLogger()
+		id := uuid.New()
+		mockService := mock_company.NewMockIService(ctrl)
+		mockService.EXPECT().UpdateCompany(gomock.Any(), &models.Company{
+			Id:      id,
+			Name:    "Big company",
+			Type:    models.NonProfit,
+			Version: 3,
+		}).Return(nil)
+
+		h := company.NewHandler(l, mockService, &cfg, nil, nil, nil, nil)
+		payload := `{"name": "Big company", "type": "NonProfit", "amountOfEmployees": 0, "registered": false}`
+		req := mux.SetURLVars(httptest.NewRequest(http.MethodPut, "/v1/companies/"+id.String(),
+			strings.NewReader(payload)), map[string]string{"id": id.String()})
+		req.Header.Set("If-Match", `"3"`)
+		w := httptest.NewRecorder()
+		h.ReplaceCompanyHandler(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+}
+
+func TestHandler_ExportCompaniesSlowly(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mock_company.NewMockIService(ctrl)
+	mockService.EXPECT().ExportCompanies(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, filter company.CompanyFilter, fn func(models.Company) error) error {
+			for i := 0; i < 1500; i++ {
+				// the export takes longer than the write timeout of the server
+				if i%500 == 0 {
+					time.Sleep(150 * time.Millisecond)
+				}
+				if err := fn(models.Company{Id: uuid.New(), Name: fmt.Sprintf("Company %d", i)}); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+
+	cfg := configs.Config{}
+	l, _ := logger.GetLogger()
+	h := company.NewHandler(l, mockService, &cfg, nil, nil, nil, nil)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(h.ExportCompaniesHandler))
+	server.Config.WriteTimeout = 200 * time.Millisecond
+	server.Start()
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/v1/companies/export")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, 1501, strings.Count(string(body), "\n"))
+}
+
+func TestHandler_ExportCompanies(t *testing.T) {
+	exported := []models.Company{
+		{Id: uuid.New(), Name: "First, Ltd", Type: models.Cooperative, AmountOfEmployees: 10, Version: 2},
+		{Id: uuid.New(), Name: "Second", Type: models.NonProfit, Registered: true, Version: 1},
+	}
+	export := func(ctx context.Context, filter company.CompanyFilter, fn func(models.Company) error) error {
+		for _, c := range exported {
+			if err := fn(c); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	testCases := []struct {
+		name     string
+		target   string
+		accept   string
+		wantType string
+		wantBody string
+	}{
+		{
+			name:     "CSV by default",
+			target:   "/v1/companies/export?type=Cooperative&limit=5",
+			wantType: "text/csv",
+			wantBody: "id,name,description,amountOfEmployees,registered,type,ownerId,version\n" +
+				exported[0].Id.String() + ",\"First, Ltd\",,10,false,Cooperative,,2\n" +
+				exported[1].Id.String() + ",Second,,0,true,NonProfit,,1\n",
+		},
+		{
+			name:     "NDJSON by Accept",
+			target:   "/v1/companies/export?type=Cooperative",
+			accept:   "application/x-ndjson, text/csv;q=0.5",
+			wantType: "application/x-ndjson",
+			wantBody: `{"id":"` + exported[0].Id.String() + `","name":"First, Ltd","description":"",` +
+				`"amountOfEmployees":10,"registered":false,"type":"Cooperative","version":2}` + "\n" +
+				`{"id":"` + exported[1].Id.String() + `","name":"Second","description":"",` +
+				`"amountOfEmployees":0,"registered":true,"type":"NonProfit","version":1}` + "\n",
+		},
+		{
+			name:     "Format parameter wins over Accept",
+			target:   "/v1/companies/export?type=Cooperative&format=csv",
+			accept:   "application/x-ndjson",
+			wantType: "text/csv",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			cfg := configs.Config{}
+			_ = env.Parse(&cfg)
+			l, _ := logger.GetLogger()
+			mockService := mock_company.NewMockIService(ctrl)
+			mockService.EXPECT().ExportCompanies(gomock.Any(), gomock.Any(), gomock.Any()).
+				DoAndReturn(func(ctx context.Context, filter company.CompanyFilter, fn func(models.Company) error) error {
+					assert.Equal(t, models.Cooperative, filter.Type)
+					return export(ctx, filter, fn)
+				})
+
+			h := company.NewHandler(l, mockService, &cfg, nil, nil, nil, nil)
+			router := mux.NewRouter()
+			h.Register(router)
+			req := httptest.NewRequest(http.MethodGet, tc.target, nil)
+			if tc.accept != "" {
+				req.Header.Set("Accept", tc.accept)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tc.wantType, w.Header().Get("Content-Type"))
+			if tc.wantBody != "" {
+				assert.Equal(t, tc.wantBody, w.Body.String())
+			}
+		})
+	}
+
+	t.Run("Unsupported format", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		cfg := configs.Config{}
+		_ = env.Parse(&cfg)
+		l, _ := logger.GetLogger()
+		h := company.NewHandler(l, mock_company.NewMockIService(ctrl), &cfg, nil, nil, nil, nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/v1/companies/export", nil)
+		req.Header.Set("Accept", "application/xml")
+		w := httptest.NewRecorder()
+		h.ExportCompaniesHandler(w, req)
+		assert.Equal(t, http.StatusNotAcceptable, w.Code)
+
+		req = httptest.NewRequest(http.MethodGet, "/v1/companies/export?format=xml", nil)
+		w = httptest.NewRecorder()
+		h.ExportCompaniesHandler(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Database error before the first row", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		cfg := configs.Config{}
+		_ = env.Parse(&cfg)
+		l, _ := logger.GetLogger()
+		mockService := mock_company.NewMockIService(ctrl)
+		mockService.EXPECT().ExportCompanies(gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(uerrors.ErrExportCompanies)
+		h := company.NewHandler(l, mockService, &cfg, nil, nil, nil, nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/v1/companies/export", nil)
+		w := httptest.NewRecorder()
+		h.ExportCompaniesHandler(w, req)
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
+
+func TestHandler_SetUserRole(t *testing.T) {
+	testCases := []struct {
+		name     string
+		target   string
+		body     string
+		role     models.Role
+		err      error
+		wantCode int
+	}{
+		{
+			name:     "Role assigned",
+			target:   "/v1/admin/users/5/role",
+			body:     `{"role":"viewer"}`,
+			role:     models.RoleViewer,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "Unknown user",
+			target:   "/v1/admin/users/5/role",
+			body:     `{"role":"editor"}`,
+			role:     models.RoleEditor,
+			err:      fmt.Errorf("error occurs: %w", uerrors.ErrGetUser),
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Own role",
+			target:   "/v1/admin/users/5/role",
+			body:     `{"role":"editor"}`,
+			role:     models.RoleEditor,
+			err:      fmt.Errorf("error occurs: %w", uerrors.ErrChangeOwnRole),
+			wantCode: http.StatusConflict,
+		},
+		{
+			name:     "Unknown role",
+			target:   "/v1/admin/users/5/role",
+			body:     `{"role":"owner"}`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Wrong user id",
+			target:   "/v1/admin/users/bill/role",
+			body:     `{"role":"editor"}`,
+			wantCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			cfg := configs.Config{}
+			l, _ := logger.GetLogger()
+			mockService := mock_company.NewMockIService(ctrl)
+			if tc.role != "" {
+				mockService.EXPECT().SetUserRole(gomock.Any(), uint(5), tc.role).
+					Return(models.User{Id: 5, Name: "bill", Role: tc.role}, tc.err)
+			}
+
+			h := company.NewHandler(l, mockService, &cfg, nil, nil, nil, nil)
+			router := mux.NewRouter()
+			h.Register(router)
+			req := httptest.NewRequest(http.MethodPut, tc.target, strings.NewReader(tc.body))
+			req.Header.Set("Authorization", "Bearer "+token(t, "1", models.RoleAdmin))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tc.wantCode, w.Code)
+			if tc.wantCode == http.StatusOK {
+				assert.JSONEq(t, `{"id":5,"name":"bill","role":"viewer"}`, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_Users(t *testing.T) {
+	testCases := []struct {
+		name     string
+		method   string
+		target   string
+		role     models.Role
+		setup    func(s *mock_company.MockIService)
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:   "Current user",
+			method: http.MethodGet,
+			target: "/v1/users/me",
+			role:   models.RoleViewer,
+			setup: func(s *mock_company.MockIService) {
+				s.EXPECT().GetUser(gomock.Any(), uint(1)).
+					Return(models.User{Id: 1, Name: "bob", PasswordHash: "hash", Role: models.RoleViewer}, nil)
+			},
+			wantCode: http.StatusOK,
+			wantBody: `{"id":1,"name":"bob","role":"viewer","mfaEnabled":false}`,
+		},
+		{
+			name:     "Current user without token",
+			method:   http.MethodGet,
+			target:   "/v1/users/me",
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:   "List users",
+			method: http.MethodGet,
+			target: "/v1/admin/users?limit=1&offset=1",
+			role:   models.RoleAdmin,
+			setup: func(s *mock_company.MockIService) {
+				s.EXPECT().ListUsers(gomock.Any(), 1, 1).
+					Return([]models.User{{Id: 2, Name: "bill", Role: models.RoleEditor}}, int64(3), nil)
+			},
+			wantCode: http.StatusOK,
+			wantBody: `{"data":[{"id":2,"name":"bill","role":"editor","mfaEnabled":false}],"total":3,"limit":1,"offset":1}`,
+		},
+		{
+			name:     "List users as editor",
+			method:   http.MethodGet,
+			target:   "/v1/admin/users",
+			role:     models.RoleEditor,
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "Wrong page",
+			method:   http.MethodGet,
+			target:   "/v1/admin/users?limit=-1",
+			role:     models.RoleAdmin,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:   "Unknown user",
+			method: http.MethodGet,
+			target: "/v1/admin/users/5",
+			role:   models.RoleAdmin,
+			setup: func(s *mock_company.MockIService) {
+				s.EXPECT().GetUser(gomock.Any(), uint(5)).Return(models.User{}, fmt.Errorf("error occurs: %w", uerrors.ErrGetUser))
+			},
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:   "Disable user",
+			method: http.MethodPost,
+			target: "/v1/admin/users/5/disable",
+			role:   models.RoleAdmin,
+			setup: func(s *mock_company.MockIService) {
+				s.EXPECT().SetUserDisabled(gomock.Any(), uint(5), true).Return(models.User{Id: 5}, nil)
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:   "Enable own account",
+			method: http.MethodPost,
+			target: "/v1/admin/users/1/enable",
+			role:   models.RoleAdmin,
+			setup: func(s *mock_company.MockIService) {
+				s.EXPECT().SetUserDisabled(gomock.Any(), uint(1), false).
+					Return(models.User{}, fmt.Errorf("error occurs: %w", uerrors.ErrChangeOwnAccount))
+			},
+			wantCode: http.StatusConflict,
+		},
+		{
+			name:   "Delete user",
+			method: http.MethodDelete,
+			target: "/v1/admin/users/5",
+			role:   models.RoleAdmin,
+			setup: func(s *mock_company.MockIService) {
+				s.EXPECT().DeleteUser(gomock.Any(), uint(5)).Return(nil)
+			},
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:     "Wrong user id",
+			method:   http.MethodDelete,
+			target:   "/v1/admin/users/bill",
+			role:     models.RoleAdmin,
+			wantCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			cfg := configs.Config{}
+			l, _ := logger.GetLogger()
+			mockService := mock_company.NewMockIService(ctrl)
+			if tc.setup != nil {
+				tc.setup(mockService)
+			}
+
+			h := company.NewHandler(l, mockService, &cfg, nil, nil, nil, nil)
+			router := mux.NewRouter()
+			h.Register(router)
+			req := httptest.NewRequest(tc.method, tc.target, nil)
+			if tc.role != "" {
+				req.Header.Set("Authorization", "Bearer "+token(t, "1", tc.role))
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tc.wantCode, w.Code)
+			if tc.wantBody != "" {
+				assert.JSONEq(t, tc.wantBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_CompanyACL(t *testing.T) {
+	cId, entryId, userId := uuid.New(), uuid.New(), uint(9)
+	ownerId := uint(1)
+	testCases := []struct {
+		name     string
+		method   string
+		target   string
+		body     string
+		role     models.Role
+		setup    func(s *mock_company.MockIService)
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:   "List ACL",
+			method: http.MethodGet,
+			target: "/v1/companies/" + cId.String() + "/acl",
+			role:   models.RoleViewer,
+			setup: func(s *mock_company.MockIService) {
+				s.EXPECT().CompanyACL(gomock.Any(), cId).Return(&ownerId, nil, nil)
+			},
+			wantCode: http.StatusOK,
+			wantBody: `{"ownerId":1,"data":[]}`,
+		},
+		{
+			name:   "Share with user",
+			method: http.MethodPost,
+			target: "/v1/companies/" + cId.String() + "/acl",
+			body:   `{"userId":9,"permission":"write"}`,
+			role:   models.RoleEditor,
+			setup: func(s *mock_company.MockIService) {
+				s.EXPECT().ShareCompany(gomock.Any(), cId, company.ShareRequest{UserId: &userId, Permission: models.PermissionWrite}).
+					Return(models.ACLEntry{Id: entryId, CompanyId: cId, UserId: &userId, Permission: models.PermissionWrite}, nil)
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "Share with nobody",
+			method:   http.MethodPost,
+			target:   "/v1/companies/" + cId.String() + "/acl",
+			body:     `{"permission":"write"}`,
+			role:     models.RoleEditor,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Share with unknown permission",
+			method:   http.MethodPost,
+			target:   "/v1/companies/" + cId.String() + "/acl",
+			body:     `{"userId":9,"permission":"owner"}`,
+			role:     models.RoleEditor,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:   "Share without permission on company",
+			method: http.MethodPost,
+			target: "/v1/companies/" + cId.String() + "/acl",
+			body:   `{"userId":9,"permission":"read"}`,
+			role:   models.RoleEditor,
+			setup: func(s *mock_company.MockIService) {
+				s.EXPECT().ShareCompany(gomock.Any(), cId, gomock.Any()).
+					Return(models.ACLEntry{}, fmt.Errorf("error occurs: %w", uerrors.ErrCompanyForbidden))
+			},
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "Share as viewer",
+			method:   http.MethodPost,
+			target:   "/v1/companies/" + cId.String() + "/acl",
+			body:     `{"userId":9,"permission":"read"}`,
+			role:     models.RoleViewer,
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:   "Unshare",
+			method: http.MethodDelete,
+			target: "/v1/companies/" + cId.String() + "/acl/" + entryId.String(),
+			role:   models.RoleEditor,
+			setup: func(s *mock_company.MockIService) {
+				s.EXPECT().UnshareCompany(gomock.Any(), cId, entryId).Return(nil)
+			},
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:   "Unshare unknown entry",
+			method: http.MethodDelete,
+			target: "/v1/companies/" + cId.String() + "/acl/" + entryId.String(),
+			role:   models.RoleEditor,
+			setup: func(s *mock_company.MockIService) {
+				s.EXPECT().UnshareCompany(gomock.Any(), cId, entryId).
+					Return(fmt.Errorf("error occurs: %w", uerrors.ErrACLEntryNotFound))
+			},
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:   "Delete company without permission",
+			method: http.MethodDelete,
+			target: "/v1/companies/" + cId.String(),
+			role:   models.RoleEditor,
+			setup: func(s *mock_company.MockIService) {
+				s.EXPECT().DeleteCompany(gomock.Any(), cId, 0).
+					Return(fmt.Errorf("error occurs: %w", uerrors.ErrCompanyForbidden))
+			},
+			wantCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			cfg := configs.Config{}
+			l, _ := logger.GetLogger()
+			mockService := mock_company.NewMockIService(ctrl)
+			if tc.setup != nil {
+				tc.setup(mockService)
+			}
+
+			h := company.NewHandler(l, mockService, &cfg, nil, nil, nil, nil)
+			router := mux.NewRouter()
+			h.Register(router)
+			req := httptest.NewRequest(tc.method, tc.target, strings.NewReader(tc.body))
+			req.Header.Set("Authorization", "Bearer "+token(t, "8", tc.role))
+			req.Header.Set("If-Match", "*")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tc.wantCode, w.Code)
+			if tc.wantBody != "" {
+				assert.JSONEq(t, tc.wantBody, w.Body.String())
+			}
+		})
+	}
+}
+
+// token issues a JWT token of the user with the role.
+func token(t *testing.T, userId string, role models.Role) string {
+	m, err := auth.NewManager(time.Minute)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	token, err := m.CreateJWT(userId, string(role))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	return token
+}
+
+func TestHandler_LoginUser(t *testing.T) {
+	testCases := []struct {
+		name      string
+		challenge string
+		loginErr  error
+		wantCode  int
+		wantBody  string
+	}{
+		{name: "Logged in", wantCode: http.StatusOK, wantBody: `{"hash":"token"}`},
+		{name: "MFA challenge", challenge: "challenge", wantCode: http.StatusOK,
+			wantBody: `{"mfaRequired":true,"challengeToken":"challenge"}`},
+		{name: "Wrong password", loginErr: uerrors.ErrCheckUserPasswordHash, wantCode: http.StatusUnauthorized},
+		{name: "Unknown user", loginErr: uerrors.ErrFindOneUser, wantCode: http.StatusUnauthorized},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			cfg := configs.Config{}
+			_ = env.Parse(&cfg)
+			mockService := mock_company.NewMockIService(ctrl)
+			user := models.User{Id: 1, Name: "bill", Role: models.RoleEditor}
+			mockService.EXPECT().Login(gomock.Any(), gomock.Any()).Return(user, tc.challenge, tc.loginErr)
+			// tokens are only issued to logged in users
+			if tc.loginErr == nil && tc.challenge == "" {
+				mockService.EXPECT().CreateToken("1", models.RoleEditor).Return("token", nil)
+			}
+
+			l, _ := logger.GetLogger()
+			h := company.NewHandler(l, mockService, &cfg, nil, nil, nil, nil)
+			router := mux.NewRouter()
+			h.Register(router)
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/login", strings.NewReader(`{"name":"bill","password":"password"}`))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tc.wantCode, w.Code)
+			if tc.wantBody != "" {
+				assert.JSONEq(t, tc.wantBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_LoginMFA(t *testing.T) {
+	testCases := []struct {
+		name      string
+		verifyErr error
+		wantCode  int
+	}{
+		{name: "Logged in", wantCode: http.StatusOK},
+		{name: "Wrong code", verifyErr: uerrors.ErrInvalidMFACode, wantCode: http.StatusUnauthorized},
+		{name: "Expired challenge", verifyErr: uerrors.ErrInvalidMFAChallenge, wantCode: http.StatusUnauthorized},
+		{name: "Database error", verifyErr: uerrors.ErrMFA, wantCode: http.StatusInternalServerError},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			cfg := configs.Config{}
+			_ = env.Parse(&cfg)
+			mockService := mock_company.NewMockIService(ctrl)
+			user := models.User{Id: 1, Name: "bill", Role: models.RoleEditor, MFAEnabled: true}
+			mockService.EXPECT().VerifyMFA(gomock.Any(), "challenge", "123456").
+				Return(user, tc.verifyErr)
+			if tc.verifyErr == nil {
+				mockService.EXPECT().CreateToken("1", models.RoleEditor).Return("token", nil)
+			}
+
+			l, _ := logger.GetLogger()
+			h := company.NewHandler(l, mockService, &cfg, nil, nil, nil, nil)
+			router := mux.NewRouter()
+			h.Register(router)
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/login/mfa",
+				strings.NewReader(`{"challengeToken":"challenge","code":"123456"}`))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tc.wantCode, w.Code)
+			if tc.wantCode == http.StatusOK {
+				assert.JSONEq(t, `{"hash":"token"}`, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package company
+
+import (
+	"encoding/json"
+	"githib.com/dkischenko/company-api/internal/events"
+	"githib.com/dkischenko/company-api/models"
+	"reflect"
+	"sort"
+)
+
+// diffIgnored lists fields which never change and are left out of diffs.
+var diffIgnored = map[string]struct{}{
+	"id":      {},
+	"version": {},
+}
+
+// Diff returns changes of the fields of a company, in the order of field names.
+// A missing company means the fields have no values at all.
+func Diff(before, after *models.Company) []models.FieldChange {
+	old, cur := fieldValues(before), fieldValues(after)
+
+	fields := make([]string, 0, len(cur))
+	for f := range cur {
+		fields = append(fields, f)
+	}
+	for f := range old {
+		if _, ok := cur[f]; !ok {
+			fields = append(fields, f)
+		}
+	}
+	sort.Strings(fields)
+
+	changes := []models.FieldChange{}
+	for _, f := range fields {
+		if _, ok := diffIgnored[f]; ok {
+			continue
+		}
+		o, n := old[f], cur[f]
+		if !reflect.DeepEqual(o, n) {
+			changes = append(changes, models.FieldChange{Field: f, Old: o, New: n})
+		}
+	}
+
+	return changes
+}
+
+// fieldValues returns the fields of the company as they are seen by clients.
+func fieldValues(c *models.Company) map[string]interface{} {
+	values := map[string]interface{}{}
+	if c == nil {
+		return values
+	}
+	// models.Company always marshals, the error can't happen
+	data, _ := json.Marshal(c)
+	_ = json.Unmarshal(data, &values)
+	return values
+}
+
+// record stores the change of a company into the outbox and the history of the company.
+func record(r Repository, e events.Event) error {
+	if err := r.CreateOutboxEvent(e); err != nil {
+		return err
+	}
+
+	rev := &models.CompanyRevision{
+		CompanyId: e.CompanyId,
+		Action:    string(e.Type),
+		ActorId:   e.ActorId,
+		Diff:      Diff(e.Before, e.After),
+		CreatedAt: e.OccurredAt,
+	}
+	if e.After != nil {
+		rev.Snapshot = *e.After
+	} else if e.Before != nil {
+		rev.Snapshot = *e.Before
+	}
+	return r.CreateRevision(rev)
+}
@@ -0,0 +1,73 @@
+package company_test
+
+import (
+	"githib.com/dkischenko/company-api/internal/company"
+	"githib.com/dkischenko/company-api/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	before := &models.Company{
+		Id:                uuid.New(),
+		Name:              "Big company",
+		Description:       "Makes things",
+		AmountOfEmployees: 100,
+		Type:              models.Corporations,
+	}
+	after := *before
+	after.Name = "Bigger company"
+	after.Registered = true
+
+	testCases := []struct {
+		name   string
+		before *models.Company
+		after  *models.Company
+		want   []models.FieldChange
+	}{
+		{
+			name:   "Updated",
+			before: before,
+			after:  &after,
+			want: []models.FieldChange{
+				{Field: "name", Old: "Big company", New: "Bigger company"},
+				{Field: "registered", Old: false, New: true},
+			},
+		},
+		{
+			name:   "Unchanged",
+			before: before,
+			after:  before,
+			want:   []models.FieldChange{},
+		},
+		{
+			name:  "Created",
+			after: before,
+			want: []models.FieldChange{
+				{Field: "amountOfEmployees", New: 100.0},
+				{Field: "description", New: "Makes things"},
+				{Field: "name", New: "Big company"},
+				{Field: "registered", New: false},
+				{Field: "type", New: "Corporations"},
+			},
+		},
+		{
+			name:   "Deleted",
+			before: &after,
+			want: []models.FieldChange{
+				{Field: "amountOfEmployees", Old: 100.0},
+				{Field: "description", Old: "Makes things"},
+				{Field: "name", Old: "Bigger company"},
+				{Field: "registered", Old: true},
+				{Field: "type", Old: "Corporations"},
+			},
+		},
+	}
+
+	for _, tcase := range testCases {
+		t.Run(tcase.name, func(t *testing.T) {
+			assert.Equal(t, tcase.want, company.Diff(tcase.before, tcase.after))
+		})
+	}
+}
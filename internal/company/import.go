@@ -0,0 +1,11 @@
+package company
+
+import "github.com/google/uuid"
+
+// ImportResult is the outcome of importing a single company. Duplicate is set
+// when the name of the company is taken, otherwise Id is the id of the created company,
+// which is nil for a dry run.
+type ImportResult struct {
+	Id        uuid.UUID
+	Duplicate bool
+}
@@ -0,0 +1,123 @@
+package company
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"githib.com/dkischenko/company-api/models"
+	"github.com/google/uuid"
+)
+
+const defaultSortField = "name"
+
+var errNoCursorSigner = errors.New("cursor signer is not configured")
+
+// sortFields lists the fields of models.Company, by JSON name,
+// a listing can be ordered by.
+var sortFields = map[string]struct{}{
+	"id":                {},
+	"name":              {},
+	"type":              {},
+	"registered":        {},
+	"amountOfEmployees": {},
+}
+
+// Keyset points at a row of a listing ordered by Sort field with Id as a tiebreaker.
+// Rows strictly after it are requested, or strictly before it when Backward is set.
+// For a backward keyset the storage returns rows nearest to the keyset first.
+type Keyset struct {
+	Value    interface{}
+	Id       uuid.UUID
+	Backward bool
+}
+
+// cursorPayload is the content of the opaque cursor token handed to clients.
+type cursorPayload struct {
+	Sort     string          `json:"s"`
+	Desc     bool            `json:"d,omitempty"`
+	Value    json.RawMessage `json:"v"`
+	Id       uuid.UUID       `json:"i"`
+	Backward bool            `json:"b,omitempty"`
+}
+
+// sortValue returns the value of the sort field of the company.
+func sortValue(c models.Company, field string) interface{} {
+	switch field {
+	case "type":
+		return string(c.Type)
+	case "registered":
+		return c.Registered
+	case "amountOfEmployees":
+		return c.AmountOfEmployees
+	case "id":
+		return c.Id
+	default:
+		return c.Name
+	}
+}
+
+// decodeSortValue restores the typed value of the sort field from the cursor.
+func decodeSortValue(field string, raw json.RawMessage) (v interface{}, err error) {
+	switch field {
+	case "registered":
+		var b bool
+		err = json.Unmarshal(raw, &b)
+		v = b
+	case "amountOfEmployees":
+		var i int
+		err = json.Unmarshal(raw, &i)
+		v = i
+	case "id":
+		var id uuid.UUID
+		err = json.Unmarshal(raw, &id)
+		v = id
+	default:
+		var s string
+		err = json.Unmarshal(raw, &s)
+		v = s
+	}
+	if err != nil {
+		return nil, fmt.Errorf("wrong value of sort field %s: %w", field, err)
+	}
+	return
+}
+
+func (s Service) encodeCursor(filter CompanyFilter, c models.Company, backward bool) (string, error) {
+	if s.cursorSigner == nil {
+		return "", errNoCursorSigner
+	}
+	value, err := json.Marshal(sortValue(c, filter.Sort))
+	if err != nil {
+		return "", err
+	}
+
+	return s.cursorSigner.Encode(cursorPayload{
+		Sort:     filter.Sort,
+		Desc:     filter.Desc,
+		Value:    value,
+		Id:       c.Id,
+		Backward: backward,
+	})
+}
+
+// decodeCursor verifies the cursor of the filter and returns the keyset it points at.
+// The cursor is only valid for the sort order it was issued for.
+func (s Service) decodeCursor(filter CompanyFilter) (*Keyset, error) {
+	if s.cursorSigner == nil {
+		return nil, errNoCursorSigner
+	}
+	p := cursorPayload{}
+	if err := s.cursorSigner.Decode(filter.Cursor, &p); err != nil {
+		return nil, err
+	}
+	if p.Sort != filter.Sort || p.Desc != filter.Desc {
+		return nil, fmt.Errorf("cursor was issued for another sort order")
+	}
+
+	value, err := decodeSortValue(p.Sort, p.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Keyset{Value: value, Id: p.Id, Backward: p.Backward}, nil
+}
@@ -6,7 +6,10 @@ package mock_company
 
 import (
 	reflect "reflect"
+	time "time"
 
+	company "githib.com/dkischenko/company-api/internal/company"
+	events "githib.com/dkischenko/company-api/internal/events"
 	models "githib.com/dkischenko/company-api/models"
 	gomock "github.com/golang/mock/gomock"
 	uuid "github.com/google/uuid"
@@ -50,6 +53,34 @@ func (mr *MockRepositoryMockRecorder) Create(company interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockRepository)(nil).Create), company)
 }
 
+// CreateOutboxEvent mocks base method.
+func (m *MockRepository) CreateOutboxEvent(e events.Event) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateOutboxEvent", e)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateOutboxEvent indicates an expected call of CreateOutboxEvent.
+func (mr *MockRepositoryMockRecorder) CreateOutboxEvent(e interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOutboxEvent", reflect.TypeOf((*MockRepository)(nil).CreateOutboxEvent), e)
+}
+
+// CreateRevision mocks base method.
+func (m *MockRepository) CreateRevision(rev *models.CompanyRevision) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateRevision", rev)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateRevision indicates an expected call of CreateRevision.
+func (mr *MockRepositoryMockRecorder) CreateRevision(rev interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRevision", reflect.TypeOf((*MockRepository)(nil).CreateRevision), rev)
+}
+
 // CreateUser mocks base method.
 func (m *MockRepository) CreateUser(user *models.User) (models.User, error) {
 	m.ctrl.T.Helper()
@@ -66,17 +97,59 @@ func (mr *MockRepositoryMockRecorder) CreateUser(user interface{}) *gomock.Call
 }
 
 // Delete mocks base method.
-func (m *MockRepository) Delete(id uuid.UUID) error {
+func (m *MockRepository) Delete(id uuid.UUID, version int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Delete", id)
+	ret := m.ctrl.Call(m, "Delete", id, version)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Delete indicates an expected call of Delete.
-func (mr *MockRepositoryMockRecorder) Delete(id interface{}) *gomock.Call {
+func (mr *MockRepositoryMockRecorder) Delete(id, version interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockRepository)(nil).Delete), id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockRepository)(nil).Delete), id, version)
+}
+
+// DeleteACL mocks base method.
+func (m *MockRepository) DeleteACL(companyId, entryId uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteACL", companyId, entryId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteACL indicates an expected call of DeleteACL.
+func (mr *MockRepositoryMockRecorder) DeleteACL(companyId, entryId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteACL", reflect.TypeOf((*MockRepository)(nil).DeleteACL), companyId, entryId)
+}
+
+// DeleteUser mocks base method.
+func (m *MockRepository) DeleteUser(userId uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUser", userId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUser indicates an expected call of DeleteUser.
+func (mr *MockRepositoryMockRecorder) DeleteUser(userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockRepository)(nil).DeleteUser), userId)
+}
+
+// Export mocks base method.
+func (m *MockRepository) Export(filter company.CompanyFilter, fn func(models.Company) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Export", filter, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Export indicates an expected call of Export.
+func (mr *MockRepositoryMockRecorder) Export(filter, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Export", reflect.TypeOf((*MockRepository)(nil).Export), filter, fn)
 }
 
 // FindOneUser mocks base method.
@@ -109,6 +182,247 @@ func (mr *MockRepositoryMockRecorder) Get(companyId interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockRepository)(nil).Get), companyId)
 }
 
+// GetGroup mocks base method.
+func (m *MockRepository) GetGroup(groupId uuid.UUID) (models.Group, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGroup", groupId)
+	ret0, _ := ret[0].(models.Group)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetGroup indicates an expected call of GetGroup.
+func (mr *MockRepositoryMockRecorder) GetGroup(groupId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGroup", reflect.TypeOf((*MockRepository)(nil).GetGroup), groupId)
+}
+
+// GetRevision mocks base method.
+func (m *MockRepository) GetRevision(companyId uuid.UUID, revision int) (models.CompanyRevision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRevision", companyId, revision)
+	ret0, _ := ret[0].(models.CompanyRevision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRevision indicates an expected call of GetRevision.
+func (mr *MockRepositoryMockRecorder) GetRevision(companyId, revision interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRevision", reflect.TypeOf((*MockRepository)(nil).GetRevision), companyId, revision)
+}
+
+// GetUser mocks base method.
+func (m *MockRepository) GetUser(userId uint) (models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUser", userId)
+	ret0, _ := ret[0].(models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUser indicates an expected call of GetUser.
+func (mr *MockRepositoryMockRecorder) GetUser(userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockRepository)(nil).GetUser), userId)
+}
+
+// Grants mocks base method.
+func (m *MockRepository) Grants(companyId uuid.UUID, userId uint) ([]models.Permission, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Grants", companyId, userId)
+	ret0, _ := ret[0].([]models.Permission)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Grants indicates an expected call of Grants.
+func (mr *MockRepositoryMockRecorder) Grants(companyId, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Grants", reflect.TypeOf((*MockRepository)(nil).Grants), companyId, userId)
+}
+
+// List mocks base method.
+func (m *MockRepository) List(filter company.CompanyFilter) ([]models.Company, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", filter)
+	ret0, _ := ret[0].([]models.Company)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// List indicates an expected call of List.
+func (mr *MockRepositoryMockRecorder) List(filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockRepository)(nil).List), filter)
+}
+
+// ListACL mocks base method.
+func (m *MockRepository) ListACL(companyId uuid.UUID) ([]models.ACLEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListACL", companyId)
+	ret0, _ := ret[0].([]models.ACLEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListACL indicates an expected call of ListACL.
+func (mr *MockRepositoryMockRecorder) ListACL(companyId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListACL", reflect.TypeOf((*MockRepository)(nil).ListACL), companyId)
+}
+
+// ListRevisions mocks base method.
+func (m *MockRepository) ListRevisions(companyId uuid.UUID, limit, offset int) ([]models.CompanyRevision, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListRevisions", companyId, limit, offset)
+	ret0, _ := ret[0].([]models.CompanyRevision)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ListRevisions indicates an expected call of ListRevisions.
+func (mr *MockRepositoryMockRecorder) ListRevisions(companyId, limit, offset interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRevisions", reflect.TypeOf((*MockRepository)(nil).ListRevisions), companyId, limit, offset)
+}
+
+// ListUsers mocks base method.
+func (m *MockRepository) ListUsers(limit, offset int) ([]models.User, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUsers", limit, offset)
+	ret0, _ := ret[0].([]models.User)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ListUsers indicates an expected call of ListUsers.
+func (mr *MockRepositoryMockRecorder) ListUsers(limit, offset interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUsers", reflect.TypeOf((*MockRepository)(nil).ListUsers), limit, offset)
+}
+
+// Purge mocks base method.
+func (m *MockRepository) Purge(deletedBefore time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Purge", deletedBefore)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Purge indicates an expected call of Purge.
+func (mr *MockRepositoryMockRecorder) Purge(deletedBefore interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Purge", reflect.TypeOf((*MockRepository)(nil).Purge), deletedBefore)
+}
+
+// Restore mocks base method.
+func (m *MockRepository) Restore(id uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Restore", id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Restore indicates an expected call of Restore.
+func (mr *MockRepositoryMockRecorder) Restore(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restore", reflect.TypeOf((*MockRepository)(nil).Restore), id)
+}
+
+// SaveACL mocks base method.
+func (m *MockRepository) SaveACL(entry *models.ACLEntry) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveACL", entry)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveACL indicates an expected call of SaveACL.
+func (mr *MockRepositoryMockRecorder) SaveACL(entry interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveACL", reflect.TypeOf((*MockRepository)(nil).SaveACL), entry)
+}
+
+// Search mocks base method.
+func (m *MockRepository) Search(query company.SearchQuery) ([]company.SearchResult, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Search", query)
+	ret0, _ := ret[0].([]company.SearchResult)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Search indicates an expected call of Search.
+func (mr *MockRepositoryMockRecorder) Search(query interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Search", reflect.TypeOf((*MockRepository)(nil).Search), query)
+}
+
+// SetUserDisabled mocks base method.
+func (m *MockRepository) SetUserDisabled(userId uint, disabledAt *time.Time) (models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserDisabled", userId, disabledAt)
+	ret0, _ := ret[0].(models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetUserDisabled indicates an expected call of SetUserDisabled.
+func (mr *MockRepositoryMockRecorder) SetUserDisabled(userId, disabledAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserDisabled", reflect.TypeOf((*MockRepository)(nil).SetUserDisabled), userId, disabledAt)
+}
+
+// SetUserRole mocks base method.
+func (m *MockRepository) SetUserRole(userId uint, role models.Role) (models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserRole", userId, role)
+	ret0, _ := ret[0].(models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetUserRole indicates an expected call of SetUserRole.
+func (mr *MockRepositoryMockRecorder) SetUserRole(userId, role interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserRole", reflect.TypeOf((*MockRepository)(nil).SetUserRole), userId, role)
+}
+
+// TakenNames mocks base method.
+func (m *MockRepository) TakenNames(names []string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TakenNames", names)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TakenNames indicates an expected call of TakenNames.
+func (mr *MockRepositoryMockRecorder) TakenNames(names interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TakenNames", reflect.TypeOf((*MockRepository)(nil).TakenNames), names)
+}
+
+// Transaction mocks base method.
+func (m *MockRepository) Transaction(fn func(company.Repository) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Transaction", fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Transaction indicates an expected call of Transaction.
+func (mr *MockRepositoryMockRecorder) Transaction(fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Transaction", reflect.TypeOf((*MockRepository)(nil).Transaction), fn)
+}
+
 // Update mocks base method.
 func (m *MockRepository) Update(company *models.Company) error {
 	m.ctrl.T.Helper()
@@ -7,13 +7,69 @@ package mock_company
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	company "githib.com/dkischenko/company-api/internal/company"
+	events "githib.com/dkischenko/company-api/internal/events"
 	models "githib.com/dkischenko/company-api/models"
+	patch "githib.com/dkischenko/company-api/pkg/patch"
 	gomock "github.com/golang/mock/gomock"
 	uuid "github.com/google/uuid"
 )
 
+// MockMFA is a mock of MFA interface.
+type MockMFA struct {
+	ctrl     *gomock.Controller
+	recorder *MockMFAMockRecorder
+}
+
+// MockMFAMockRecorder is the mock recorder for MockMFA.
+type MockMFAMockRecorder struct {
+	mock *MockMFA
+}
+
+// NewMockMFA creates a new mock instance.
+func NewMockMFA(ctrl *gomock.Controller) *MockMFA {
+	mock := &MockMFA{ctrl: ctrl}
+	mock.recorder = &MockMFAMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMFA) EXPECT() *MockMFAMockRecorder {
+	return m.recorder
+}
+
+// Challenge mocks base method.
+func (m *MockMFA) Challenge(ctx context.Context, userId uint) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Challenge", ctx, userId)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Challenge indicates an expected call of Challenge.
+func (mr *MockMFAMockRecorder) Challenge(ctx, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Challenge", reflect.TypeOf((*MockMFA)(nil).Challenge), ctx, userId)
+}
+
+// Verify mocks base method.
+func (m *MockMFA) Verify(ctx context.Context, challengeToken, code string) (models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Verify", ctx, challengeToken, code)
+	ret0, _ := ret[0].(models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Verify indicates an expected call of Verify.
+func (mr *MockMFAMockRecorder) Verify(ctx, challengeToken, code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Verify", reflect.TypeOf((*MockMFA)(nil).Verify), ctx, challengeToken, code)
+}
+
 // MockIService is a mock of IService interface.
 type MockIService struct {
 	ctrl     *gomock.Controller
@@ -37,6 +93,53 @@ func (m *MockIService) EXPECT() *MockIServiceMockRecorder {
 	return m.recorder
 }
 
+// CompanyACL mocks base method.
+func (m *MockIService) CompanyACL(ctx context.Context, companyId uuid.UUID) (*uint, []models.ACLEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompanyACL", ctx, companyId)
+	ret0, _ := ret[0].(*uint)
+	ret1, _ := ret[1].([]models.ACLEntry)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// CompanyACL indicates an expected call of CompanyACL.
+func (mr *MockIServiceMockRecorder) CompanyACL(ctx, companyId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompanyACL", reflect.TypeOf((*MockIService)(nil).CompanyACL), ctx, companyId)
+}
+
+// CompanyHistory mocks base method.
+func (m *MockIService) CompanyHistory(ctx context.Context, companyId uuid.UUID, limit, offset int) ([]models.CompanyRevision, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompanyHistory", ctx, companyId, limit, offset)
+	ret0, _ := ret[0].([]models.CompanyRevision)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// CompanyHistory indicates an expected call of CompanyHistory.
+func (mr *MockIServiceMockRecorder) CompanyHistory(ctx, companyId, limit, offset interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompanyHistory", reflect.TypeOf((*MockIService)(nil).CompanyHistory), ctx, companyId, limit, offset)
+}
+
+// CompanyRevision mocks base method.
+func (m *MockIService) CompanyRevision(ctx context.Context, companyId uuid.UUID, revision int) (models.CompanyRevision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompanyRevision", ctx, companyId, revision)
+	ret0, _ := ret[0].(models.CompanyRevision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CompanyRevision indicates an expected call of CompanyRevision.
+func (mr *MockIServiceMockRecorder) CompanyRevision(ctx, companyId, revision interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompanyRevision", reflect.TypeOf((*MockIService)(nil).CompanyRevision), ctx, companyId, revision)
+}
+
 // CreateCompany mocks base method.
 func (m *MockIService) CreateCompany(ctx context.Context, company models.Company) (models.Company, error) {
 	m.ctrl.T.Helper()
@@ -53,47 +156,75 @@ func (mr *MockIServiceMockRecorder) CreateCompany(ctx, company interface{}) *gom
 }
 
 // CreateToken mocks base method.
-func (m *MockIService) CreateToken(uId string) (string, error) {
+func (m *MockIService) CreateToken(uId string, role models.Role) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateToken", uId)
+	ret := m.ctrl.Call(m, "CreateToken", uId, role)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateToken indicates an expected call of CreateToken.
-func (mr *MockIServiceMockRecorder) CreateToken(uId interface{}) *gomock.Call {
+func (mr *MockIServiceMockRecorder) CreateToken(uId, role interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateToken", reflect.TypeOf((*MockIService)(nil).CreateToken), uId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateToken", reflect.TypeOf((*MockIService)(nil).CreateToken), uId, role)
 }
 
 // CreateUser mocks base method.
-func (m *MockIService) CreateUser(user *company.UserRequest) (models.User, error) {
+func (m *MockIService) CreateUser(user *company.UserRequest, role models.Role) (models.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateUser", user)
+	ret := m.ctrl.Call(m, "CreateUser", user, role)
 	ret0, _ := ret[0].(models.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateUser indicates an expected call of CreateUser.
-func (mr *MockIServiceMockRecorder) CreateUser(user interface{}) *gomock.Call {
+func (mr *MockIServiceMockRecorder) CreateUser(user, role interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockIService)(nil).CreateUser), user)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockIService)(nil).CreateUser), user, role)
 }
 
 // DeleteCompany mocks base method.
-func (m *MockIService) DeleteCompany(companyId uuid.UUID) error {
+func (m *MockIService) DeleteCompany(ctx context.Context, companyId uuid.UUID, version int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteCompany", companyId)
+	ret := m.ctrl.Call(m, "DeleteCompany", ctx, companyId, version)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // DeleteCompany indicates an expected call of DeleteCompany.
-func (mr *MockIServiceMockRecorder) DeleteCompany(companyId interface{}) *gomock.Call {
+func (mr *MockIServiceMockRecorder) DeleteCompany(ctx, companyId, version interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCompany", reflect.TypeOf((*MockIService)(nil).DeleteCompany), companyId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCompany", reflect.TypeOf((*MockIService)(nil).DeleteCompany), ctx, companyId, version)
+}
+
+// DeleteUser mocks base method.
+func (m *MockIService) DeleteUser(ctx context.Context, userId uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUser", ctx, userId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUser indicates an expected call of DeleteUser.
+func (mr *MockIServiceMockRecorder) DeleteUser(ctx, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockIService)(nil).DeleteUser), ctx, userId)
+}
+
+// ExportCompanies mocks base method.
+func (m *MockIService) ExportCompanies(ctx context.Context, filter company.CompanyFilter, fn func(models.Company) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportCompanies", ctx, filter, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ExportCompanies indicates an expected call of ExportCompanies.
+func (mr *MockIServiceMockRecorder) ExportCompanies(ctx, filter, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportCompanies", reflect.TypeOf((*MockIService)(nil).ExportCompanies), ctx, filter, fn)
 }
 
 // GetCompany mocks base method.
@@ -111,21 +242,219 @@ func (mr *MockIServiceMockRecorder) GetCompany(ctx, companyId interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCompany", reflect.TypeOf((*MockIService)(nil).GetCompany), ctx, companyId)
 }
 
-// Login mocks base method.
-func (m *MockIService) Login(ctx context.Context, ur *company.UserRequest) (models.User, error) {
+// GetUser mocks base method.
+func (m *MockIService) GetUser(ctx context.Context, userId uint) (models.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Login", ctx, ur)
+	ret := m.ctrl.Call(m, "GetUser", ctx, userId)
 	ret0, _ := ret[0].(models.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
+// GetUser indicates an expected call of GetUser.
+func (mr *MockIServiceMockRecorder) GetUser(ctx, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockIService)(nil).GetUser), ctx, userId)
+}
+
+// ImportCompanies mocks base method.
+func (m *MockIService) ImportCompanies(ctx context.Context, companies []models.Company, dryRun bool) ([]company.ImportResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImportCompanies", ctx, companies, dryRun)
+	ret0, _ := ret[0].([]company.ImportResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ImportCompanies indicates an expected call of ImportCompanies.
+func (mr *MockIServiceMockRecorder) ImportCompanies(ctx, companies, dryRun interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImportCompanies", reflect.TypeOf((*MockIService)(nil).ImportCompanies), ctx, companies, dryRun)
+}
+
+// ListCompanies mocks base method.
+func (m *MockIService) ListCompanies(ctx context.Context, filter company.CompanyFilter) (company.CompanyPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListCompanies", ctx, filter)
+	ret0, _ := ret[0].(company.CompanyPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListCompanies indicates an expected call of ListCompanies.
+func (mr *MockIServiceMockRecorder) ListCompanies(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListCompanies", reflect.TypeOf((*MockIService)(nil).ListCompanies), ctx, filter)
+}
+
+// ListUsers mocks base method.
+func (m *MockIService) ListUsers(ctx context.Context, limit, offset int) ([]models.User, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUsers", ctx, limit, offset)
+	ret0, _ := ret[0].([]models.User)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ListUsers indicates an expected call of ListUsers.
+func (mr *MockIServiceMockRecorder) ListUsers(ctx, limit, offset interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUsers", reflect.TypeOf((*MockIService)(nil).ListUsers), ctx, limit, offset)
+}
+
+// Login mocks base method.
+func (m *MockIService) Login(ctx context.Context, ur *company.UserRequest) (models.User, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Login", ctx, ur)
+	ret0, _ := ret[0].(models.User)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
 // Login indicates an expected call of Login.
 func (mr *MockIServiceMockRecorder) Login(ctx, ur interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Login", reflect.TypeOf((*MockIService)(nil).Login), ctx, ur)
 }
 
+// PatchCompany mocks base method.
+func (m *MockIService) PatchCompany(ctx context.Context, companyId uuid.UUID, version int, p patch.Patch) (models.Company, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PatchCompany", ctx, companyId, version, p)
+	ret0, _ := ret[0].(models.Company)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PatchCompany indicates an expected call of PatchCompany.
+func (mr *MockIServiceMockRecorder) PatchCompany(ctx, companyId, version, p interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PatchCompany", reflect.TypeOf((*MockIService)(nil).PatchCompany), ctx, companyId, version, p)
+}
+
+// PurgeCompanies mocks base method.
+func (m *MockIService) PurgeCompanies(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeCompanies", ctx, deletedBefore)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeCompanies indicates an expected call of PurgeCompanies.
+func (mr *MockIServiceMockRecorder) PurgeCompanies(ctx, deletedBefore interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeCompanies", reflect.TypeOf((*MockIService)(nil).PurgeCompanies), ctx, deletedBefore)
+}
+
+// RestoreCompany mocks base method.
+func (m *MockIService) RestoreCompany(ctx context.Context, companyId uuid.UUID) (models.Company, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreCompany", ctx, companyId)
+	ret0, _ := ret[0].(models.Company)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestoreCompany indicates an expected call of RestoreCompany.
+func (mr *MockIServiceMockRecorder) RestoreCompany(ctx, companyId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreCompany", reflect.TypeOf((*MockIService)(nil).RestoreCompany), ctx, companyId)
+}
+
+// SearchCompanies mocks base method.
+func (m *MockIService) SearchCompanies(ctx context.Context, query company.SearchQuery) ([]company.SearchResult, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchCompanies", ctx, query)
+	ret0, _ := ret[0].([]company.SearchResult)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// SearchCompanies indicates an expected call of SearchCompanies.
+func (mr *MockIServiceMockRecorder) SearchCompanies(ctx, query interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchCompanies", reflect.TypeOf((*MockIService)(nil).SearchCompanies), ctx, query)
+}
+
+// SetUserDisabled mocks base method.
+func (m *MockIService) SetUserDisabled(ctx context.Context, userId uint, disabled bool) (models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserDisabled", ctx, userId, disabled)
+	ret0, _ := ret[0].(models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetUserDisabled indicates an expected call of SetUserDisabled.
+func (mr *MockIServiceMockRecorder) SetUserDisabled(ctx, userId, disabled interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserDisabled", reflect.TypeOf((*MockIService)(nil).SetUserDisabled), ctx, userId, disabled)
+}
+
+// SetUserRole mocks base method.
+func (m *MockIService) SetUserRole(ctx context.Context, userId uint, role models.Role) (models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserRole", ctx, userId, role)
+	ret0, _ := ret[0].(models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetUserRole indicates an expected call of SetUserRole.
+func (mr *MockIServiceMockRecorder) SetUserRole(ctx, userId, role interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserRole", reflect.TypeOf((*MockIService)(nil).SetUserRole), ctx, userId, role)
+}
+
+// ShareCompany mocks base method.
+func (m *MockIService) ShareCompany(ctx context.Context, companyId uuid.UUID, req company.ShareRequest) (models.ACLEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ShareCompany", ctx, companyId, req)
+	ret0, _ := ret[0].(models.ACLEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ShareCompany indicates an expected call of ShareCompany.
+func (mr *MockIServiceMockRecorder) ShareCompany(ctx, companyId, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ShareCompany", reflect.TypeOf((*MockIService)(nil).ShareCompany), ctx, companyId, req)
+}
+
+// SubscribeEvents mocks base method.
+func (m *MockIService) SubscribeEvents(ctx context.Context, lastEventId string) ([]events.Message, <-chan events.Message, func()) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SubscribeEvents", ctx, lastEventId)
+	ret0, _ := ret[0].([]events.Message)
+	ret1, _ := ret[1].(<-chan events.Message)
+	ret2, _ := ret[2].(func())
+	return ret0, ret1, ret2
+}
+
+// SubscribeEvents indicates an expected call of SubscribeEvents.
+func (mr *MockIServiceMockRecorder) SubscribeEvents(ctx, lastEventId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SubscribeEvents", reflect.TypeOf((*MockIService)(nil).SubscribeEvents), ctx, lastEventId)
+}
+
+// UnshareCompany mocks base method.
+func (m *MockIService) UnshareCompany(ctx context.Context, companyId, entryId uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnshareCompany", ctx, companyId, entryId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UnshareCompany indicates an expected call of UnshareCompany.
+func (mr *MockIServiceMockRecorder) UnshareCompany(ctx, companyId, entryId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnshareCompany", reflect.TypeOf((*MockIService)(nil).UnshareCompany), ctx, companyId, entryId)
+}
+
 // UpdateCompany mocks base method.
 func (m *MockIService) UpdateCompany(ctx context.Context, company *models.Company) error {
 	m.ctrl.T.Helper()
@@ -139,3 +468,18 @@ func (mr *MockIServiceMockRecorder) UpdateCompany(ctx, company interface{}) *gom
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateCompany", reflect.TypeOf((*MockIService)(nil).UpdateCompany), ctx, company)
 }
+
+// VerifyMFA mocks base method.
+func (m *MockIService) VerifyMFA(ctx context.Context, challenge, code string) (models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyMFA", ctx, challenge, code)
+	ret0, _ := ret[0].(models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyMFA indicates an expected call of VerifyMFA.
+func (mr *MockIServiceMockRecorder) VerifyMFA(ctx, challenge, code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyMFA", reflect.TypeOf((*MockIService)(nil).VerifyMFA), ctx, challenge, code)
+}
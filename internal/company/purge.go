@@ -0,0 +1,41 @@
+package company
+
+import (
+	"context"
+	"githib.com/dkischenko/company-api/pkg/logger"
+	"time"
+)
+
+// Purger permanently removes companies deleted longer than the retention period ago.
+type Purger struct {
+	logger    *logger.Logger
+	service   IService
+	interval  time.Duration
+	retention time.Duration
+}
+
+func NewPurger(logger *logger.Logger, service IService, interval time.Duration, retention time.Duration) *Purger {
+	return &Purger{
+		logger:    logger,
+		service:   service,
+		interval:  interval,
+		retention: retention,
+	}
+}
+
+// Run purges companies every interval until ctx is done.
+func (p *Purger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		// failures are logged by the service, the next run retries them
+		_, _ = p.service.PurgeCompanies(ctx, time.Now().Add(-p.retention))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
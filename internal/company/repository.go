@@ -1,16 +1,69 @@
 package company
 
 import (
+	"githib.com/dkischenko/company-api/internal/events"
 	"githib.com/dkischenko/company-api/models"
 	"github.com/google/uuid"
+	"time"
 )
 
 //go:generate mockgen -source=repository.go -destination=mocks/repository_mock.go
 type Repository interface {
+	// Transaction runs fn with a Repository bound to a single database transaction,
+	// which is committed when fn returns nil and rolled back otherwise.
+	Transaction(fn func(r Repository) error) error
 	Create(company models.Company) (models.Company, error)
 	Get(companyId uuid.UUID) (company models.Company, err error)
+	// Update replaces the company if its stored version still equals company.Version
+	// and increments the version, it returns uerrors.ErrVersionMismatch otherwise.
 	Update(company *models.Company) (err error)
-	Delete(id uuid.UUID) (err error)
+	// Delete deletes the company if its stored version equals version,
+	// it returns uerrors.ErrVersionMismatch otherwise.
+	Delete(id uuid.UUID, version int) (err error)
+	// Restore undoes the deletion of the company, which is not purged yet.
+	Restore(id uuid.UUID) (err error)
+	// Purge permanently removes companies deleted before deletedBefore
+	// along with their revisions and ACL entries.
+	Purge(deletedBefore time.Time) (purged int64, err error)
+	List(filter CompanyFilter) (companies []models.Company, total int64, err error)
+	// Export calls fn for every company matching the filter in the order of the filter,
+	// paging is ignored. It stops at the first error returned by fn.
+	Export(filter CompanyFilter, fn func(c models.Company) error) (err error)
+	Search(query SearchQuery) (results []SearchResult, total int64, err error)
+	// TakenNames returns those of the names which are taken by companies.
+	TakenNames(names []string) (taken []string, err error)
+	CreateOutboxEvent(e events.Event) (err error)
+	// CreateRevision stores the revision with the number following
+	// the last revision of the company.
+	CreateRevision(rev *models.CompanyRevision) (err error)
+	// ListRevisions returns revisions of the company, the latest first.
+	ListRevisions(companyId uuid.UUID, limit, offset int) (revisions []models.CompanyRevision, total int64, err error)
+	GetRevision(companyId uuid.UUID, revision int) (rev models.CompanyRevision, err error)
+	// Grants returns the permissions on the company granted to the user,
+	// directly or by the groups of the user.
+	Grants(companyId uuid.UUID, userId uint) (permissions []models.Permission, err error)
+	ListACL(companyId uuid.UUID) (entries []models.ACLEntry, err error)
+	// SaveACL replaces the permission of the user or group of the entry on its company,
+	// or creates the entry when the user or group has none yet.
+	SaveACL(entry *models.ACLEntry) (err error)
+	// DeleteACL returns uerrors.ErrACLEntryNotFound unless the company has the entry.
+	DeleteACL(companyId, entryId uuid.UUID) (err error)
+	// GetGroup returns uerrors.ErrGroupNotFound for unknown groups.
+	GetGroup(groupId uuid.UUID) (g models.Group, err error)
 	CreateUser(user *models.User) (u models.User, err error)
 	FindOneUser(name string) (u models.User, err error)
+	// ListUsers returns users in the order of their ids.
+	ListUsers(limit, offset int) (users []models.User, total int64, err error)
+	// GetUser returns uerrors.ErrGetUser for unknown users.
+	GetUser(userId uint) (u models.User, err error)
+	// SetUserDisabled disables the user at disabledAt, or enables it again when
+	// disabledAt is nil. It returns uerrors.ErrGetUser for unknown users.
+	SetUserDisabled(userId uint, disabledAt *time.Time) (u models.User, err error)
+	// DeleteUser deletes the user along with its tokens, API keys, MFA secrets, OAuth clients,
+	// group memberships, ACL entries, webhooks, idempotency keys and login failures,
+	// and clears the owner of its companies.
+	// It returns uerrors.ErrGetUser for unknown users.
+	DeleteUser(userId uint) (err error)
+	// SetUserRole returns uerrors.ErrGetUser for unknown users.
+	SetUserRole(userId uint, role models.Role) (u models.User, err error)
 }
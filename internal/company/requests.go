@@ -1,15 +1,102 @@
 package company
 
+import (
+	"githib.com/dkischenko/company-api/models"
+	"github.com/google/uuid"
+)
+
 type UserRequest struct {
 	Name     string `json:"name" validate:"required,alpha"`
 	Password string `json:"password" validate:"required"`
 }
 
 type UserCreateResponse struct {
-	ID   uint   `json:"id"`
-	Name string `json:"name"`
+	ID   uint        `json:"id"`
+	Name string      `json:"name"`
+	Role models.Role `json:"role"`
+}
+
+type RoleRequest struct {
+	Role models.Role `json:"role" validate:"required,oneof=viewer editor admin"`
+}
+
+type UserListResponse struct {
+	Data   []models.User `json:"data"`
+	Total  int64         `json:"total"`
+	Limit  int           `json:"limit"`
+	Offset int           `json:"offset"`
 }
 
 type UserLoginResponse struct {
-	Hash string `json:"hash"`
+	Hash         string `json:"hash"`
+	RefreshToken string `json:"refreshToken,omitempty"`
+}
+
+// MFAChallengeResponse answers the login of a user with MFA, the challenge
+// token is exchanged with a code at /v1/login/mfa.
+type MFAChallengeResponse struct {
+	MFARequired    bool   `json:"mfaRequired"`
+	ChallengeToken string `json:"challengeToken"`
+}
+
+type MFALoginRequest struct {
+	ChallengeToken string `json:"challengeToken" validate:"required"`
+	Code           string `json:"code" validate:"required"`
+}
+
+type PaginationLinks struct {
+	Self  string `json:"self"`
+	First string `json:"first"`
+	Prev  string `json:"prev,omitempty"`
+	Next  string `json:"next,omitempty"`
+	Last  string `json:"last,omitempty"`
+}
+
+type CompanyListResponse struct {
+	Data       []models.Company `json:"data"`
+	Total      int64            `json:"total"`
+	Limit      int              `json:"limit"`
+	Offset     int              `json:"offset"`
+	NextCursor string           `json:"nextCursor,omitempty"`
+	PrevCursor string           `json:"prevCursor,omitempty"`
+	Links      PaginationLinks  `json:"links"`
+}
+
+// CompanyPage is a single page of a company listing.
+type CompanyPage struct {
+	Companies  []models.Company
+	Total      int64
+	NextCursor string
+	PrevCursor string
+}
+
+type CompanySearchResponse struct {
+	Data   []SearchResult  `json:"data"`
+	Total  int64           `json:"total"`
+	Limit  int             `json:"limit"`
+	Offset int             `json:"offset"`
+	Links  PaginationLinks `json:"links"`
+}
+
+type CompanyHistoryResponse struct {
+	Data   []models.CompanyRevision `json:"data"`
+	Total  int64                    `json:"total"`
+	Limit  int                      `json:"limit"`
+	Offset int                      `json:"offset"`
+}
+
+// ShareRequest grants the permission on a company to either the user or the group.
+type ShareRequest struct {
+	UserId     *uint             `json:"userId" validate:"required_without=GroupId,excluded_with=GroupId"`
+	GroupId    *uuid.UUID        `json:"groupId" validate:"required_without=UserId"`
+	Permission models.Permission `json:"permission" validate:"required,oneof=read write admin"`
+}
+
+type CompanyACLResponse struct {
+	OwnerId *uint             `json:"ownerId,omitempty"`
+	Data    []models.ACLEntry `json:"data"`
+}
+
+type PurgeResponse struct {
+	Purged int64 `json:"purged"`
 }
@@ -0,0 +1,166 @@
+package company
+
+import (
+	"fmt"
+	"githib.com/dkischenko/company-api/models"
+	"net/http"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	querySearch       = "q"
+	maxSearchQueryLen = 256
+
+	// HighlightStart and HighlightStop enclose matched terms in snippets.
+	HighlightStart = "<mark>"
+	HighlightStop  = "</mark>"
+
+	snippetRadius = 80
+	nameWeight    = 1.0
+	descWeight    = 0.4
+)
+
+// SearchQuery describes a full-text search over company names and descriptions.
+type SearchQuery struct {
+	Query  string
+	Limit  int
+	Offset int
+}
+
+// SearchResult is a company matched by a search, with its relevance
+// and a fragment of the description with matched terms highlighted.
+type SearchResult struct {
+	models.Company
+	Rank    float64 `json:"rank"`
+	Snippet string  `json:"snippet"`
+}
+
+// parseSearchQuery builds SearchQuery from the query string of the request.
+func parseSearchQuery(r *http.Request) (sq SearchQuery, err error) {
+	q := r.URL.Query()
+
+	sq.Query = strings.TrimSpace(q.Get(querySearch))
+	if sq.Query == "" {
+		return sq, fmt.Errorf("%s must not be empty", querySearch)
+	}
+	if utf8.RuneCountInString(sq.Query) > maxSearchQueryLen {
+		return sq, fmt.Errorf("%s must not be longer than %d characters", querySearch, maxSearchQueryLen)
+	}
+
+	sq.Limit, sq.Offset, err = parsePage(q)
+	return sq, err
+}
+
+// SearchTerms splits the search query into lower-cased terms.
+func SearchTerms(query string) []string {
+	return strings.Fields(strings.ToLower(query))
+}
+
+// SubstringSearch is a fallback search for repositories without full-text
+// capabilities. It keeps the companies containing every term in the name
+// or the description, ranks them by the weighted number of occurrences
+// and returns the requested page of them along with the total amount of matches.
+func SubstringSearch(companies []models.Company, query SearchQuery) ([]SearchResult, int64) {
+	terms := SearchTerms(query.Query)
+	results := make([]SearchResult, 0, len(companies))
+
+	for _, c := range companies {
+		name, desc := strings.ToLower(c.Name), strings.ToLower(c.Description)
+		rank, matched := 0.0, true
+		for _, t := range terms {
+			n, d := strings.Count(name, t), strings.Count(desc, t)
+			if n+d == 0 {
+				matched = false
+				break
+			}
+			rank += nameWeight*float64(n) + descWeight*float64(d)
+		}
+		if !matched {
+			continue
+		}
+		results = append(results, SearchResult{
+			Company: c,
+			Rank:    rank,
+			Snippet: Highlight(c.Description, terms),
+		})
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Rank > results[j].Rank
+	})
+
+	total := int64(len(results))
+	if query.Offset >= len(results) {
+		return []SearchResult{}, total
+	}
+	results = results[query.Offset:]
+	if len(results) > query.Limit {
+		results = results[:query.Limit]
+	}
+
+	return results, total
+}
+
+// Highlight cuts a fragment of the text around the first matched term
+// and encloses all occurrences of the terms in it with HighlightStart and HighlightStop.
+func Highlight(text string, terms []string) string {
+	lower := strings.ToLower(text)
+	// lower-casing may change byte length of some runes,
+	// positions can only be mapped back when it did not
+	if len(lower) != len(text) {
+		return text
+	}
+
+	first := -1
+	for _, t := range terms {
+		if i := strings.Index(lower, t); i >= 0 && (first < 0 || i < first) {
+			first = i
+		}
+	}
+	if first < 0 {
+		first = 0
+	}
+
+	start, end := first-snippetRadius, first+snippetRadius
+	if start < 0 {
+		start = 0
+	}
+	if end > len(text) {
+		end = len(text)
+	}
+	for start > 0 && !utf8.RuneStart(text[start]) {
+		start--
+	}
+	for end < len(text) && !utf8.RuneStart(text[end]) {
+		end++
+	}
+
+	b := strings.Builder{}
+	if start > 0 {
+		b.WriteString("...")
+	}
+	for i := start; i < end; {
+		matched := ""
+		for _, t := range terms {
+			if len(t) > len(matched) && strings.HasPrefix(lower[i:end], t) {
+				matched = t
+			}
+		}
+		if matched == "" {
+			b.WriteByte(text[i])
+			i++
+			continue
+		}
+		b.WriteString(HighlightStart)
+		b.WriteString(text[i : i+len(matched)])
+		b.WriteString(HighlightStop)
+		i += len(matched)
+	}
+	if end < len(text) {
+		b.WriteString("...")
+	}
+
+	return b.String()
+}
@@ -0,0 +1,66 @@
+package company_test
+
+import (
+	"githib.com/dkischenko/company-api/internal/company"
+	"githib.com/dkischenko/company-api/models"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSubstringSearch(t *testing.T) {
+	companies := []models.Company{
+		{Name: "Green Energy", Description: "Solar panels and wind farms"},
+		{Name: "Solar Systems", Description: "We install solar panels on roofs"},
+		{Name: "Bakery", Description: "Fresh bread every morning"},
+	}
+
+	testCases := []struct {
+		name      string
+		query     company.SearchQuery
+		wantNames []string
+		wantTotal int64
+	}{
+		{
+			name:      "Ranked by weighted occurrences",
+			query:     company.SearchQuery{Query: "SOLAR", Limit: 10},
+			wantNames: []string{"Solar Systems", "Green Energy"},
+			wantTotal: 2,
+		},
+		{
+			name:      "All terms must match",
+			query:     company.SearchQuery{Query: "solar wind", Limit: 10},
+			wantNames: []string{"Green Energy"},
+			wantTotal: 1,
+		},
+		{
+			name:      "Paged",
+			query:     company.SearchQuery{Query: "panels", Limit: 1, Offset: 1},
+			wantNames: []string{"Solar Systems"},
+			wantTotal: 2,
+		},
+		{
+			name:      "Offset past results",
+			query:     company.SearchQuery{Query: "bread", Limit: 10, Offset: 5},
+			wantNames: []string{},
+			wantTotal: 1,
+		},
+	}
+
+	for _, tcase := range testCases {
+		t.Run(tcase.name, func(t *testing.T) {
+			results, total := company.SubstringSearch(companies, tcase.query)
+			names := []string{}
+			for _, r := range results {
+				names = append(names, r.Name)
+			}
+			assert.Equal(t, tcase.wantNames, names)
+			assert.Equal(t, tcase.wantTotal, total)
+		})
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	assert.Equal(t, "We install <mark>solar</mark> <mark>Panels</mark> on roofs",
+		company.Highlight("We install solar Panels on roofs", []string{"solar", "panels"}))
+	assert.Equal(t, "No match", company.Highlight("No match", []string{"solar"}))
+}
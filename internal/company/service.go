@@ -1,14 +1,22 @@
 package company
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	uerrors "githib.com/dkischenko/company-api/internal/errors"
+	"githib.com/dkischenko/company-api/internal/events"
 	"githib.com/dkischenko/company-api/models"
 	"githib.com/dkischenko/company-api/pkg/auth"
+	"githib.com/dkischenko/company-api/pkg/cursor"
 	"githib.com/dkischenko/company-api/pkg/hasher"
 	"githib.com/dkischenko/company-api/pkg/logger"
+	"githib.com/dkischenko/company-api/pkg/password"
+	"githib.com/dkischenko/company-api/pkg/patch"
 	"github.com/google/uuid"
+	"strconv"
 	"time"
 )
 
@@ -16,59 +24,473 @@ type Service struct {
 	logger       *logger.Logger
 	storage      Repository
 	tokenManager *auth.Manager
+	cursorSigner *cursor.Signer
+	stream       *events.Broker
+	passwords    *password.Policy
+	mfa          MFA
+}
+
+// MFA issues and verifies the challenges of users with multi-factor authentication.
+type MFA interface {
+	Challenge(ctx context.Context, userId uint) (challengeToken string, err error)
+	Verify(ctx context.Context, challengeToken, code string) (u models.User, err error)
 }
 
 //go:generate mockgen -source=service.go -destination=mocks/service_mock.go
 type IService interface {
 	CreateCompany(ctx context.Context, company models.Company) (c models.Company, err error)
 	UpdateCompany(ctx context.Context, company *models.Company) (err error)
-	DeleteCompany(companyId uuid.UUID) (err error)
+	PatchCompany(ctx context.Context, companyId uuid.UUID, version int, p patch.Patch) (company models.Company, err error)
+	DeleteCompany(ctx context.Context, companyId uuid.UUID, version int) (err error)
+	RestoreCompany(ctx context.Context, companyId uuid.UUID) (company models.Company, err error)
+	PurgeCompanies(ctx context.Context, deletedBefore time.Time) (purged int64, err error)
+	ImportCompanies(ctx context.Context, companies []models.Company, dryRun bool) (results []ImportResult, err error)
 	GetCompany(ctx context.Context, companyId uuid.UUID) (company models.Company, err error)
-	CreateUser(user *UserRequest) (u models.User, err error)
-	Login(ctx context.Context, ur *UserRequest) (u models.User, err error)
-	CreateToken(uId string) (hash string, err error)
+	ListCompanies(ctx context.Context, filter CompanyFilter) (page CompanyPage, err error)
+	ExportCompanies(ctx context.Context, filter CompanyFilter, fn func(c models.Company) error) (err error)
+	SearchCompanies(ctx context.Context, query SearchQuery) (results []SearchResult, total int64, err error)
+	CompanyHistory(ctx context.Context, companyId uuid.UUID, limit, offset int) (revisions []models.CompanyRevision, total int64, err error)
+	CompanyRevision(ctx context.Context, companyId uuid.UUID, revision int) (rev models.CompanyRevision, err error)
+	// CompanyACL returns the owner and the ACL entries of the company.
+	CompanyACL(ctx context.Context, companyId uuid.UUID) (ownerId *uint, entries []models.ACLEntry, err error)
+	// ShareCompany grants the permission of the request on the company,
+	// replacing the permission the user or group had before.
+	ShareCompany(ctx context.Context, companyId uuid.UUID, req ShareRequest) (entry models.ACLEntry, err error)
+	UnshareCompany(ctx context.Context, companyId, entryId uuid.UUID) (err error)
+	SubscribeEvents(ctx context.Context, lastEventId string) (backlog []events.Message, messages <-chan events.Message, cancel func())
+	CreateUser(user *UserRequest, role models.Role) (u models.User, err error)
+	SetUserRole(ctx context.Context, userId uint, role models.Role) (u models.User, err error)
+	ListUsers(ctx context.Context, limit, offset int) (users []models.User, total int64, err error)
+	GetUser(ctx context.Context, userId uint) (u models.User, err error)
+	// SetUserDisabled disables or enables the user. Disabled users can't log in
+	// and their tokens are rejected.
+	SetUserDisabled(ctx context.Context, userId uint, disabled bool) (u models.User, err error)
+	DeleteUser(ctx context.Context, userId uint) (err error)
+	// Login checks the credentials of the user. Users with MFA get a challenge
+	// instead, which is exchanged with a code by VerifyMFA.
+	Login(ctx context.Context, ur *UserRequest) (u models.User, challenge string, err error)
+	// VerifyMFA returns the user of the challenge when the code is valid.
+	VerifyMFA(ctx context.Context, challenge, code string) (u models.User, err error)
+	CreateToken(uId string, role models.Role) (hash string, err error)
 }
 
-func NewService(logger *logger.Logger, storage Repository, tokenTTL time.Duration) IService {
+// NewService creates the service of companies and users, passwords of new users
+// are checked by the policy and logins of users with MFA are challenged by mfa.
+func NewService(logger *logger.Logger, storage Repository, tokenTTL time.Duration, stream *events.Broker,
+	passwords *password.Policy, mfa MFA) IService {
 	tm, err := auth.NewManager(tokenTTL)
 	if err != nil {
 		logger.Entry.Errorf("error with token manager: %s", err)
 	}
+	cs, err := cursor.NewSigner()
+	if err != nil {
+		logger.Entry.Errorf("error with cursor signer: %s", err)
+	}
 
 	return &Service{
 		tokenManager: tm,
+		cursorSigner: cs,
 		logger:       logger,
 		storage:      storage,
+		stream:       stream,
+		passwords:    passwords,
+		mfa:          mfa,
 	}
 }
 
+// CreateCompany creates the company owned by the user of the request.
 func (s Service) CreateCompany(ctx context.Context, company models.Company) (c models.Company, err error) {
-	c, err = s.storage.Create(company)
+	company.OwnerId = userIdFromContext(ctx)
+	var e events.Event
+	err = s.storage.Transaction(func(r Repository) error {
+		c, err = r.Create(company)
+		if err != nil {
+			return err
+		}
+		e = events.New(events.CompanyCreated, auth.UserIdFromContext(ctx), nil, &c)
+		return record(r, e)
+	})
 	if err != nil {
 		s.logger.Entry.Errorf("failed to create company: %s", err)
 		return models.Company{}, fmt.Errorf("error occurs: %w", uerrors.ErrCreateCompany)
 	}
+	s.notify(ctx, e)
 	return
 }
 
+// UpdateCompany replaces all fields of the company, as long as it's still
+// of company.Version. The version after the update is set to company.
 func (s Service) UpdateCompany(ctx context.Context, company *models.Company) (err error) {
-	err = s.storage.Update(company)
+	after, err := s.update(ctx, company.Id, company.Version, func(before models.Company) (models.Company, error) {
+		return *company, nil
+	})
+	if err != nil {
+		return err
+	}
+	company.Version, company.OwnerId = after.Version, after.OwnerId
+	return
+}
+
+// PatchCompany applies the patch to the stored company, as long as it's still
+// of the version. The patched company must pass ValidateCompany.
+func (s Service) PatchCompany(ctx context.Context, companyId uuid.UUID, version int, p patch.Patch) (company models.Company, err error) {
+	return s.update(ctx, companyId, version, func(before models.Company) (patched models.Company, err error) {
+		doc, err := json.Marshal(before)
+		if err != nil {
+			return patched, err
+		}
+		if doc, err = p.Apply(doc); err != nil {
+			return patched, fmt.Errorf("%w: %s", uerrors.ErrInvalidPatch, err)
+		}
+		d := json.NewDecoder(bytes.NewReader(doc))
+		d.DisallowUnknownFields()
+		if err = d.Decode(&patched); err != nil {
+			return patched, fmt.Errorf("%w: %s", uerrors.ErrInvalidPatch, err)
+		}
+		if patched.Id != before.Id {
+			return patched, fmt.Errorf("%w: id can't be changed", uerrors.ErrInvalidPatch)
+		}
+		if err = ValidateCompany(&patched); err != nil {
+			return patched, fmt.Errorf("%w: %s", uerrors.ErrInvalidPatch, err)
+		}
+		return patched, nil
+	})
+}
+
+// update replaces the company with the result of change within a transaction.
+// The change is only made to the version of the company, a zero version skips the check,
+// and only by users with write permission on it.
+func (s Service) update(ctx context.Context, companyId uuid.UUID, version int,
+	change func(before models.Company) (models.Company, error)) (after models.Company, err error) {
+	var e events.Event
+	err = s.storage.Transaction(func(r Repository) error {
+		before, err := r.Get(companyId)
+		if err != nil {
+			return fmt.Errorf("cannot get company before update: %w", err)
+		}
+		if err = s.authorize(ctx, r, before, models.PermissionWrite); err != nil {
+			return err
+		}
+		if version != 0 && version != before.Version {
+			return uerrors.ErrVersionMismatch
+		}
+		changed, err := change(before)
+		if err != nil {
+			return err
+		}
+		changed.Id, changed.Version = before.Id, before.Version
+		if err = r.Update(&changed); err != nil {
+			return err
+		}
+		if after, err = r.Get(companyId); err != nil {
+			return fmt.Errorf("cannot get company after update: %w", err)
+		}
+		e = events.New(events.CompanyUpdated, auth.UserIdFromContext(ctx), &before, &after)
+		return record(r, e)
+	})
+	if errors.Is(err, uerrors.ErrVersionMismatch) || errors.Is(err, uerrors.ErrInvalidPatch) ||
+		errors.Is(err, uerrors.ErrGetCompany) || errors.Is(err, uerrors.ErrCompanyForbidden) {
+		return models.Company{}, fmt.Errorf("error occurs: %w", err)
+	}
 	if err != nil {
 		s.logger.Entry.Errorf("failed to update company: %s", err)
-		return fmt.Errorf("error occurs: %w", uerrors.ErrUpdateCompany)
+		return models.Company{}, fmt.Errorf("error occurs: %w", uerrors.ErrUpdateCompany)
 	}
+	s.notify(ctx, e)
 	return
 }
 
-func (s Service) DeleteCompany(companyId uuid.UUID) (err error) {
-	err = s.storage.Delete(companyId)
+// DeleteCompany deletes the company only if it's still of the version,
+// a zero version skips the check. Deleting needs write permission on the company.
+func (s Service) DeleteCompany(ctx context.Context, companyId uuid.UUID, version int) (err error) {
+	var e events.Event
+	err = s.storage.Transaction(func(r Repository) error {
+		before, err := r.Get(companyId)
+		if err != nil {
+			return fmt.Errorf("cannot get company before delete: %w", err)
+		}
+		if err = s.authorize(ctx, r, before, models.PermissionWrite); err != nil {
+			return err
+		}
+		if version != 0 && version != before.Version {
+			return uerrors.ErrVersionMismatch
+		}
+		if err = r.Delete(companyId, before.Version); err != nil {
+			return err
+		}
+		e = events.New(events.CompanyDeleted, auth.UserIdFromContext(ctx), &before, nil)
+		return record(r, e)
+	})
+	if errors.Is(err, uerrors.ErrVersionMismatch) || errors.Is(err, uerrors.ErrCompanyForbidden) ||
+		errors.Is(err, uerrors.ErrGetCompany) {
+		return fmt.Errorf("error occurs: %w", err)
+	}
 	if err != nil {
-		s.logger.Entry.Errorf("failed to create country: %s", err)
+		s.logger.Entry.Errorf("failed to delete company: %s", err)
 		return fmt.Errorf("error occurs: %w", uerrors.ErrDeleteCompany)
 	}
+	s.notify(ctx, e)
+	return
+}
+
+// RestoreCompany undoes the deletion of the company, restoring needs write permission on it.
+func (s Service) RestoreCompany(ctx context.Context, companyId uuid.UUID) (company models.Company, err error) {
+	var e events.Event
+	err = s.storage.Transaction(func(r Repository) error {
+		if err := r.Restore(companyId); err != nil {
+			return err
+		}
+		company, err = r.Get(companyId)
+		if err != nil {
+			return fmt.Errorf("cannot get company after restore: %w", err)
+		}
+		if err = s.authorize(ctx, r, company, models.PermissionWrite); err != nil {
+			return err
+		}
+		e = events.New(events.CompanyRestored, auth.UserIdFromContext(ctx), nil, &company)
+		return record(r, e)
+	})
+	if errors.Is(err, uerrors.ErrGetCompany) || errors.Is(err, uerrors.ErrCompanyNameTaken) ||
+		errors.Is(err, uerrors.ErrCompanyForbidden) {
+		s.logger.Entry.Errorf("can't restore company: %s", err)
+		return models.Company{}, fmt.Errorf("error occurs: %w", err)
+	}
+	if err != nil {
+		s.logger.Entry.Errorf("failed to restore company: %s", err)
+		return models.Company{}, fmt.Errorf("error occurs: %w", uerrors.ErrRestoreCompany)
+	}
+	s.notify(ctx, e)
+	return
+}
+
+func (s Service) PurgeCompanies(ctx context.Context, deletedBefore time.Time) (purged int64, err error) {
+	purged, err = s.storage.Purge(deletedBefore)
+	if err != nil {
+		s.logger.Entry.Errorf("failed to purge companies: %s", err)
+		return 0, fmt.Errorf("error occurs: %w", uerrors.ErrPurgeCompanies)
+	}
+	if purged > 0 {
+		s.logger.Entry.Infof("purged %d companies deleted before %s", purged, deletedBefore.Format(time.RFC3339))
+	}
+	return
+}
+
+// ImportCompanies creates the validated companies, owned by the user of the request, within
+// a single transaction, skipping those whose names are already taken. Results are in the order of companies.
+// A dry run only looks for the taken names.
+func (s Service) ImportCompanies(ctx context.Context, companies []models.Company, dryRun bool) (results []ImportResult, err error) {
+	names := make([]string, len(companies))
+	for i, c := range companies {
+		names[i] = c.Name
+	}
+
+	var published []events.Event
+	err = s.storage.Transaction(func(r Repository) error {
+		published = published[:0]
+		results = make([]ImportResult, len(companies))
+		taken, err := r.TakenNames(names)
+		if err != nil {
+			return err
+		}
+		isTaken := make(map[string]struct{}, len(taken))
+		for _, name := range taken {
+			isTaken[name] = struct{}{}
+		}
+
+		for i, c := range companies {
+			if _, ok := isTaken[c.Name]; ok {
+				results[i].Duplicate = true
+				continue
+			}
+			if dryRun {
+				continue
+			}
+			c.Id, c.Version, c.OwnerId = uuid.Nil, 0, userIdFromContext(ctx)
+			if c, err = r.Create(c); err != nil {
+				return err
+			}
+			results[i].Id = c.Id
+			e := events.New(events.CompanyCreated, auth.UserIdFromContext(ctx), nil, &c)
+			if err = record(r, e); err != nil {
+				return err
+			}
+			published = append(published, e)
+		}
+		return nil
+	})
+	if err != nil {
+		s.logger.Entry.Errorf("failed to import companies: %s", err)
+		return nil, fmt.Errorf("error occurs: %w", uerrors.ErrImportCompanies)
+	}
+	for _, e := range published {
+		s.notify(ctx, e)
+	}
+	return
+}
+
+// CompanyACL returns the owner and the ACL entries of the company to users with read permission on it.
+func (s Service) CompanyACL(ctx context.Context, companyId uuid.UUID) (ownerId *uint, entries []models.ACLEntry, err error) {
+	c, err := s.storage.Get(companyId)
+	if err == nil {
+		err = s.authorize(ctx, s.storage, c, models.PermissionRead)
+	}
+	if err == nil {
+		entries, err = s.storage.ListACL(companyId)
+	}
+	if errors.Is(err, uerrors.ErrGetCompany) || errors.Is(err, uerrors.ErrCompanyForbidden) {
+		return nil, nil, fmt.Errorf("error occurs: %w", err)
+	}
+	if err != nil {
+		s.logger.Entry.Errorf("failed to list ACL of company %s: %s", companyId, err)
+		return nil, nil, fmt.Errorf("error occurs: %w", uerrors.ErrShareCompany)
+	}
+	return c.OwnerId, entries, nil
+}
+
+// ShareCompany grants the permission on the company to the user or group of the request,
+// sharing needs admin permission on the company.
+func (s Service) ShareCompany(ctx context.Context, companyId uuid.UUID, req ShareRequest) (entry models.ACLEntry, err error) {
+	if (req.UserId == nil) == (req.GroupId == nil) || !req.Permission.Valid() {
+		return entry, fmt.Errorf("error occurs: %w", uerrors.ErrInvalidACLEntry)
+	}
+	entry = models.ACLEntry{CompanyId: companyId, UserId: req.UserId, GroupId: req.GroupId, Permission: req.Permission}
+	err = s.storage.Transaction(func(r Repository) error {
+		c, err := r.Get(companyId)
+		if err != nil {
+			return err
+		}
+		if err = s.authorize(ctx, r, c, models.PermissionAdmin); err != nil {
+			return err
+		}
+		if req.UserId != nil {
+			_, err = r.GetUser(*req.UserId)
+		} else {
+			_, err = r.GetGroup(*req.GroupId)
+		}
+		if err != nil {
+			return err
+		}
+		return r.SaveACL(&entry)
+	})
+	if errors.Is(err, uerrors.ErrGetCompany) || errors.Is(err, uerrors.ErrCompanyForbidden) ||
+		errors.Is(err, uerrors.ErrGetUser) || errors.Is(err, uerrors.ErrGroupNotFound) {
+		return models.ACLEntry{}, fmt.Errorf("error occurs: %w", err)
+	}
+	if err != nil {
+		s.logger.Entry.Errorf("failed to share company %s: %s", companyId, err)
+		return models.ACLEntry{}, fmt.Errorf("error occurs: %w", uerrors.ErrShareCompany)
+	}
+	s.logger.Entry.Infof("user %s granted %s on company %s by entry %s",
+		auth.UserIdFromContext(ctx), entry.Permission, companyId, entry.Id)
+	return
+}
+
+// UnshareCompany deletes the ACL entry of the company, unsharing needs admin permission on the company.
+func (s Service) UnshareCompany(ctx context.Context, companyId, entryId uuid.UUID) (err error) {
+	err = s.storage.Transaction(func(r Repository) error {
+		c, err := r.Get(companyId)
+		if err != nil {
+			return err
+		}
+		if err = s.authorize(ctx, r, c, models.PermissionAdmin); err != nil {
+			return err
+		}
+		return r.DeleteACL(companyId, entryId)
+	})
+	if errors.Is(err, uerrors.ErrGetCompany) || errors.Is(err, uerrors.ErrCompanyForbidden) ||
+		errors.Is(err, uerrors.ErrACLEntryNotFound) {
+		return fmt.Errorf("error occurs: %w", err)
+	}
+	if err != nil {
+		s.logger.Entry.Errorf("failed to unshare company %s: %s", companyId, err)
+		return fmt.Errorf("error occurs: %w", uerrors.ErrShareCompany)
+	}
+	s.logger.Entry.Infof("user %s deleted ACL entry %s of company %s", auth.UserIdFromContext(ctx), entryId, companyId)
+	return
+}
+
+// authorize checks the permission of the user of the request on the company.
+// Admins and owners have every permission, other users those granted by ACL entries.
+// Companies without an owner, created before ownership or left by deleted owners,
+// are accessible only to admins and users granted access by ACL entries.
+func (s Service) authorize(ctx context.Context, r Repository, c models.Company, need models.Permission) error {
+	if models.Role(auth.RoleFromContext(ctx)).Includes(models.RoleAdmin) {
+		return nil
+	}
+	userId := userIdFromContext(ctx)
+	if userId == nil {
+		return uerrors.ErrCompanyForbidden
+	}
+	if c.OwnerId != nil && *c.OwnerId == *userId {
+		return nil
+	}
+	granted, err := r.Grants(c.Id, *userId)
+	if err != nil {
+		return err
+	}
+	for _, p := range granted {
+		if p.Includes(need) {
+			return nil
+		}
+	}
+	return uerrors.ErrCompanyForbidden
+}
+
+// userIdFromContext returns the id of the user of the request, nil when there's none.
+func userIdFromContext(ctx context.Context) *uint {
+	userId, err := strconv.ParseUint(auth.UserIdFromContext(ctx), 10, 32)
+	if err != nil {
+		return nil
+	}
+	id := uint(userId)
+	return &id
+}
+
+// CompanyHistory returns a page of revisions of the company, the latest first.
+// Companies without any revision are reported as not found.
+func (s Service) CompanyHistory(ctx context.Context, companyId uuid.UUID, limit, offset int) (revisions []models.CompanyRevision, total int64, err error) {
+	revisions, total, err = s.storage.ListRevisions(companyId, limit, offset)
+	if err != nil {
+		s.logger.Entry.Errorf("failed to list revisions of company %s: %s", companyId, err)
+		return nil, 0, fmt.Errorf("error occurs: %w", uerrors.ErrCompanyHistory)
+	}
+	if total == 0 {
+		return nil, 0, fmt.Errorf("error occurs: %w", uerrors.ErrRevisionNotFound)
+	}
+	return
+}
+
+func (s Service) CompanyRevision(ctx context.Context, companyId uuid.UUID, revision int) (rev models.CompanyRevision, err error) {
+	rev, err = s.storage.GetRevision(companyId, revision)
+	if errors.Is(err, uerrors.ErrRevisionNotFound) {
+		return rev, fmt.Errorf("error occurs: %w", err)
+	}
+	if err != nil {
+		s.logger.Entry.Errorf("failed to get revision %d of company %s: %s", revision, companyId, err)
+		return rev, fmt.Errorf("error occurs: %w", uerrors.ErrCompanyHistory)
+	}
 	return
 }
 
+// notify pushes the committed change to the live stream of company events.
+// Durable delivery is up to the outbox, the stream is best effort.
+func (s Service) notify(ctx context.Context, e events.Event) {
+	if s.stream == nil {
+		return
+	}
+	if err := s.stream.Publish(ctx, e); err != nil {
+		s.logger.Entry.Errorf("failed to stream %s event of company %s: %s", e.Type, e.CompanyId, err)
+	}
+}
+
+func (s Service) SubscribeEvents(ctx context.Context, lastEventId string) (backlog []events.Message, messages <-chan events.Message, cancel func()) {
+	if s.stream == nil {
+		// nothing will ever be published, the subscriber just waits
+		return nil, make(chan events.Message), func() {}
+	}
+	return s.stream.Subscribe(lastEventId)
+}
+
 func (s Service) GetCompany(ctx context.Context, cId uuid.UUID) (company models.Company, err error) {
 	company, err = s.storage.Get(cId)
 	if err != nil {
@@ -78,7 +500,87 @@ func (s Service) GetCompany(ctx context.Context, cId uuid.UUID) (company models.
 	return
 }
 
-func (s Service) CreateUser(user *UserRequest) (u models.User, err error) {
+// ListCompanies returns the page of companies addressed by the filter.
+// One extra row is requested from the storage to find out whether
+// the listing continues past the page in the direction of the scan.
+func (s Service) ListCompanies(ctx context.Context, filter CompanyFilter) (page CompanyPage, err error) {
+	if filter.Cursor != "" {
+		filter.Keyset, err = s.decodeCursor(filter)
+		if err != nil {
+			s.logger.Entry.Errorf("failed to decode cursor: %s", err)
+			return page, fmt.Errorf("error occurs: %w", uerrors.ErrInvalidCursor)
+		}
+		filter.Offset = 0
+	}
+
+	query := filter
+	query.Limit = filter.Limit + 1
+	companies, total, err := s.storage.List(query)
+	if err != nil {
+		s.logger.Entry.Errorf("failed to list companies: %s", err)
+		return page, fmt.Errorf("error occurs: %w", uerrors.ErrListCompanies)
+	}
+
+	hasMore := len(companies) > filter.Limit
+	if hasMore {
+		companies = companies[:filter.Limit]
+	}
+
+	var hasNext, hasPrev bool
+	switch {
+	case filter.Keyset == nil:
+		hasNext, hasPrev = hasMore, filter.Offset > 0
+	case filter.Keyset.Backward:
+		for i, j := 0, len(companies)-1; i < j; i, j = i+1, j-1 {
+			companies[i], companies[j] = companies[j], companies[i]
+		}
+		hasNext, hasPrev = true, hasMore
+	default:
+		hasNext, hasPrev = hasMore, true
+	}
+
+	page.Companies = companies
+	page.Total = total
+	if len(companies) == 0 {
+		return page, nil
+	}
+	if hasNext {
+		if page.NextCursor, err = s.encodeCursor(filter, companies[len(companies)-1], false); err != nil {
+			s.logger.Entry.Errorf("failed to encode cursor: %s", err)
+		}
+	}
+	if hasPrev {
+		if page.PrevCursor, err = s.encodeCursor(filter, companies[0], true); err != nil {
+			s.logger.Entry.Errorf("failed to encode cursor: %s", err)
+		}
+	}
+
+	return page, nil
+}
+
+// ExportCompanies passes all companies matching the filter to fn one by one,
+// in the order of the filter. Paging of the filter is ignored.
+func (s Service) ExportCompanies(ctx context.Context, filter CompanyFilter, fn func(c models.Company) error) (err error) {
+	if err = s.storage.Export(filter, fn); err != nil {
+		s.logger.Entry.Errorf("failed to export companies: %s", err)
+		return fmt.Errorf("error occurs: %w", uerrors.ErrExportCompanies)
+	}
+	return
+}
+
+func (s Service) SearchCompanies(ctx context.Context, query SearchQuery) (results []SearchResult, total int64, err error) {
+	results, total, err = s.storage.Search(query)
+	if err != nil {
+		s.logger.Entry.Errorf("failed to search companies: %s", err)
+		return nil, 0, fmt.Errorf("error occurs: %w", uerrors.ErrSearchCompanies)
+	}
+	return
+}
+
+func (s Service) CreateUser(user *UserRequest, role models.Role) (u models.User, err error) {
+	if err = s.passwords.Check(user.Password); err != nil {
+		return models.User{}, fmt.Errorf("error occurs: %w", err)
+	}
 	hashPassword, err := hasher.HashPassword(user.Password)
 	if err != nil {
 		s.logger.Entry.Errorf("troubles with hashing password: %s", user.Password)
@@ -87,6 +589,7 @@ func (s Service) CreateUser(user *UserRequest) (u models.User, err error) {
 	usr := &models.User{
 		Name:         user.Name,
 		PasswordHash: hashPassword,
+		Role:         role,
 	}
 
 	u, err = s.storage.CreateUser(usr)
@@ -98,23 +601,127 @@ func (s Service) CreateUser(user *UserRequest) (u models.User, err error) {
 	return
 }
 
-func (s Service) Login(ctx context.Context, ur *UserRequest) (u models.User, err error) {
+func (s Service) Login(ctx context.Context, ur *UserRequest) (u models.User, challenge string, err error) {
 	u, err = s.storage.FindOneUser(ur.Name)
 	if err != nil {
 		s.logger.Entry.Errorf("failed find user with error: %s", err)
-		return models.User{}, fmt.Errorf("error occurs: %w", uerrors.ErrFindOneUser)
+		return models.User{}, "", fmt.Errorf("error occurs: %w", uerrors.ErrFindOneUser)
 	}
 
 	if !hasher.CheckPasswordHash(u.PasswordHash, ur.Password) {
 		s.logger.Entry.Errorf("user used wrong password: %s", err)
-		return models.User{}, fmt.Errorf("error occurs: %w", uerrors.ErrCheckUserPasswordHash)
+		return models.User{}, "", fmt.Errorf("error occurs: %w", uerrors.ErrCheckUserPasswordHash)
+	}
+	if u.Disabled() {
+		return models.User{}, "", fmt.Errorf("error occurs: %w", uerrors.ErrUserDisabled)
+	}
+
+	if !u.MFAEnabled {
+		return u, "", nil
+	}
+	if s.mfa == nil {
+		s.logger.Entry.Errorf("user %d has MFA enabled, but MFA isn't configured", u.Id)
+		return models.User{}, "", fmt.Errorf("error occurs: %w", uerrors.ErrMFA)
 	}
+	challenge, err = s.mfa.Challenge(ctx, u.Id)
+	if err != nil {
+		return models.User{}, "", err
+	}
+	return u, challenge, nil
+}
+
+func (s Service) VerifyMFA(ctx context.Context, challenge, code string) (u models.User, err error) {
+	if s.mfa == nil {
+		return u, fmt.Errorf("error occurs: %w", uerrors.ErrInvalidMFAChallenge)
+	}
+	u, err = s.mfa.Verify(ctx, challenge, code)
+	if err == nil && u.Disabled() {
+		return models.User{}, fmt.Errorf("error occurs: %w", uerrors.ErrUserDisabled)
+	}
+	return
+}
+
+// SetUserRole assigns the role to the user. Users can't change their own role,
+// so the last admin can't lock everyone out.
+func (s Service) SetUserRole(ctx context.Context, userId uint, role models.Role) (u models.User, err error) {
+	if strconv.FormatUint(uint64(userId), 10) == auth.UserIdFromContext(ctx) {
+		return u, fmt.Errorf("error occurs: %w", uerrors.ErrChangeOwnRole)
+	}
+	u, err = s.storage.SetUserRole(userId, role)
+	if errors.Is(err, uerrors.ErrGetUser) {
+		return u, fmt.Errorf("error occurs: %w", err)
+	}
+	if err != nil {
+		s.logger.Entry.Errorf("failed to set role of user %d: %s", userId, err)
+		return u, fmt.Errorf("error occurs: %w", uerrors.ErrSetUserRole)
+	}
+	s.logger.Entry.Infof("user %s set role of user %d to %s", auth.UserIdFromContext(ctx), userId, role)
+	return
+}
+
+func (s Service) ListUsers(ctx context.Context, limit, offset int) (users []models.User, total int64, err error) {
+	users, total, err = s.storage.ListUsers(limit, offset)
+	if err != nil {
+		s.logger.Entry.Errorf("failed to list users: %s", err)
+		return nil, 0, fmt.Errorf("error occurs: %w", uerrors.ErrListUsers)
+	}
+	return
+}
+
+func (s Service) GetUser(ctx context.Context, userId uint) (u models.User, err error) {
+	u, err = s.storage.GetUser(userId)
+	if err != nil && !errors.Is(err, uerrors.ErrGetUser) {
+		s.logger.Entry.Errorf("failed to get user %d: %s", userId, err)
+	}
+	if err != nil {
+		return u, fmt.Errorf("error occurs: %w", err)
+	}
+	return
+}
 
+// SetUserDisabled disables or enables the user. Like roles, users can't
+// disable their own account.
+func (s Service) SetUserDisabled(ctx context.Context, userId uint, disabled bool) (u models.User, err error) {
+	if strconv.FormatUint(uint64(userId), 10) == auth.UserIdFromContext(ctx) {
+		return u, fmt.Errorf("error occurs: %w", uerrors.ErrChangeOwnAccount)
+	}
+	var disabledAt *time.Time
+	if disabled {
+		now := time.Now()
+		disabledAt = &now
+	}
+	u, err = s.storage.SetUserDisabled(userId, disabledAt)
+	if errors.Is(err, uerrors.ErrGetUser) {
+		return u, fmt.Errorf("error occurs: %w", err)
+	}
+	if err != nil {
+		s.logger.Entry.Errorf("failed to update user %d: %s", userId, err)
+		return u, fmt.Errorf("error occurs: %w", uerrors.ErrUpdateUser)
+	}
+	s.logger.Entry.Infof("user %s set user %d disabled: %t", auth.UserIdFromContext(ctx), userId, disabled)
+	return
+}
+
+// DeleteUser deletes the user and everything it authenticates with,
+// users can't delete their own account.
+func (s Service) DeleteUser(ctx context.Context, userId uint) (err error) {
+	if strconv.FormatUint(uint64(userId), 10) == auth.UserIdFromContext(ctx) {
+		return fmt.Errorf("error occurs: %w", uerrors.ErrChangeOwnAccount)
+	}
+	err = s.storage.DeleteUser(userId)
+	if errors.Is(err, uerrors.ErrGetUser) {
+		return fmt.Errorf("error occurs: %w", err)
+	}
+	if err != nil {
+		s.logger.Entry.Errorf("failed to delete user %d: %s", userId, err)
+		return fmt.Errorf("error occurs: %w", uerrors.ErrDeleteUser)
+	}
+	s.logger.Entry.Infof("user %s deleted user %d", auth.UserIdFromContext(ctx), userId)
 	return
 }
 
-func (s Service) CreateToken(uId string) (hash string, err error) {
-	hash, err = s.tokenManager.CreateJWT(uId)
+func (s Service) CreateToken(uId string, role models.Role) (hash string, err error) {
+	hash, err = s.tokenManager.CreateJWT(uId, string(role))
 	if err != nil {
 		s.logger.Entry.Errorf("problems with creating jwt token: %s", err)
 		return "", fmt.Errorf("error occurs: %w", uerrors.ErrCreateJWTToken)
@@ -7,13 +7,18 @@ import (
 	"githib.com/dkischenko/company-api/internal/company"
 	mock_company "githib.com/dkischenko/company-api/internal/company/mocks"
 	uerrors "githib.com/dkischenko/company-api/internal/errors"
+	"githib.com/dkischenko/company-api/internal/events"
 	"githib.com/dkischenko/company-api/models"
+	"githib.com/dkischenko/company-api/pkg/auth"
 	"githib.com/dkischenko/company-api/pkg/hasher"
 	"githib.com/dkischenko/company-api/pkg/logger"
+	"githib.com/dkischenko/company-api/pkg/password"
+	"githib.com/dkischenko/company-api/pkg/patch"
 	"github.com/golang/mock/gomock"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 )
@@ -23,7 +28,7 @@ func TestNewService(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 	mockRepo := mock_company.NewMockRepository(ctrl)
-	assert.NotNil(t, company.NewService(l, mockRepo, 3600))
+	assert.NotNil(t, company.NewService(l, mockRepo, 3600, nil, nil, nil), nil)
 }
 
 func TestService_Login(t *testing.T) {
@@ -44,7 +49,7 @@ func TestService_Login(t *testing.T) {
 			Name:         ur.Name,
 			PasswordHash: hash,
 		}, nil).AnyTimes()
-		service := company.NewService(l, mockRepo, 3600)
+		service := company.NewService(l, mockRepo, 3600, nil, nil, nil)
 		u, err := mockRepo.FindOneUser(ur.Name)
 		if err != nil {
 			t.Fatalf("Can't find user with credentials due error: %s", err)
@@ -54,7 +59,7 @@ func TestService_Login(t *testing.T) {
 			t.Fatalf("User with wrong password. Error: %s", err)
 		}
 
-		usr, err := service.Login(ctx, ur)
+		usr, _, err := service.Login(ctx, ur)
 		if err != nil {
 			t.Fatalf("Unexpected error: %s", err)
 		}
@@ -74,12 +79,12 @@ func TestService_LoginFindOneError(t *testing.T) {
 			Return(models.User{}, fmt.Errorf("Error occurs: %w", uerrors.ErrFindOneUser)).AnyTimes()
 
 		l, _ := logger.GetLogger()
-		s := company.NewService(l, mockRepo, 3600)
+		s := company.NewService(l, mockRepo, 3600, nil, nil, nil)
 		ur := &company.UserRequest{
 			Name:     "Bob",
 			Password: "password",
 		}
-		_, err := s.Login(ctx, ur)
+		_, _, err := s.Login(ctx, ur)
 		if err != nil {
 			assert.ErrorIs(t, err, uerrors.ErrFindOneUser)
 		} else {
@@ -88,6 +93,40 @@ func TestService_LoginFindOneError(t *testing.T) {
 	})
 }
 
+// challenger issues challenges named after the user and rejects every code.
+type challenger struct{}
+
+func (challenger) Challenge(ctx context.Context, userId uint) (string, error) {
+	return "challenge-" + strconv.FormatUint(uint64(userId), 10), nil
+}
+
+func (challenger) Verify(ctx context.Context, challengeToken, code string) (models.User, error) {
+	return models.User{}, uerrors.ErrInvalidMFACode
+}
+
+func TestService_LoginMFA(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	hash, _ := hasher.HashPassword("password")
+	mockRepo := mock_company.NewMockRepository(ctrl)
+	mockRepo.EXPECT().FindOneUser("Bob").
+		Return(models.User{Id: 1, Name: "Bob", PasswordHash: hash, MFAEnabled: true}, nil).Times(2)
+
+	l, _ := logger.GetLogger()
+	ur := &company.UserRequest{Name: "Bob", Password: "password"}
+	s := company.NewService(l, mockRepo, 3600*time.Second, nil, nil, challenger{})
+	u, challenge, err := s.Login(context.Background(), ur)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), u.Id)
+	assert.Equal(t, "challenge-1", challenge)
+
+	// users with MFA can't log in without it
+	s = company.NewService(l, mockRepo, 3600*time.Second, nil, nil, nil)
+	_, _, err = s.Login(context.Background(), ur)
+	assert.ErrorIs(t, err, uerrors.ErrMFA)
+}
+
 func TestService_CreateCompany(t *testing.T) {
 	t.Run("Create company", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
@@ -102,6 +141,9 @@ func TestService_CreateCompany(t *testing.T) {
 			Type:              "Corporations",
 		}
 		companyUUID, _ := uuid.FromBytes([]byte("af056d5a-0f61-4635-a174-cfddf4b1b01e"))
+		expectTransaction(mockRepo)
+		mockRepo.EXPECT().CreateOutboxEvent(gomock.Any()).Return(nil).AnyTimes()
+		mockRepo.EXPECT().CreateRevision(gomock.Any()).Return(nil).AnyTimes()
 		mockRepo.EXPECT().Create(cmp).Return(models.Company{
 			Id:                companyUUID,
 			Name:              "Big company",
@@ -111,7 +153,7 @@ func TestService_CreateCompany(t *testing.T) {
 			Type:              "Corporations",
 		}, nil).AnyTimes()
 		l, _ := logger.GetLogger()
-		s := company.NewService(l, mockRepo, 3600*time.Second)
+		s := company.NewService(l, mockRepo, 3600*time.Second, nil, nil, nil)
 		id, err := s.CreateCompany(context.Background(), cmp)
 		if err != nil {
 			t.Fatalf("Cannot store company via service due error: %s", err)
@@ -133,10 +175,11 @@ func TestService_CreateCompanyErr(t *testing.T) {
 			Registered:        false,
 			Type:              "Corporations",
 		}
+		expectTransaction(mockRepo)
 		mockRepo.EXPECT().Create(cmp).Return(models.Company{},
 			fmt.Errorf("Error occurs: %w", uerrors.ErrCreateCompany)).AnyTimes()
 		l, _ := logger.GetLogger()
-		s := company.NewService(l, mockRepo, 3600*time.Second)
+		s := company.NewService(l, mockRepo, 3600*time.Second, nil, nil, nil)
 		_, err := s.CreateCompany(context.Background(), cmp)
 		if err != nil {
 			assert.ErrorIs(t, err, uerrors.ErrCreateCompany)
@@ -160,10 +203,14 @@ func TestService_UpdateCompany(t *testing.T) {
 			Type:              "Corporations",
 		}
 
+		expectTransaction(mockRepo)
+		mockRepo.EXPECT().CreateOutboxEvent(gomock.Any()).Return(nil).AnyTimes()
+		mockRepo.EXPECT().CreateRevision(gomock.Any()).Return(nil).AnyTimes()
+		mockRepo.EXPECT().Get(cmp.Id).Return(*cmp, nil).AnyTimes()
 		mockRepo.EXPECT().Update(cmp).Return(nil)
 		l, _ := logger.GetLogger()
-		s := company.NewService(l, mockRepo, 3600*time.Second)
-		err := s.UpdateCompany(context.Background(), cmp)
+		s := company.NewService(l, mockRepo, 3600*time.Second, nil, nil, nil)
+		err := s.UpdateCompany(asAdmin(), cmp)
 		if err != nil {
 			t.Fatalf("Cannot update company via service due error: %s", err)
 		}
@@ -184,11 +231,13 @@ func TestService_UpdateCompanyErr(t *testing.T) {
 			Type:              "Corporations",
 		}
 
+		expectTransaction(mockRepo)
+		mockRepo.EXPECT().Get(cmp.Id).Return(*cmp, nil).AnyTimes()
 		mockRepo.EXPECT().Update(cmp).
 			Return(fmt.Errorf("Error occurs: %w", uerrors.ErrUpdateCompany))
 		l, _ := logger.GetLogger()
-		s := company.NewService(l, mockRepo, 3600*time.Second)
-		err := s.UpdateCompany(context.Background(), cmp)
+		s := company.NewService(l, mockRepo, 3600*time.Second, nil, nil, nil)
+		err := s.UpdateCompany(asAdmin(), cmp)
 		if err != nil {
 			assert.ErrorIs(t, err, uerrors.ErrUpdateCompany)
 		} else {
@@ -197,18 +246,169 @@ func TestService_UpdateCompanyErr(t *testing.T) {
 	})
 }
 
+func TestService_UpdateCompanyVersion(t *testing.T) {
+	t.Run("Stale version", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		cmp := &models.Company{Id: uuid.New(), Name: "Big company", Version: 2}
+		mockRepo := mock_company.NewMockRepository(ctrl)
+		expectTransaction(mockRepo)
+		mockRepo.EXPECT().Get(cmp.Id).Return(models.Company{Id: cmp.Id, Version: 3}, nil)
+
+		l, _ := logger.GetLogger()
+		s := company.NewService(l, mockRepo, 3600*time.Second, nil, nil, nil)
+		assert.ErrorIs(t, s.UpdateCompany(asAdmin(), cmp), uerrors.ErrVersionMismatch)
+	})
+
+	t.Run("Concurrent update", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		cmp := &models.Company{Id: uuid.New(), Name: "Big company", Version: 3}
+		mockRepo := mock_company.NewMockRepository(ctrl)
+		expectTransaction(mockRepo)
+		mockRepo.EXPECT().Get(cmp.Id).Return(models.Company{Id: cmp.Id, Version: 3}, nil)
+		mockRepo.EXPECT().Update(cmp).Return(uerrors.ErrVersionMismatch)
+
+		l, _ := logger.GetLogger()
+		s := company.NewService(l, mockRepo, 3600*time.Second, nil, nil, nil)
+		assert.ErrorIs(t, s.UpdateCompany(asAdmin(), cmp), uerrors.ErrVersionMismatch)
+	})
+
+	t.Run("New version is returned", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		cmp := &models.Company{Id: uuid.New(), Name: "Big company"}
+		mockRepo := mock_company.NewMockRepository(ctrl)
+		expectTransaction(mockRepo)
+		mockRepo.EXPECT().CreateOutboxEvent(gomock.Any()).Return(nil)
+		mockRepo.EXPECT().CreateRevision(gomock.Any()).Return(nil)
+		gomock.InOrder(
+			mockRepo.EXPECT().Get(cmp.Id).Return(models.Company{Id: cmp.Id, Version: 3}, nil),
+			mockRepo.EXPECT().Update(gomock.Any()).DoAndReturn(func(c *models.Company) error {
+				assert.Equal(t, 3, c.Version)
+				return nil
+			}),
+			mockRepo.EXPECT().Get(cmp.Id).Return(models.Company{Id: cmp.Id, Version: 4}, nil),
+		)
+
+		l, _ := logger.GetLogger()
+		s := company.NewService(l, mockRepo, 3600*time.Second, nil, nil, nil)
+		assert.NoError(t, s.UpdateCompany(asAdmin(), cmp))
+		assert.Equal(t, 4, cmp.Version)
+	})
+}
+
+func TestService_DeleteCompanyVersion(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	id := uuid.New()
+	mockRepo := mock_company.NewMockRepository(ctrl)
+	expectTransaction(mockRepo)
+	mockRepo.EXPECT().Get(id).Return(models.Company{Id: id, Version: 3}, nil)
+
+	l, _ := logger.GetLogger()
+	s := company.NewService(l, mockRepo, 3600*time.Second, nil, nil, nil)
+	assert.ErrorIs(t, s.DeleteCompany(asAdmin(), id, 2), uerrors.ErrVersionMismatch)
+}
+
+func TestService_PatchCompany(t *testing.T) {
+	stored := models.Company{
+		Id:                uuid.New(),
+		Name:              "Big company",
+		Description:       "Makes things",
+		AmountOfEmployees: 100,
+		Registered:        true,
+		Type:              models.Corporations,
+		Version:           3,
+	}
+
+	t.Run("Zero values are written", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mock_company.NewMockRepository(ctrl)
+		expectTransaction(mockRepo)
+		mockRepo.EXPECT().CreateOutboxEvent(gomock.Any()).Return(nil)
+		mockRepo.EXPECT().CreateRevision(gomock.Any()).Return(nil)
+		want := stored
+		want.AmountOfEmployees, want.Registered = 0, false
+		gomock.InOrder(
+			mockRepo.EXPECT().Get(stored.Id).Return(stored, nil),
+			mockRepo.EXPECT().Update(&want).Return(nil),
+			mockRepo.EXPECT().Get(stored.Id).Return(want, nil),
+		)
+
+		l, _ := logger.GetLogger()
+		s := company.NewService(l, mockRepo, 3600*time.Second, nil, nil, nil)
+		p, _ := patch.ParseMergePatch([]byte(`{"amountOfEmployees": 0, "registered": false}`))
+		c, err := s.PatchCompany(asAdmin(), stored.Id, 3, p)
+		assert.NoError(t, err)
+		assert.Equal(t, want, c)
+	})
+
+	testCases := []struct {
+		name  string
+		patch string
+	}{
+		{
+			name:  "Required field removed",
+			patch: `[{"op": "remove", "path": "/name"}]`,
+		},
+		{
+			name:  "Id changed",
+			patch: `[{"op": "replace", "path": "/id", "value": "af056d5a-0f61-4635-a174-cfddf4b1b01e"}]`,
+		},
+		{
+			name:  "Unknown field",
+			patch: `[{"op": "add", "path": "/owner", "value": "bill"}]`,
+		},
+		{
+			name:  "Failed test",
+			patch: `[{"op": "test", "path": "/name", "value": "Small company"}]`,
+		},
+	}
+
+	for _, tcase := range testCases {
+		t.Run(tcase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mock_company.NewMockRepository(ctrl)
+			expectTransaction(mockRepo)
+			mockRepo.EXPECT().Get(stored.Id).Return(stored, nil)
+
+			l, _ := logger.GetLogger()
+			s := company.NewService(l, mockRepo, 3600*time.Second, nil, nil, nil)
+			p, err := patch.ParseJSONPatch([]byte(tcase.patch))
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			_, err = s.PatchCompany(asAdmin(), stored.Id, 0, p)
+			assert.ErrorIs(t, err, uerrors.ErrInvalidPatch)
+		})
+	}
+}
+
 func TestService_DeleteCompany(t *testing.T) {
 	t.Run("Delete company", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
 		mockRepo := mock_company.NewMockRepository(ctrl)
 		companyUUID, _ := uuid.FromBytes([]byte("af056d5a-0f61-4635-a174-cfddf4b1b01e"))
-		mockRepo.EXPECT().Delete(companyUUID).Return(nil).AnyTimes()
+		expectTransaction(mockRepo)
+		mockRepo.EXPECT().CreateOutboxEvent(gomock.Any()).Return(nil).AnyTimes()
+		mockRepo.EXPECT().CreateRevision(gomock.Any()).Return(nil).AnyTimes()
+		mockRepo.EXPECT().Get(companyUUID).Return(models.Company{Id: companyUUID}, nil).AnyTimes()
+		mockRepo.EXPECT().Delete(companyUUID, 0).Return(nil).AnyTimes()
 
 		l, _ := logger.GetLogger()
-		s := company.NewService(l, mockRepo, 3600*time.Second)
+		s := company.NewService(l, mockRepo, 3600*time.Second, nil, nil, nil)
 
-		err := s.DeleteCompany(companyUUID)
+		err := s.DeleteCompany(asAdmin(), companyUUID, 0)
 		if err != nil {
 			t.Fatalf("Cannot delete company via service due error: %s", err)
 		}
@@ -221,13 +421,15 @@ func TestService_DeleteCompanyErr(t *testing.T) {
 		defer ctrl.Finish()
 		mockRepo := mock_company.NewMockRepository(ctrl)
 		companyUUID, _ := uuid.FromBytes([]byte("af056d5a-0f61-4635-a174-cfddf4b1b01e"))
-		mockRepo.EXPECT().Delete(companyUUID).
+		expectTransaction(mockRepo)
+		mockRepo.EXPECT().Get(companyUUID).Return(models.Company{Id: companyUUID}, nil).AnyTimes()
+		mockRepo.EXPECT().Delete(companyUUID, 0).
 			Return(fmt.Errorf("Error occurs: %w", uerrors.ErrDeleteCompany)).AnyTimes()
 
 		l, _ := logger.GetLogger()
-		s := company.NewService(l, mockRepo, 3600*time.Second)
+		s := company.NewService(l, mockRepo, 3600*time.Second, nil, nil, nil)
 
-		err := s.DeleteCompany(companyUUID)
+		err := s.DeleteCompany(asAdmin(), companyUUID, 0)
 		if err != nil {
 			assert.ErrorIs(t, err, uerrors.ErrDeleteCompany)
 		} else {
@@ -236,6 +438,22 @@ func TestService_DeleteCompanyErr(t *testing.T) {
 	})
 }
 
+func TestService_DeleteCompanyNotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	id := uuid.New()
+	mockRepo := mock_company.NewMockRepository(ctrl)
+	expectTransaction(mockRepo)
+	mockRepo.EXPECT().Get(id).Return(models.Company{}, uerrors.ErrGetCompany)
+
+	l, _ := logger.GetLogger()
+	s := company.NewService(l, mockRepo, 3600*time.Second, nil, nil, nil)
+	err := s.DeleteCompany(asAdmin(), id, 0)
+	assert.ErrorIs(t, err, uerrors.ErrGetCompany)
+	assert.NotErrorIs(t, err, uerrors.ErrDeleteCompany)
+}
+
 func TestService_GetCompany(t *testing.T) {
 	t.Run("Get company", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
@@ -253,7 +471,7 @@ func TestService_GetCompany(t *testing.T) {
 		}, nil).AnyTimes()
 
 		l, _ := logger.GetLogger()
-		s := company.NewService(l, mockRepo, 3600*time.Second)
+		s := company.NewService(l, mockRepo, 3600*time.Second, nil, nil, nil)
 		cmp, err := s.GetCompany(context.Background(), companyUUID)
 		if err != nil {
 			t.Fatalf("Unexpected error: %s", err)
@@ -273,7 +491,7 @@ func TestService_GetCompanyErr(t *testing.T) {
 			Return(models.Company{}, fmt.Errorf("Error occurs: %w", uerrors.ErrGetCompany)).AnyTimes()
 
 		l, _ := logger.GetLogger()
-		s := company.NewService(l, mockRepo, 3600*time.Second)
+		s := company.NewService(l, mockRepo, 3600*time.Second, nil, nil, nil)
 		_, err := s.GetCompany(context.Background(), companyUUID)
 		if err != nil {
 			assert.ErrorIs(t, err, uerrors.ErrGetCompany)
@@ -334,7 +552,7 @@ func TestService_CreateUser(t *testing.T) {
 				PasswordHash: "$2a$10$iXI1JdlUiz8CG9QZ6lLKg.d2XsukC4vWPFMVWiFMKQnL4YFvs13Cy",
 			}, nil).AnyTimes()
 
-			service := company.NewService(l, mockRepo, 3600)
+			service := company.NewService(l, mockRepo, 3600, nil, nil, nil)
 			if len(tcase.user.Name) == 0 {
 				if tcase.wantError {
 					t.Skip("Username can't be empty")
@@ -362,7 +580,7 @@ func TestService_CreateUser(t *testing.T) {
 			assert.NotNil(t, userCreated.Id, "User id can't be nil")
 
 			usr := tcase.user
-			userCreated, err = service.CreateUser(usr)
+			userCreated, err = service.CreateUser(usr, models.RoleEditor)
 			if err != nil {
 				t.Fatalf("Cannot store user via service due error: %s", err)
 			}
@@ -371,6 +589,23 @@ func TestService_CreateUser(t *testing.T) {
 	}
 }
 
+func TestService_CreateUserWeakPassword(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	l, _ := logger.GetLogger()
+	mockRepo := mock_company.NewMockRepository(ctrl)
+	mockRepo.EXPECT().CreateUser(gomock.Any()).Times(0)
+
+	passwords, err := password.NewPolicy(12, 3, "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	service := company.NewService(l, mockRepo, 3600, nil, passwords, nil)
+	_, err = service.CreateUser(&company.UserRequest{Name: "Bill", Password: "password"}, models.RoleEditor)
+	assert.ErrorIs(t, err, password.ErrWeakPassword)
+}
+
 func TestService_CreateToken(t *testing.T) {
 	t.Run("Create token", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
@@ -378,9 +613,9 @@ func TestService_CreateToken(t *testing.T) {
 
 		l, _ := logger.GetLogger()
 		mockRepo := mock_company.NewMockRepository(ctrl)
-		service := company.NewService(l, mockRepo, 3600)
+		service := company.NewService(l, mockRepo, 3600, nil, nil, nil)
 		uId := strconv.FormatUint(uint64(1), 10)
-		hash, err := service.CreateToken(uId)
+		hash, err := service.CreateToken(uId, models.RoleViewer)
 
 		if err != nil {
 			t.Fatalf("unexpected error")
@@ -389,3 +624,794 @@ func TestService_CreateToken(t *testing.T) {
 		assert.NotNil(t, hash)
 	})
 }
+
+func TestService_ListCompanies(t *testing.T) {
+	t.Run("List companies", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		filter := company.CompanyFilter{Type: models.Corporations, Sort: "name", Limit: 10}
+		query := filter
+		query.Limit = 11
+		mockRepo := mock_company.NewMockRepository(ctrl)
+		mockRepo.EXPECT().List(query).Return([]models.Company{
+			{
+				Id:                uuid.New(),
+				Name:              "Big company",
+				AmountOfEmployees: 100,
+				Type:              models.Corporations,
+			},
+		}, int64(1), nil)
+
+		l, _ := logger.GetLogger()
+		s := company.NewService(l, mockRepo, 3600*time.Second, nil, nil, nil)
+		page, err := s.ListCompanies(context.Background(), filter)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		assert.Len(t, page.Companies, 1)
+		assert.Equal(t, int64(1), page.Total)
+		assert.Empty(t, page.NextCursor)
+		assert.Empty(t, page.PrevCursor)
+	})
+}
+
+func TestService_ListCompaniesByCursor(t *testing.T) {
+	t.Run("List companies by cursor", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		companies := []models.Company{
+			{Id: uuid.New(), Name: "Alpha", AmountOfEmployees: 10},
+			{Id: uuid.New(), Name: "Beta", AmountOfEmployees: 20},
+			{Id: uuid.New(), Name: "Gamma", AmountOfEmployees: 20},
+		}
+		filter := company.CompanyFilter{Sort: "amountOfEmployees", Limit: 2}
+		mockRepo := mock_company.NewMockRepository(ctrl)
+		mockRepo.EXPECT().List(gomock.Any()).DoAndReturn(
+			func(f company.CompanyFilter) ([]models.Company, int64, error) {
+				assert.Equal(t, 3, f.Limit)
+				if f.Keyset == nil {
+					return companies, 3, nil
+				}
+				if f.Keyset.Backward {
+					assert.Equal(t, companies[2].Id, f.Keyset.Id)
+					return []models.Company{companies[1], companies[0]}, 3, nil
+				}
+				assert.Equal(t, 20, f.Keyset.Value)
+				assert.Equal(t, companies[1].Id, f.Keyset.Id)
+				return companies[2:], 3, nil
+			}).Times(3)
+
+		l, _ := logger.GetLogger()
+		s := company.NewService(l, mockRepo, 3600*time.Second, nil, nil, nil)
+		page, err := s.ListCompanies(context.Background(), filter)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		assert.Equal(t, companies[:2], page.Companies)
+		assert.NotEmpty(t, page.NextCursor)
+		assert.Empty(t, page.PrevCursor)
+
+		filter.Cursor = page.NextCursor
+		page, err = s.ListCompanies(context.Background(), filter)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		assert.Equal(t, companies[2:], page.Companies)
+		assert.Empty(t, page.NextCursor)
+		assert.NotEmpty(t, page.PrevCursor)
+
+		filter.Cursor = page.PrevCursor
+		page, err = s.ListCompanies(context.Background(), filter)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		assert.Equal(t, companies[:2], page.Companies)
+		assert.NotEmpty(t, page.NextCursor)
+		assert.Empty(t, page.PrevCursor)
+	})
+}
+
+func TestService_ListCompaniesInvalidCursor(t *testing.T) {
+	testCases := []struct {
+		name   string
+		cursor func(s company.IService) string
+	}{
+		{
+			name: "Malformed cursor",
+			cursor: func(s company.IService) string {
+				return "definitely-not-a-cursor"
+			},
+		},
+		{
+			name: "Tampered cursor",
+			cursor: func(s company.IService) string {
+				page, _ := s.ListCompanies(context.Background(), company.CompanyFilter{Sort: "name", Limit: 1})
+				return strings.Replace(page.NextCursor, ".", "x.", 1)
+			},
+		},
+		{
+			name: "Cursor of another sort order",
+			cursor: func(s company.IService) string {
+				page, _ := s.ListCompanies(context.Background(), company.CompanyFilter{Sort: "type", Limit: 1})
+				return page.NextCursor
+			},
+		},
+	}
+
+	for _, tcase := range testCases {
+		t.Run(tcase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mock_company.NewMockRepository(ctrl)
+			mockRepo.EXPECT().List(gomock.Any()).Return([]models.Company{
+				{Id: uuid.New(), Name: "Alpha"},
+				{Id: uuid.New(), Name: "Beta"},
+			}, int64(2), nil).AnyTimes()
+
+			l, _ := logger.GetLogger()
+			s := company.NewService(l, mockRepo, 3600*time.Second, nil, nil, nil)
+			filter := company.CompanyFilter{Sort: "name", Limit: 1, Cursor: tcase.cursor(s)}
+			_, err := s.ListCompanies(context.Background(), filter)
+			assert.ErrorIs(t, err, uerrors.ErrInvalidCursor)
+		})
+	}
+}
+
+func TestService_ListCompaniesErr(t *testing.T) {
+	t.Run("List companies err", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		filter := company.CompanyFilter{Limit: 10}
+		mockRepo := mock_company.NewMockRepository(ctrl)
+		mockRepo.EXPECT().List(gomock.Any()).Return(nil, int64(0), errors.New("connection refused"))
+
+		l, _ := logger.GetLogger()
+		s := company.NewService(l, mockRepo, 3600*time.Second, nil, nil, nil)
+		_, err := s.ListCompanies(context.Background(), filter)
+		assert.ErrorIs(t, err, uerrors.ErrListCompanies)
+	})
+}
+
+func TestService_SearchCompanies(t *testing.T) {
+	t.Run("Search companies err", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		query := company.SearchQuery{Query: "solar", Limit: 10}
+		mockRepo := mock_company.NewMockRepository(ctrl)
+		mockRepo.EXPECT().Search(query).Return(nil, int64(0), errors.New("connection refused"))
+
+		l, _ := logger.GetLogger()
+		s := company.NewService(l, mockRepo, 3600*time.Second, nil, nil, nil)
+		_, _, err := s.SearchCompanies(context.Background(), query)
+		assert.ErrorIs(t, err, uerrors.ErrSearchCompanies)
+	})
+}
+
+func TestService_CompanyEventsToOutbox(t *testing.T) {
+	t.Run("Store company events in outbox", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		ctx := auth.WithUserId(context.Background(), "42")
+		ownerId := uint(42)
+		created := models.Company{Id: uuid.New(), Name: "Big company", AmountOfEmployees: 100, OwnerId: &ownerId}
+		updated := created
+		updated.AmountOfEmployees = 200
+
+		mockRepo := mock_company.NewMockRepository(ctrl)
+		expectTransaction(mockRepo)
+		stored := []events.Event{}
+		mockRepo.EXPECT().CreateOutboxEvent(gomock.Any()).DoAndReturn(func(e events.Event) error {
+			stored = append(stored, e)
+			return nil
+		}).Times(3)
+		revisions := []*models.CompanyRevision{}
+		mockRepo.EXPECT().CreateRevision(gomock.Any()).DoAndReturn(func(rev *models.CompanyRevision) error {
+			revisions = append(revisions, rev)
+			return nil
+		}).Times(3)
+		gomock.InOrder(
+			mockRepo.EXPECT().Create(gomock.Any()).Return(created, nil),
+			mockRepo.EXPECT().Get(created.Id).Return(created, nil),
+			mockRepo.EXPECT().Update(&updated).Return(nil),
+			mockRepo.EXPECT().Get(created.Id).Return(updated, nil),
+			mockRepo.EXPECT().Get(created.Id).Return(updated, nil),
+			mockRepo.EXPECT().Delete(created.Id, 0).Return(nil),
+		)
+
+		l, _ := logger.GetLogger()
+		s := company.NewService(l, mockRepo, 3600*time.Second, nil, nil, nil)
+		_, err := s.CreateCompany(ctx, models.Company{Name: "Big company", AmountOfEmployees: 100})
+		assert.NoError(t, err)
+		assert.NoError(t, s.UpdateCompany(ctx, &updated))
+		assert.NoError(t, s.DeleteCompany(ctx, created.Id, 0))
+
+		if !assert.Len(t, stored, 3) {
+			return
+		}
+		assert.Equal(t, events.CompanyCreated, stored[0].Type)
+		assert.Nil(t, stored[0].Before)
+		assert.Equal(t, created, *stored[0].After)
+
+		assert.Equal(t, events.CompanyUpdated, stored[1].Type)
+		assert.Equal(t, 100, stored[1].Before.AmountOfEmployees)
+		assert.Equal(t, 200, stored[1].After.AmountOfEmployees)
+
+		assert.Equal(t, events.CompanyDeleted, stored[2].Type)
+		assert.Nil(t, stored[2].After)
+
+		for _, e := range stored {
+			assert.Equal(t, created.Id, e.CompanyId)
+			assert.Equal(t, "42", e.ActorId)
+		}
+
+		if !assert.Len(t, revisions, 3) {
+			return
+		}
+		assert.Equal(t, string(events.CompanyUpdated), revisions[1].Action)
+		assert.Equal(t, "42", revisions[1].ActorId)
+		assert.Equal(t, updated, revisions[1].Snapshot)
+		assert.Equal(t, []models.FieldChange{{Field: "amountOfEmployees", Old: 100.0, New: 200.0}}, revisions[1].Diff)
+		assert.Equal(t, updated, revisions[2].Snapshot)
+	})
+
+	t.Run("Failed outbox write fails the change", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mock_company.NewMockRepository(ctrl)
+		expectTransaction(mockRepo)
+		mockRepo.EXPECT().Create(gomock.Any()).Return(models.Company{Id: uuid.New()}, nil)
+		mockRepo.EXPECT().CreateOutboxEvent(gomock.Any()).Return(errors.New("connection refused"))
+
+		l, _ := logger.GetLogger()
+		s := company.NewService(l, mockRepo, 3600*time.Second, nil, nil, nil)
+		_, err := s.CreateCompany(context.Background(), models.Company{Name: "Big company"})
+		assert.ErrorIs(t, err, uerrors.ErrCreateCompany)
+	})
+}
+
+func TestService_SubscribeEvents(t *testing.T) {
+	t.Run("Committed changes are streamed", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		created := models.Company{Id: uuid.New(), Name: "Big company"}
+		mockRepo := mock_company.NewMockRepository(ctrl)
+		expectTransaction(mockRepo)
+		mockRepo.EXPECT().Create(gomock.Any()).Return(created, nil)
+		mockRepo.EXPECT().Get(created.Id).Return(created, nil)
+		mockRepo.EXPECT().Delete(created.Id, 0).Return(nil)
+		mockRepo.EXPECT().CreateOutboxEvent(gomock.Any()).Return(nil).Times(2)
+		mockRepo.EXPECT().CreateRevision(gomock.Any()).Return(nil).Times(2)
+
+		l, _ := logger.GetLogger()
+		s := company.NewService(l, mockRepo, 3600*time.Second, events.NewBroker(10), nil, nil)
+		backlog, messages, cancel := s.SubscribeEvents(context.Background(), "")
+		defer cancel()
+		assert.Empty(t, backlog)
+
+		_, err := s.CreateCompany(context.Background(), models.Company{Name: "Big company"})
+		assert.NoError(t, err)
+		assert.NoError(t, s.DeleteCompany(asAdmin(), created.Id, 0))
+
+		first := <-messages
+		assert.Equal(t, events.CompanyCreated, first.Event.Type)
+		assert.Equal(t, created.Id, first.Event.CompanyId)
+		second := <-messages
+		assert.Equal(t, events.CompanyDeleted, second.Event.Type)
+
+		resumed, _, cancelResumed := s.SubscribeEvents(context.Background(), first.Id())
+		defer cancelResumed()
+		if assert.Len(t, resumed, 1) {
+			assert.Equal(t, second.Id(), resumed[0].Id())
+		}
+	})
+
+	t.Run("Failed changes are not streamed", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mock_company.NewMockRepository(ctrl)
+		expectTransaction(mockRepo)
+		mockRepo.EXPECT().Create(gomock.Any()).Return(models.Company{Id: uuid.New()}, nil)
+		mockRepo.EXPECT().CreateOutboxEvent(gomock.Any()).Return(errors.New("connection refused"))
+
+		l, _ := logger.GetLogger()
+		stream := events.NewBroker(10)
+		s := company.NewService(l, mockRepo, 3600*time.Second, stream, nil, nil)
+		_, err := s.CreateCompany(context.Background(), models.Company{Name: "Big company"})
+		assert.ErrorIs(t, err, uerrors.ErrCreateCompany)
+
+		backlog, _, cancel := stream.Subscribe("0-0")
+		defer cancel()
+		assert.Empty(t, backlog)
+	})
+}
+
+func TestService_RestoreCompany(t *testing.T) {
+	t.Run("Restored company is announced", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		restored := models.Company{Id: uuid.New(), Name: "Big company"}
+		mockRepo := mock_company.NewMockRepository(ctrl)
+		expectTransaction(mockRepo)
+		mockRepo.EXPECT().Restore(restored.Id).Return(nil)
+		mockRepo.EXPECT().Get(restored.Id).Return(restored, nil)
+		mockRepo.EXPECT().CreateOutboxEvent(gomock.Any()).DoAndReturn(func(e events.Event) error {
+			assert.Equal(t, events.CompanyRestored, e.Type)
+			assert.Equal(t, restored.Id, e.CompanyId)
+			return nil
+		})
+		mockRepo.EXPECT().CreateRevision(gomock.Any()).Return(nil)
+
+		l, _ := logger.GetLogger()
+		s := company.NewService(l, mockRepo, 3600*time.Second, nil, nil, nil)
+		c, err := s.RestoreCompany(asAdmin(), restored.Id)
+		assert.NoError(t, err)
+		assert.Equal(t, restored, c)
+	})
+
+	t.Run("Company is not deleted", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		id := uuid.New()
+		mockRepo := mock_company.NewMockRepository(ctrl)
+		expectTransaction(mockRepo)
+		mockRepo.EXPECT().Restore(id).Return(uerrors.ErrGetCompany)
+
+		l, _ := logger.GetLogger()
+		s := company.NewService(l, mockRepo, 3600*time.Second, nil, nil, nil)
+		_, err := s.RestoreCompany(asAdmin(), id)
+		assert.ErrorIs(t, err, uerrors.ErrGetCompany)
+	})
+
+	t.Run("Database error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		id := uuid.New()
+		mockRepo := mock_company.NewMockRepository(ctrl)
+		expectTransaction(mockRepo)
+		mockRepo.EXPECT().Restore(id).Return(errors.New("connection refused"))
+
+		l, _ := logger.GetLogger()
+		s := company.NewService(l, mockRepo, 3600*time.Second, nil, nil, nil)
+		_, err := s.RestoreCompany(asAdmin(), id)
+		assert.ErrorIs(t, err, uerrors.ErrRestoreCompany)
+	})
+}
+
+func TestService_PurgeCompanies(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	deletedBefore := time.Now().Add(-time.Hour)
+	mockRepo := mock_company.NewMockRepository(ctrl)
+	mockRepo.EXPECT().Purge(deletedBefore).Return(int64(2), nil)
+	mockRepo.EXPECT().Purge(deletedBefore).Return(int64(0), errors.New("connection refused"))
+
+	l, _ := logger.GetLogger()
+	s := company.NewService(l, mockRepo, 3600*time.Second, nil, nil, nil)
+	purged, err := s.PurgeCompanies(context.Background(), deletedBefore)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), purged)
+
+	_, err = s.PurgeCompanies(context.Background(), deletedBefore)
+	assert.ErrorIs(t, err, uerrors.ErrPurgeCompanies)
+}
+
+// asAdmin returns the context of a request of an admin, who may change every company.
+func asAdmin() context.Context {
+	return auth.WithRole(context.Background(), string(models.RoleAdmin))
+}
+
+// expectTransaction makes the mock run transactions against itself.
+func expectTransaction(repo *mock_company.MockRepository) {
+	repo.EXPECT().Transaction(gomock.Any()).DoAndReturn(func(fn func(company.Repository) error) error {
+		return fn(repo)
+	}).AnyTimes()
+}
+
+func TestService_ImportCompanies(t *testing.T) {
+	companies := []models.Company{
+		{Name: "New company", Type: models.Cooperative},
+		{Name: "Old company", Type: models.NonProfit},
+	}
+
+	t.Run("Taken names are skipped", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		created := models.Company{Id: uuid.New(), Name: "New company", Type: models.Cooperative, Version: 1}
+		mockRepo := mock_company.NewMockRepository(ctrl)
+		expectTransaction(mockRepo)
+		mockRepo.EXPECT().TakenNames([]string{"New company", "Old company"}).Return([]string{"Old company"}, nil)
+		mockRepo.EXPECT().Create(companies[0]).Return(created, nil)
+		mockRepo.EXPECT().CreateOutboxEvent(gomock.Any()).DoAndReturn(func(e events.Event) error {
+			assert.Equal(t, events.CompanyCreated, e.Type)
+			assert.Equal(t, created.Id, e.CompanyId)
+			return nil
+		})
+		mockRepo.EXPECT().CreateRevision(gomock.Any()).Return(nil)
+
+		l, _ := logger.GetLogger()
+		s := company.NewService(l, mockRepo, 3600*time.Second, nil, nil, nil)
+		results, err := s.ImportCompanies(context.Background(), companies, false)
+		assert.NoError(t, err)
+		assert.Equal(t, []company.ImportResult{{Id: created.Id}, {Duplicate: true}}, results)
+	})
+
+	t.Run("Dry run creates nothing", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mock_company.NewMockRepository(ctrl)
+		expectTransaction(mockRepo)
+		mockRepo.EXPECT().TakenNames(gomock.Any()).Return([]string{"Old company"}, nil)
+
+		l, _ := logger.GetLogger()
+		s := company.NewService(l, mockRepo, 3600*time.Second, nil, nil, nil)
+		results, err := s.ImportCompanies(context.Background(), companies, true)
+		assert.NoError(t, err)
+		assert.Equal(t, []company.ImportResult{{}, {Duplicate: true}}, results)
+	})
+
+	t.Run("Database error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mock_company.NewMockRepository(ctrl)
+		expectTransaction(mockRepo)
+		mockRepo.EXPECT().TakenNames(gomock.Any()).Return(nil, nil)
+		mockRepo.EXPECT().Create(gomock.Any()).Return(models.Company{}, errors.New("connection refused"))
+
+		l, _ := logger.GetLogger()
+		s := company.NewService(l, mockRepo, 3600*time.Second, nil, nil, nil)
+		_, err := s.ImportCompanies(context.Background(), companies, false)
+		assert.ErrorIs(t, err, uerrors.ErrImportCompanies)
+	})
+}
+
+func TestService_ExportCompanies(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	filter := company.CompanyFilter{Type: models.NonProfit, Sort: "name"}
+	mockRepo := mock_company.NewMockRepository(ctrl)
+	mockRepo.EXPECT().Export(filter, gomock.Any()).Return(errors.New("connection refused"))
+
+	l, _ := logger.GetLogger()
+	s := company.NewService(l, mockRepo, 3600*time.Second, nil, nil, nil)
+	err := s.ExportCompanies(context.Background(), filter, func(models.Company) error { return nil })
+	assert.ErrorIs(t, err, uerrors.ErrExportCompanies)
+}
+
+func TestService_SetUserRole(t *testing.T) {
+	t.Run("Role assigned", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mock_company.NewMockRepository(ctrl)
+		mockRepo.EXPECT().SetUserRole(uint(5), models.RoleAdmin).
+			Return(models.User{Id: 5, Name: "bill", Role: models.RoleAdmin}, nil)
+
+		l, _ := logger.GetLogger()
+		s := company.NewService(l, mockRepo, 3600*time.Second, nil, nil, nil)
+		u, err := s.SetUserRole(auth.WithUserId(context.Background(), "1"), 5, models.RoleAdmin)
+		assert.NoError(t, err)
+		assert.Equal(t, models.RoleAdmin, u.Role)
+	})
+
+	t.Run("Own role", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		l, _ := logger.GetLogger()
+		s := company.NewService(l, mock_company.NewMockRepository(ctrl), 3600*time.Second, nil, nil, nil)
+		_, err := s.SetUserRole(auth.WithUserId(context.Background(), "5"), 5, models.RoleViewer)
+		assert.ErrorIs(t, err, uerrors.ErrChangeOwnRole)
+	})
+
+	t.Run("Unknown user", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mock_company.NewMockRepository(ctrl)
+		mockRepo.EXPECT().SetUserRole(uint(5), models.RoleEditor).Return(models.User{}, uerrors.ErrGetUser)
+
+		l, _ := logger.GetLogger()
+		s := company.NewService(l, mockRepo, 3600*time.Second, nil, nil, nil)
+		_, err := s.SetUserRole(context.Background(), 5, models.RoleEditor)
+		assert.ErrorIs(t, err, uerrors.ErrGetUser)
+	})
+}
+
+func TestService_LoginDisabled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	hash, _ := hasher.HashPassword("password")
+	disabledAt := time.Now()
+	mockRepo := mock_company.NewMockRepository(ctrl)
+	mockRepo.EXPECT().FindOneUser("Bob").
+		Return(models.User{Id: 1, Name: "Bob", PasswordHash: hash, DisabledAt: &disabledAt}, nil)
+
+	l, _ := logger.GetLogger()
+	s := company.NewService(l, mockRepo, 3600*time.Second, nil, nil, nil)
+	_, _, err := s.Login(context.Background(), &company.UserRequest{Name: "Bob", Password: "password"})
+	assert.ErrorIs(t, err, uerrors.ErrUserDisabled)
+}
+
+func TestService_SetUserDisabled(t *testing.T) {
+	t.Run("User disabled", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mock_company.NewMockRepository(ctrl)
+		mockRepo.EXPECT().SetUserDisabled(uint(5), gomock.Not(gomock.Nil())).
+			DoAndReturn(func(userId uint, disabledAt *time.Time) (models.User, error) {
+				return models.User{Id: userId, DisabledAt: disabledAt}, nil
+			})
+
+		l, _ := logger.GetLogger()
+		s := company.NewService(l, mockRepo, 3600*time.Second, nil, nil, nil)
+		u, err := s.SetUserDisabled(auth.WithUserId(context.Background(), "1"), 5, true)
+		assert.NoError(t, err)
+		assert.True(t, u.Disabled())
+	})
+
+	t.Run("User enabled", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mock_company.NewMockRepository(ctrl)
+		mockRepo.EXPECT().SetUserDisabled(uint(5), nil).Return(models.User{Id: 5}, nil)
+
+		l, _ := logger.GetLogger()
+		s := company.NewService(l, mockRepo, 3600*time.Second, nil, nil, nil)
+		u, err := s.SetUserDisabled(auth.WithUserId(context.Background(), "1"), 5, false)
+		assert.NoError(t, err)
+		assert.False(t, u.Disabled())
+	})
+
+	t.Run("Own account", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		l, _ := logger.GetLogger()
+		s := company.NewService(l, mock_company.NewMockRepository(ctrl), 3600*time.Second, nil, nil, nil)
+		_, err := s.SetUserDisabled(auth.WithUserId(context.Background(), "5"), 5, true)
+		assert.ErrorIs(t, err, uerrors.ErrChangeOwnAccount)
+	})
+
+	t.Run("Database error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mock_company.NewMockRepository(ctrl)
+		mockRepo.EXPECT().SetUserDisabled(uint(5), gomock.Any()).Return(models.User{}, errors.New("connection refused"))
+
+		l, _ := logger.GetLogger()
+		s := company.NewService(l, mockRepo, 3600*time.Second, nil, nil, nil)
+		_, err := s.SetUserDisabled(context.Background(), 5, true)
+		assert.ErrorIs(t, err, uerrors.ErrUpdateUser)
+	})
+}
+
+func TestService_DeleteUser(t *testing.T) {
+	tests := []struct {
+		name      string
+		callerId  string
+		deleteErr error
+		wantErr   error
+	}{
+		{name: "User deleted", callerId: "1"},
+		{name: "Own account", callerId: "5", wantErr: uerrors.ErrChangeOwnAccount},
+		{name: "Unknown user", callerId: "1", deleteErr: uerrors.ErrGetUser, wantErr: uerrors.ErrGetUser},
+		{name: "Database error", callerId: "1", deleteErr: errors.New("connection refused"), wantErr: uerrors.ErrDeleteUser},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mock_company.NewMockRepository(ctrl)
+			if tc.wantErr != uerrors.ErrChangeOwnAccount {
+				mockRepo.EXPECT().DeleteUser(uint(5)).Return(tc.deleteErr)
+			}
+
+			l, _ := logger.GetLogger()
+			s := company.NewService(l, mockRepo, 3600*time.Second, nil, nil, nil)
+			err := s.DeleteUser(auth.WithUserId(context.Background(), tc.callerId), 5)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestService_UpdateCompanyAccess(t *testing.T) {
+	ownerId := uint(7)
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		owner   *uint
+		grants  []models.Permission
+		wantErr error
+	}{
+		{name: "Owner", ctx: auth.WithUserId(context.Background(), "7"), owner: &ownerId},
+		{name: "Admin", ctx: auth.WithRole(auth.WithUserId(context.Background(), "8"), string(models.RoleAdmin)), owner: &ownerId},
+		{name: "Company without owner", ctx: auth.WithUserId(context.Background(), "8"), wantErr: uerrors.ErrCompanyForbidden},
+		{name: "Company without owner granted write", ctx: auth.WithUserId(context.Background(), "8"),
+			grants: []models.Permission{models.PermissionWrite}},
+		{name: "Write granted", ctx: auth.WithUserId(context.Background(), "8"), owner: &ownerId,
+			grants: []models.Permission{models.PermissionRead, models.PermissionWrite}},
+		{name: "Read granted", ctx: auth.WithUserId(context.Background(), "8"), owner: &ownerId,
+			grants: []models.Permission{models.PermissionRead}, wantErr: uerrors.ErrCompanyForbidden},
+		{name: "Nothing granted", ctx: auth.WithUserId(context.Background(), "8"), owner: &ownerId,
+			wantErr: uerrors.ErrCompanyForbidden},
+		{name: "Without user", ctx: context.Background(), owner: &ownerId, wantErr: uerrors.ErrCompanyForbidden},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			cmp := models.Company{Id: uuid.New(), Name: "Big company", Type: models.Corporations, Version: 1, OwnerId: tc.owner}
+			mockRepo := mock_company.NewMockRepository(ctrl)
+			expectTransaction(mockRepo)
+			mockRepo.EXPECT().Get(cmp.Id).Return(cmp, nil).AnyTimes()
+			mockRepo.EXPECT().Grants(cmp.Id, uint(8)).Return(tc.grants, nil).AnyTimes()
+			if tc.wantErr == nil {
+				mockRepo.EXPECT().Update(gomock.Any()).Return(nil)
+				mockRepo.EXPECT().CreateOutboxEvent(gomock.Any()).Return(nil)
+				mockRepo.EXPECT().CreateRevision(gomock.Any()).Return(nil)
+			}
+
+			l, _ := logger.GetLogger()
+			s := company.NewService(l, mockRepo, 3600*time.Second, nil, nil, nil)
+			updated := cmp
+			updated.OwnerId = nil
+			err := s.UpdateCompany(tc.ctx, &updated)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.owner, updated.OwnerId)
+		})
+	}
+}
+
+func TestService_DeleteCompanyForbidden(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ownerId := uint(7)
+	cmp := models.Company{Id: uuid.New(), Version: 1, OwnerId: &ownerId}
+	mockRepo := mock_company.NewMockRepository(ctrl)
+	expectTransaction(mockRepo)
+	mockRepo.EXPECT().Get(cmp.Id).Return(cmp, nil)
+	mockRepo.EXPECT().Grants(cmp.Id, uint(8)).Return(nil, nil)
+
+	l, _ := logger.GetLogger()
+	s := company.NewService(l, mockRepo, 3600*time.Second, nil, nil, nil)
+	err := s.DeleteCompany(auth.WithUserId(context.Background(), "8"), cmp.Id, 0)
+	assert.ErrorIs(t, err, uerrors.ErrCompanyForbidden)
+}
+
+func TestService_CreateCompanyOwner(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock_company.NewMockRepository(ctrl)
+	expectTransaction(mockRepo)
+	mockRepo.EXPECT().Create(gomock.Any()).DoAndReturn(func(c models.Company) (models.Company, error) {
+		return c, nil
+	})
+	mockRepo.EXPECT().CreateOutboxEvent(gomock.Any()).Return(nil)
+	mockRepo.EXPECT().CreateRevision(gomock.Any()).Return(nil)
+
+	l, _ := logger.GetLogger()
+	s := company.NewService(l, mockRepo, 3600*time.Second, nil, nil, nil)
+	other := uint(1)
+	c, err := s.CreateCompany(auth.WithUserId(context.Background(), "7"),
+		models.Company{Name: "Big company", Type: models.Corporations, OwnerId: &other})
+	assert.NoError(t, err)
+	if assert.NotNil(t, c.OwnerId) {
+		assert.Equal(t, uint(7), *c.OwnerId)
+	}
+}
+
+func TestService_ShareCompany(t *testing.T) {
+	ownerId, userId, groupId := uint(7), uint(9), uuid.New()
+	tests := []struct {
+		name     string
+		callerId string
+		req      company.ShareRequest
+		grants   []models.Permission
+		userErr  error
+		wantErr  error
+	}{
+		{name: "Shared by owner", callerId: "7",
+			req: company.ShareRequest{UserId: &userId, Permission: models.PermissionWrite}},
+		{name: "Shared with group", callerId: "7",
+			req: company.ShareRequest{GroupId: &groupId, Permission: models.PermissionRead}},
+		{name: "Shared by user with admin permission", callerId: "8", grants: []models.Permission{models.PermissionAdmin},
+			req: company.ShareRequest{UserId: &userId, Permission: models.PermissionAdmin}},
+		{name: "Shared by user with write permission", callerId: "8", grants: []models.Permission{models.PermissionWrite},
+			req: company.ShareRequest{UserId: &userId, Permission: models.PermissionWrite}, wantErr: uerrors.ErrCompanyForbidden},
+		{name: "Unknown user", callerId: "7", userErr: uerrors.ErrGetUser,
+			req: company.ShareRequest{UserId: &userId, Permission: models.PermissionRead}, wantErr: uerrors.ErrGetUser},
+		{name: "Both user and group", callerId: "7",
+			req: company.ShareRequest{UserId: &userId, GroupId: &groupId, Permission: models.PermissionRead}, wantErr: uerrors.ErrInvalidACLEntry},
+		{name: "Unknown permission", callerId: "7",
+			req: company.ShareRequest{UserId: &userId, Permission: "owner"}, wantErr: uerrors.ErrInvalidACLEntry},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			cmp := models.Company{Id: uuid.New(), OwnerId: &ownerId}
+			mockRepo := mock_company.NewMockRepository(ctrl)
+			expectTransaction(mockRepo)
+			mockRepo.EXPECT().Get(cmp.Id).Return(cmp, nil).AnyTimes()
+			mockRepo.EXPECT().Grants(cmp.Id, uint(8)).Return(tc.grants, nil).AnyTimes()
+			mockRepo.EXPECT().GetUser(userId).Return(models.User{Id: userId}, tc.userErr).AnyTimes()
+			mockRepo.EXPECT().GetGroup(groupId).Return(models.Group{Id: groupId}, nil).AnyTimes()
+			if tc.wantErr == nil {
+				mockRepo.EXPECT().SaveACL(gomock.Any()).DoAndReturn(func(e *models.ACLEntry) error {
+					assert.Equal(t, cmp.Id, e.CompanyId)
+					assert.Equal(t, tc.req.Permission, e.Permission)
+					e.Id = uuid.New()
+					return nil
+				})
+			}
+
+			l, _ := logger.GetLogger()
+			s := company.NewService(l, mockRepo, 3600*time.Second, nil, nil, nil)
+			entry, err := s.ShareCompany(auth.WithUserId(context.Background(), tc.callerId), cmp.Id, tc.req)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.NotEqual(t, uuid.Nil, entry.Id)
+		})
+	}
+}
+
+func TestService_UnshareCompany(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cmp := models.Company{Id: uuid.New()}
+	entryId := uuid.New()
+	mockRepo := mock_company.NewMockRepository(ctrl)
+	expectTransaction(mockRepo)
+	mockRepo.EXPECT().Get(cmp.Id).Return(cmp, nil).Times(2)
+	mockRepo.EXPECT().DeleteACL(cmp.Id, entryId).Return(uerrors.ErrACLEntryNotFound)
+	mockRepo.EXPECT().Grants(cmp.Id, uint(8)).Return(nil, nil)
+
+	l, _ := logger.GetLogger()
+	s := company.NewService(l, mockRepo, 3600*time.Second, nil, nil, nil)
+	admin := auth.WithRole(auth.WithUserId(context.Background(), "1"), string(models.RoleAdmin))
+	assert.ErrorIs(t, s.UnshareCompany(admin, cmp.Id, entryId), uerrors.ErrACLEntryNotFound)
+	// companies created before ownership are shared by admins and users granted admin permission
+	editor := auth.WithRole(auth.WithUserId(context.Background(), "8"), string(models.RoleEditor))
+	assert.ErrorIs(t, s.UnshareCompany(editor, cmp.Id, entryId), uerrors.ErrCompanyForbidden)
+}
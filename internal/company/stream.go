@@ -0,0 +1,90 @@
+package company
+
+import (
+	"encoding/json"
+	"fmt"
+	"githib.com/dkischenko/company-api/internal/events"
+	"net/http"
+	"time"
+)
+
+const (
+	// eventStreamDuration keeps a stream within the write timeout of the server.
+	// Clients reconnect after streamRetry and resume from the last event they got.
+	eventStreamDuration = 10 * time.Second
+	streamRetry         = time.Second
+	streamHeartbeat     = 5 * time.Second
+
+	queryLastEventId = "lastEventId"
+)
+
+// CompanyEventsHandler streams changes of companies as Server-Sent Events.
+// The stream resumes after the event given by the Last-Event-ID header,
+// or the lastEventId query parameter for clients which can't set headers,
+// as long as the event is still buffered.
+func (h handler) CompanyEventsHandler(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		h.logger.Entry.Error("response writer does not support streaming")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	lastEventId := r.Header.Get(headerLastEventId)
+	if lastEventId == "" {
+		lastEventId = r.URL.Query().Get(queryLastEventId)
+	}
+	backlog, messages, cancel := h.service.SubscribeEvents(r.Context(), lastEventId)
+	defer cancel()
+
+	w.Header().Set(headerContentType, headerValueEventStream)
+	w.Header().Set(headerCacheControl, "no-cache")
+	w.WriteHeader(http.StatusOK)
+	if _, err := fmt.Fprintf(w, "retry: %d\n\n", streamRetry.Milliseconds()); err != nil {
+		return
+	}
+	for _, m := range backlog {
+		if err := writeEvent(w, m); err != nil {
+			h.logger.Entry.Errorf("can't stream company event: %+v", err)
+			return
+		}
+	}
+	flusher.Flush()
+
+	deadline := time.NewTimer(eventStreamDuration)
+	defer deadline.Stop()
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-deadline.C:
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		case m, ok := <-messages:
+			if !ok {
+				// the subscriber fell behind, the client will resume from the last event
+				return
+			}
+			if err := writeEvent(w, m); err != nil {
+				h.logger.Entry.Errorf("can't stream company event: %+v", err)
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
+
+func writeEvent(w http.ResponseWriter, m events.Message) error {
+	data, err := json.Marshal(m.Event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", m.Id(), m.Event.Type, data)
+	return err
+}
@@ -0,0 +1,14 @@
+package company
+
+import (
+	"githib.com/dkischenko/company-api/models"
+	"github.com/go-playground/validator/v10"
+)
+
+var companyValidator = validator.New()
+
+// ValidateCompany checks the company against the rules declared on models.Company.
+// Every way of creating a company must pass it through here.
+func ValidateCompany(c *models.Company) error {
+	return companyValidator.Struct(c)
+}
@@ -8,12 +8,78 @@ type ErrorResponse struct {
 }
 
 var (
-	ErrFindOneUser           = errors.New("error with finding user")
-	ErrCheckUserPasswordHash = errors.New("error with using wrong password")
-	ErrCreateJWTToken        = errors.New("error with creation of JWT token of user")
-	ErrCreateCompany         = errors.New("error with creating company due a database issue")
-	ErrGetCompany            = errors.New("error with getting company due a database issue")
-	ErrGetUser               = errors.New("error with getting user due a database issue")
-	ErrUpdateCompany         = errors.New("error with updating company due a database issue")
-	ErrDeleteCompany         = errors.New("error with deleting company due a database issue")
+	ErrFindOneUser            = errors.New("error with finding user")
+	ErrCheckUserPasswordHash  = errors.New("error with using wrong password")
+	ErrCreateJWTToken         = errors.New("error with creation of JWT token of user")
+	ErrCreateCompany          = errors.New("error with creating company due a database issue")
+	ErrGetCompany             = errors.New("error with getting company due a database issue")
+	ErrGetUser                = errors.New("error with getting user due a database issue")
+	ErrSetUserRole            = errors.New("error with setting role of user due a database issue")
+	ErrChangeOwnRole          = errors.New("error with user changing own role")
+	ErrUserDisabled           = errors.New("error with user being disabled")
+	ErrChangeOwnAccount       = errors.New("error with user disabling or deleting own account")
+	ErrListUsers              = errors.New("error with listing users due a database issue")
+	ErrUpdateUser             = errors.New("error with updating user due a database issue")
+	ErrDeleteUser             = errors.New("error with deleting user due a database issue")
+	ErrUpdateCompany          = errors.New("error with updating company due a database issue")
+	ErrDeleteCompany          = errors.New("error with deleting company due a database issue")
+	ErrVersionMismatch        = errors.New("error with company version, it was changed by someone else")
+	ErrInvalidPatch           = errors.New("error with applying patch to company")
+	ErrListCompanies          = errors.New("error with listing companies due a database issue")
+	ErrInvalidCursor          = errors.New("error with decoding pagination cursor")
+	ErrSearchCompanies        = errors.New("error with searching companies due a database issue")
+	ErrExportCompanies        = errors.New("error with exporting companies due a database issue")
+	ErrRestoreCompany         = errors.New("error with restoring company due a database issue")
+	ErrCompanyNameTaken       = errors.New("error with company name already taken by another company")
+	ErrPurgeCompanies         = errors.New("error with purging deleted companies due a database issue")
+	ErrRevisionNotFound       = errors.New("error with finding company revision")
+	ErrCompanyHistory         = errors.New("error with getting company history due a database issue")
+	ErrCompanyForbidden       = errors.New("error with user not being allowed to access company")
+	ErrInvalidACLEntry        = errors.New("error with ACL entry data")
+	ErrACLEntryNotFound       = errors.New("error with finding ACL entry")
+	ErrShareCompany           = errors.New("error with sharing company due a database issue")
+	ErrGroupNotFound          = errors.New("error with finding group")
+	ErrGroupMemberNotFound    = errors.New("error with user not being member of group")
+	ErrGroupNameTaken         = errors.New("error with group name already taken by another group")
+	ErrGroup                  = errors.New("error with groups due a database issue")
+	ErrWebhookNotFound        = errors.New("error with finding webhook subscription")
+	ErrInvalidWebhook         = errors.New("error with webhook subscription data")
+	ErrCreateWebhook          = errors.New("error with creating webhook subscription due a database issue")
+	ErrUpdateWebhook          = errors.New("error with updating webhook subscription due a database issue")
+	ErrDeleteWebhook          = errors.New("error with deleting webhook subscription due a database issue")
+	ErrListWebhooks           = errors.New("error with listing webhook subscriptions due a database issue")
+	ErrIdempotencyKeyNotFound = errors.New("error with finding idempotency key")
+	ErrIdempotencyKeyExists   = errors.New("error with idempotency key already in use")
+	ErrImportCompanies        = errors.New("error with importing companies due a database issue")
+	ErrInvalidImport          = errors.New("error with reading imported companies")
+	ErrImportJobNotFound      = errors.New("error with finding import job")
+	ErrSaveImportJob          = errors.New("error with saving import job due a database issue")
+	ErrInvalidRefreshToken    = errors.New("error with refresh token being unknown or expired")
+	ErrRefreshTokenReused     = errors.New("error with refresh token used more than once")
+	ErrIssueToken             = errors.New("error with issuing tokens due a database issue")
+	ErrRevokeToken            = errors.New("error with revoking tokens due a database issue")
+	ErrInvalidAPIKey          = errors.New("error with API key being unknown, expired or revoked")
+	ErrAPIKeyNotFound         = errors.New("error with finding API key")
+	ErrInvalidAPIKeyRequest   = errors.New("error with API key data")
+	ErrCreateAPIKey           = errors.New("error with creating API key due a database issue")
+	ErrListAPIKeys            = errors.New("error with listing API keys due a database issue")
+	ErrRevokeAPIKey           = errors.New("error with revoking API key due a database issue")
+	ErrLoginThrottled         = errors.New("error with too many failed logins")
+	ErrUnlockUser             = errors.New("error with unlocking user due a database issue")
+	ErrInvalidResetToken      = errors.New("error with password reset token being unknown, used or expired")
+	ErrChangePassword         = errors.New("error with changing password due a database issue")
+	ErrResetPassword          = errors.New("error with resetting password due a database issue")
+	ErrMFAAlreadyEnabled      = errors.New("error with MFA being enabled already")
+	ErrMFANotEnrolled         = errors.New("error with MFA not being enrolled")
+	ErrInvalidMFACode         = errors.New("error with MFA code being wrong or used")
+	ErrInvalidMFAChallenge    = errors.New("error with MFA challenge being unknown or expired")
+	ErrMFA                    = errors.New("error with MFA due a database issue")
+	ErrInvalidClient          = errors.New("error with OAuth client being unknown or its secret wrong")
+	ErrInvalidGrant           = errors.New("error with OAuth grant being wrong")
+	ErrMFARequired            = errors.New("error with user requiring MFA")
+	ErrUnauthorizedClient     = errors.New("error with OAuth client not being allowed to use the grant")
+	ErrInvalidScope           = errors.New("error with OAuth scope not being granted to the client")
+	ErrOAuthClientNotFound    = errors.New("error with OAuth client not being found")
+	ErrInvalidOAuthClient     = errors.New("error with OAuth client request being invalid")
+	ErrOAuth                  = errors.New("error with OAuth due a database issue")
 )
@@ -0,0 +1,120 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const subscriberBuffer = 64
+
+// Message is an event numbered by the broker. Ids are only comparable
+// within one run of the process, which is told apart by Epoch.
+type Message struct {
+	Epoch int64
+	Seq   uint64
+	Event Event
+}
+
+// Id returns the id of the message as it's sent to clients.
+func (m Message) Id() string {
+	return fmt.Sprintf("%d-%d", m.Epoch, m.Seq)
+}
+
+// Broker implements Publisher by fanning events out to in-process subscribers.
+// The latest events are kept in a bounded ring buffer, so subscribers
+// can resume after the last event they have seen.
+type Broker struct {
+	mu          sync.Mutex
+	epoch       int64
+	seq         uint64
+	ring        []Message
+	start       int
+	count       int
+	subscribers map[chan Message]struct{}
+}
+
+func NewBroker(size int) *Broker {
+	if size < 1 {
+		size = 1
+	}
+	return &Broker{
+		epoch:       time.Now().UnixNano(),
+		ring:        make([]Message, size),
+		subscribers: make(map[chan Message]struct{}),
+	}
+}
+
+// Publish never blocks: a subscriber which can't keep up is disconnected
+// and expected to resume from the last event it has received.
+func (b *Broker) Publish(ctx context.Context, e Event) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.seq++
+	m := Message{Epoch: b.epoch, Seq: b.seq, Event: e}
+	if b.count < len(b.ring) {
+		b.ring[(b.start+b.count)%len(b.ring)] = m
+		b.count++
+	} else {
+		b.ring[b.start] = m
+		b.start = (b.start + 1) % len(b.ring)
+	}
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- m:
+		default:
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+
+	return nil
+}
+
+// Subscribe returns the buffered messages published after lastId along with
+// the channel of the following ones. An empty lastId means no backlog,
+// an id of another run of the process means the whole buffer.
+// The channel is closed when the subscriber is disconnected, cancel must be called
+// once the subscriber is not interested anymore.
+func (b *Broker) Subscribe(lastId string) (backlog []Message, messages <-chan Message, cancel func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if lastId != "" {
+		epoch, seq := parseId(lastId)
+		for i := 0; i < b.count; i++ {
+			m := b.ring[(b.start+i)%len(b.ring)]
+			if epoch != b.epoch || m.Seq > seq {
+				backlog = append(backlog, m)
+			}
+		}
+	}
+
+	ch := make(chan Message, subscriberBuffer)
+	b.subscribers[ch] = struct{}{}
+	cancel = func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, ok := b.subscribers[ch]; ok {
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+
+	return backlog, ch, cancel
+}
+
+func parseId(id string) (epoch int64, seq uint64) {
+	parts := strings.SplitN(id, "-", 2)
+	if len(parts) != 2 {
+		return 0, 0
+	}
+	epoch, _ = strconv.ParseInt(parts[0], 10, 64)
+	seq, _ = strconv.ParseUint(parts[1], 10, 64)
+	return
+}
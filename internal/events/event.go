@@ -0,0 +1,46 @@
+package events
+
+import (
+	"githib.com/dkischenko/company-api/models"
+	"github.com/google/uuid"
+	"time"
+)
+
+type Type string
+
+const (
+	CompanyCreated  Type = "company.created"
+	CompanyUpdated  Type = "company.updated"
+	CompanyDeleted  Type = "company.deleted"
+	CompanyRestored Type = "company.restored"
+)
+
+// Event describes a change of a company. Before is empty for created
+// companies and After is empty for deleted ones.
+type Event struct {
+	Id         uuid.UUID       `json:"id"`
+	Type       Type            `json:"type"`
+	CompanyId  uuid.UUID       `json:"companyId"`
+	ActorId    string          `json:"actorId"`
+	Before     *models.Company `json:"before"`
+	After      *models.Company `json:"after"`
+	OccurredAt time.Time       `json:"occurredAt"`
+}
+
+func New(t Type, actorId string, before, after *models.Company) Event {
+	e := Event{
+		Id:         uuid.New(),
+		Type:       t,
+		ActorId:    actorId,
+		Before:     before,
+		After:      after,
+		OccurredAt: time.Now().UTC(),
+	}
+	if after != nil {
+		e.CompanyId = after.Id
+	} else if before != nil {
+		e.CompanyId = before.Id
+	}
+
+	return e
+}
@@ -0,0 +1,54 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"githib.com/dkischenko/company-api/configs"
+	"github.com/segmentio/kafka-go"
+	"time"
+)
+
+const headerEventType = "event-type"
+
+// KafkaPublisher writes events to the configured Kafka topic. Events are keyed
+// by company id, so all changes of a company land in the same partition in order.
+type KafkaPublisher struct {
+	writer   *kafka.Writer
+	deadline time.Duration
+}
+
+func NewKafkaPublisher(cfg *configs.Config) *KafkaPublisher {
+	deadline := time.Duration(cfg.KafkaWriteDeadline) * time.Second
+	return &KafkaPublisher{
+		writer: &kafka.Writer{
+			Addr:         cfg.KafkaAddr(),
+			Topic:        cfg.KafkaTopic,
+			Balancer:     &kafka.Hash{},
+			RequiredAcks: kafka.RequireAll,
+			WriteTimeout: deadline,
+		},
+		deadline: deadline,
+	}
+}
+
+func (p *KafkaPublisher) Publish(ctx context.Context, e Event) error {
+	value, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("cannot marshal event: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.deadline)
+	defer cancel()
+
+	return p.writer.WriteMessages(ctx, kafka.Message{
+		Key:     []byte(e.CompanyId.String()),
+		Value:   value,
+		Headers: []kafka.Header{{Key: headerEventType, Value: []byte(e.Type)}},
+		Time:    e.OccurredAt,
+	})
+}
+
+func (p *KafkaPublisher) Close() error {
+	return p.writer.Close()
+}
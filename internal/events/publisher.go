@@ -0,0 +1,52 @@
+package events
+
+import (
+	"context"
+	"sync"
+)
+
+// Publisher delivers company events to the interested parties.
+type Publisher interface {
+	Publish(ctx context.Context, e Event) error
+}
+
+// NopPublisher drops all events.
+type NopPublisher struct{}
+
+func (NopPublisher) Publish(ctx context.Context, e Event) error {
+	return nil
+}
+
+// MultiPublisher publishes every event to all of its publishers,
+// it fails if any of them failed.
+type MultiPublisher []Publisher
+
+func (m MultiPublisher) Publish(ctx context.Context, e Event) error {
+	var failed error
+	for _, p := range m {
+		if err := p.Publish(ctx, e); err != nil && failed == nil {
+			failed = err
+		}
+	}
+	return failed
+}
+
+// MemoryPublisher keeps published events in memory, it's intended for tests.
+type MemoryPublisher struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func (p *MemoryPublisher) Publish(ctx context.Context, e Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.events = append(p.events, e)
+	return nil
+}
+
+// Events returns a copy of the events published so far.
+func (p *MemoryPublisher) Events() []Event {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]Event(nil), p.events...)
+}
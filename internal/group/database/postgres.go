@@ -0,0 +1,99 @@
+package database
+
+import (
+	"errors"
+	uerrors "githib.com/dkischenko/company-api/internal/errors"
+	"githib.com/dkischenko/company-api/internal/group"
+	"githib.com/dkischenko/company-api/models"
+	"githib.com/dkischenko/company-api/pkg/logger"
+	"github.com/google/uuid"
+	"github.com/jackc/pgconn"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// uniqueViolation is the SQLSTATE code of a unique constraint violation.
+const uniqueViolation = "23505"
+
+type postgres struct {
+	logger *logger.Logger
+	db     *gorm.DB
+}
+
+func NewStorage(db *gorm.DB, logger *logger.Logger) group.Repository {
+	return &postgres{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (p postgres) Create(g *models.Group) (err error) {
+	err = p.db.Create(g).Error
+	if pgErr := (&pgconn.PgError{}); errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return uerrors.ErrGroupNameTaken
+	}
+	return
+}
+
+func (p postgres) List() (groups []models.Group, err error) {
+	err = p.db.Order("name").Find(&groups).Error
+	return
+}
+
+func (p postgres) Delete(id uuid.UUID) (err error) {
+	return p.db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Where("id = ?", id).Delete(&models.Group{})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return uerrors.ErrGroupNotFound
+		}
+		if err := tx.Where("group_id = ?", id).Delete(&models.GroupMember{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("group_id = ?", id).Delete(&models.ACLEntry{}).Error
+	})
+}
+
+func (p postgres) ListMembers(id uuid.UUID) (members []models.GroupMember, err error) {
+	if err = p.exists(&models.Group{}, id, uerrors.ErrGroupNotFound); err != nil {
+		return nil, err
+	}
+	err = p.db.Where("group_id = ?", id).Order("user_id").Find(&members).Error
+	return
+}
+
+func (p postgres) AddMember(id uuid.UUID, userId uint) (err error) {
+	if err = p.exists(&models.Group{}, id, uerrors.ErrGroupNotFound); err != nil {
+		return err
+	}
+	if err = p.exists(&models.User{}, userId, uerrors.ErrGetUser); err != nil {
+		return err
+	}
+	return p.db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.GroupMember{GroupId: id, UserId: userId}).Error
+}
+
+func (p postgres) RemoveMember(id uuid.UUID, userId uint) (err error) {
+	res := p.db.Where("group_id = ? AND user_id = ?", id, userId).Delete(&models.GroupMember{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return uerrors.ErrGroupMemberNotFound
+	}
+	return nil
+}
+
+// exists returns notFound unless there's a row of the model with the id.
+func (p postgres) exists(model interface{}, id interface{}, notFound error) error {
+	var count int64
+	if err := p.db.Model(model).Where("id = ?", id).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return notFound
+	}
+	return nil
+}
@@ -0,0 +1,200 @@
+package group
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	uerrors "githib.com/dkischenko/company-api/internal/errors"
+	"githib.com/dkischenko/company-api/internal/middleware"
+	"githib.com/dkischenko/company-api/models"
+	"githib.com/dkischenko/company-api/pkg/logger"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"net/http"
+	"strconv"
+)
+
+const (
+	groups                 = "/v1/admin/groups"
+	groupWithId            = "/v1/admin/groups/{id}"
+	groupMembers           = "/v1/admin/groups/{id}/members"
+	groupMember            = "/v1/admin/groups/{id}/members/{userId}"
+	headerContentType      = "Content-Type"
+	headerValueContentType = "application/json"
+)
+
+type handler struct {
+	logger  *logger.Logger
+	service IService
+}
+
+func NewHandler(logger *logger.Logger, service IService) *handler {
+	return &handler{
+		logger:  logger,
+		service: service,
+	}
+}
+
+// Register adds the routes of groups, which are managed by admins only.
+func (h handler) Register(router *mux.Router) {
+	middleware.HandleRoutes(router, []middleware.Route{
+		{Method: http.MethodPost, Path: groups, Role: models.RoleAdmin, Scope: models.ScopeAdmin,
+			Handler: h.CreateGroupHandler},
+		{Method: http.MethodGet, Path: groups, Role: models.RoleAdmin, Scope: models.ScopeAdmin,
+			Handler: h.ListGroupsHandler},
+		{Method: http.MethodDelete, Path: groupWithId, Role: models.RoleAdmin, Scope: models.ScopeAdmin,
+			Handler: h.DeleteGroupHandler},
+		{Method: http.MethodGet, Path: groupMembers, Role: models.RoleAdmin, Scope: models.ScopeAdmin,
+			Handler: h.ListMembersHandler},
+		{Method: http.MethodPut, Path: groupMember, Role: models.RoleAdmin, Scope: models.ScopeAdmin,
+			Handler: h.AddMemberHandler},
+		{Method: http.MethodDelete, Path: groupMember, Role: models.RoleAdmin, Scope: models.ScopeAdmin,
+			Handler: h.RemoveMemberHandler},
+	})
+}
+
+func (h handler) CreateGroupHandler(w http.ResponseWriter, r *http.Request) {
+	req := GroupRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Entry.Error("wrong json format")
+		h.writeError(w, http.StatusBadRequest, "wrong json format")
+		return
+	}
+	if err := validator.New().Struct(req); err != nil {
+		h.logger.Entry.Errorf("got wrong group data: %+v", err)
+		h.writeError(w, http.StatusBadRequest, fmt.Sprintf("got wrong group data: %+v", err))
+		return
+	}
+
+	g, err := h.service.CreateGroup(r.Context(), req)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusCreated, g)
+}
+
+func (h handler) ListGroupsHandler(w http.ResponseWriter, r *http.Request) {
+	list, err := h.service.ListGroups(r.Context())
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+	if list == nil {
+		list = []models.Group{}
+	}
+
+	h.writeJSON(w, http.StatusOK, list)
+}
+
+func (h handler) DeleteGroupHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.logger.Entry.Errorf("can't parse UUID: %+v", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err = h.service.DeleteGroup(r.Context(), id); err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h handler) ListMembersHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.logger.Entry.Errorf("can't parse UUID: %+v", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	members, err := h.service.ListMembers(r.Context(), id)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+	if members == nil {
+		members = []models.GroupMember{}
+	}
+
+	h.writeJSON(w, http.StatusOK, members)
+}
+
+func (h handler) AddMemberHandler(w http.ResponseWriter, r *http.Request) {
+	id, userId, ok := h.memberFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.service.AddMember(r.Context(), id, userId); err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h handler) RemoveMemberHandler(w http.ResponseWriter, r *http.Request) {
+	id, userId, ok := h.memberFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.service.RemoveMember(r.Context(), id, userId); err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// memberFromPath returns the group and user ids of the path, answering
+// the request itself when they're malformed.
+func (h handler) memberFromPath(w http.ResponseWriter, r *http.Request) (id uuid.UUID, userId uint, ok bool) {
+	params := mux.Vars(r)
+	id, err := uuid.Parse(params["id"])
+	if err != nil {
+		h.logger.Entry.Errorf("can't parse UUID: %+v", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return id, 0, false
+	}
+	u, err := strconv.ParseUint(params["userId"], 10, 32)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, "user id must be a positive integer")
+		return id, 0, false
+	}
+	return id, uint(u), true
+}
+
+func (h handler) writeServiceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, uerrors.ErrGroupNotFound):
+		h.writeError(w, http.StatusNotFound, uerrors.ErrGroupNotFound.Error())
+	case errors.Is(err, uerrors.ErrGroupMemberNotFound):
+		h.writeError(w, http.StatusNotFound, uerrors.ErrGroupMemberNotFound.Error())
+	case errors.Is(err, uerrors.ErrGetUser):
+		h.writeError(w, http.StatusNotFound, "user not found")
+	case errors.Is(err, uerrors.ErrGroupNameTaken):
+		h.writeError(w, http.StatusConflict, uerrors.ErrGroupNameTaken.Error())
+	default:
+		h.logger.Entry.Errorf("group request failed: %+v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func (h handler) writeError(w http.ResponseWriter, code int, message string) {
+	h.writeJSON(w, code, uerrors.ErrorResponse{Code: code, Message: message})
+}
+
+func (h handler) writeJSON(w http.ResponseWriter, code int, body interface{}) {
+	w.Header().Add(headerContentType, headerValueContentType)
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		h.logger.Entry.Errorf("problems with encoding data: %+v", err)
+	}
+}
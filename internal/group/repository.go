@@ -0,0 +1,23 @@
+package group
+
+import (
+	"githib.com/dkischenko/company-api/models"
+	"github.com/google/uuid"
+)
+
+//go:generate mockgen -source=repository.go -destination=mocks/repository_mock.go
+type Repository interface {
+	// Create returns uerrors.ErrGroupNameTaken when another group has the name.
+	Create(g *models.Group) (err error)
+	List() (groups []models.Group, err error)
+	// Delete deletes the group along with its members and ACL entries,
+	// it returns uerrors.ErrGroupNotFound for unknown groups.
+	Delete(id uuid.UUID) (err error)
+	// ListMembers returns uerrors.ErrGroupNotFound for unknown groups.
+	ListMembers(id uuid.UUID) (members []models.GroupMember, err error)
+	// AddMember returns uerrors.ErrGroupNotFound for unknown groups and
+	// uerrors.ErrGetUser for unknown users, adding a member twice does nothing.
+	AddMember(id uuid.UUID, userId uint) (err error)
+	// RemoveMember returns uerrors.ErrGroupMemberNotFound unless the user is a member of the group.
+	RemoveMember(id uuid.UUID, userId uint) (err error)
+}
@@ -0,0 +1,5 @@
+package group
+
+type GroupRequest struct {
+	Name string `json:"name" validate:"required,max=255"`
+}
@@ -0,0 +1,106 @@
+package group
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	uerrors "githib.com/dkischenko/company-api/internal/errors"
+	"githib.com/dkischenko/company-api/models"
+	"githib.com/dkischenko/company-api/pkg/auth"
+	"githib.com/dkischenko/company-api/pkg/logger"
+	"github.com/google/uuid"
+)
+
+type Service struct {
+	logger  *logger.Logger
+	storage Repository
+}
+
+//go:generate mockgen -source=service.go -destination=mocks/service_mock.go
+type IService interface {
+	CreateGroup(ctx context.Context, req GroupRequest) (g models.Group, err error)
+	ListGroups(ctx context.Context) (groups []models.Group, err error)
+	// DeleteGroup deletes the group, companies shared with the group
+	// are no longer shared with its members.
+	DeleteGroup(ctx context.Context, id uuid.UUID) (err error)
+	ListMembers(ctx context.Context, id uuid.UUID) (members []models.GroupMember, err error)
+	AddMember(ctx context.Context, id uuid.UUID, userId uint) (err error)
+	RemoveMember(ctx context.Context, id uuid.UUID, userId uint) (err error)
+}
+
+func NewService(logger *logger.Logger, storage Repository) IService {
+	return &Service{
+		logger:  logger,
+		storage: storage,
+	}
+}
+
+func (s Service) CreateGroup(ctx context.Context, req GroupRequest) (g models.Group, err error) {
+	g = models.Group{Name: req.Name}
+	err = s.storage.Create(&g)
+	if errors.Is(err, uerrors.ErrGroupNameTaken) {
+		return models.Group{}, fmt.Errorf("error occurs: %w", err)
+	}
+	if err != nil {
+		s.logger.Entry.Errorf("failed to create group: %s", err)
+		return models.Group{}, fmt.Errorf("error occurs: %w", uerrors.ErrGroup)
+	}
+	s.logger.Entry.Infof("user %s created group %s", auth.UserIdFromContext(ctx), g.Id)
+	return
+}
+
+func (s Service) ListGroups(ctx context.Context) (groups []models.Group, err error) {
+	groups, err = s.storage.List()
+	if err != nil {
+		s.logger.Entry.Errorf("failed to list groups: %s", err)
+		return nil, fmt.Errorf("error occurs: %w", uerrors.ErrGroup)
+	}
+	return
+}
+
+func (s Service) DeleteGroup(ctx context.Context, id uuid.UUID) (err error) {
+	if err = s.wrap(s.storage.Delete(id), "delete group"); err != nil {
+		return err
+	}
+	s.logger.Entry.Infof("user %s deleted group %s", auth.UserIdFromContext(ctx), id)
+	return
+}
+
+func (s Service) ListMembers(ctx context.Context, id uuid.UUID) (members []models.GroupMember, err error) {
+	members, err = s.storage.ListMembers(id)
+	if err = s.wrap(err, "list members of group"); err != nil {
+		return nil, err
+	}
+	return
+}
+
+func (s Service) AddMember(ctx context.Context, id uuid.UUID, userId uint) (err error) {
+	if err = s.wrap(s.storage.AddMember(id, userId), "add member to group"); err != nil {
+		return err
+	}
+	s.logger.Entry.Infof("user %s added user %d to group %s", auth.UserIdFromContext(ctx), userId, id)
+	return
+}
+
+func (s Service) RemoveMember(ctx context.Context, id uuid.UUID, userId uint) (err error) {
+	if err = s.wrap(s.storage.RemoveMember(id, userId), "remove member from group"); err != nil {
+		return err
+	}
+	s.logger.Entry.Infof("user %s removed user %d from group %s", auth.UserIdFromContext(ctx), userId, id)
+	return
+}
+
+// wrap passes errors of unknown groups, users and members on
+// and logs the others as database issues.
+func (s Service) wrap(err error, action string) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, uerrors.ErrGroupNotFound), errors.Is(err, uerrors.ErrGetUser),
+		errors.Is(err, uerrors.ErrGroupMemberNotFound):
+		return fmt.Errorf("error occurs: %w", err)
+	default:
+		s.logger.Entry.Errorf("failed to %s: %s", action, err)
+		return fmt.Errorf("error occurs: %w", uerrors.ErrGroup)
+	}
+}
@@ -0,0 +1,112 @@
+package group_test
+
+import (
+	"context"
+	"errors"
+	uerrors "githib.com/dkischenko/company-api/internal/errors"
+	"githib.com/dkischenko/company-api/internal/group"
+	mock_group "githib.com/dkischenko/company-api/internal/group/mocks"
+	"githib.com/dkischenko/company-api/models"
+	"githib.com/dkischenko/company-api/pkg/logger"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestService_CreateGroup(t *testing.T) {
+	tests := []struct {
+		name      string
+		createErr error
+		wantErr   error
+	}{
+		{name: "Group created"},
+		{name: "Name taken", createErr: uerrors.ErrGroupNameTaken, wantErr: uerrors.ErrGroupNameTaken},
+		{name: "Database error", createErr: errors.New("connection refused"), wantErr: uerrors.ErrGroup},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mock_group.NewMockRepository(ctrl)
+			mockRepo.EXPECT().Create(gomock.Any()).DoAndReturn(func(g *models.Group) error {
+				assert.Equal(t, "sales", g.Name)
+				g.Id = uuid.New()
+				return tc.createErr
+			})
+
+			l, _ := logger.GetLogger()
+			s := group.NewService(l, mockRepo)
+			g, err := s.CreateGroup(context.Background(), group.GroupRequest{Name: "sales"})
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.NotEqual(t, uuid.Nil, g.Id)
+		})
+	}
+}
+
+func TestService_AddMember(t *testing.T) {
+	tests := []struct {
+		name    string
+		addErr  error
+		wantErr error
+	}{
+		{name: "Member added"},
+		{name: "Unknown group", addErr: uerrors.ErrGroupNotFound, wantErr: uerrors.ErrGroupNotFound},
+		{name: "Unknown user", addErr: uerrors.ErrGetUser, wantErr: uerrors.ErrGetUser},
+		{name: "Database error", addErr: errors.New("connection refused"), wantErr: uerrors.ErrGroup},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			id := uuid.New()
+			mockRepo := mock_group.NewMockRepository(ctrl)
+			mockRepo.EXPECT().AddMember(id, uint(7)).Return(tc.addErr)
+
+			l, _ := logger.GetLogger()
+			s := group.NewService(l, mockRepo)
+			err := s.AddMember(context.Background(), id, 7)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestService_RemoveMember(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	id := uuid.New()
+	mockRepo := mock_group.NewMockRepository(ctrl)
+	mockRepo.EXPECT().RemoveMember(id, uint(7)).Return(nil)
+	mockRepo.EXPECT().RemoveMember(id, uint(8)).Return(uerrors.ErrGroupMemberNotFound)
+
+	l, _ := logger.GetLogger()
+	s := group.NewService(l, mockRepo)
+	assert.NoError(t, s.RemoveMember(context.Background(), id, 7))
+	assert.ErrorIs(t, s.RemoveMember(context.Background(), id, 8), uerrors.ErrGroupMemberNotFound)
+}
+
+func TestService_DeleteGroup(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	id := uuid.New()
+	mockRepo := mock_group.NewMockRepository(ctrl)
+	mockRepo.EXPECT().Delete(id).Return(uerrors.ErrGroupNotFound)
+
+	l, _ := logger.GetLogger()
+	s := group.NewService(l, mockRepo)
+	assert.ErrorIs(t, s.DeleteGroup(context.Background(), id), uerrors.ErrGroupNotFound)
+}
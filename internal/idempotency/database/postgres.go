@@ -0,0 +1,59 @@
+package database
+
+import (
+	"errors"
+	uerrors "githib.com/dkischenko/company-api/internal/errors"
+	"githib.com/dkischenko/company-api/internal/idempotency"
+	"githib.com/dkischenko/company-api/models"
+	"githib.com/dkischenko/company-api/pkg/logger"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type postgres struct {
+	logger *logger.Logger
+	db     *gorm.DB
+}
+
+func NewStorage(db *gorm.DB, logger *logger.Logger) idempotency.Repository {
+	return &postgres{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (p postgres) Get(userId, key string) (k models.IdempotencyKey, err error) {
+	err = p.db.Where("user_id = ? AND key = ?", userId, key).First(&k).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return k, uerrors.ErrIdempotencyKeyNotFound
+	}
+	return
+}
+
+func (p postgres) Reserve(k *models.IdempotencyKey) (err error) {
+	res := p.db.Clauses(clause.OnConflict{DoNothing: true}).Create(k)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return uerrors.ErrIdempotencyKeyExists
+	}
+	return nil
+}
+
+func (p postgres) Complete(k *models.IdempotencyKey) (err error) {
+	return p.db.Model(&models.IdempotencyKey{}).
+		Where("user_id = ? AND key = ?", k.UserId, k.Key).
+		Select("status_code", "header", "body").
+		Updates(&models.IdempotencyKey{StatusCode: k.StatusCode, Header: k.Header, Body: k.Body}).Error
+}
+
+func (p postgres) Release(userId, key string) (err error) {
+	return p.db.Where("user_id = ? AND key = ?", userId, key).Delete(&models.IdempotencyKey{}).Error
+}
+
+func (p postgres) DeleteExpired(now time.Time) (deleted int64, err error) {
+	res := p.db.Where("expires_at <= ?", now).Delete(&models.IdempotencyKey{})
+	return res.RowsAffected, res.Error
+}
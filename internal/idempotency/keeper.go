@@ -0,0 +1,200 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	uerrors "githib.com/dkischenko/company-api/internal/errors"
+	"githib.com/dkischenko/company-api/models"
+	"githib.com/dkischenko/company-api/pkg/auth"
+	"githib.com/dkischenko/company-api/pkg/logger"
+	"io"
+	"net/http"
+	"time"
+)
+
+const (
+	HeaderIdempotencyKey = "Idempotency-Key"
+	HeaderReplayed       = "Idempotent-Replayed"
+
+	maxKeyLength    = 255
+	cleanupInterval = time.Hour
+
+	headerContentType      = "Content-Type"
+	headerValueContentType = "application/json"
+)
+
+// Keeper makes requests carrying the Idempotency-Key header safe to retry.
+// The response to the first request with a key is stored for the ttl
+// and replayed to the retries of the request. Keys are scoped to the authorized user.
+type Keeper struct {
+	logger  *logger.Logger
+	storage Repository
+	ttl     time.Duration
+}
+
+func NewKeeper(logger *logger.Logger, storage Repository, ttl time.Duration) *Keeper {
+	return &Keeper{
+		logger:  logger,
+		storage: storage,
+		ttl:     ttl,
+	}
+}
+
+// Run deletes expired keys until ctx is done.
+func (k *Keeper) Run(ctx context.Context) {
+	ticker := time.NewTicker(cleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := k.storage.DeleteExpired(time.Now()); err != nil {
+			k.logger.Entry.Errorf("failed to delete expired idempotency keys: %s", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Middleware applies the keeper to the handler. A nil keeper leaves the handler as is.
+func (k *Keeper) Middleware(next http.Handler) http.Handler {
+	if k == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(HeaderIdempotencyKey)
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > maxKeyLength {
+			k.writeError(w, http.StatusBadRequest,
+				fmt.Sprintf("%s must not be longer than %d characters", HeaderIdempotencyKey, maxKeyLength))
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			k.writeError(w, http.StatusBadRequest, "can't read request body")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		userId := auth.UserIdFromContext(r.Context())
+		hash := requestHash(r, body)
+		stored, err := k.storage.Get(userId, key)
+		if err == nil && !stored.ExpiresAt.After(time.Now()) {
+			if err = k.storage.Release(userId, key); err == nil {
+				err = uerrors.ErrIdempotencyKeyNotFound
+			}
+		}
+
+		switch {
+		case err == nil:
+			k.replay(w, stored, hash)
+			return
+		case !errors.Is(err, uerrors.ErrIdempotencyKeyNotFound):
+			k.logger.Entry.Errorf("can't get idempotency key: %s", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		now := time.Now()
+		reserved := &models.IdempotencyKey{
+			Key:         key,
+			UserId:      userId,
+			RequestHash: hash,
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(k.ttl),
+		}
+		if err = k.storage.Reserve(reserved); errors.Is(err, uerrors.ErrIdempotencyKeyExists) {
+			k.writeError(w, http.StatusConflict, "request with the same idempotency key is in progress")
+			return
+		} else if err != nil {
+			k.logger.Entry.Errorf("can't reserve idempotency key: %s", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		rec := &recorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		// server errors are not remembered, so the request can be retried
+		if rec.status >= http.StatusInternalServerError {
+			if err := k.storage.Release(userId, key); err != nil {
+				k.logger.Entry.Errorf("can't release idempotency key: %s", err)
+			}
+			return
+		}
+		reserved.StatusCode = rec.status
+		reserved.Header = rec.Header().Clone()
+		reserved.Body = rec.body.Bytes()
+		if err := k.storage.Complete(reserved); err != nil {
+			k.logger.Entry.Errorf("can't store response of idempotency key: %s", err)
+		}
+	})
+}
+
+func (k *Keeper) replay(w http.ResponseWriter, stored models.IdempotencyKey, hash string) {
+	switch {
+	case stored.RequestHash != hash:
+		k.writeError(w, http.StatusUnprocessableEntity, "idempotency key was already used for another request")
+	case stored.StatusCode == 0:
+		k.writeError(w, http.StatusConflict, "request with the same idempotency key is in progress")
+	default:
+		for name, values := range stored.Header {
+			w.Header()[name] = values
+		}
+		w.Header().Set(HeaderReplayed, "true")
+		w.WriteHeader(stored.StatusCode)
+		if _, err := w.Write(stored.Body); err != nil {
+			k.logger.Entry.Errorf("can't replay response: %s", err)
+		}
+	}
+}
+
+func (k *Keeper) writeError(w http.ResponseWriter, code int, message string) {
+	w.Header().Add(headerContentType, headerValueContentType)
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(uerrors.ErrorResponse{Code: code, Message: message}); err != nil {
+		k.logger.Entry.Errorf("problems with encoding data: %+v", err)
+	}
+}
+
+// requestHash identifies the request by its method, path and body.
+func requestHash(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method + " " + r.URL.Path + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// recorder passes the response through and keeps a copy of it.
+type recorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (r *recorder) WriteHeader(status int) {
+	// some handlers write the header again on encoding failures,
+	// only the first status reaches the client
+	if !r.wroteHeader {
+		r.status, r.wroteHeader = status, true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
@@ -0,0 +1,160 @@
+package idempotency_test
+
+import (
+	uerrors "githib.com/dkischenko/company-api/internal/errors"
+	"githib.com/dkischenko/company-api/internal/idempotency"
+	mock_idempotency "githib.com/dkischenko/company-api/internal/idempotency/mocks"
+	"githib.com/dkischenko/company-api/models"
+	"githib.com/dkischenko/company-api/pkg/logger"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// createHandler counts its calls and answers them like creation of a company.
+type createHandler struct {
+	calls  int
+	status int
+}
+
+func (h *createHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.calls++
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(h.status)
+	_, _ = w.Write([]byte(`{"name":"Big company"}`))
+}
+
+func request(key, body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/v1/companies", strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(idempotency.HeaderIdempotencyKey, key)
+	}
+	return req
+}
+
+func TestKeeper_Replay(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var stored models.IdempotencyKey
+	mockRepo := mock_idempotency.NewMockRepository(ctrl)
+	gomock.InOrder(
+		mockRepo.EXPECT().Get("", "key-1").Return(models.IdempotencyKey{}, uerrors.ErrIdempotencyKeyNotFound),
+		mockRepo.EXPECT().Reserve(gomock.Any()).Return(nil),
+		mockRepo.EXPECT().Complete(gomock.Any()).DoAndReturn(func(k *models.IdempotencyKey) error {
+			stored = *k
+			return nil
+		}),
+		mockRepo.EXPECT().Get("", "key-1").DoAndReturn(func(userId, key string) (models.IdempotencyKey, error) {
+			return stored, nil
+		}).Times(2),
+	)
+
+	l, _ := logger.GetLogger()
+	next := &createHandler{status: http.StatusCreated}
+	h := idempotency.NewKeeper(l, mockRepo, time.Hour).Middleware(next)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, request("key-1", `{"name":"Big company"}`))
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, http.StatusCreated, stored.StatusCode)
+	assert.True(t, stored.ExpiresAt.After(time.Now()))
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, request("key-1", `{"name":"Big company"}`))
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, `{"name":"Big company"}`, w.Body.String())
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.Equal(t, "true", w.Header().Get(idempotency.HeaderReplayed))
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, request("key-1", `{"name":"Other company"}`))
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+	assert.Equal(t, 1, next.calls)
+}
+
+func TestKeeper_Middleware(t *testing.T) {
+	testCases := []struct {
+		name   string
+		key    string
+		status int
+		expect func(r *mock_idempotency.MockRepository)
+		code   int
+		calls  int
+	}{
+		{
+			name:   "Without key",
+			status: http.StatusCreated,
+			code:   http.StatusCreated,
+			calls:  1,
+		},
+		{
+			name: "Key is too long",
+			key:  strings.Repeat("k", 256),
+			code: http.StatusBadRequest,
+		},
+		{
+			name: "Request is in progress",
+			key:  "key-1",
+			expect: func(r *mock_idempotency.MockRepository) {
+				r.EXPECT().Get("", "key-1").Return(models.IdempotencyKey{}, uerrors.ErrIdempotencyKeyNotFound)
+				r.EXPECT().Reserve(gomock.Any()).Return(uerrors.ErrIdempotencyKeyExists)
+			},
+			code: http.StatusConflict,
+		},
+		{
+			name:   "Server errors are not remembered",
+			key:    "key-1",
+			status: http.StatusInternalServerError,
+			expect: func(r *mock_idempotency.MockRepository) {
+				r.EXPECT().Get("", "key-1").Return(models.IdempotencyKey{}, uerrors.ErrIdempotencyKeyNotFound)
+				r.EXPECT().Reserve(gomock.Any()).Return(nil)
+				r.EXPECT().Release("", "key-1").Return(nil)
+			},
+			code:  http.StatusInternalServerError,
+			calls: 1,
+		},
+		{
+			name:   "Expired key is reused",
+			key:    "key-1",
+			status: http.StatusCreated,
+			expect: func(r *mock_idempotency.MockRepository) {
+				r.EXPECT().Get("", "key-1").Return(models.IdempotencyKey{
+					Key:        "key-1",
+					StatusCode: http.StatusCreated,
+					ExpiresAt:  time.Now().Add(-time.Minute),
+				}, nil)
+				r.EXPECT().Release("", "key-1").Return(nil)
+				r.EXPECT().Reserve(gomock.Any()).Return(nil)
+				r.EXPECT().Complete(gomock.Any()).Return(nil)
+			},
+			code:  http.StatusCreated,
+			calls: 1,
+		},
+	}
+
+	for _, tcase := range testCases {
+		t.Run(tcase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mock_idempotency.NewMockRepository(ctrl)
+			if tcase.expect != nil {
+				tcase.expect(mockRepo)
+			}
+
+			l, _ := logger.GetLogger()
+			next := &createHandler{status: tcase.status}
+			w := httptest.NewRecorder()
+			idempotency.NewKeeper(l, mockRepo, time.Hour).Middleware(next).
+				ServeHTTP(w, request(tcase.key, `{"name":"Big company"}`))
+			assert.Equal(t, tcase.code, w.Code)
+			assert.Equal(t, tcase.calls, next.calls)
+		})
+	}
+}
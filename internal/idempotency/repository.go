@@ -0,0 +1,19 @@
+package idempotency
+
+import (
+	"githib.com/dkischenko/company-api/models"
+	"time"
+)
+
+//go:generate mockgen -source=repository.go -destination=mocks/repository_mock.go
+type Repository interface {
+	// Get returns uerrors.ErrIdempotencyKeyNotFound for unknown keys.
+	Get(userId, key string) (k models.IdempotencyKey, err error)
+	// Reserve stores the key of a new request,
+	// it returns uerrors.ErrIdempotencyKeyExists when the key is already stored.
+	Reserve(k *models.IdempotencyKey) (err error)
+	// Complete stores the response to the request of the key.
+	Complete(k *models.IdempotencyKey) (err error)
+	Release(userId, key string) (err error)
+	DeleteExpired(now time.Time) (deleted int64, err error)
+}
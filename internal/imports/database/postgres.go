@@ -0,0 +1,45 @@
+package database
+
+import (
+	"errors"
+	uerrors "githib.com/dkischenko/company-api/internal/errors"
+	"githib.com/dkischenko/company-api/internal/imports"
+	"githib.com/dkischenko/company-api/models"
+	"githib.com/dkischenko/company-api/pkg/logger"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type postgres struct {
+	logger *logger.Logger
+	db     *gorm.DB
+}
+
+func NewStorage(db *gorm.DB, logger *logger.Logger) imports.Repository {
+	return &postgres{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (p postgres) Create(job *models.ImportJob) (err error) {
+	return p.db.Create(job).Error
+}
+
+func (p postgres) Get(id uuid.UUID) (job models.ImportJob, err error) {
+	err = p.db.Where("id = ?", id).First(&job).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return job, uerrors.ErrImportJobNotFound
+	}
+	return
+}
+
+func (p postgres) Update(job *models.ImportJob) (err error) {
+	return p.db.Save(job).Error
+}
+
+func (p postgres) UpdateProgress(job *models.ImportJob) (err error) {
+	return p.db.Model(&models.ImportJob{Id: job.Id}).
+		Select("status", "total", "created", "valid", "invalid", "duplicates", "failed", "updated_at").
+		Updates(job).Error
+}
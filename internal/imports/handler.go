@@ -0,0 +1,172 @@
+package imports
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	uerrors "githib.com/dkischenko/company-api/internal/errors"
+	"githib.com/dkischenko/company-api/internal/middleware"
+	"githib.com/dkischenko/company-api/models"
+	"githib.com/dkischenko/company-api/pkg/logger"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+)
+
+const (
+	companyImport          = "/v1/companies/import"
+	companyImportJob       = "/v1/companies/import/{id}"
+	queryDryRun            = "dryRun"
+	queryAsync             = "async"
+	headerContentType      = "Content-Type"
+	headerLocation         = "Location"
+	headerValueContentType = "application/json"
+
+	// maxImportSize limits the body of an import request.
+	maxImportSize = 32 << 20
+	// asyncImportSize is the size of the body above which the import runs in background.
+	// It keeps synchronous imports, around a thousand rows at most, well within the write
+	// timeout of the server, so their clients don't lose the report of committed rows.
+	asyncImportSize = 64 << 10
+)
+
+type handler struct {
+	logger  *logger.Logger
+	service IService
+}
+
+func NewHandler(logger *logger.Logger, service IService) *handler {
+	return &handler{
+		logger:  logger,
+		service: service,
+	}
+}
+
+func (h handler) Register(router *mux.Router) {
+	middleware.HandleRoutes(router, []middleware.Route{
+		{Method: http.MethodPost, Path: companyImport, Role: models.RoleEditor, Scope: models.ScopeCompaniesWrite,
+			Handler: h.ImportCompaniesHandler},
+		{Method: http.MethodGet, Path: companyImportJob, Role: models.RoleViewer, Scope: models.ScopeCompaniesRead,
+			Handler: h.GetImportJobHandler},
+	})
+}
+
+// ImportCompaniesHandler imports companies from the CSV or NDJSON body.
+// Small imports are answered with the finished job, large ones and those asked
+// to be async are accepted and run in background.
+func (h handler) ImportCompaniesHandler(w http.ResponseWriter, r *http.Request) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get(headerContentType))
+	format, ok := formats[mediaType]
+	if !ok {
+		h.writeError(w, http.StatusUnsupportedMediaType,
+			fmt.Sprintf("unsupported content type %q, use text/csv or application/x-ndjson", mediaType))
+		return
+	}
+
+	dryRun, err := parseBoolParam(r, queryDryRun)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	async, err := parseBoolParam(r, queryAsync)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	body := http.MaxBytesReader(w, r.Body, maxImportSize)
+	rows, err := io.ReadAll(io.LimitReader(body, asyncImportSize+1))
+	if err == nil && (async || len(rows) > asyncImportSize) {
+		var rest []byte
+		rest, err = io.ReadAll(body)
+		rows = append(rows, rest...)
+	}
+	if tooLarge := (&http.MaxBytesError{}); errors.As(err, &tooLarge) {
+		h.writeError(w, http.StatusRequestEntityTooLarge,
+			fmt.Sprintf("import must not be larger than %d bytes", maxImportSize))
+		return
+	}
+	if err != nil {
+		h.logger.Entry.Errorf("can't read import: %+v", err)
+		h.writeError(w, http.StatusBadRequest, "can't read import")
+		return
+	}
+
+	if async || len(rows) > asyncImportSize {
+		job, err := h.service.StartImport(r.Context(), format, rows, dryRun)
+		if err != nil {
+			h.writeServiceError(w, err)
+			return
+		}
+		w.Header().Set(headerLocation, companyImport+"/"+job.Id.String())
+		h.writeJSON(w, http.StatusAccepted, job)
+		return
+	}
+
+	job, err := h.service.Import(r.Context(), format, bytes.NewReader(rows), dryRun)
+	if errors.Is(err, uerrors.ErrInvalidImport) {
+		h.writeError(w, http.StatusBadRequest, job.Error)
+		return
+	}
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, job)
+}
+
+func (h handler) GetImportJobHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.logger.Entry.Errorf("can't parse UUID: %+v", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	job, err := h.service.GetJob(r.Context(), id)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, job)
+}
+
+func parseBoolParam(r *http.Request, name string) (bool, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("%s must be a boolean", name)
+	}
+	return b, nil
+}
+
+func (h handler) writeServiceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, uerrors.ErrImportJobNotFound):
+		h.writeError(w, http.StatusNotFound, uerrors.ErrImportJobNotFound.Error())
+	default:
+		h.logger.Entry.Errorf("import request failed: %+v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func (h handler) writeError(w http.ResponseWriter, code int, message string) {
+	h.writeJSON(w, code, uerrors.ErrorResponse{Code: code, Message: message})
+}
+
+func (h handler) writeJSON(w http.ResponseWriter, code int, body interface{}) {
+	w.Header().Add(headerContentType, headerValueContentType)
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		h.logger.Entry.Errorf("problems with encoding data: %+v", err)
+	}
+}
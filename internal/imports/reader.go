@@ -0,0 +1,149 @@
+package imports
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"githib.com/dkischenko/company-api/internal/company"
+	uerrors "githib.com/dkischenko/company-api/internal/errors"
+	"githib.com/dkischenko/company-api/models"
+	"io"
+	"strings"
+)
+
+type Format string
+
+const (
+	FormatCSV    Format = "csv"
+	FormatNDJSON Format = "ndjson"
+
+	// maxLineSize limits a single line of NDJSON.
+	maxLineSize = 1 << 20
+)
+
+// formats maps media types accepted by imports to their formats.
+var formats = map[string]Format{
+	"text/csv":             FormatCSV,
+	"application/x-ndjson": FormatNDJSON,
+	"application/ndjson":   FormatNDJSON,
+}
+
+// rowError is a problem with a single row, which doesn't stop the import.
+type rowError struct {
+	err error
+}
+
+func (e *rowError) Error() string {
+	return e.err.Error()
+}
+
+type rowReader interface {
+	// Next returns the company of the next row along with the line the row starts at.
+	// It returns io.EOF after the last row and *rowError for a row which can't be read.
+	Next() (c models.Company, line int, err error)
+}
+
+func newRowReader(format Format, r io.Reader) (rowReader, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVReader(r)
+	case FormatNDJSON:
+		s := bufio.NewScanner(r)
+		s.Buffer(make([]byte, 64*1024), maxLineSize)
+		return &ndjsonReader{scanner: s}, nil
+	default:
+		return nil, fmt.Errorf("%w: unknown format %s", uerrors.ErrInvalidImport, format)
+	}
+}
+
+// csvReader reads companies from CSV with a header naming company.CSVColumns,
+// in any order. Missing columns leave the fields of companies empty.
+type csvReader struct {
+	reader  *csv.Reader
+	columns []string
+}
+
+func newCSVReader(r io.Reader) (*csvReader, error) {
+	cr := &csvReader{reader: csv.NewReader(r)}
+	header, err := cr.reader.Read()
+	if err == io.EOF {
+		return cr, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: cannot read header: %s", uerrors.ErrInvalidImport, err)
+	}
+
+	header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	seen := make(map[string]struct{}, len(header))
+	for _, column := range header {
+		if _, ok := seen[column]; ok {
+			return nil, fmt.Errorf("%w: duplicated column %s", uerrors.ErrInvalidImport, column)
+		}
+		seen[column] = struct{}{}
+		if err := company.SetCSVField(&models.Company{}, column, ""); err != nil {
+			return nil, fmt.Errorf("%w: %s", uerrors.ErrInvalidImport, err)
+		}
+	}
+	cr.columns = header
+
+	return cr, nil
+}
+
+func (r *csvReader) Next() (c models.Company, line int, err error) {
+	if r.columns == nil {
+		return c, 0, io.EOF
+	}
+
+	record, err := r.reader.Read()
+	if pErr := (&csv.ParseError{}); errors.As(err, &pErr) {
+		return c, pErr.StartLine, &rowError{err: pErr.Err}
+	}
+	if err == io.EOF {
+		return c, 0, err
+	}
+	if err != nil {
+		return c, 0, fmt.Errorf("%w: %s", uerrors.ErrInvalidImport, err)
+	}
+	line, _ = r.reader.FieldPos(0)
+
+	for i, column := range r.columns {
+		if err := company.SetCSVField(&c, column, record[i]); err != nil {
+			return c, line, &rowError{err: err}
+		}
+	}
+	return c, line, nil
+}
+
+// ndjsonReader reads companies from JSON objects, one per line. Blank lines are skipped.
+type ndjsonReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func (r *ndjsonReader) Next() (c models.Company, line int, err error) {
+	for r.scanner.Scan() {
+		r.line++
+		row := bytes.TrimSpace(r.scanner.Bytes())
+		if len(row) == 0 {
+			continue
+		}
+
+		d := json.NewDecoder(bytes.NewReader(row))
+		d.DisallowUnknownFields()
+		if err = d.Decode(&c); err != nil {
+			return models.Company{}, r.line, &rowError{err: err}
+		}
+		if d.More() {
+			return models.Company{}, r.line, &rowError{err: errors.New("unexpected data after object")}
+		}
+		return c, r.line, nil
+	}
+
+	if err = r.scanner.Err(); err != nil {
+		return c, r.line + 1, fmt.Errorf("%w: cannot read line %d: %s", uerrors.ErrInvalidImport, r.line+1, err)
+	}
+	return c, 0, io.EOF
+}
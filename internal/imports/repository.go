@@ -0,0 +1,17 @@
+package imports
+
+import (
+	"githib.com/dkischenko/company-api/models"
+	"github.com/google/uuid"
+)
+
+//go:generate mockgen -source=repository.go -destination=mocks/repository_mock.go
+type Repository interface {
+	Create(job *models.ImportJob) (err error)
+	// Get returns uerrors.ErrImportJobNotFound for unknown jobs.
+	Get(id uuid.UUID) (job models.ImportJob, err error)
+	// Update stores the whole job including the report on its rows.
+	Update(job *models.ImportJob) (err error)
+	// UpdateProgress stores only the status and the counters of the job.
+	UpdateProgress(job *models.ImportJob) (err error)
+}
@@ -0,0 +1,217 @@
+package imports
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"githib.com/dkischenko/company-api/internal/company"
+	uerrors "githib.com/dkischenko/company-api/internal/errors"
+	"githib.com/dkischenko/company-api/models"
+	"githib.com/dkischenko/company-api/pkg/auth"
+	"githib.com/dkischenko/company-api/pkg/logger"
+	"github.com/google/uuid"
+	"io"
+	"time"
+)
+
+type Service struct {
+	logger    *logger.Logger
+	companies company.IService
+	storage   Repository
+	batchSize int
+}
+
+//go:generate mockgen -source=service.go -destination=mocks/service_mock.go
+type IService interface {
+	// Import imports the rows and returns the finished job.
+	Import(ctx context.Context, format Format, rows io.Reader, dryRun bool) (job models.ImportJob, err error)
+	// StartImport returns the pending job right away and imports the rows in background.
+	StartImport(ctx context.Context, format Format, rows []byte, dryRun bool) (job models.ImportJob, err error)
+	GetJob(ctx context.Context, id uuid.UUID) (job models.ImportJob, err error)
+}
+
+// NewService creates the service of imports, which creates companies
+// in transactions of batchSize rows.
+func NewService(logger *logger.Logger, companies company.IService, storage Repository, batchSize int) IService {
+	return &Service{
+		logger:    logger,
+		companies: companies,
+		storage:   storage,
+		batchSize: batchSize,
+	}
+}
+
+func (s Service) Import(ctx context.Context, format Format, rows io.Reader, dryRun bool) (job models.ImportJob, err error) {
+	if job, err = s.createJob(ctx, format, dryRun); err != nil {
+		return job, err
+	}
+	err = s.run(ctx, &job, rows)
+	return job, err
+}
+
+func (s Service) StartImport(ctx context.Context, format Format, rows []byte, dryRun bool) (job models.ImportJob, err error) {
+	if job, err = s.createJob(ctx, format, dryRun); err != nil {
+		return job, err
+	}
+
+	// the import outlives the request, only the user is taken over from it
+	bg := auth.WithUserId(context.Background(), auth.UserIdFromContext(ctx))
+	running := job
+	go func() {
+		if err := s.run(bg, &running, bytes.NewReader(rows)); err != nil {
+			s.logger.Entry.Errorf("import job %s failed: %s", running.Id, err)
+		}
+	}()
+
+	return job, nil
+}
+
+// GetJob returns the job if it belongs to the user of the request.
+func (s Service) GetJob(ctx context.Context, id uuid.UUID) (job models.ImportJob, err error) {
+	job, err = s.storage.Get(id)
+	if err != nil {
+		if !errors.Is(err, uerrors.ErrImportJobNotFound) {
+			s.logger.Entry.Errorf("failed to get import job: %s", err)
+		}
+		return models.ImportJob{}, fmt.Errorf("error occurs: %w", uerrors.ErrImportJobNotFound)
+	}
+	if job.OwnerId != auth.UserIdFromContext(ctx) {
+		return models.ImportJob{}, fmt.Errorf("error occurs: %w", uerrors.ErrImportJobNotFound)
+	}
+	return
+}
+
+func (s Service) createJob(ctx context.Context, format Format, dryRun bool) (job models.ImportJob, err error) {
+	job = models.ImportJob{
+		OwnerId: auth.UserIdFromContext(ctx),
+		Format:  string(format),
+		DryRun:  dryRun,
+		Status:  models.ImportPending,
+		Rows:    []models.ImportRow{},
+	}
+	if err = s.storage.Create(&job); err != nil {
+		s.logger.Entry.Errorf("failed to create import job: %s", err)
+		return models.ImportJob{}, fmt.Errorf("error occurs: %w", uerrors.ErrSaveImportJob)
+	}
+	return
+}
+
+// run imports the rows into the job and stores the job once it's finished.
+// A job fails as a whole only when the rows can't be read at all.
+func (s Service) run(ctx context.Context, job *models.ImportJob, rows io.Reader) error {
+	job.Status = models.ImportRunning
+	s.saveProgress(job)
+
+	err := s.importRows(ctx, job, rows)
+	finished := time.Now()
+	job.FinishedAt = &finished
+	job.Status = models.ImportSucceeded
+	if err != nil {
+		job.Status = models.ImportFailed
+		job.Error = err.Error()
+	}
+
+	if saveErr := s.storage.Update(job); saveErr != nil {
+		s.logger.Entry.Errorf("failed to save import job %s: %s", job.Id, saveErr)
+		if err == nil {
+			return fmt.Errorf("error occurs: %w", uerrors.ErrSaveImportJob)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("error occurs: %w", err)
+	}
+	return nil
+}
+
+// importRows validates the rows one by one and passes the valid ones
+// to the company service in batches. Names repeated within the import
+// are reported as duplicates of the row they first appeared in.
+func (s Service) importRows(ctx context.Context, job *models.ImportJob, rows io.Reader) error {
+	reader, err := newRowReader(Format(job.Format), rows)
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[string]int)
+	batch := make([]models.Company, 0, s.batchSize)
+	// pending holds indexes of the rows of the batch in the report
+	pending := make([]int, 0, s.batchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		results, err := s.companies.ImportCompanies(ctx, batch, job.DryRun)
+		for i, idx := range pending {
+			row := &job.Rows[idx]
+			switch {
+			case err != nil:
+				row.Status, row.Error = models.RowFailed, uerrors.ErrImportCompanies.Error()
+				job.Failed++
+			case results[i].Duplicate:
+				row.Status, row.Error = models.RowDuplicate, "name is already taken by another company"
+				job.Duplicates++
+			case job.DryRun:
+				row.Status = models.RowValid
+				job.Valid++
+			default:
+				id := results[i].Id
+				row.Status, row.CompanyId = models.RowCreated, &id
+				job.Created++
+			}
+		}
+		batch, pending = batch[:0], pending[:0]
+		s.saveProgress(job)
+	}
+
+	for {
+		c, line, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if rErr := (&rowError{}); errors.As(err, &rErr) {
+			job.Total++
+			job.Invalid++
+			job.Rows = append(job.Rows, models.ImportRow{Line: line, Status: models.RowInvalid, Error: rErr.Error()})
+			continue
+		}
+		if err != nil {
+			flush()
+			return err
+		}
+
+		job.Total++
+		row := models.ImportRow{Line: line, Name: c.Name}
+		if err = company.ValidateCompany(&c); err != nil {
+			row.Status, row.Error = models.RowInvalid, err.Error()
+			job.Invalid++
+			job.Rows = append(job.Rows, row)
+			continue
+		}
+		if first, ok := seen[c.Name]; ok {
+			row.Status, row.Error = models.RowDuplicate, fmt.Sprintf("name is already used at line %d", first)
+			job.Duplicates++
+			job.Rows = append(job.Rows, row)
+			continue
+		}
+		seen[c.Name] = line
+
+		job.Rows = append(job.Rows, row)
+		pending = append(pending, len(job.Rows)-1)
+		batch = append(batch, c)
+		if len(batch) >= s.batchSize {
+			flush()
+		}
+	}
+	flush()
+
+	return nil
+}
+
+// saveProgress stores the counters of the running job, so they can be watched.
+// The import goes on even if they can't be stored.
+func (s Service) saveProgress(job *models.ImportJob) {
+	if err := s.storage.UpdateProgress(job); err != nil {
+		s.logger.Entry.Errorf("failed to save progress of import job %s: %s", job.Id, err)
+	}
+}
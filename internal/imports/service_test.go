@@ -0,0 +1,222 @@
+package imports_test
+
+import (
+	"context"
+	"errors"
+	"githib.com/dkischenko/company-api/internal/company"
+	mock_company "githib.com/dkischenko/company-api/internal/company/mocks"
+	uerrors "githib.com/dkischenko/company-api/internal/errors"
+	"githib.com/dkischenko/company-api/internal/imports"
+	mock_imports "githib.com/dkischenko/company-api/internal/imports/mocks"
+	"githib.com/dkischenko/company-api/models"
+	"githib.com/dkischenko/company-api/pkg/auth"
+	"githib.com/dkischenko/company-api/pkg/logger"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func newStorage(ctrl *gomock.Controller) *mock_imports.MockRepository {
+	repo := mock_imports.NewMockRepository(ctrl)
+	repo.EXPECT().Create(gomock.Any()).DoAndReturn(func(job *models.ImportJob) error {
+		job.Id = uuid.New()
+		return nil
+	})
+	repo.EXPECT().UpdateProgress(gomock.Any()).Return(nil).AnyTimes()
+	return repo
+}
+
+func TestService_Import(t *testing.T) {
+	createdId := uuid.New()
+	tests := []struct {
+		name    string
+		format  imports.Format
+		rows    string
+		dryRun  bool
+		batches [][]string
+		results [][]company.ImportResult
+		want    []models.ImportRow
+	}{
+		{
+			name:   "CSV",
+			format: imports.FormatCSV,
+			rows: "name,type,amountOfEmployees,registered\n" +
+				"First,Cooperative,10,true\n" +
+				"Second,Unknown,10,true\n" +
+				"Third,NonProfit,many,false\n" +
+				"\"Fourth\nand more\",NonProfit,1,false\n" +
+				"First,NonProfit,1,false\n" +
+				"Fifth,Corporations,1\n",
+			batches: [][]string{{"First", "Fourth\nand more"}},
+			results: [][]company.ImportResult{{{Id: createdId}, {Duplicate: true}}},
+			want: []models.ImportRow{
+				{Line: 2, Status: models.RowCreated, Name: "First", CompanyId: &createdId},
+				{Line: 3, Status: models.RowInvalid, Name: "Second"},
+				{Line: 4, Status: models.RowInvalid},
+				{Line: 5, Status: models.RowDuplicate, Name: "Fourth\nand more"},
+				{Line: 7, Status: models.RowDuplicate, Name: "First"},
+				{Line: 8, Status: models.RowInvalid},
+			},
+		},
+		{
+			name:   "NDJSON in batches",
+			format: imports.FormatNDJSON,
+			rows: `{"name":"First","type":"Cooperative"}` + "\n\n" +
+				`{"name":"Second","type":"NonProfit","founded":1990}` + "\n" +
+				`{"name":"Third","type":"NonProfit"}` + "\n" +
+				`{"name":"Fourth","type":"NonProfit"}` + "\n",
+			dryRun:  true,
+			batches: [][]string{{"First", "Third"}, {"Fourth"}},
+			results: [][]company.ImportResult{{{}, {}}, {{}}},
+			want: []models.ImportRow{
+				{Line: 1, Status: models.RowValid, Name: "First"},
+				{Line: 3, Status: models.RowInvalid},
+				{Line: 4, Status: models.RowValid, Name: "Third"},
+				{Line: 5, Status: models.RowValid, Name: "Fourth"},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockCompanies := mock_company.NewMockIService(ctrl)
+			for i := range tc.batches {
+				names, results := tc.batches[i], tc.results[i]
+				mockCompanies.EXPECT().ImportCompanies(gomock.Any(), gomock.Any(), tc.dryRun).
+					DoAndReturn(func(ctx context.Context, companies []models.Company, dryRun bool) ([]company.ImportResult, error) {
+						got := make([]string, len(companies))
+						for i, c := range companies {
+							got[i] = c.Name
+						}
+						assert.Equal(t, names, got)
+						return results, nil
+					})
+			}
+			mockRepo := newStorage(ctrl)
+			mockRepo.EXPECT().Update(gomock.Any()).Return(nil)
+
+			l, _ := logger.GetLogger()
+			s := imports.NewService(l, mockCompanies, mockRepo, 2)
+			ctx := auth.WithUserId(context.Background(), "7")
+			job, err := s.Import(ctx, tc.format, strings.NewReader(tc.rows), tc.dryRun)
+			assert.NoError(t, err)
+			assert.Equal(t, "7", job.OwnerId)
+			assert.Equal(t, models.ImportSucceeded, job.Status)
+			assert.NotNil(t, job.FinishedAt)
+			assert.Equal(t, len(tc.want), job.Total)
+
+			assert.Len(t, job.Rows, len(tc.want))
+			for i := range job.Rows {
+				// errors are only checked to be explained
+				if s := tc.want[i].Status; s == models.RowInvalid || s == models.RowDuplicate {
+					assert.NotEmpty(t, job.Rows[i].Error)
+					job.Rows[i].Error = ""
+				}
+			}
+			assert.Equal(t, tc.want, job.Rows)
+		})
+	}
+}
+
+func TestService_ImportErr(t *testing.T) {
+	t.Run("Unknown column", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := newStorage(ctrl)
+		mockRepo.EXPECT().Update(gomock.Any()).Return(nil)
+
+		l, _ := logger.GetLogger()
+		s := imports.NewService(l, mock_company.NewMockIService(ctrl), mockRepo, 2)
+		job, err := s.Import(context.Background(), imports.FormatCSV, strings.NewReader("name,founded\nFirst,1990\n"), false)
+		assert.ErrorIs(t, err, uerrors.ErrInvalidImport)
+		assert.Equal(t, models.ImportFailed, job.Status)
+		assert.Contains(t, job.Error, "founded")
+	})
+
+	t.Run("Failed batch", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockCompanies := mock_company.NewMockIService(ctrl)
+		mockCompanies.EXPECT().ImportCompanies(gomock.Any(), gomock.Any(), false).
+			Return(nil, uerrors.ErrImportCompanies)
+		mockRepo := newStorage(ctrl)
+		mockRepo.EXPECT().Update(gomock.Any()).Return(nil)
+
+		l, _ := logger.GetLogger()
+		s := imports.NewService(l, mockCompanies, mockRepo, 2)
+		job, err := s.Import(context.Background(), imports.FormatNDJSON,
+			strings.NewReader(`{"name":"First","type":"Cooperative"}`), false)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, job.Failed)
+		assert.Equal(t, models.RowFailed, job.Rows[0].Status)
+	})
+
+	t.Run("Job can't be created", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mock_imports.NewMockRepository(ctrl)
+		mockRepo.EXPECT().Create(gomock.Any()).Return(errors.New("connection refused"))
+
+		l, _ := logger.GetLogger()
+		s := imports.NewService(l, mock_company.NewMockIService(ctrl), mockRepo, 2)
+		_, err := s.Import(context.Background(), imports.FormatCSV, strings.NewReader(""), false)
+		assert.ErrorIs(t, err, uerrors.ErrSaveImportJob)
+	})
+}
+
+func TestService_StartImport(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	done := make(chan models.ImportJob)
+	mockCompanies := mock_company.NewMockIService(ctrl)
+	mockCompanies.EXPECT().ImportCompanies(gomock.Any(), gomock.Any(), false).
+		DoAndReturn(func(ctx context.Context, companies []models.Company, dryRun bool) ([]company.ImportResult, error) {
+			assert.Equal(t, "7", auth.UserIdFromContext(ctx))
+			return []company.ImportResult{{Id: uuid.New()}}, nil
+		})
+	mockRepo := newStorage(ctrl)
+	mockRepo.EXPECT().Update(gomock.Any()).DoAndReturn(func(job *models.ImportJob) error {
+		done <- *job
+		return nil
+	})
+
+	l, _ := logger.GetLogger()
+	s := imports.NewService(l, mockCompanies, mockRepo, 2)
+	ctx := auth.WithUserId(context.Background(), "7")
+	job, err := s.StartImport(ctx, imports.FormatCSV, []byte("name,type\nFirst,Cooperative\n"), false)
+	assert.NoError(t, err)
+	assert.Equal(t, models.ImportPending, job.Status)
+
+	finished := <-done
+	assert.Equal(t, job.Id, finished.Id)
+	assert.Equal(t, models.ImportSucceeded, finished.Status)
+	assert.Equal(t, 1, finished.Created)
+}
+
+func TestService_GetJob(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	job := models.ImportJob{Id: uuid.New(), OwnerId: "7"}
+	mockRepo := mock_imports.NewMockRepository(ctrl)
+	mockRepo.EXPECT().Get(job.Id).Return(job, nil).Times(2)
+
+	l, _ := logger.GetLogger()
+	s := imports.NewService(l, mock_company.NewMockIService(ctrl), mockRepo, 2)
+
+	got, err := s.GetJob(auth.WithUserId(context.Background(), "7"), job.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, job, got)
+
+	_, err = s.GetJob(auth.WithUserId(context.Background(), "8"), job.Id)
+	assert.ErrorIs(t, err, uerrors.ErrImportJobNotFound)
+}
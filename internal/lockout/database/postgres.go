@@ -0,0 +1,59 @@
+package database
+
+import (
+	"errors"
+	uerrors "githib.com/dkischenko/company-api/internal/errors"
+	"githib.com/dkischenko/company-api/internal/lockout"
+	"githib.com/dkischenko/company-api/models"
+	"githib.com/dkischenko/company-api/pkg/logger"
+	"gorm.io/gorm"
+	"time"
+)
+
+type postgres struct {
+	logger *logger.Logger
+	db     *gorm.DB
+}
+
+func NewStorage(db *gorm.DB, logger *logger.Logger) lockout.Repository {
+	return &postgres{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (p postgres) GetFailures(keys []string) (failures []models.LoginFailure, err error) {
+	err = p.db.Where("key IN ?", keys).Find(&failures).Error
+	return
+}
+
+func (p postgres) RecordFailure(key string, now, windowStart time.Time) (failure models.LoginFailure, err error) {
+	// concurrent failures of the same key are all counted
+	err = p.db.Raw(`INSERT INTO login_failures (key, failures, last_failed_at) VALUES (?, 1, ?)
+		ON CONFLICT (key) DO UPDATE SET
+			failures = CASE WHEN login_failures.last_failed_at < ? THEN 1 ELSE login_failures.failures + 1 END,
+			locked_until = CASE WHEN login_failures.last_failed_at < ? THEN NULL ELSE login_failures.locked_until END,
+			last_failed_at = EXCLUDED.last_failed_at
+		RETURNING *`, key, now, windowStart, windowStart).Scan(&failure).Error
+	return
+}
+
+func (p postgres) Lock(key string, until time.Time) (err error) {
+	return p.db.Model(&models.LoginFailure{}).Where("key = ?", key).Update("locked_until", until).Error
+}
+
+func (p postgres) Reset(key string) (err error) {
+	return p.db.Where("key = ?", key).Delete(&models.LoginFailure{}).Error
+}
+
+func (p postgres) GetUser(userId uint) (u models.User, err error) {
+	err = p.db.Where("id = ?", userId).First(&u).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return u, uerrors.ErrGetUser
+	}
+	return
+}
+
+func (p postgres) CreateAuditEvent(e *models.AuditEvent) (err error) {
+	return p.db.Create(e).Error
+}
@@ -0,0 +1,175 @@
+package lockout
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	uerrors "githib.com/dkischenko/company-api/internal/errors"
+	"githib.com/dkischenko/company-api/models"
+	"githib.com/dkischenko/company-api/pkg/auth"
+	"githib.com/dkischenko/company-api/pkg/logger"
+	"time"
+)
+
+// Policy limits failed logins. Every user name and IP address gets FreeAttempts
+// failures, after that it has to wait before the next attempt, twice as long
+// after each failure. A user name is locked for Lockout after MaxAttempts failures,
+// an IP address after MaxIPAttempts. Failures are forgotten after Lockout.
+type Policy struct {
+	FreeAttempts  int
+	MaxAttempts   int
+	MaxIPAttempts int
+	Lockout       time.Duration
+}
+
+// Guard throttles logins by the Policy. A nil guard lets every login through.
+// The guard fails open, logins go on when failures can't be read or counted.
+type Guard struct {
+	logger  *logger.Logger
+	storage Repository
+	policy  Policy
+}
+
+func NewGuard(logger *logger.Logger, storage Repository, policy Policy) *Guard {
+	return &Guard{
+		logger:  logger,
+		storage: storage,
+		policy:  policy,
+	}
+}
+
+// UserKey is the key of the login failures of the user name.
+func UserKey(name string) string {
+	return "user:" + name
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
+
+// Check returns uerrors.ErrLoginThrottled with the time to wait when the user name
+// or the IP address is locked or has to wait before the next attempt.
+func (g *Guard) Check(ctx context.Context, name, ip string) (retryAfter time.Duration, err error) {
+	if g == nil {
+		return 0, nil
+	}
+	failures, err := g.storage.GetFailures([]string{UserKey(name), ipKey(ip)})
+	if err != nil {
+		g.logger.Entry.Errorf("failed to get login failures: %s", err)
+		return 0, nil
+	}
+
+	now := time.Now()
+	for _, f := range failures {
+		if wait := g.wait(f, now); wait > retryAfter {
+			retryAfter = wait
+		}
+	}
+	if retryAfter > 0 {
+		return retryAfter, fmt.Errorf("error occurs: %w", uerrors.ErrLoginThrottled)
+	}
+	return 0, nil
+}
+
+// Failed counts the failed login of the user name from the IP address, locks them
+// when they ran out of attempts and returns the time to wait before the next attempt.
+func (g *Guard) Failed(ctx context.Context, name, ip string) (retryAfter time.Duration) {
+	if g == nil {
+		return 0
+	}
+	now := time.Now()
+	limits := []struct {
+		key         string
+		maxAttempts int
+	}{
+		{key: UserKey(name), maxAttempts: g.policy.MaxAttempts},
+		{key: ipKey(ip), maxAttempts: g.policy.MaxIPAttempts},
+	}
+	for _, limit := range limits {
+		f, err := g.storage.RecordFailure(limit.key, now, now.Add(-g.policy.Lockout))
+		if err != nil {
+			g.logger.Entry.Errorf("failed to record login failure of %s: %s", limit.key, err)
+			continue
+		}
+		if f.Failures >= limit.maxAttempts && f.LockedUntil == nil {
+			until := now.Add(g.policy.Lockout)
+			if err = g.storage.Lock(limit.key, until); err != nil {
+				g.logger.Entry.Errorf("failed to lock %s: %s", limit.key, err)
+				continue
+			}
+			f.LockedUntil = &until
+			g.audit(ctx, models.AuditLoginLocked, limit.key, ip)
+		}
+		if wait := g.wait(f, now); wait > retryAfter {
+			retryAfter = wait
+		}
+	}
+	return retryAfter
+}
+
+// Succeeded forgets the failures of the user name. Failures of the IP address
+// are kept, so logging into an own account doesn't reset guessing of others.
+func (g *Guard) Succeeded(ctx context.Context, name string) {
+	if g == nil {
+		return
+	}
+	if err := g.storage.Reset(UserKey(name)); err != nil {
+		g.logger.Entry.Errorf("failed to reset login failures: %s", err)
+	}
+}
+
+// Unlock forgets the failures of the user, so the user can log in right away.
+func (g *Guard) Unlock(ctx context.Context, userId uint) (err error) {
+	if g == nil {
+		return nil
+	}
+	u, err := g.storage.GetUser(userId)
+	if errors.Is(err, uerrors.ErrGetUser) {
+		return fmt.Errorf("error occurs: %w", err)
+	}
+	if err == nil {
+		err = g.storage.Reset(UserKey(u.Name))
+	}
+	if err != nil {
+		g.logger.Entry.Errorf("failed to unlock user %d: %s", userId, err)
+		return fmt.Errorf("error occurs: %w", uerrors.ErrUnlockUser)
+	}
+	g.audit(ctx, models.AuditLoginUnlocked, UserKey(u.Name), "")
+	return nil
+}
+
+// wait returns the time left until the next attempt of the failures at now.
+func (g *Guard) wait(f models.LoginFailure, now time.Time) time.Duration {
+	if f.LastFailedAt.Add(g.policy.Lockout).Before(now) {
+		return 0
+	}
+	if f.LockedUntil != nil && f.LockedUntil.After(now) {
+		return f.LockedUntil.Sub(now)
+	}
+	if f.Failures <= g.policy.FreeAttempts {
+		return 0
+	}
+	delay := g.policy.Lockout
+	if shift := f.Failures - g.policy.FreeAttempts - 1; shift < 32 && time.Second<<shift < delay {
+		delay = time.Second << shift
+	}
+	if next := f.LastFailedAt.Add(delay); next.After(now) {
+		return next.Sub(now)
+	}
+	return 0
+}
+
+// audit stores the event and logs it, so it's not lost if it can't be stored.
+func (g *Guard) audit(ctx context.Context, t models.AuditType, subject, ip string) {
+	e := &models.AuditEvent{
+		Type:       t,
+		ActorId:    auth.UserIdFromContext(ctx),
+		Subject:    subject,
+		Ip:         ip,
+		OccurredAt: time.Now().UTC(),
+	}
+	g.logger.Entry.Warningf("audit: %s of %s by %q from %q", e.Type, e.Subject, e.ActorId, e.Ip)
+	if err := g.storage.CreateAuditEvent(e); err != nil {
+		g.logger.Entry.Errorf("failed to store audit event %s of %s: %s", e.Type, e.Subject, err)
+	}
+}
@@ -0,0 +1,139 @@
+package lockout_test
+
+import (
+	"context"
+	uerrors "githib.com/dkischenko/company-api/internal/errors"
+	"githib.com/dkischenko/company-api/internal/lockout"
+	mock_lockout "githib.com/dkischenko/company-api/internal/lockout/mocks"
+	"githib.com/dkischenko/company-api/models"
+	"githib.com/dkischenko/company-api/pkg/auth"
+	"githib.com/dkischenko/company-api/pkg/logger"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+var policy = lockout.Policy{FreeAttempts: 3, MaxAttempts: 5, MaxIPAttempts: 20, Lockout: 15 * time.Minute}
+
+func TestGuard_Check(t *testing.T) {
+	now := time.Now()
+	lockedUntil := now.Add(10 * time.Minute)
+	tests := []struct {
+		name     string
+		failures []models.LoginFailure
+		minWait  time.Duration
+		maxWait  time.Duration
+	}{
+		{name: "No failures"},
+		{
+			name:     "Free attempts",
+			failures: []models.LoginFailure{{Key: "user:bob", Failures: 3, LastFailedAt: now}},
+		},
+		{
+			name:     "Delay after free attempts",
+			failures: []models.LoginFailure{{Key: "user:bob", Failures: 5, LastFailedAt: now}},
+			minWait:  time.Second,
+			maxWait:  2 * time.Second,
+		},
+		{
+			name:     "Delay passed",
+			failures: []models.LoginFailure{{Key: "user:bob", Failures: 4, LastFailedAt: now.Add(-2 * time.Second)}},
+		},
+		{
+			name: "Locked IP address",
+			failures: []models.LoginFailure{
+				{Key: "user:bob", Failures: 4, LastFailedAt: now},
+				{Key: "ip:10.0.0.1", Failures: 20, LastFailedAt: now, LockedUntil: &lockedUntil},
+			},
+			minWait: 9 * time.Minute,
+			maxWait: 10 * time.Minute,
+		},
+		{
+			name:     "Forgotten failures",
+			failures: []models.LoginFailure{{Key: "user:bob", Failures: 9, LastFailedAt: now.Add(-time.Hour)}},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mock_lockout.NewMockRepository(ctrl)
+			mockRepo.EXPECT().GetFailures([]string{"user:bob", "ip:10.0.0.1"}).Return(tc.failures, nil)
+
+			l, _ := logger.GetLogger()
+			g := lockout.NewGuard(l, mockRepo, policy)
+			retryAfter, err := g.Check(context.Background(), "bob", "10.0.0.1")
+			if tc.maxWait == 0 {
+				assert.NoError(t, err)
+				assert.Zero(t, retryAfter)
+				return
+			}
+			assert.ErrorIs(t, err, uerrors.ErrLoginThrottled)
+			assert.GreaterOrEqual(t, retryAfter, tc.minWait)
+			assert.LessOrEqual(t, retryAfter, tc.maxWait)
+		})
+	}
+}
+
+func TestGuard_Failed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock_lockout.NewMockRepository(ctrl)
+	mockRepo.EXPECT().RecordFailure("user:bob", gomock.Any(), gomock.Any()).DoAndReturn(
+		func(key string, now, windowStart time.Time) (models.LoginFailure, error) {
+			assert.Equal(t, policy.Lockout, now.Sub(windowStart))
+			return models.LoginFailure{Key: key, Failures: 5, LastFailedAt: now}, nil
+		})
+	mockRepo.EXPECT().RecordFailure("ip:10.0.0.1", gomock.Any(), gomock.Any()).DoAndReturn(
+		func(key string, now, windowStart time.Time) (models.LoginFailure, error) {
+			return models.LoginFailure{Key: key, Failures: 5, LastFailedAt: now}, nil
+		})
+	mockRepo.EXPECT().Lock("user:bob", gomock.Any()).Return(nil)
+	mockRepo.EXPECT().CreateAuditEvent(gomock.Any()).DoAndReturn(func(e *models.AuditEvent) error {
+		assert.Equal(t, models.AuditLoginLocked, e.Type)
+		assert.Equal(t, "user:bob", e.Subject)
+		assert.Equal(t, "10.0.0.1", e.Ip)
+		return nil
+	})
+
+	l, _ := logger.GetLogger()
+	g := lockout.NewGuard(l, mockRepo, policy)
+	retryAfter := g.Failed(context.Background(), "bob", "10.0.0.1")
+	assert.InDelta(t, policy.Lockout, retryAfter, float64(time.Second))
+}
+
+func TestGuard_Unlock(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock_lockout.NewMockRepository(ctrl)
+	mockRepo.EXPECT().GetUser(uint(5)).Return(models.User{Id: 5, Name: "bob"}, nil)
+	mockRepo.EXPECT().GetUser(uint(6)).Return(models.User{}, uerrors.ErrGetUser)
+	mockRepo.EXPECT().Reset("user:bob").Return(nil)
+	mockRepo.EXPECT().CreateAuditEvent(gomock.Any()).DoAndReturn(func(e *models.AuditEvent) error {
+		assert.Equal(t, models.AuditLoginUnlocked, e.Type)
+		assert.Equal(t, "1", e.ActorId)
+		return nil
+	})
+
+	l, _ := logger.GetLogger()
+	g := lockout.NewGuard(l, mockRepo, policy)
+	ctx := auth.WithUserId(context.Background(), "1")
+	assert.NoError(t, g.Unlock(ctx, 5))
+	assert.ErrorIs(t, g.Unlock(ctx, 6), uerrors.ErrGetUser)
+}
+
+func TestClientIP(t *testing.T) {
+	req := httptest.NewRequest("POST", "/v1/login", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	assert.Equal(t, "10.0.0.1", lockout.ClientIP(req, ""))
+
+	req.Header.Set("X-Forwarded-For", "1.1.1.1, 192.168.0.7")
+	assert.Equal(t, "10.0.0.1", lockout.ClientIP(req, ""))
+	assert.Equal(t, "192.168.0.7", lockout.ClientIP(req, "X-Forwarded-For"))
+}
@@ -0,0 +1,84 @@
+package lockout
+
+import (
+	"encoding/json"
+	"errors"
+	uerrors "githib.com/dkischenko/company-api/internal/errors"
+	"githib.com/dkischenko/company-api/internal/middleware"
+	"githib.com/dkischenko/company-api/models"
+	"githib.com/dkischenko/company-api/pkg/logger"
+	"github.com/gorilla/mux"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	userUnlock             = "/v1/admin/users/{id}/unlock"
+	headerContentType      = "Content-Type"
+	headerValueContentType = "application/json"
+)
+
+type handler struct {
+	logger *logger.Logger
+	guard  *Guard
+}
+
+func NewHandler(logger *logger.Logger, guard *Guard) *handler {
+	return &handler{
+		logger: logger,
+		guard:  guard,
+	}
+}
+
+func (h handler) Register(router *mux.Router) {
+	middleware.HandleRoutes(router, []middleware.Route{
+		{Method: http.MethodPost, Path: userUnlock, Role: models.RoleAdmin, Scope: models.ScopeAdmin,
+			Handler: h.UnlockUserHandler},
+	})
+}
+
+func (h handler) UnlockUserHandler(w http.ResponseWriter, r *http.Request) {
+	userId, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 32)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, "user id must be a positive integer")
+		return
+	}
+
+	err = h.guard.Unlock(r.Context(), uint(userId))
+	switch {
+	case errors.Is(err, uerrors.ErrGetUser):
+		h.writeError(w, http.StatusNotFound, "user not found")
+	case err != nil:
+		h.logger.Entry.Errorf("unlock request failed: %+v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	default:
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// ClientIP returns the IP address of the client of the request. Behind a proxy
+// the address is taken from the last entry of the header the proxy appends it to,
+// as entries before it can be forged by the client.
+func ClientIP(r *http.Request, proxyHeader string) string {
+	if proxyHeader != "" {
+		if v := r.Header.Get(proxyHeader); v != "" {
+			entries := strings.Split(v, ",")
+			return strings.TrimSpace(entries[len(entries)-1])
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func (h handler) writeError(w http.ResponseWriter, code int, message string) {
+	w.Header().Add(headerContentType, headerValueContentType)
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(uerrors.ErrorResponse{Code: code, Message: message}); err != nil {
+		h.logger.Entry.Errorf("problems with encoding data: %+v", err)
+	}
+}
@@ -0,0 +1,20 @@
+package lockout
+
+import (
+	"githib.com/dkischenko/company-api/models"
+	"time"
+)
+
+//go:generate mockgen -source=repository.go -destination=mocks/repository_mock.go
+type Repository interface {
+	// GetFailures returns the failures of the keys, keys without failures are left out.
+	GetFailures(keys []string) (failures []models.LoginFailure, err error)
+	// RecordFailure counts the failure of the key at now, counting starts over
+	// when the last failure happened before windowStart.
+	RecordFailure(key string, now, windowStart time.Time) (failure models.LoginFailure, err error)
+	Lock(key string, until time.Time) (err error)
+	Reset(key string) (err error)
+	// GetUser returns uerrors.ErrGetUser for unknown users.
+	GetUser(userId uint) (u models.User, err error)
+	CreateAuditEvent(e *models.AuditEvent) (err error)
+}
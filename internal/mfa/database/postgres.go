@@ -0,0 +1,131 @@
+package database
+
+import (
+	"errors"
+	uerrors "githib.com/dkischenko/company-api/internal/errors"
+	"githib.com/dkischenko/company-api/internal/mfa"
+	"githib.com/dkischenko/company-api/models"
+	"githib.com/dkischenko/company-api/pkg/logger"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type postgres struct {
+	logger *logger.Logger
+	db     *gorm.DB
+}
+
+func NewStorage(db *gorm.DB, logger *logger.Logger) mfa.Repository {
+	return &postgres{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (p postgres) Transaction(fn func(r mfa.Repository) error) error {
+	return p.db.Transaction(func(tx *gorm.DB) error {
+		return fn(postgres{db: tx, logger: p.logger})
+	})
+}
+
+func (p postgres) GetUser(userId uint) (u models.User, err error) {
+	err = p.db.Where("id = ?", userId).First(&u).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return u, uerrors.ErrGetUser
+	}
+	return
+}
+
+func (p postgres) GetSecret(userId uint) (s models.MFASecret, err error) {
+	err = p.db.Where("user_id = ?", userId).First(&s).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return s, uerrors.ErrMFANotEnrolled
+	}
+	return
+}
+
+func (p postgres) SaveSecret(s *models.MFASecret) (err error) {
+	return p.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"secret", "confirmed_at", "last_step", "created_at"}),
+	}).Create(s).Error
+}
+
+func (p postgres) EnableMFA(userId uint, at time.Time) (err error) {
+	err = p.db.Model(&models.MFASecret{}).Where("user_id = ?", userId).Update("confirmed_at", at).Error
+	if err != nil {
+		return err
+	}
+	return p.db.Model(&models.User{}).Where("id = ?", userId).Update("mfa_enabled", true).Error
+}
+
+func (p postgres) DisableMFA(userId uint) (err error) {
+	for _, m := range []interface{}{&models.MFASecret{}, &models.RecoveryCode{}, &models.MFAChallenge{}} {
+		if err = p.db.Where("user_id = ?", userId).Delete(m).Error; err != nil {
+			return err
+		}
+	}
+	return p.db.Model(&models.User{}).Where("id = ?", userId).Update("mfa_enabled", false).Error
+}
+
+func (p postgres) UseStep(userId uint, step int64) (err error) {
+	// a code is only accepted by the first of concurrent logins
+	res := p.db.Model(&models.MFASecret{}).
+		Where("user_id = ? AND last_step < ?", userId, step).
+		Update("last_step", step)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return uerrors.ErrInvalidMFACode
+	}
+	return nil
+}
+
+func (p postgres) ReplaceRecoveryCodes(userId uint, codes []models.RecoveryCode) (err error) {
+	if err = p.db.Where("user_id = ?", userId).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return err
+	}
+	return p.db.Create(&codes).Error
+}
+
+func (p postgres) UseRecoveryCode(userId uint, hash string, at time.Time) (err error) {
+	res := p.db.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userId, hash).
+		Update("used_at", at)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return uerrors.ErrInvalidMFACode
+	}
+	return nil
+}
+
+func (p postgres) CreateChallenge(c *models.MFAChallenge) (err error) {
+	err = p.db.Where("user_id = ? AND expires_at < ?", c.UserId, time.Now()).Delete(&models.MFAChallenge{}).Error
+	if err != nil {
+		return err
+	}
+	return p.db.Create(c).Error
+}
+
+func (p postgres) GetChallenge(hash string) (c models.MFAChallenge, err error) {
+	err = p.db.Where("token_hash = ?", hash).First(&c).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return c, uerrors.ErrInvalidMFAChallenge
+	}
+	return
+}
+
+func (p postgres) CountAttempt(id uuid.UUID) (attempts int, err error) {
+	err = p.db.Raw("UPDATE mfa_challenges SET attempts = attempts + 1 WHERE id = ? RETURNING attempts", id).
+		Scan(&attempts).Error
+	return
+}
+
+func (p postgres) DeleteChallenge(id uuid.UUID) (err error) {
+	return p.db.Where("id = ?", id).Delete(&models.MFAChallenge{}).Error
+}
@@ -0,0 +1,144 @@
+package mfa
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	uerrors "githib.com/dkischenko/company-api/internal/errors"
+	"githib.com/dkischenko/company-api/internal/middleware"
+	"githib.com/dkischenko/company-api/models"
+	"githib.com/dkischenko/company-api/pkg/logger"
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+	"net/http"
+)
+
+const (
+	userMFA                = "/v1/users/me/mfa"
+	userMFAConfirm         = "/v1/users/me/mfa/confirm"
+	userMFARecoveryCodes   = "/v1/users/me/mfa/recovery-codes"
+	userMFADisable         = "/v1/users/me/mfa/disable"
+	headerContentType      = "Content-Type"
+	headerValueContentType = "application/json"
+)
+
+type handler struct {
+	logger  *logger.Logger
+	service IService
+}
+
+func NewHandler(logger *logger.Logger, service IService) *handler {
+	return &handler{
+		logger:  logger,
+		service: service,
+	}
+}
+
+// Register adds the routes of MFA. They have no scope, so MFA can't be managed with API keys.
+func (h handler) Register(router *mux.Router) {
+	middleware.HandleRoutes(router, []middleware.Route{
+		{Method: http.MethodPost, Path: userMFA, Role: models.RoleViewer, Handler: h.EnrollHandler},
+		{Method: http.MethodPost, Path: userMFAConfirm, Role: models.RoleViewer, Handler: h.ConfirmHandler},
+		{Method: http.MethodPost, Path: userMFARecoveryCodes, Role: models.RoleViewer,
+			Handler: h.RegenerateRecoveryCodesHandler},
+		{Method: http.MethodPost, Path: userMFADisable, Role: models.RoleViewer, Handler: h.DisableHandler},
+	})
+}
+
+// EnrollHandler returns a new secret, enrolling again replaces a secret that wasn't confirmed.
+func (h handler) EnrollHandler(w http.ResponseWriter, r *http.Request) {
+	e, err := h.service.Enroll(r.Context())
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusCreated, e)
+}
+
+func (h handler) ConfirmHandler(w http.ResponseWriter, r *http.Request) {
+	req := CodeRequest{}
+	if !h.decode(w, r, &req) {
+		return
+	}
+
+	codes, err := h.service.Confirm(r.Context(), req.Code)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+func (h handler) RegenerateRecoveryCodesHandler(w http.ResponseWriter, r *http.Request) {
+	req := CodeRequest{}
+	if !h.decode(w, r, &req) {
+		return
+	}
+
+	codes, err := h.service.RegenerateRecoveryCodes(r.Context(), req.Code)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+func (h handler) DisableHandler(w http.ResponseWriter, r *http.Request) {
+	req := CodeRequest{}
+	if !h.decode(w, r, &req) {
+		return
+	}
+
+	if err := h.service.Disable(r.Context(), req.Code); err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// decode reads the request into req and writes the error when it's malformed or invalid.
+func (h handler) decode(w http.ResponseWriter, r *http.Request, req interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		h.logger.Entry.Error("wrong json format")
+		h.writeError(w, http.StatusBadRequest, "wrong json format")
+		return false
+	}
+	if err := validator.New().Struct(req); err != nil {
+		h.logger.Entry.Errorf("got wrong MFA data: %+v", err)
+		h.writeError(w, http.StatusBadRequest, fmt.Sprintf("got wrong MFA data: %+v", err))
+		return false
+	}
+	return true
+}
+
+func (h handler) writeServiceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, uerrors.ErrInvalidMFACode):
+		h.writeError(w, http.StatusForbidden, "MFA code is wrong or used")
+	case errors.Is(err, uerrors.ErrMFAAlreadyEnabled):
+		h.writeError(w, http.StatusConflict, "MFA is enabled already")
+	case errors.Is(err, uerrors.ErrMFANotEnrolled):
+		h.writeError(w, http.StatusConflict, "MFA is not enrolled")
+	case errors.Is(err, uerrors.ErrGetUser):
+		h.writeError(w, http.StatusUnauthorized, "user not found")
+	default:
+		h.logger.Entry.Errorf("MFA request failed: %+v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func (h handler) writeError(w http.ResponseWriter, code int, message string) {
+	h.writeJSON(w, code, uerrors.ErrorResponse{Code: code, Message: message})
+}
+
+func (h handler) writeJSON(w http.ResponseWriter, code int, body interface{}) {
+	w.Header().Add(headerContentType, headerValueContentType)
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		h.logger.Entry.Errorf("problems with encoding data: %+v", err)
+	}
+}
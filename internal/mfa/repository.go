@@ -0,0 +1,39 @@
+package mfa
+
+import (
+	"githib.com/dkischenko/company-api/models"
+	"github.com/google/uuid"
+	"time"
+)
+
+//go:generate mockgen -source=repository.go -destination=mocks/repository_mock.go
+type Repository interface {
+	// Transaction runs fn with a Repository bound to a single database transaction,
+	// which is committed when fn returns nil and rolled back otherwise.
+	Transaction(fn func(r Repository) error) error
+	// GetUser returns uerrors.ErrGetUser for unknown users.
+	GetUser(userId uint) (u models.User, err error)
+	// GetSecret returns uerrors.ErrMFANotEnrolled when the user has no secret.
+	GetSecret(userId uint) (s models.MFASecret, err error)
+	// SaveSecret stores the secret, replacing the one the user has.
+	SaveSecret(s *models.MFASecret) (err error)
+	// EnableMFA confirms the secret of the user and turns MFA on.
+	EnableMFA(userId uint, at time.Time) (err error)
+	// DisableMFA deletes the secret, recovery codes and challenges of the user and turns MFA off.
+	DisableMFA(userId uint) (err error)
+	// UseStep records the time step of an accepted code, it returns
+	// uerrors.ErrInvalidMFACode when a code of the step or a later one was used.
+	UseStep(userId uint, step int64) (err error)
+	// ReplaceRecoveryCodes deletes the recovery codes of the user and stores the codes.
+	ReplaceRecoveryCodes(userId uint, codes []models.RecoveryCode) (err error)
+	// UseRecoveryCode marks the unused code of the user as used,
+	// it returns uerrors.ErrInvalidMFACode when there is none.
+	UseRecoveryCode(userId uint, hash string, at time.Time) (err error)
+	// CreateChallenge stores the challenge and deletes the expired challenges of its user.
+	CreateChallenge(c *models.MFAChallenge) (err error)
+	// GetChallenge returns uerrors.ErrInvalidMFAChallenge for unknown hashes.
+	GetChallenge(hash string) (c models.MFAChallenge, err error)
+	// CountAttempt increments the attempts of the challenge and returns them.
+	CountAttempt(id uuid.UUID) (attempts int, err error)
+	DeleteChallenge(id uuid.UUID) (err error)
+}
@@ -0,0 +1,329 @@
+package mfa
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	uerrors "githib.com/dkischenko/company-api/internal/errors"
+	"githib.com/dkischenko/company-api/models"
+	"githib.com/dkischenko/company-api/pkg/auth"
+	"githib.com/dkischenko/company-api/pkg/hasher"
+	"githib.com/dkischenko/company-api/pkg/logger"
+	"githib.com/dkischenko/company-api/pkg/totp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// recoveryCodes is the number of recovery codes a user gets.
+	recoveryCodes = 10
+	// recoveryCodeSize is the number of random bytes of a recovery code, 80 bits
+	// keep the unsalted hashes of the codes out of reach of brute force.
+	recoveryCodeSize = 10
+	// recoveryCodeGroup is the number of characters between separators of a recovery code.
+	recoveryCodeGroup = 4
+	// challengeTokenSize is the number of random bytes of a challenge token.
+	challengeTokenSize = 32
+	// maxChallengeAttempts is the number of codes tried with a challenge before it's dropped.
+	maxChallengeAttempts = 5
+	// codeSkew is the number of time steps codes are accepted before and after the current one.
+	codeSkew = 1
+)
+
+// Enrollment is the secret of an authenticator app, the URI is the payload of its QR code.
+type Enrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+type Service struct {
+	logger       *logger.Logger
+	storage      Repository
+	issuer       string
+	challengeTTL time.Duration
+}
+
+//go:generate mockgen -source=service.go -destination=mocks/service_mock.go
+type IService interface {
+	// Enroll generates the TOTP secret of the user of the request,
+	// MFA stays off until the secret is confirmed.
+	Enroll(ctx context.Context) (e Enrollment, err error)
+	// Confirm turns MFA on when the code is valid for the enrolled secret
+	// and returns the recovery codes of the user.
+	Confirm(ctx context.Context, code string) (codes []string, err error)
+	// RegenerateRecoveryCodes replaces the recovery codes of the user when the code is valid.
+	RegenerateRecoveryCodes(ctx context.Context, code string) (codes []string, err error)
+	// Disable turns MFA off when the code is valid.
+	Disable(ctx context.Context, code string) (err error)
+	// Challenge issues the token exchanged with a code for the access token of the user.
+	Challenge(ctx context.Context, userId uint) (challengeToken string, err error)
+	// Verify returns the user of the challenge when the code is valid. On uerrors.ErrInvalidMFACode
+	// the user is returned as well, so the failure can be counted against it.
+	Verify(ctx context.Context, challengeToken, code string) (u models.User, err error)
+}
+
+// NewService creates the service of MFA, secrets are shown in authenticator apps
+// as accounts of the issuer and challenges are valid for challengeTTL.
+func NewService(logger *logger.Logger, storage Repository, issuer string, challengeTTL time.Duration) IService {
+	return &Service{
+		logger:       logger,
+		storage:      storage,
+		issuer:       issuer,
+		challengeTTL: challengeTTL,
+	}
+}
+
+func (s Service) Enroll(ctx context.Context) (e Enrollment, err error) {
+	u, err := s.user(ctx)
+	if err != nil {
+		return e, err
+	}
+	if u.MFAEnabled {
+		return e, fmt.Errorf("error occurs: %w", uerrors.ErrMFAAlreadyEnabled)
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err == nil {
+		err = s.storage.SaveSecret(&models.MFASecret{UserId: u.Id, Secret: secret, CreatedAt: time.Now()})
+	}
+	if err != nil {
+		s.logger.Entry.Errorf("failed to enroll MFA of user %d: %s", u.Id, err)
+		return e, fmt.Errorf("error occurs: %w", uerrors.ErrMFA)
+	}
+	return Enrollment{Secret: secret, URI: totp.URI(s.issuer, u.Name, secret)}, nil
+}
+
+func (s Service) Confirm(ctx context.Context, code string) (codes []string, err error) {
+	u, err := s.user(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if u.MFAEnabled {
+		return nil, fmt.Errorf("error occurs: %w", uerrors.ErrMFAAlreadyEnabled)
+	}
+
+	err = s.storage.Transaction(func(r Repository) error {
+		secret, err := r.GetSecret(u.Id)
+		if err != nil {
+			return err
+		}
+		// recovery codes don't exist yet, the authenticator has to be proven
+		if err = s.checkTOTP(r, secret, code); err != nil {
+			return err
+		}
+		if err = r.EnableMFA(u.Id, time.Now()); err != nil {
+			return err
+		}
+		codes, err = s.replaceRecoveryCodes(r, u.Id)
+		return err
+	})
+	if err != nil {
+		return nil, s.wrap(u.Id, err)
+	}
+	return codes, nil
+}
+
+func (s Service) RegenerateRecoveryCodes(ctx context.Context, code string) (codes []string, err error) {
+	u, err := s.enabledUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.storage.Transaction(func(r Repository) error {
+		if err := s.checkCode(r, u.Id, code); err != nil {
+			return err
+		}
+		codes, err = s.replaceRecoveryCodes(r, u.Id)
+		return err
+	})
+	if err != nil {
+		return nil, s.wrap(u.Id, err)
+	}
+	return codes, nil
+}
+
+func (s Service) Disable(ctx context.Context, code string) (err error) {
+	u, err := s.enabledUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = s.storage.Transaction(func(r Repository) error {
+		if err := s.checkCode(r, u.Id, code); err != nil {
+			return err
+		}
+		return r.DisableMFA(u.Id)
+	})
+	if err != nil {
+		return s.wrap(u.Id, err)
+	}
+	return nil
+}
+
+func (s Service) Challenge(ctx context.Context, userId uint) (challengeToken string, err error) {
+	challengeToken, err = randomToken(challengeTokenSize)
+	if err == nil {
+		err = s.storage.CreateChallenge(&models.MFAChallenge{
+			UserId:    userId,
+			TokenHash: hasher.HashToken(challengeToken),
+			ExpiresAt: time.Now().Add(s.challengeTTL),
+		})
+	}
+	if err != nil {
+		s.logger.Entry.Errorf("failed to create MFA challenge of user %d: %s", userId, err)
+		return "", fmt.Errorf("error occurs: %w", uerrors.ErrMFA)
+	}
+	return challengeToken, nil
+}
+
+func (s Service) Verify(ctx context.Context, challengeToken, code string) (u models.User, err error) {
+	c, err := s.storage.GetChallenge(hasher.HashToken(challengeToken))
+	if err == nil && !c.ExpiresAt.After(time.Now()) {
+		err = uerrors.ErrInvalidMFAChallenge
+	}
+	if err != nil {
+		return u, s.wrap(0, err)
+	}
+
+	// attempts are counted before the code is checked, so concurrent guesses count too
+	attempts, err := s.storage.CountAttempt(c.Id)
+	if err == nil && (attempts == 0 || attempts > maxChallengeAttempts) {
+		err = uerrors.ErrInvalidMFAChallenge
+	}
+	if err == nil {
+		u, err = s.storage.GetUser(c.UserId)
+	}
+	if err == nil {
+		err = s.storage.Transaction(func(r Repository) error {
+			return s.checkCode(r, c.UserId, code)
+		})
+	}
+	if errors.Is(err, uerrors.ErrInvalidMFACode) {
+		return u, s.wrap(c.UserId, err)
+	}
+	// the challenge is dropped once the user is gone or turned MFA off
+	if errors.Is(err, uerrors.ErrInvalidMFAChallenge) || errors.Is(err, uerrors.ErrGetUser) ||
+		errors.Is(err, uerrors.ErrMFANotEnrolled) {
+		s.deleteChallenge(c)
+		return models.User{}, fmt.Errorf("error occurs: %w", uerrors.ErrInvalidMFAChallenge)
+	}
+	if err != nil {
+		return models.User{}, s.wrap(c.UserId, err)
+	}
+
+	s.deleteChallenge(c)
+	return u, nil
+}
+
+// user returns the user of the request.
+func (s Service) user(ctx context.Context) (u models.User, err error) {
+	userId, err := strconv.ParseUint(auth.UserIdFromContext(ctx), 10, 32)
+	if err != nil {
+		return u, fmt.Errorf("error occurs: %w", uerrors.ErrGetUser)
+	}
+	u, err = s.storage.GetUser(uint(userId))
+	if err != nil {
+		return u, s.wrap(uint(userId), err)
+	}
+	return u, nil
+}
+
+// enabledUser returns the user of the request, who must have MFA turned on.
+func (s Service) enabledUser(ctx context.Context) (u models.User, err error) {
+	u, err = s.user(ctx)
+	if err == nil && !u.MFAEnabled {
+		err = fmt.Errorf("error occurs: %w", uerrors.ErrMFANotEnrolled)
+	}
+	return u, err
+}
+
+// checkCode accepts a TOTP code of the confirmed secret of the user or one of the recovery codes.
+func (s Service) checkCode(r Repository, userId uint, code string) error {
+	secret, err := r.GetSecret(userId)
+	if err != nil {
+		return err
+	}
+	if secret.ConfirmedAt == nil {
+		return uerrors.ErrMFANotEnrolled
+	}
+	if len(code) == totp.Digits {
+		return s.checkTOTP(r, secret, code)
+	}
+	return r.UseRecoveryCode(userId, hasher.HashToken(normalizeRecoveryCode(code)), time.Now())
+}
+
+// checkTOTP accepts a TOTP code of the secret once.
+func (s Service) checkTOTP(r Repository, secret models.MFASecret, code string) error {
+	step, ok, err := totp.Validate(secret.Secret, code, time.Now(), codeSkew)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return uerrors.ErrInvalidMFACode
+	}
+	return r.UseStep(secret.UserId, step)
+}
+
+// replaceRecoveryCodes stores the hashes of new recovery codes of the user and returns the codes.
+func (s Service) replaceRecoveryCodes(r Repository, userId uint) ([]string, error) {
+	codes := make([]string, 0, recoveryCodes)
+	stored := make([]models.RecoveryCode, 0, recoveryCodes)
+	for i := 0; i < recoveryCodes; i++ {
+		b := make([]byte, recoveryCodeSize)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		code := base32.StdEncoding.EncodeToString(b)
+		codes = append(codes, groupRecoveryCode(code))
+		stored = append(stored, models.RecoveryCode{UserId: userId, CodeHash: hasher.HashToken(code)})
+	}
+	if err := r.ReplaceRecoveryCodes(userId, stored); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func (s Service) deleteChallenge(c models.MFAChallenge) {
+	if err := s.storage.DeleteChallenge(c.Id); err != nil {
+		s.logger.Entry.Errorf("failed to delete MFA challenge %s: %s", c.Id, err)
+	}
+}
+
+// wrap passes the errors of the service on and reports other errors as failed storage.
+func (s Service) wrap(userId uint, err error) error {
+	for _, known := range []error{uerrors.ErrGetUser, uerrors.ErrMFANotEnrolled, uerrors.ErrInvalidMFACode,
+		uerrors.ErrInvalidMFAChallenge, uerrors.ErrMFAAlreadyEnabled} {
+		if errors.Is(err, known) {
+			return fmt.Errorf("error occurs: %w", known)
+		}
+	}
+	s.logger.Entry.Errorf("failed MFA of user %d: %s", userId, err)
+	return fmt.Errorf("error occurs: %w", uerrors.ErrMFA)
+}
+
+// groupRecoveryCode separates the characters of a recovery code into groups for readability.
+func groupRecoveryCode(code string) string {
+	groups := make([]string, 0, len(code)/recoveryCodeGroup+1)
+	for len(code) > recoveryCodeGroup {
+		groups = append(groups, code[:recoveryCodeGroup])
+		code = code[recoveryCodeGroup:]
+	}
+	return strings.Join(append(groups, code), "-")
+}
+
+// normalizeRecoveryCode drops the separators and case of a recovery code, as users type it.
+func normalizeRecoveryCode(code string) string {
+	return strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
+
+func randomToken(size int) (string, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,169 @@
+package mfa_test
+
+import (
+	"context"
+	uerrors "githib.com/dkischenko/company-api/internal/errors"
+	"githib.com/dkischenko/company-api/internal/mfa"
+	mock_mfa "githib.com/dkischenko/company-api/internal/mfa/mocks"
+	"githib.com/dkischenko/company-api/models"
+	"githib.com/dkischenko/company-api/pkg/auth"
+	"githib.com/dkischenko/company-api/pkg/hasher"
+	"githib.com/dkischenko/company-api/pkg/logger"
+	"githib.com/dkischenko/company-api/pkg/totp"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+const secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func code(t *testing.T) string {
+	c, err := totp.Code(secret, totp.Step(time.Now()))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	return c
+}
+
+// inTransaction runs the transactions of the mocked repository with itself.
+func inTransaction(mockRepo *mock_mfa.MockRepository) {
+	mockRepo.EXPECT().Transaction(gomock.Any()).DoAndReturn(func(fn func(r mfa.Repository) error) error {
+		return fn(mockRepo)
+	}).AnyTimes()
+}
+
+func TestService_Enroll(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var stored models.MFASecret
+	mockRepo := mock_mfa.NewMockRepository(ctrl)
+	mockRepo.EXPECT().GetUser(uint(7)).Return(models.User{Id: 7, Name: "bob"}, nil)
+	mockRepo.EXPECT().SaveSecret(gomock.Any()).DoAndReturn(func(s *models.MFASecret) error {
+		stored = *s
+		return nil
+	})
+
+	l, _ := logger.GetLogger()
+	s := mfa.NewService(l, mockRepo, "company-api", time.Minute)
+	e, err := s.Enroll(auth.WithUserId(context.Background(), "7"))
+	assert.NoError(t, err)
+	assert.Equal(t, stored.Secret, e.Secret)
+	assert.Nil(t, stored.ConfirmedAt)
+	assert.True(t, strings.HasPrefix(e.URI, "otpauth://totp/company-api:bob?"))
+
+	mockRepo.EXPECT().GetUser(uint(7)).Return(models.User{Id: 7, Name: "bob", MFAEnabled: true}, nil)
+	_, err = s.Enroll(auth.WithUserId(context.Background(), "7"))
+	assert.ErrorIs(t, err, uerrors.ErrMFAAlreadyEnabled)
+}
+
+func TestService_Confirm(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var stored []models.RecoveryCode
+	mockRepo := mock_mfa.NewMockRepository(ctrl)
+	inTransaction(mockRepo)
+	mockRepo.EXPECT().GetUser(uint(7)).Return(models.User{Id: 7, Name: "bob"}, nil).Times(2)
+	mockRepo.EXPECT().GetSecret(uint(7)).Return(models.MFASecret{UserId: 7, Secret: secret}, nil).Times(2)
+	mockRepo.EXPECT().UseStep(uint(7), totp.Step(time.Now())).Return(nil)
+	mockRepo.EXPECT().EnableMFA(uint(7), gomock.Any()).Return(nil)
+	mockRepo.EXPECT().ReplaceRecoveryCodes(uint(7), gomock.Any()).DoAndReturn(func(userId uint, codes []models.RecoveryCode) error {
+		stored = codes
+		return nil
+	})
+
+	l, _ := logger.GetLogger()
+	s := mfa.NewService(l, mockRepo, "company-api", time.Minute)
+	ctx := auth.WithUserId(context.Background(), "7")
+	_, err := s.Confirm(ctx, "000000")
+	assert.ErrorIs(t, err, uerrors.ErrInvalidMFACode)
+
+	codes, err := s.Confirm(ctx, code(t))
+	assert.NoError(t, err)
+	assert.Len(t, codes, 10)
+	assert.Len(t, stored, 10)
+	assert.Regexp(t, `^[A-Z2-7]{4}(-[A-Z2-7]{4}){3}$`, codes[0])
+	// only hashes of recovery codes are stored
+	assert.Equal(t, hasher.HashToken(strings.ReplaceAll(codes[0], "-", "")), stored[0].CodeHash)
+}
+
+func TestService_Verify(t *testing.T) {
+	confirmed := time.Now().Add(-time.Hour)
+	enrolled := models.MFASecret{UserId: 7, Secret: secret, ConfirmedAt: &confirmed}
+	user := models.User{Id: 7, Name: "bob", MFAEnabled: true}
+	tests := []struct {
+		name      string
+		challenge models.MFAChallenge
+		getErr    error
+		attempts  int
+		code      string
+		useStep   error
+		recovery  error
+		deleted   bool
+		wantUser  bool
+		wantErr   error
+	}{
+		{name: "TOTP code", challenge: models.MFAChallenge{UserId: 7, ExpiresAt: time.Now().Add(time.Minute)},
+			attempts: 1, deleted: true, wantUser: true},
+		{name: "Recovery code", challenge: models.MFAChallenge{UserId: 7, ExpiresAt: time.Now().Add(time.Minute)},
+			attempts: 1, code: "abcd-efgh-ijkl-mnop", deleted: true, wantUser: true},
+		{name: "Used TOTP code", challenge: models.MFAChallenge{UserId: 7, ExpiresAt: time.Now().Add(time.Minute)},
+			attempts: 1, useStep: uerrors.ErrInvalidMFACode, wantUser: true, wantErr: uerrors.ErrInvalidMFACode},
+		{name: "Wrong recovery code", challenge: models.MFAChallenge{UserId: 7, ExpiresAt: time.Now().Add(time.Minute)},
+			attempts: 1, code: "abcd-efgh-ijkl-mnop", recovery: uerrors.ErrInvalidMFACode, wantUser: true, wantErr: uerrors.ErrInvalidMFACode},
+		{name: "Too many attempts", challenge: models.MFAChallenge{UserId: 7, ExpiresAt: time.Now().Add(time.Minute)},
+			attempts: 6, deleted: true, wantErr: uerrors.ErrInvalidMFAChallenge},
+		{name: "Expired challenge", challenge: models.MFAChallenge{UserId: 7, ExpiresAt: time.Now().Add(-time.Minute)},
+			wantErr: uerrors.ErrInvalidMFAChallenge},
+		{name: "Unknown challenge", getErr: uerrors.ErrInvalidMFAChallenge, wantErr: uerrors.ErrInvalidMFAChallenge},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			tc.challenge.Id = uuid.New()
+			mockRepo := mock_mfa.NewMockRepository(ctrl)
+			inTransaction(mockRepo)
+			mockRepo.EXPECT().GetChallenge(hasher.HashToken("challenge")).Return(tc.challenge, tc.getErr)
+			if tc.attempts > 0 {
+				mockRepo.EXPECT().CountAttempt(tc.challenge.Id).Return(tc.attempts, nil)
+			}
+			if tc.attempts > 0 && tc.attempts <= 5 {
+				mockRepo.EXPECT().GetUser(uint(7)).Return(user, nil)
+				mockRepo.EXPECT().GetSecret(uint(7)).Return(enrolled, nil)
+			}
+			if tc.code == "" {
+				tc.code = code(t)
+				if tc.attempts > 0 && tc.attempts <= 5 {
+					mockRepo.EXPECT().UseStep(uint(7), gomock.Any()).Return(tc.useStep)
+				}
+			} else {
+				mockRepo.EXPECT().UseRecoveryCode(uint(7), hasher.HashToken("ABCDEFGHIJKLMNOP"), gomock.Any()).
+					Return(tc.recovery)
+			}
+			if tc.deleted {
+				mockRepo.EXPECT().DeleteChallenge(tc.challenge.Id).Return(nil)
+			}
+
+			l, _ := logger.GetLogger()
+			s := mfa.NewService(l, mockRepo, "company-api", time.Minute)
+			u, err := s.Verify(context.Background(), "challenge", tc.code)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			if tc.wantUser {
+				assert.Equal(t, user, u)
+			} else {
+				assert.Zero(t, u.Id)
+			}
+		})
+	}
+}
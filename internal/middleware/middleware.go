@@ -1,11 +1,16 @@
 package middleware
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	uerrors "githib.com/dkischenko/company-api/internal/errors"
+	"githib.com/dkischenko/company-api/models"
+	"githib.com/dkischenko/company-api/pkg/auth"
 	"githib.com/dkischenko/company-api/pkg/logger"
 	"github.com/golang-jwt/jwt/v4"
 	"net/http"
-	"os"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -38,58 +43,161 @@ func PanicAndRecover(next http.Handler) http.Handler {
 	})
 }
 
-func IsAuthorized(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		l, err := logger.GetLogger()
-		if err != nil {
-			panic(fmt.Sprintf("cannot init logger: %+v", err))
-		}
+const HeaderAPIKey = "X-API-Key"
 
-		if r.Method != http.MethodGet && strings.Contains(r.URL.Path, "companies") {
-			l.Entry.Logger.Infof("just some info for logger")
+// RevocationList tells whether an access token was revoked before it expired,
+// or its user was disabled or deleted, or its OAuth client was deleted since it was issued.
+type RevocationList interface {
+	IsRevoked(ctx context.Context, jti string) (revoked bool, err error)
+	IsUserDisabled(ctx context.Context, userId string) (disabled bool, err error)
+	IsClientDeleted(ctx context.Context, clientId string) (deleted bool, err error)
+}
+
+// APIKeyVerifier authorizes requests carrying an API key.
+type APIKeyVerifier interface {
+	// VerifyAPIKey returns the key and the current role of its user,
+	// or uerrors.ErrInvalidAPIKey when the key is unknown, expired or revoked.
+	VerifyAPIKey(ctx context.Context, key string) (k models.APIKey, role models.Role, err error)
+}
+
+// Authenticate verifies the JWT token or the API key of the request, if there is one,
+// and stores the user and the role it was issued for in the context of the request.
+// Requests with an API key are limited to the scopes of the key as well,
+// requests with a token issued to an OAuth client to the scopes of the token.
+// Tokens on the revocation list and tokens of disabled users are rejected,
+// a nil list revokes nothing.
+// Basic credentials are left to the OAuth endpoints authenticating clients.
+// API keys are rejected when there's no verifier.
+// Requests without credentials go on anonymously, it's up to the policy of the route
+// to reject them.
+func Authenticate(revoked RevocationList, apiKeys APIKeyVerifier) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			tokenString := r.Header.Get("Authorization")
-			if len(tokenString) == 0 {
-				w.WriteHeader(http.StatusUnauthorized)
-				_, err := w.Write([]byte("Missing Authorization Header"))
-				if err != nil {
-					panic(fmt.Sprintf("cannot write data to the connection: %+v", err))
-				}
-				l.Entry.Logger.Warning("Missing Authorization Header")
-				return
+			if strings.HasPrefix(tokenString, "Basic ") {
+				tokenString = ""
 			}
-
-			tokenString = strings.Replace(tokenString, "Bearer ", "", 1)
-			_, err := verifyToken(tokenString)
-			if err != nil {
-				w.WriteHeader(http.StatusUnauthorized)
-				_, err = w.Write([]byte(fmt.Sprintf("Error verifying JWT token: %+v", err)))
-				if err != nil {
-					panic(fmt.Sprintf("cannot write data to the connection: %+v", err))
-				}
-				return
+			apiKey := r.Header.Get(HeaderAPIKey)
+			switch {
+			case len(tokenString) != 0 && len(apiKey) != 0:
+				deny(w, http.StatusBadRequest, fmt.Sprintf("Use either Authorization or %s header", HeaderAPIKey))
+			case len(apiKey) != 0:
+				authenticateKey(w, r, next, apiKeys, apiKey)
+			case len(tokenString) != 0:
+				authenticateToken(w, r, next, revoked, tokenString)
+			default:
+				next.ServeHTTP(w, r)
 			}
-		}
-		next.ServeHTTP(w, r)
-	})
+		})
+	}
 }
 
-func verifyToken(tokenString string) (user_id string, err error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+func authenticateToken(w http.ResponseWriter, r *http.Request, next http.Handler, revoked RevocationList, tokenString string) {
+	tokenString = strings.Replace(tokenString, "Bearer ", "", 1)
+	claims, err := verifyToken(tokenString)
+	if err == nil && revoked != nil && claims.jti != "" {
+		var isRevoked bool
+		if isRevoked, err = revoked.IsRevoked(r.Context(), claims.jti); err != nil {
+			deny(w, http.StatusInternalServerError, "Error checking JWT token")
+			return
+		}
+		if isRevoked {
+			err = fmt.Errorf("token is revoked")
+		}
+	}
+	if err == nil && revoked != nil {
+		var disabled bool
+		if disabled, err = revoked.IsUserDisabled(r.Context(), claims.userId); err != nil {
+			deny(w, http.StatusInternalServerError, "Error checking JWT token")
+			return
+		}
+		if disabled {
+			err = fmt.Errorf("user is disabled")
+		}
+	}
+	if err == nil && revoked != nil && claims.clientId != "" {
+		var deleted bool
+		if deleted, err = revoked.IsClientDeleted(r.Context(), claims.clientId); err != nil {
+			deny(w, http.StatusInternalServerError, "Error checking JWT token")
+			return
 		}
+		if deleted {
+			err = fmt.Errorf("client is deleted")
+		}
+	}
+	if err != nil {
+		deny(w, http.StatusUnauthorized, fmt.Sprintf("Error verifying JWT token: %+v", err))
+		return
+	}
 
-		return []byte(os.Getenv("SIGNINKEY")), nil
-	})
+	ctx := auth.WithRole(auth.WithUserId(r.Context(), claims.userId), claims.role)
+	ctx = auth.WithTokenId(ctx, claims.jti)
+	if claims.scoped {
+		ctx = auth.WithScopes(ctx, claims.scopes)
+	}
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
 
+func authenticateKey(w http.ResponseWriter, r *http.Request, next http.Handler, apiKeys APIKeyVerifier, apiKey string) {
+	if apiKeys == nil {
+		deny(w, http.StatusUnauthorized, "API keys are not accepted")
+		return
+	}
+	key, role, err := apiKeys.VerifyAPIKey(r.Context(), apiKey)
+	if errors.Is(err, uerrors.ErrInvalidAPIKey) {
+		deny(w, http.StatusUnauthorized, "Invalid API key")
+		return
+	}
 	if err != nil {
-		return "", err
+		deny(w, http.StatusInternalServerError, "Error checking API key")
+		return
 	}
 
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok || !token.Valid || claims["user_id"] == nil {
-		return "", fmt.Errorf("error get user claims from token")
+	scopes := make([]string, len(key.Scopes))
+	for i, scope := range key.Scopes {
+		scopes[i] = string(scope)
+	}
+	ctx := auth.WithUserId(r.Context(), strconv.FormatUint(uint64(key.UserId), 10))
+	ctx = auth.WithScopes(auth.WithRole(ctx, string(role)), scopes)
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
+
+type tokenClaims struct {
+	userId   string
+	role     string
+	jti      string
+	clientId string
+	scopes   []string
+	scoped   bool
+}
+
+// verifyToken returns the claims of the token signed with a key of the key set
+// of the process. Tokens issued before roles have no role at all, as tokens issued
+// before revocation have no jti.
+func verifyToken(tokenString string) (claims tokenClaims, err error) {
+	keys, err := auth.Keys()
+	if err != nil {
+		return claims, err
+	}
+	token, err := jwt.Parse(tokenString, keys.Keyfunc)
+	if err != nil {
+		return claims, err
+	}
+
+	mapClaims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid || mapClaims["user_id"] == nil {
+		return claims, fmt.Errorf("error get user claims from token")
+	}
+	if claims.userId, ok = mapClaims["user_id"].(string); !ok {
+		return claims, fmt.Errorf("error get user claims from token")
+	}
+	claims.role, _ = mapClaims["role"].(string)
+	claims.jti, _ = mapClaims["jti"].(string)
+	claims.clientId, _ = mapClaims["client_id"].(string)
+	var scope string
+	if scope, claims.scoped = mapClaims["scope"].(string); claims.scoped {
+		claims.scopes = strings.Fields(scope)
 	}
 
-	return claims["user_id"].(string), nil
+	return claims, nil
 }
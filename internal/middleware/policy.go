@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"fmt"
+	"githib.com/dkischenko/company-api/models"
+	"githib.com/dkischenko/company-api/pkg/auth"
+	"github.com/gorilla/mux"
+	"net/http"
+)
+
+// Route is an endpoint together with the least role allowed to call it.
+// Routes without a role are open to anonymous requests,
+// models.RoleViewer admits any authorized user.
+// Requests authorized by an API key need the scope of the route as well,
+// routes with a role but without a scope are closed to API keys.
+type Route struct {
+	Method  string
+	Path    string
+	Role    models.Role
+	Scope   models.Scope
+	Handler http.HandlerFunc
+}
+
+// HandleRoutes registers the routes on the router in the given order,
+// each guarded by its role.
+func HandleRoutes(router *mux.Router, routes []Route) {
+	for _, route := range routes {
+		var h http.Handler = route.Handler
+		if route.Role != "" {
+			h = RequireRole(route.Role)(RequireScope(route.Scope)(h))
+		}
+		router.Handle(route.Path, h).Methods(route.Method)
+	}
+}
+
+// RequireRole lets through only requests authorized by Authenticate on behalf
+// of a user whose role includes the role. Anonymous requests are answered
+// with 401 Unauthorized, requests of users lacking the role with 403 Forbidden.
+func RequireRole(role models.Role) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if auth.UserIdFromContext(r.Context()) == "" {
+				deny(w, http.StatusUnauthorized, "Missing Authorization Header")
+				return
+			}
+			if !models.Role(auth.RoleFromContext(r.Context())).Includes(role) {
+				deny(w, http.StatusForbidden, fmt.Sprintf("Role %s required", role))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireScope lets through requests authorized by an API key or OAuth token only
+// when the key or token is granted the scope. Other requests are left to RequireRole.
+func RequireScope(scope models.Scope) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			scopes, limited := auth.ScopesFromContext(r.Context())
+			if !limited {
+				next.ServeHTTP(w, r)
+				return
+			}
+			for _, s := range scopes {
+				if scope != "" && models.Scope(s) == scope {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+			if scope == "" {
+				deny(w, http.StatusForbidden, "Route is not available to API keys")
+				return
+			}
+			deny(w, http.StatusForbidden, fmt.Sprintf("Scope %s required", scope))
+		})
+	}
+}
+
+func deny(w http.ResponseWriter, code int, message string) {
+	w.WriteHeader(code)
+	if _, err := w.Write([]byte(message)); err != nil {
+		panic(fmt.Sprintf("cannot write data to the connection: %+v", err))
+	}
+}
@@ -0,0 +1,43 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// Message is a notification of a user.
+type Message struct {
+	UserId   uint      `json:"userId"`
+	UserName string    `json:"userName"`
+	Subject  string    `json:"subject"`
+	Text     string    `json:"text"`
+	SentAt   time.Time `json:"sentAt"`
+}
+
+// Notifier delivers messages to users, such as tokens to reset their passwords.
+type Notifier interface {
+	Notify(ctx context.Context, m Message) error
+}
+
+// WriterNotifier writes messages as JSON lines instead of delivering them,
+// it's intended for development, where the writer is a file or stdout.
+type WriterNotifier struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewWriterNotifier(w io.Writer) *WriterNotifier {
+	return &WriterNotifier{w: w}
+}
+
+func (n *WriterNotifier) Notify(ctx context.Context, m Message) error {
+	if m.SentAt.IsZero() {
+		m.SentAt = time.Now().UTC()
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return json.NewEncoder(n.w).Encode(m)
+}
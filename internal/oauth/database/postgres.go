@@ -0,0 +1,67 @@
+package database
+
+import (
+	"errors"
+	uerrors "githib.com/dkischenko/company-api/internal/errors"
+	"githib.com/dkischenko/company-api/internal/oauth"
+	"githib.com/dkischenko/company-api/models"
+	"githib.com/dkischenko/company-api/pkg/logger"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type postgres struct {
+	logger *logger.Logger
+	db     *gorm.DB
+}
+
+func NewStorage(db *gorm.DB, logger *logger.Logger) oauth.Repository {
+	return &postgres{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (p postgres) CreateClient(c *models.OAuthClient) (err error) {
+	return p.db.Create(c).Error
+}
+
+func (p postgres) ListClients() (clients []models.OAuthClient, err error) {
+	err = p.db.Order("created_at DESC").Find(&clients).Error
+	return
+}
+
+func (p postgres) GetClient(id uuid.UUID) (c models.OAuthClient, err error) {
+	err = p.db.Where("id = ?", id).First(&c).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return c, uerrors.ErrOAuthClientNotFound
+	}
+	return
+}
+
+func (p postgres) DeleteClient(id uuid.UUID) (err error) {
+	res := p.db.Where("id = ?", id).Delete(&models.OAuthClient{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return uerrors.ErrOAuthClientNotFound
+	}
+	return nil
+}
+
+func (p postgres) GetUser(userId uint) (u models.User, err error) {
+	err = p.db.Where("id = ?", userId).First(&u).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return u, uerrors.ErrGetUser
+	}
+	return
+}
+
+func (p postgres) FindUser(name string) (u models.User, err error) {
+	err = p.db.Where("name = ?", name).First(&u).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return u, uerrors.ErrGetUser
+	}
+	return
+}
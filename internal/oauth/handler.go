@@ -0,0 +1,293 @@
+package oauth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	uerrors "githib.com/dkischenko/company-api/internal/errors"
+	"githib.com/dkischenko/company-api/internal/lockout"
+	"githib.com/dkischenko/company-api/internal/middleware"
+	"githib.com/dkischenko/company-api/models"
+	"githib.com/dkischenko/company-api/pkg/logger"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	oauthToken             = "/oauth/token"
+	oauthIntrospect        = "/oauth/introspect"
+	oauthRevoke            = "/oauth/revoke"
+	oauthClients           = "/v1/admin/oauth/clients"
+	oauthClientWithId      = "/v1/admin/oauth/clients/{id}"
+	headerContentType      = "Content-Type"
+	headerCacheControl     = "Cache-Control"
+	headerPragma           = "Pragma"
+	headerRetryAfter       = "Retry-After"
+	headerWWWAuthenticate  = "WWW-Authenticate"
+	headerValueContentType = "application/json"
+
+	// error codes of RFC 6749
+	errInvalidRequest       = "invalid_request"
+	errInvalidClient        = "invalid_client"
+	errInvalidGrant         = "invalid_grant"
+	errUnauthorizedClient   = "unauthorized_client"
+	errUnsupportedGrantType = "unsupported_grant_type"
+	errInvalidScope         = "invalid_scope"
+)
+
+type handler struct {
+	logger         *logger.Logger
+	service        IService
+	lockout        *lockout.Guard
+	clientIPHeader string
+}
+
+// NewHandler creates the handler of OAuth. Failed logins of the password grant
+// are throttled by the guard like those of /v1/login.
+func NewHandler(logger *logger.Logger, service IService, guard *lockout.Guard, clientIPHeader string) *handler {
+	return &handler{
+		logger:         logger,
+		service:        service,
+		lockout:        guard,
+		clientIPHeader: clientIPHeader,
+	}
+}
+
+// Register adds the routes of OAuth. The endpoints of RFC 6749, 7662 and 7009 are public,
+// clients authenticate themselves. Clients have no scope, so they can't be managed
+// with API keys or OAuth tokens.
+func (h handler) Register(router *mux.Router) {
+	middleware.HandleRoutes(router, []middleware.Route{
+		{Method: http.MethodPost, Path: oauthToken, Handler: h.TokenHandler},
+		{Method: http.MethodPost, Path: oauthIntrospect, Handler: h.IntrospectHandler},
+		{Method: http.MethodPost, Path: oauthRevoke, Handler: h.RevokeHandler},
+		{Method: http.MethodPost, Path: oauthClients, Role: models.RoleAdmin, Handler: h.CreateClientHandler},
+		{Method: http.MethodGet, Path: oauthClients, Role: models.RoleAdmin, Handler: h.ListClientsHandler},
+		{Method: http.MethodDelete, Path: oauthClientWithId, Role: models.RoleAdmin, Handler: h.DeleteClientHandler},
+	})
+}
+
+// TokenHandler issues access tokens by the client credentials and password grants.
+func (h handler) TokenHandler(w http.ResponseWriter, r *http.Request) {
+	c, ok := h.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	var (
+		t   TokenResponse
+		err error
+	)
+	scope := r.PostForm.Get("scope")
+	switch grant := models.GrantType(r.PostForm.Get("grant_type")); grant {
+	case models.GrantClientCredentials:
+		t, err = h.service.ClientCredentials(r.Context(), c, scope)
+	case models.GrantPassword:
+		name, password := r.PostForm.Get("username"), r.PostForm.Get("password")
+		if name == "" || password == "" {
+			h.writeOAuthError(w, http.StatusBadRequest, errInvalidRequest, "username and password are required")
+			return
+		}
+		ip := lockout.ClientIP(r, h.clientIPHeader)
+		if retryAfter, err := h.lockout.Check(r.Context(), name, ip); err != nil {
+			setRetryAfter(w, retryAfter)
+			h.writeOAuthError(w, http.StatusTooManyRequests, errInvalidGrant, "too many failed logins, try again later")
+			return
+		}
+		t, err = h.service.Password(r.Context(), c, name, password, scope)
+		if errors.Is(err, uerrors.ErrInvalidGrant) {
+			setRetryAfter(w, h.lockout.Failed(r.Context(), name, ip))
+		} else if err == nil {
+			h.lockout.Succeeded(r.Context(), name)
+		}
+	case "":
+		h.writeOAuthError(w, http.StatusBadRequest, errInvalidRequest, "grant_type is required")
+		return
+	default:
+		h.writeOAuthError(w, http.StatusBadRequest, errUnsupportedGrantType,
+			fmt.Sprintf("grant_type %s is not supported", grant))
+		return
+	}
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set(headerCacheControl, "no-store")
+	w.Header().Set(headerPragma, "no-cache")
+	h.writeJSON(w, http.StatusOK, t)
+}
+
+// IntrospectHandler tells clients whether a token is active, as defined by RFC 7662.
+func (h handler) IntrospectHandler(w http.ResponseWriter, r *http.Request) {
+	if _, ok := h.authenticate(w, r); !ok {
+		return
+	}
+	accessToken := r.PostForm.Get("token")
+	if accessToken == "" {
+		h.writeOAuthError(w, http.StatusBadRequest, errInvalidRequest, "token is required")
+		return
+	}
+
+	i, err := h.service.Introspect(r.Context(), accessToken)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set(headerCacheControl, "no-store")
+	h.writeJSON(w, http.StatusOK, i)
+}
+
+// RevokeHandler revokes tokens of the client, as defined by RFC 7009.
+// Unknown and invalid tokens are answered with success as well.
+func (h handler) RevokeHandler(w http.ResponseWriter, r *http.Request) {
+	c, ok := h.authenticate(w, r)
+	if !ok {
+		return
+	}
+	accessToken := r.PostForm.Get("token")
+	if accessToken == "" {
+		h.writeOAuthError(w, http.StatusBadRequest, errInvalidRequest, "token is required")
+		return
+	}
+
+	if err := h.service.Revoke(r.Context(), c, accessToken); err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h handler) CreateClientHandler(w http.ResponseWriter, r *http.Request) {
+	req := ClientRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Entry.Error("wrong json format")
+		h.writeError(w, http.StatusBadRequest, "wrong json format")
+		return
+	}
+	if err := validator.New().Struct(req); err != nil {
+		h.logger.Entry.Errorf("got wrong OAuth client data: %+v", err)
+		h.writeError(w, http.StatusBadRequest, fmt.Sprintf("got wrong OAuth client data: %+v", err))
+		return
+	}
+
+	c, secret, err := h.service.CreateClient(r.Context(), req)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusCreated, ClientCreateResponse{OAuthClient: c, ClientSecret: secret})
+}
+
+func (h handler) ListClientsHandler(w http.ResponseWriter, r *http.Request) {
+	clients, err := h.service.ListClients(r.Context())
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+	if clients == nil {
+		clients = []models.OAuthClient{}
+	}
+
+	h.writeJSON(w, http.StatusOK, clients)
+}
+
+func (h handler) DeleteClientHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.logger.Entry.Errorf("can't parse UUID: %+v", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err = h.service.DeleteClient(r.Context(), id); err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// authenticate parses the form of the request and returns the client of its credentials,
+// sent with HTTP Basic authentication or as client_id and client_secret parameters.
+func (h handler) authenticate(w http.ResponseWriter, r *http.Request) (c models.OAuthClient, ok bool) {
+	if err := r.ParseForm(); err != nil {
+		h.writeOAuthError(w, http.StatusBadRequest, errInvalidRequest, "can't parse form")
+		return c, false
+	}
+	clientId, secret, basic := r.BasicAuth()
+	if basic {
+		// RFC 6749 form-encodes the credentials before they are encoded by Basic authentication
+		clientId, _ = url.QueryUnescape(clientId)
+		secret, _ = url.QueryUnescape(secret)
+	} else {
+		clientId, secret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	if clientId == "" || secret == "" {
+		w.Header().Set(headerWWWAuthenticate, `Basic realm="oauth"`)
+		h.writeOAuthError(w, http.StatusUnauthorized, errInvalidClient, "client authentication is required")
+		return c, false
+	}
+
+	c, err := h.service.Authenticate(r.Context(), clientId, secret)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return c, false
+	}
+	return c, true
+}
+
+func (h handler) writeServiceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, uerrors.ErrInvalidClient):
+		w.Header().Set(headerWWWAuthenticate, `Basic realm="oauth"`)
+		h.writeOAuthError(w, http.StatusUnauthorized, errInvalidClient, "client is unknown or its secret is wrong")
+	case errors.Is(err, uerrors.ErrInvalidGrant):
+		h.writeOAuthError(w, http.StatusBadRequest, errInvalidGrant, "wrong user name or password")
+	case errors.Is(err, uerrors.ErrMFARequired):
+		h.writeOAuthError(w, http.StatusBadRequest, errInvalidGrant, "user requires MFA, log in at /v1/login")
+	case errors.Is(err, uerrors.ErrUnauthorizedClient):
+		h.writeOAuthError(w, http.StatusBadRequest, errUnauthorizedClient, "client is not allowed to use the grant")
+	case errors.Is(err, uerrors.ErrInvalidScope):
+		h.writeOAuthError(w, http.StatusBadRequest, errInvalidScope, "scope is not granted to the client")
+	case errors.Is(err, uerrors.ErrInvalidOAuthClient):
+		h.writeError(w, http.StatusBadRequest, "user of the client not found")
+	case errors.Is(err, uerrors.ErrOAuthClientNotFound):
+		h.writeError(w, http.StatusNotFound, uerrors.ErrOAuthClientNotFound.Error())
+	default:
+		h.logger.Entry.Errorf("OAuth request failed: %+v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// setRetryAfter tells the client the seconds to wait, rounded up, if it has to wait at all.
+func setRetryAfter(w http.ResponseWriter, retryAfter time.Duration) {
+	if retryAfter > 0 {
+		w.Header().Set(headerRetryAfter, strconv.Itoa(int((retryAfter+time.Second-1)/time.Second)))
+	}
+}
+
+func (h handler) writeOAuthError(w http.ResponseWriter, code int, oauthError, description string) {
+	w.Header().Set(headerCacheControl, "no-store")
+	h.writeJSON(w, code, ErrorResponse{Error: oauthError, Description: description})
+}
+
+func (h handler) writeError(w http.ResponseWriter, code int, message string) {
+	h.writeJSON(w, code, uerrors.ErrorResponse{Code: code, Message: message})
+}
+
+func (h handler) writeJSON(w http.ResponseWriter, code int, body interface{}) {
+	w.Header().Add(headerContentType, headerValueContentType)
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		h.logger.Entry.Errorf("problems with encoding data: %+v", err)
+	}
+}
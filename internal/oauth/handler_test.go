@@ -0,0 +1,99 @@
+package oauth_test
+
+import (
+	uerrors "githib.com/dkischenko/company-api/internal/errors"
+	"githib.com/dkischenko/company-api/internal/oauth"
+	mock_oauth "githib.com/dkischenko/company-api/internal/oauth/mocks"
+	"githib.com/dkischenko/company-api/pkg/logger"
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestHandler_TokenHandler(t *testing.T) {
+	c := client()
+	testCases := []struct {
+		name      string
+		form      url.Values
+		basic     bool
+		authErr   error
+		grant     bool
+		wantCode  int
+		wantError string
+	}{
+		{name: "Basic credentials", form: url.Values{"grant_type": {"client_credentials"}}, basic: true, grant: true,
+			wantCode: http.StatusOK},
+		{name: "Form credentials", form: url.Values{"grant_type": {"client_credentials"}, "client_id": {c.Id.String()},
+			"client_secret": {"cos_secret"}}, grant: true, wantCode: http.StatusOK},
+		{name: "Missing credentials", form: url.Values{"grant_type": {"client_credentials"}},
+			wantCode: http.StatusUnauthorized, wantError: "invalid_client"},
+		{name: "Wrong secret", form: url.Values{"grant_type": {"client_credentials"}}, basic: true,
+			authErr: uerrors.ErrInvalidClient, wantCode: http.StatusUnauthorized, wantError: "invalid_client"},
+		{name: "Unsupported grant", form: url.Values{"grant_type": {"authorization_code"}}, basic: true,
+			wantCode: http.StatusBadRequest, wantError: "unsupported_grant_type"},
+		{name: "Password grant without password", form: url.Values{"grant_type": {"password"}, "username": {"bob"}},
+			basic: true, wantCode: http.StatusBadRequest, wantError: "invalid_request"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockService := mock_oauth.NewMockIService(ctrl)
+			if tc.basic || tc.form.Get("client_id") != "" {
+				mockService.EXPECT().Authenticate(gomock.Any(), c.Id.String(), "cos_secret").Return(c, tc.authErr)
+			}
+			if tc.grant {
+				mockService.EXPECT().ClientCredentials(gomock.Any(), c, "").
+					Return(oauth.TokenResponse{AccessToken: "token", TokenType: "Bearer", ExpiresIn: 60}, nil)
+			}
+
+			l, _ := logger.GetLogger()
+			router := mux.NewRouter()
+			oauth.NewHandler(l, mockService, nil, "").Register(router)
+
+			req := httptest.NewRequest(http.MethodPost, "/oauth/token", strings.NewReader(tc.form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if tc.basic {
+				req.SetBasicAuth(c.Id.String(), "cos_secret")
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tc.wantCode, w.Code)
+			assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+			if tc.wantError != "" {
+				assert.Contains(t, w.Body.String(), `"error":"`+tc.wantError+`"`)
+			} else {
+				assert.JSONEq(t, `{"access_token":"token","token_type":"Bearer","expires_in":60,"scope":""}`, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_IntrospectHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	c := client()
+	mockService := mock_oauth.NewMockIService(ctrl)
+	mockService.EXPECT().Authenticate(gomock.Any(), c.Id.String(), "cos_secret").Return(c, nil)
+	mockService.EXPECT().Introspect(gomock.Any(), "token").Return(oauth.Introspection{}, nil)
+
+	l, _ := logger.GetLogger()
+	router := mux.NewRouter()
+	oauth.NewHandler(l, mockService, nil, "").Register(router)
+
+	req := httptest.NewRequest(http.MethodPost, "/oauth/introspect", strings.NewReader("token=token"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(c.Id.String(), "cos_secret")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"active":false}`, w.Body.String())
+}
@@ -0,0 +1,157 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: service.go
+
+// Package mock_oauth is a generated GoMock package.
+package mock_oauth
+
+import (
+	context "context"
+	reflect "reflect"
+
+	oauth "githib.com/dkischenko/company-api/internal/oauth"
+	models "githib.com/dkischenko/company-api/models"
+	gomock "github.com/golang/mock/gomock"
+	uuid "github.com/google/uuid"
+)
+
+// MockIService is a mock of IService interface.
+type MockIService struct {
+	ctrl     *gomock.Controller
+	recorder *MockIServiceMockRecorder
+}
+
+// MockIServiceMockRecorder is the mock recorder for MockIService.
+type MockIServiceMockRecorder struct {
+	mock *MockIService
+}
+
+// NewMockIService creates a new mock instance.
+func NewMockIService(ctrl *gomock.Controller) *MockIService {
+	mock := &MockIService{ctrl: ctrl}
+	mock.recorder = &MockIServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIService) EXPECT() *MockIServiceMockRecorder {
+	return m.recorder
+}
+
+// Authenticate mocks base method.
+func (m *MockIService) Authenticate(ctx context.Context, clientId, secret string) (models.OAuthClient, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Authenticate", ctx, clientId, secret)
+	ret0, _ := ret[0].(models.OAuthClient)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Authenticate indicates an expected call of Authenticate.
+func (mr *MockIServiceMockRecorder) Authenticate(ctx, clientId, secret interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Authenticate", reflect.TypeOf((*MockIService)(nil).Authenticate), ctx, clientId, secret)
+}
+
+// ClientCredentials mocks base method.
+func (m *MockIService) ClientCredentials(ctx context.Context, c models.OAuthClient, scope string) (oauth.TokenResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClientCredentials", ctx, c, scope)
+	ret0, _ := ret[0].(oauth.TokenResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClientCredentials indicates an expected call of ClientCredentials.
+func (mr *MockIServiceMockRecorder) ClientCredentials(ctx, c, scope interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClientCredentials", reflect.TypeOf((*MockIService)(nil).ClientCredentials), ctx, c, scope)
+}
+
+// CreateClient mocks base method.
+func (m *MockIService) CreateClient(ctx context.Context, req oauth.ClientRequest) (models.OAuthClient, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateClient", ctx, req)
+	ret0, _ := ret[0].(models.OAuthClient)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// CreateClient indicates an expected call of CreateClient.
+func (mr *MockIServiceMockRecorder) CreateClient(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateClient", reflect.TypeOf((*MockIService)(nil).CreateClient), ctx, req)
+}
+
+// DeleteClient mocks base method.
+func (m *MockIService) DeleteClient(ctx context.Context, id uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteClient", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteClient indicates an expected call of DeleteClient.
+func (mr *MockIServiceMockRecorder) DeleteClient(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteClient", reflect.TypeOf((*MockIService)(nil).DeleteClient), ctx, id)
+}
+
+// Introspect mocks base method.
+func (m *MockIService) Introspect(ctx context.Context, accessToken string) (oauth.Introspection, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Introspect", ctx, accessToken)
+	ret0, _ := ret[0].(oauth.Introspection)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Introspect indicates an expected call of Introspect.
+func (mr *MockIServiceMockRecorder) Introspect(ctx, accessToken interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Introspect", reflect.TypeOf((*MockIService)(nil).Introspect), ctx, accessToken)
+}
+
+// ListClients mocks base method.
+func (m *MockIService) ListClients(ctx context.Context) ([]models.OAuthClient, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListClients", ctx)
+	ret0, _ := ret[0].([]models.OAuthClient)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListClients indicates an expected call of ListClients.
+func (mr *MockIServiceMockRecorder) ListClients(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListClients", reflect.TypeOf((*MockIService)(nil).ListClients), ctx)
+}
+
+// Password mocks base method.
+func (m *MockIService) Password(ctx context.Context, c models.OAuthClient, name, password, scope string) (oauth.TokenResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Password", ctx, c, name, password, scope)
+	ret0, _ := ret[0].(oauth.TokenResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Password indicates an expected call of Password.
+func (mr *MockIServiceMockRecorder) Password(ctx, c, name, password, scope interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Password", reflect.TypeOf((*MockIService)(nil).Password), ctx, c, name, password, scope)
+}
+
+// Revoke mocks base method.
+func (m *MockIService) Revoke(ctx context.Context, c models.OAuthClient, accessToken string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Revoke", ctx, c, accessToken)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Revoke indicates an expected call of Revoke.
+func (mr *MockIServiceMockRecorder) Revoke(ctx, c, accessToken interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Revoke", reflect.TypeOf((*MockIService)(nil).Revoke), ctx, c, accessToken)
+}
@@ -0,0 +1,20 @@
+package oauth
+
+import (
+	"githib.com/dkischenko/company-api/models"
+	"github.com/google/uuid"
+)
+
+//go:generate mockgen -source=repository.go -destination=mocks/repository_mock.go
+type Repository interface {
+	CreateClient(c *models.OAuthClient) (err error)
+	ListClients() (clients []models.OAuthClient, err error)
+	// GetClient returns uerrors.ErrOAuthClientNotFound for unknown clients.
+	GetClient(id uuid.UUID) (c models.OAuthClient, err error)
+	// DeleteClient returns uerrors.ErrOAuthClientNotFound for unknown clients.
+	DeleteClient(id uuid.UUID) (err error)
+	// GetUser returns uerrors.ErrGetUser for unknown users.
+	GetUser(userId uint) (u models.User, err error)
+	// FindUser returns uerrors.ErrGetUser for unknown users.
+	FindUser(name string) (u models.User, err error)
+}
@@ -0,0 +1,45 @@
+package oauth
+
+import "githib.com/dkischenko/company-api/models"
+
+type ClientRequest struct {
+	Name string `json:"name" validate:"required,max=255"`
+	// UserId is the user tokens of the client credentials grant are issued for,
+	// the admin registering the client by default.
+	UserId uint               `json:"userId"`
+	Scopes []models.Scope     `json:"scopes" validate:"required,min=1,dive,oneof=companies:read companies:write webhooks admin"`
+	Grants []models.GrantType `json:"grants" validate:"required,min=1,dive,oneof=client_credentials password"`
+}
+
+// ClientCreateResponse reveals the secret, it's the only time the secret is returned by the API.
+type ClientCreateResponse struct {
+	models.OAuthClient
+	ClientSecret string `json:"clientSecret"`
+}
+
+// TokenResponse is the access token response of RFC 6749.
+type TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+	Scope       string `json:"scope"`
+}
+
+// Introspection is the introspection response of RFC 7662, inactive tokens only tell so.
+type Introspection struct {
+	Active    bool   `json:"active"`
+	Scope     string `json:"scope,omitempty"`
+	ClientId  string `json:"client_id,omitempty"`
+	Subject   string `json:"sub,omitempty"`
+	Role      string `json:"role,omitempty"`
+	TokenType string `json:"token_type,omitempty"`
+	ExpiresAt int64  `json:"exp,omitempty"`
+	IssuedAt  int64  `json:"iat,omitempty"`
+	Id        string `json:"jti,omitempty"`
+}
+
+// ErrorResponse is the error response of RFC 6749.
+type ErrorResponse struct {
+	Error       string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+}
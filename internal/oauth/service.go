@@ -0,0 +1,287 @@
+package oauth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	uerrors "githib.com/dkischenko/company-api/internal/errors"
+	"githib.com/dkischenko/company-api/internal/token"
+	"githib.com/dkischenko/company-api/models"
+	"githib.com/dkischenko/company-api/pkg/auth"
+	"githib.com/dkischenko/company-api/pkg/hasher"
+	"githib.com/dkischenko/company-api/pkg/logger"
+	"github.com/google/uuid"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// secretPrefix marks client secrets, so leaked secrets are easy to find by scanners.
+	secretPrefix = "cos_"
+	secretSize   = 32
+	tokenType    = "Bearer"
+)
+
+type Service struct {
+	logger       *logger.Logger
+	storage      Repository
+	tokens       token.IService
+	tokenManager *auth.Manager
+}
+
+//go:generate mockgen -source=service.go -destination=mocks/service_mock.go
+type IService interface {
+	// CreateClient registers the client, the secret is returned only here.
+	CreateClient(ctx context.Context, req ClientRequest) (c models.OAuthClient, secret string, err error)
+	ListClients(ctx context.Context) (clients []models.OAuthClient, err error)
+	DeleteClient(ctx context.Context, id uuid.UUID) (err error)
+	// Authenticate returns the client of the credentials or uerrors.ErrInvalidClient.
+	Authenticate(ctx context.Context, clientId, secret string) (c models.OAuthClient, err error)
+	// ClientCredentials issues the token of the user of the client.
+	ClientCredentials(ctx context.Context, c models.OAuthClient, scope string) (t TokenResponse, err error)
+	// Password issues the token of the user of the credentials to the client.
+	Password(ctx context.Context, c models.OAuthClient, name, password, scope string) (t TokenResponse, err error)
+	// Introspect tells whether the access token is active and what it grants.
+	Introspect(ctx context.Context, accessToken string) (i Introspection, err error)
+	// Revoke revokes the access token when it was issued to the client,
+	// other tokens are ignored.
+	Revoke(ctx context.Context, c models.OAuthClient, accessToken string) (err error)
+}
+
+// NewService creates the service of OAuth issuing access tokens valid for accessTTL,
+// which are revoked by the service of tokens.
+func NewService(logger *logger.Logger, storage Repository, tokens token.IService, accessTTL time.Duration) IService {
+	tm, err := auth.NewManager(accessTTL)
+	if err != nil {
+		logger.Entry.Errorf("error with token manager: %s", err)
+	}
+
+	return &Service{
+		logger:       logger,
+		storage:      storage,
+		tokens:       tokens,
+		tokenManager: tm,
+	}
+}
+
+func (s Service) CreateClient(ctx context.Context, req ClientRequest) (c models.OAuthClient, secret string, err error) {
+	if req.UserId == 0 {
+		userId, err := strconv.ParseUint(auth.UserIdFromContext(ctx), 10, 32)
+		if err != nil {
+			return c, "", fmt.Errorf("error occurs: %w", uerrors.ErrGetUser)
+		}
+		req.UserId = uint(userId)
+	}
+	_, err = s.storage.GetUser(req.UserId)
+	if errors.Is(err, uerrors.ErrGetUser) {
+		return c, "", fmt.Errorf("error occurs: %w", uerrors.ErrInvalidOAuthClient)
+	}
+	if err != nil {
+		s.logger.Entry.Errorf("failed to get user %d: %s", req.UserId, err)
+		return c, "", fmt.Errorf("error occurs: %w", uerrors.ErrOAuth)
+	}
+
+	b := make([]byte, secretSize)
+	if _, err = rand.Read(b); err != nil {
+		s.logger.Entry.Errorf("failed to generate client secret: %s", err)
+		return c, "", fmt.Errorf("error occurs: %w", uerrors.ErrOAuth)
+	}
+	secret = secretPrefix + base64.RawURLEncoding.EncodeToString(b)
+
+	c = models.OAuthClient{
+		UserId:     req.UserId,
+		Name:       req.Name,
+		SecretHash: hasher.HashToken(secret),
+		Scopes:     req.Scopes,
+		Grants:     req.Grants,
+	}
+	if err = s.storage.CreateClient(&c); err != nil {
+		s.logger.Entry.Errorf("failed to create OAuth client: %s", err)
+		return models.OAuthClient{}, "", fmt.Errorf("error occurs: %w", uerrors.ErrOAuth)
+	}
+	s.logger.Entry.Infof("user %s registered OAuth client %s of user %d", auth.UserIdFromContext(ctx), c.Id, c.UserId)
+	return c, secret, nil
+}
+
+func (s Service) ListClients(ctx context.Context) (clients []models.OAuthClient, err error) {
+	clients, err = s.storage.ListClients()
+	if err != nil {
+		s.logger.Entry.Errorf("failed to list OAuth clients: %s", err)
+		return nil, fmt.Errorf("error occurs: %w", uerrors.ErrOAuth)
+	}
+	return
+}
+
+// DeleteClient deletes the client, tokens issued to it are no longer active.
+func (s Service) DeleteClient(ctx context.Context, id uuid.UUID) (err error) {
+	err = s.storage.DeleteClient(id)
+	if errors.Is(err, uerrors.ErrOAuthClientNotFound) {
+		return fmt.Errorf("error occurs: %w", err)
+	}
+	if err != nil {
+		s.logger.Entry.Errorf("failed to delete OAuth client %s: %s", id, err)
+		return fmt.Errorf("error occurs: %w", uerrors.ErrOAuth)
+	}
+	return nil
+}
+
+func (s Service) Authenticate(ctx context.Context, clientId, secret string) (c models.OAuthClient, err error) {
+	id, err := uuid.Parse(clientId)
+	if err != nil {
+		return c, fmt.Errorf("error occurs: %w", uerrors.ErrInvalidClient)
+	}
+	c, err = s.storage.GetClient(id)
+	if errors.Is(err, uerrors.ErrOAuthClientNotFound) {
+		return c, fmt.Errorf("error occurs: %w", uerrors.ErrInvalidClient)
+	}
+	if err != nil {
+		s.logger.Entry.Errorf("failed to get OAuth client %s: %s", id, err)
+		return c, fmt.Errorf("error occurs: %w", uerrors.ErrOAuth)
+	}
+	if subtle.ConstantTimeCompare([]byte(hasher.HashToken(secret)), []byte(c.SecretHash)) != 1 {
+		return models.OAuthClient{}, fmt.Errorf("error occurs: %w", uerrors.ErrInvalidClient)
+	}
+	return c, nil
+}
+
+func (s Service) ClientCredentials(ctx context.Context, c models.OAuthClient, scope string) (t TokenResponse, err error) {
+	if !allowed(c, models.GrantClientCredentials) {
+		return t, fmt.Errorf("error occurs: %w", uerrors.ErrUnauthorizedClient)
+	}
+	u, err := s.storage.GetUser(c.UserId)
+	if errors.Is(err, uerrors.ErrGetUser) {
+		return t, fmt.Errorf("error occurs: %w", uerrors.ErrInvalidGrant)
+	}
+	if err != nil {
+		s.logger.Entry.Errorf("failed to get user %d: %s", c.UserId, err)
+		return t, fmt.Errorf("error occurs: %w", uerrors.ErrOAuth)
+	}
+	if u.Disabled() {
+		return t, fmt.Errorf("error occurs: %w", uerrors.ErrInvalidGrant)
+	}
+	return s.issue(c, u, scope)
+}
+
+func (s Service) Password(ctx context.Context, c models.OAuthClient, name, password, scope string) (t TokenResponse, err error) {
+	if !allowed(c, models.GrantPassword) {
+		return t, fmt.Errorf("error occurs: %w", uerrors.ErrUnauthorizedClient)
+	}
+	u, err := s.storage.FindUser(name)
+	if err != nil && !errors.Is(err, uerrors.ErrGetUser) {
+		s.logger.Entry.Errorf("failed to find user: %s", err)
+		return t, fmt.Errorf("error occurs: %w", uerrors.ErrOAuth)
+	}
+	if err != nil || !hasher.CheckPasswordHash(u.PasswordHash, password) || u.Disabled() {
+		return t, fmt.Errorf("error occurs: %w", uerrors.ErrInvalidGrant)
+	}
+	// the grant has no step for the code of the second factor
+	if u.MFAEnabled {
+		return t, fmt.Errorf("error occurs: %w", uerrors.ErrMFARequired)
+	}
+	return s.issue(c, u, scope)
+}
+
+func (s Service) Introspect(ctx context.Context, accessToken string) (i Introspection, err error) {
+	claims, err := s.tokenManager.ParseJWT(accessToken)
+	if err != nil {
+		return Introspection{}, nil
+	}
+	if claims.Id != "" && s.tokens != nil {
+		revoked, err := s.tokens.IsRevoked(ctx, claims.Id)
+		if err != nil {
+			s.logger.Entry.Errorf("failed to check revocation of token %s: %s", claims.Id, err)
+			return i, fmt.Errorf("error occurs: %w", uerrors.ErrOAuth)
+		}
+		if revoked {
+			return Introspection{}, nil
+		}
+	}
+	if s.tokens != nil {
+		disabled, err := s.tokens.IsUserDisabled(ctx, claims.UserId)
+		if err != nil {
+			s.logger.Entry.Errorf("failed to check user of token %s: %s", claims.Id, err)
+			return i, fmt.Errorf("error occurs: %w", uerrors.ErrOAuth)
+		}
+		if disabled {
+			return Introspection{}, nil
+		}
+	}
+	// tokens of deleted clients are no longer active
+	if claims.ClientId != "" {
+		id, _ := uuid.Parse(claims.ClientId)
+		_, err = s.storage.GetClient(id)
+		if errors.Is(err, uerrors.ErrOAuthClientNotFound) {
+			return Introspection{}, nil
+		}
+		if err != nil {
+			s.logger.Entry.Errorf("failed to get OAuth client %s: %s", claims.ClientId, err)
+			return i, fmt.Errorf("error occurs: %w", uerrors.ErrOAuth)
+		}
+	}
+
+	return Introspection{
+		Active:    true,
+		Scope:     strings.Join(claims.Scopes, " "),
+		ClientId:  claims.ClientId,
+		Subject:   claims.UserId,
+		Role:      claims.Role,
+		TokenType: tokenType,
+		ExpiresAt: claims.ExpiresAt.Unix(),
+		IssuedAt:  claims.IssuedAt.Unix(),
+		Id:        claims.Id,
+	}, nil
+}
+
+func (s Service) Revoke(ctx context.Context, c models.OAuthClient, accessToken string) (err error) {
+	claims, err := s.tokenManager.ParseJWT(accessToken)
+	// invalid tokens and tokens of other clients are ignored, as revoking them would do nothing
+	if err != nil || claims.ClientId != c.Id.String() || claims.Id == "" || s.tokens == nil {
+		return nil
+	}
+	return s.tokens.RevokeAccessToken(ctx, claims.Id, claims.ExpiresAt)
+}
+
+// issue signs the token of the user for the client, limited to the requested scopes,
+// which are all scopes of the client when none are requested.
+func (s Service) issue(c models.OAuthClient, u models.User, scope string) (t TokenResponse, err error) {
+	scopes := make([]string, 0, len(c.Scopes))
+	granted := make(map[string]bool, len(c.Scopes))
+	for _, sc := range c.Scopes {
+		granted[string(sc)] = true
+		scopes = append(scopes, string(sc))
+	}
+	if requested := strings.Fields(scope); len(requested) > 0 {
+		for _, sc := range requested {
+			if !granted[sc] {
+				return t, fmt.Errorf("error occurs: %w", uerrors.ErrInvalidScope)
+			}
+		}
+		scopes = requested
+	}
+
+	accessToken, err := s.tokenManager.CreateClientJWT(strconv.FormatUint(uint64(u.Id), 10), string(u.Role),
+		c.Id.String(), scopes)
+	if err != nil {
+		s.logger.Entry.Errorf("problems with creating jwt token: %s", err)
+		return t, fmt.Errorf("error occurs: %w", uerrors.ErrCreateJWTToken)
+	}
+	return TokenResponse{
+		AccessToken: accessToken,
+		TokenType:   tokenType,
+		ExpiresIn:   int64(s.tokenManager.TokenTTL() / time.Second),
+		Scope:       strings.Join(scopes, " "),
+	}, nil
+}
+
+func allowed(c models.OAuthClient, grant models.GrantType) bool {
+	for _, g := range c.Grants {
+		if g == grant {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,196 @@
+package oauth_test
+
+import (
+	"context"
+	uerrors "githib.com/dkischenko/company-api/internal/errors"
+	"githib.com/dkischenko/company-api/internal/oauth"
+	mock_oauth "githib.com/dkischenko/company-api/internal/oauth/mocks"
+	mock_token "githib.com/dkischenko/company-api/internal/token/mocks"
+	"githib.com/dkischenko/company-api/models"
+	"githib.com/dkischenko/company-api/pkg/auth"
+	"githib.com/dkischenko/company-api/pkg/hasher"
+	"githib.com/dkischenko/company-api/pkg/logger"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func client() models.OAuthClient {
+	return models.OAuthClient{
+		Id:         uuid.New(),
+		UserId:     7,
+		SecretHash: hasher.HashToken("cos_secret"),
+		Scopes:     []models.Scope{models.ScopeCompaniesRead, models.ScopeCompaniesWrite},
+		Grants:     []models.GrantType{models.GrantClientCredentials},
+	}
+}
+
+func TestService_CreateClient(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var stored models.OAuthClient
+	mockRepo := mock_oauth.NewMockRepository(ctrl)
+	mockRepo.EXPECT().GetUser(uint(1)).Return(models.User{Id: 1}, nil)
+	mockRepo.EXPECT().CreateClient(gomock.Any()).DoAndReturn(func(c *models.OAuthClient) error {
+		stored = *c
+		return nil
+	})
+
+	l, _ := logger.GetLogger()
+	s := oauth.NewService(l, mockRepo, nil, time.Minute)
+	req := oauth.ClientRequest{Name: "billing", Scopes: []models.Scope{models.ScopeCompaniesRead},
+		Grants: []models.GrantType{models.GrantClientCredentials}}
+	_, secret, err := s.CreateClient(auth.WithUserId(context.Background(), "1"), req)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(secret, "cos_"))
+	assert.Equal(t, uint(1), stored.UserId)
+	assert.Equal(t, hasher.HashToken(secret), stored.SecretHash)
+}
+
+func TestService_Authenticate(t *testing.T) {
+	c := client()
+	tests := []struct {
+		name     string
+		clientId string
+		secret   string
+		getErr   error
+		wantErr  error
+	}{
+		{name: "Valid secret", clientId: c.Id.String(), secret: "cos_secret"},
+		{name: "Wrong secret", clientId: c.Id.String(), secret: "cos_guess", wantErr: uerrors.ErrInvalidClient},
+		{name: "Unknown client", clientId: c.Id.String(), secret: "cos_secret", getErr: uerrors.ErrOAuthClientNotFound,
+			wantErr: uerrors.ErrInvalidClient},
+		{name: "Malformed client id", clientId: "billing", secret: "cos_secret", wantErr: uerrors.ErrInvalidClient},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mock_oauth.NewMockRepository(ctrl)
+			if _, err := uuid.Parse(tc.clientId); err == nil {
+				mockRepo.EXPECT().GetClient(c.Id).Return(c, tc.getErr)
+			}
+
+			l, _ := logger.GetLogger()
+			s := oauth.NewService(l, mockRepo, nil, time.Minute)
+			got, err := s.Authenticate(context.Background(), tc.clientId, tc.secret)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, c.Id, got.Id)
+		})
+	}
+}
+
+func TestService_ClientCredentials(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	c := client()
+	mockRepo := mock_oauth.NewMockRepository(ctrl)
+	mockRepo.EXPECT().GetUser(uint(7)).Return(models.User{Id: 7, Role: models.RoleEditor}, nil).AnyTimes()
+	mockRepo.EXPECT().GetClient(c.Id).Return(c, nil).AnyTimes()
+	mockTokens := mock_token.NewMockIService(ctrl)
+	mockTokens.EXPECT().IsRevoked(gomock.Any(), gomock.Any()).Return(false, nil).AnyTimes()
+	mockTokens.EXPECT().IsUserDisabled(gomock.Any(), "7").Return(false, nil).AnyTimes()
+
+	l, _ := logger.GetLogger()
+	s := oauth.NewService(l, mockRepo, mockTokens, time.Minute)
+	ctx := context.Background()
+
+	tok, err := s.ClientCredentials(ctx, c, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "Bearer", tok.TokenType)
+	assert.Equal(t, int64(60), tok.ExpiresIn)
+	assert.Equal(t, "companies:read companies:write", tok.Scope)
+
+	tok, err = s.ClientCredentials(ctx, c, "companies:read")
+	assert.NoError(t, err)
+	i, err := s.Introspect(ctx, tok.AccessToken)
+	assert.NoError(t, err)
+	assert.True(t, i.Active)
+	assert.Equal(t, "companies:read", i.Scope)
+	assert.Equal(t, c.Id.String(), i.ClientId)
+	assert.Equal(t, "7", i.Subject)
+
+	_, err = s.ClientCredentials(ctx, c, "admin")
+	assert.ErrorIs(t, err, uerrors.ErrInvalidScope)
+	_, err = s.Password(ctx, c, "bob", "password", "")
+	assert.ErrorIs(t, err, uerrors.ErrUnauthorizedClient)
+
+	i, err = s.Introspect(ctx, "not a token")
+	assert.NoError(t, err)
+	assert.False(t, i.Active)
+}
+
+func TestService_Password(t *testing.T) {
+	hash, _ := hasher.HashPassword("password")
+	disabledAt := time.Now()
+	tests := []struct {
+		name     string
+		user     models.User
+		findErr  error
+		password string
+		wantErr  error
+	}{
+		{name: "Valid credentials", user: models.User{Id: 7, PasswordHash: hash}, password: "password"},
+		{name: "Wrong password", user: models.User{Id: 7, PasswordHash: hash}, password: "guess", wantErr: uerrors.ErrInvalidGrant},
+		{name: "Unknown user", findErr: uerrors.ErrGetUser, password: "password", wantErr: uerrors.ErrInvalidGrant},
+		{name: "User with MFA", user: models.User{Id: 7, PasswordHash: hash, MFAEnabled: true}, password: "password",
+			wantErr: uerrors.ErrMFARequired},
+		{name: "Disabled user", user: models.User{Id: 7, PasswordHash: hash, DisabledAt: &disabledAt}, password: "password",
+			wantErr: uerrors.ErrInvalidGrant},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			c := client()
+			c.Grants = []models.GrantType{models.GrantPassword}
+			mockRepo := mock_oauth.NewMockRepository(ctrl)
+			mockRepo.EXPECT().FindUser("bob").Return(tc.user, tc.findErr)
+
+			l, _ := logger.GetLogger()
+			s := oauth.NewService(l, mockRepo, nil, time.Minute)
+			_, err := s.Password(context.Background(), c, "bob", tc.password, "")
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestService_Revoke(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	c, other := client(), client()
+	mockRepo := mock_oauth.NewMockRepository(ctrl)
+	mockRepo.EXPECT().GetUser(uint(7)).Return(models.User{Id: 7, Role: models.RoleEditor}, nil).AnyTimes()
+	mockTokens := mock_token.NewMockIService(ctrl)
+	// only the token of the client itself is revoked
+	mockTokens.EXPECT().RevokeAccessToken(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(1)
+
+	l, _ := logger.GetLogger()
+	s := oauth.NewService(l, mockRepo, mockTokens, time.Minute)
+	ctx := context.Background()
+	tok, err := s.ClientCredentials(ctx, c, "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	assert.NoError(t, s.Revoke(ctx, other, tok.AccessToken))
+	assert.NoError(t, s.Revoke(ctx, c, "not a token"))
+	assert.NoError(t, s.Revoke(ctx, c, tok.AccessToken))
+}
@@ -0,0 +1,76 @@
+package database
+
+import (
+	"githib.com/dkischenko/company-api/internal/outbox"
+	"githib.com/dkischenko/company-api/models"
+	"githib.com/dkischenko/company-api/pkg/logger"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type postgres struct {
+	logger *logger.Logger
+	db     *gorm.DB
+}
+
+func NewStorage(db *gorm.DB, logger *logger.Logger) outbox.Repository {
+	return &postgres{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (p postgres) Claim(limit int, now, until time.Time) (events []models.OutboxEvent, err error) {
+	err = p.db.Transaction(func(tx *gorm.DB) error {
+		// the head of every company queue is found first, so a company whose
+		// oldest event is waiting for a retry doesn't get its later events published.
+		// Heads being claimed by another relay are locked and skipped, while the events
+		// behind them aren't heads, so no two relays publish events of a company at once.
+		heads := tx.Model(&models.OutboxEvent{}).
+			Select("DISTINCT ON (company_id) id").
+			Order("company_id").Order("id")
+		err := tx.Where("id IN (?) AND next_attempt_at <= ?", heads, now).
+			Order("id").
+			Limit(limit).
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Find(&events).Error
+		if err != nil || len(events) == 0 {
+			return err
+		}
+
+		ids := make([]uint64, len(events))
+		for i, e := range events {
+			ids[i] = e.Id
+		}
+		return tx.Model(&models.OutboxEvent{}).Where("id IN ?", ids).Update("next_attempt_at", until).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func (p postgres) Delete(id uint64) (err error) {
+	return p.db.Delete(&models.OutboxEvent{}, id).Error
+}
+
+func (p postgres) MarkFailed(id uint64, attempts int, nextAttemptAt time.Time, lastError string) (err error) {
+	return p.db.Model(&models.OutboxEvent{Id: id}).Updates(map[string]interface{}{
+		"attempts":        attempts,
+		"next_attempt_at": nextAttemptAt,
+		"last_error":      lastError,
+	}).Error
+}
+
+func (p postgres) Backlog() (b outbox.Backlog, err error) {
+	row := struct {
+		Pending    int64
+		Failing    int64
+		OldestTime *time.Time
+	}{}
+	err = p.db.Model(&models.OutboxEvent{}).
+		Select("COUNT(*) AS pending, COUNT(*) FILTER (WHERE attempts > 0) AS failing, MIN(created_at) AS oldest_time").
+		Scan(&row).Error
+	return outbox.Backlog(row), err
+}
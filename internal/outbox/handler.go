@@ -0,0 +1,52 @@
+package outbox
+
+import (
+	"encoding/json"
+	"githib.com/dkischenko/company-api/internal/middleware"
+	"githib.com/dkischenko/company-api/models"
+	"githib.com/dkischenko/company-api/pkg/logger"
+	"github.com/gorilla/mux"
+	"net/http"
+)
+
+const (
+	outboxStats            = "/v1/outbox/stats"
+	headerContentType      = "Content-Type"
+	headerValueContentType = "application/json"
+)
+
+type handler struct {
+	logger *logger.Logger
+	relay  *Relay
+}
+
+func NewHandler(logger *logger.Logger, relay *Relay) *handler {
+	return &handler{
+		logger: logger,
+		relay:  relay,
+	}
+}
+
+// Register adds the route of the outbox stats, which are for admins only
+// as the last error of an event may tell details of the broker.
+func (h handler) Register(router *mux.Router) {
+	middleware.HandleRoutes(router, []middleware.Route{
+		{Method: http.MethodGet, Path: outboxStats, Role: models.RoleAdmin, Scope: models.ScopeAdmin, Handler: h.StatsHandler},
+	})
+}
+
+func (h handler) StatsHandler(w http.ResponseWriter, r *http.Request) {
+	stats, err := h.relay.Stats()
+	if err != nil {
+		h.logger.Entry.Errorf("can't get outbox stats: %+v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add(headerContentType, headerValueContentType)
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(stats); err != nil {
+		h.logger.Entry.Errorf("can't get outbox stats: %+v", err)
+		return
+	}
+}
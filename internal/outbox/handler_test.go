@@ -0,0 +1,49 @@
+package outbox_test
+
+import (
+	"githib.com/dkischenko/company-api/internal/middleware"
+	"githib.com/dkischenko/company-api/internal/outbox"
+	"githib.com/dkischenko/company-api/models"
+	"githib.com/dkischenko/company-api/pkg/auth"
+	"githib.com/dkischenko/company-api/pkg/logger"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHandler_StatsAdminOnly(t *testing.T) {
+	m, err := auth.NewManager(time.Minute)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	viewer, _ := m.CreateJWT("7", string(models.RoleViewer))
+
+	l, _ := logger.GetLogger()
+	router := mux.NewRouter()
+	outbox.NewHandler(l, nil).Register(router)
+	router.Use(middleware.Authenticate(nil, nil))
+
+	testCases := []struct {
+		name     string
+		token    string
+		wantCode int
+	}{
+		{name: "Anonymous", wantCode: http.StatusUnauthorized},
+		{name: "Viewer", token: viewer, wantCode: http.StatusForbidden},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/v1/outbox/stats", nil)
+			if tc.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tc.token)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tc.wantCode, w.Code)
+		})
+	}
+}
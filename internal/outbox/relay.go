@@ -0,0 +1,177 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"githib.com/dkischenko/company-api/internal/events"
+	"githib.com/dkischenko/company-api/pkg/logger"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultBatchSize = 100
+	minBackoff       = time.Second
+	maxBackoff       = 5 * time.Minute
+	// claimLease is how long claimed events are hidden from other relays. Events of a batch
+	// not published within half of it are left to the next claim after the lease.
+	claimLease = 10 * time.Minute
+)
+
+// Relay drains the outbox to the publisher. An event is deleted only after
+// the publisher accepted it, so delivery is at-least-once and consumers
+// should deduplicate events by their id. Only the oldest undelivered event
+// of a company is taken, so events of a company are published in order
+// and a failing event holds back the later events of its company only.
+// A batch is claimed for a lease in a short transaction and published outside
+// of it, so relays of several instances share the outbox without publishing
+// an event twice, and events of a relay which died are published after the lease.
+type Relay struct {
+	logger    *logger.Logger
+	storage   Repository
+	publisher events.Publisher
+	interval  time.Duration
+	batchSize int
+
+	published uint64
+	failures  uint64
+	mu        sync.Mutex
+	lastError string
+}
+
+// Stats tells how far the relay is behind and how often it fails.
+// Published and Failures are counted since the start of the process.
+type Stats struct {
+	Pending    int64   `json:"pending"`
+	Failing    int64   `json:"failing"`
+	LagSeconds float64 `json:"lagSeconds"`
+	Published  uint64  `json:"published"`
+	Failures   uint64  `json:"failures"`
+	LastError  string  `json:"lastError,omitempty"`
+}
+
+func NewRelay(logger *logger.Logger, storage Repository, publisher events.Publisher, interval time.Duration, batchSize int) *Relay {
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+	return &Relay{
+		logger:    logger,
+		storage:   storage,
+		publisher: publisher,
+		interval:  interval,
+		batchSize: batchSize,
+	}
+}
+
+// Run relays events every interval until ctx is done.
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			n, err := r.Process(ctx)
+			if err != nil {
+				r.logger.Entry.Errorf("failed to relay outbox events: %s", err)
+			}
+			// a full batch means more events are likely waiting
+			if err != nil || n < r.batchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Process makes one delivery attempt for a batch of due events
+// and returns the number of events claimed from the outbox.
+func (r *Relay) Process(ctx context.Context) (n int, err error) {
+	now := time.Now()
+	due, err := r.storage.Claim(r.batchSize, now, now.Add(claimLease))
+	if err != nil {
+		return 0, fmt.Errorf("cannot claim outbox events: %w", err)
+	}
+	deadline := now.Add(claimLease / 2)
+
+	for _, o := range due {
+		if ctx.Err() != nil {
+			return len(due), ctx.Err()
+		}
+		if time.Now().After(deadline) {
+			r.logger.Entry.Warningf("outbox batch is published too slowly, the rest is left to the next claim")
+			break
+		}
+
+		e := events.Event{}
+		if err = json.Unmarshal(o.Payload, &e); err == nil {
+			err = r.publisher.Publish(ctx, e)
+		}
+		if err != nil {
+			r.fail(o.Id, o.Attempts+1, err)
+			continue
+		}
+
+		if err = r.storage.Delete(o.Id); err != nil {
+			// the event will be published once more after the lease
+			r.logger.Entry.Errorf("failed to delete published outbox event %d: %s", o.Id, err)
+			continue
+		}
+		atomic.AddUint64(&r.published, 1)
+	}
+	return len(due), nil
+}
+
+func (r *Relay) fail(id uint64, attempts int, cause error) {
+	atomic.AddUint64(&r.failures, 1)
+	r.mu.Lock()
+	r.lastError = cause.Error()
+	r.mu.Unlock()
+
+	next := time.Now().Add(backoff(attempts))
+	r.logger.Entry.Warningf("failed to publish outbox event %d (attempt %d), retry at %s: %s",
+		id, attempts, next.Format(time.RFC3339), cause)
+	if err := r.storage.MarkFailed(id, attempts, next, cause.Error()); err != nil {
+		r.logger.Entry.Errorf("failed to mark outbox event %d as failed: %s", id, err)
+	}
+}
+
+// backoff doubles the delay before every next attempt up to maxBackoff.
+func backoff(attempts int) time.Duration {
+	d := minBackoff
+	for i := 1; i < attempts && d < maxBackoff; i++ {
+		d *= 2
+	}
+	if d > maxBackoff {
+		d = maxBackoff
+	}
+	return d
+}
+
+func (r *Relay) Stats() (Stats, error) {
+	b, err := r.storage.Backlog()
+	if err != nil {
+		return Stats{}, fmt.Errorf("cannot get outbox backlog: %w", err)
+	}
+
+	s := Stats{
+		Pending:   b.Pending,
+		Failing:   b.Failing,
+		Published: atomic.LoadUint64(&r.published),
+		Failures:  atomic.LoadUint64(&r.failures),
+	}
+	if b.OldestTime != nil {
+		s.LagSeconds = time.Since(*b.OldestTime).Seconds()
+	}
+	r.mu.Lock()
+	s.LastError = r.lastError
+	r.mu.Unlock()
+
+	return s, nil
+}
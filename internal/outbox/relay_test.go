@@ -0,0 +1,127 @@
+package outbox_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"githib.com/dkischenko/company-api/internal/events"
+	"githib.com/dkischenko/company-api/internal/outbox"
+	mock_outbox "githib.com/dkischenko/company-api/internal/outbox/mocks"
+	"githib.com/dkischenko/company-api/models"
+	"githib.com/dkischenko/company-api/pkg/logger"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+type failingPublisher struct{}
+
+func (failingPublisher) Publish(ctx context.Context, e events.Event) error {
+	return errors.New("broker is not available")
+}
+
+func outboxEvent(t *testing.T, id uint64, attempts int) models.OutboxEvent {
+	e := events.New(events.CompanyCreated, "1", nil, &models.Company{Id: uuid.New(), Name: "Big company"})
+	payload, err := json.Marshal(e)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	return models.OutboxEvent{
+		Id:        id,
+		EventId:   e.Id,
+		CompanyId: e.CompanyId,
+		Type:      string(e.Type),
+		Payload:   payload,
+		Attempts:  attempts,
+	}
+}
+
+func TestRelay_Process(t *testing.T) {
+	t.Run("Published events are deleted", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		due := []models.OutboxEvent{outboxEvent(t, 1, 0), outboxEvent(t, 2, 0)}
+		mockRepo := mock_outbox.NewMockRepository(ctrl)
+		mockRepo.EXPECT().Claim(10, gomock.Any(), gomock.Any()).
+			DoAndReturn(func(limit int, now, until time.Time) ([]models.OutboxEvent, error) {
+				// claimed events are hidden from other relays while they are published
+				assert.WithinDuration(t, now.Add(10*time.Minute), until, time.Second)
+				return due, nil
+			})
+		mockRepo.EXPECT().Delete(uint64(1)).Return(nil)
+		mockRepo.EXPECT().Delete(uint64(2)).Return(nil)
+		mockRepo.EXPECT().Backlog().Return(outbox.Backlog{}, nil)
+
+		l, _ := logger.GetLogger()
+		publisher := &events.MemoryPublisher{}
+		relay := outbox.NewRelay(l, mockRepo, publisher, time.Second, 10)
+		n, err := relay.Process(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, 2, n)
+
+		published := publisher.Events()
+		if assert.Len(t, published, 2) {
+			assert.Equal(t, due[0].EventId, published[0].Id)
+			assert.Equal(t, due[1].EventId, published[1].Id)
+		}
+
+		stats, err := relay.Stats()
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(2), stats.Published)
+		assert.Equal(t, uint64(0), stats.Failures)
+	})
+
+	t.Run("Failed events are retried with backoff", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		oldest := time.Now().Add(-time.Minute)
+		mockRepo := mock_outbox.NewMockRepository(ctrl)
+		mockRepo.EXPECT().Claim(10, gomock.Any(), gomock.Any()).Return([]models.OutboxEvent{outboxEvent(t, 7, 2)}, nil)
+		mockRepo.EXPECT().MarkFailed(uint64(7), 3, gomock.Any(), "broker is not available").
+			DoAndReturn(func(id uint64, attempts int, next time.Time, lastError string) error {
+				assert.WithinDuration(t, time.Now().Add(4*time.Second), next, time.Second)
+				return nil
+			})
+		mockRepo.EXPECT().Backlog().Return(outbox.Backlog{Pending: 1, Failing: 1, OldestTime: &oldest}, nil)
+
+		l, _ := logger.GetLogger()
+		relay := outbox.NewRelay(l, mockRepo, failingPublisher{}, time.Second, 10)
+		_, err := relay.Process(context.Background())
+		assert.NoError(t, err)
+
+		stats, err := relay.Stats()
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), stats.Failing)
+		assert.Equal(t, uint64(1), stats.Failures)
+		assert.Equal(t, "broker is not available", stats.LastError)
+		assert.GreaterOrEqual(t, stats.LagSeconds, 60.0)
+	})
+
+	t.Run("Failed delete doesn't stop the batch", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mock_outbox.NewMockRepository(ctrl)
+		mockRepo.EXPECT().Claim(10, gomock.Any(), gomock.Any()).
+			Return([]models.OutboxEvent{outboxEvent(t, 1, 0), outboxEvent(t, 2, 0)}, nil)
+		mockRepo.EXPECT().Delete(uint64(1)).Return(errors.New("connection refused"))
+		mockRepo.EXPECT().Delete(uint64(2)).Return(nil)
+		mockRepo.EXPECT().Backlog().Return(outbox.Backlog{Pending: 1}, nil)
+
+		l, _ := logger.GetLogger()
+		publisher := &events.MemoryPublisher{}
+		relay := outbox.NewRelay(l, mockRepo, publisher, time.Second, 10)
+		n, err := relay.Process(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, 2, n)
+		assert.Len(t, publisher.Events(), 2)
+
+		stats, err := relay.Stats()
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(1), stats.Published)
+	})
+}
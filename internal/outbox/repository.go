@@ -0,0 +1,24 @@
+package outbox
+
+import (
+	"githib.com/dkischenko/company-api/models"
+	"time"
+)
+
+//go:generate mockgen -source=repository.go -destination=mocks/repository_mock.go
+type Repository interface {
+	// Claim returns up to limit events which are the oldest undelivered events
+	// of their companies and are due for a delivery attempt at now, and moves
+	// their next attempt to until, so other relays skip them until then.
+	Claim(limit int, now, until time.Time) (events []models.OutboxEvent, err error)
+	Delete(id uint64) (err error)
+	MarkFailed(id uint64, attempts int, nextAttemptAt time.Time, lastError string) (err error)
+	Backlog() (b Backlog, err error)
+}
+
+// Backlog summarises the undelivered events.
+type Backlog struct {
+	Pending    int64
+	Failing    int64
+	OldestTime *time.Time
+}
@@ -0,0 +1,40 @@
+package token
+
+import (
+	"context"
+	"githib.com/dkischenko/company-api/pkg/logger"
+	"time"
+)
+
+const cleanupInterval = time.Hour
+
+// Cleaner deletes expired refresh tokens and entries of the revocation list.
+type Cleaner struct {
+	logger  *logger.Logger
+	service IService
+}
+
+func NewCleaner(logger *logger.Logger, service IService) *Cleaner {
+	return &Cleaner{
+		logger:  logger,
+		service: service,
+	}
+}
+
+// Run deletes expired tokens until ctx is done.
+func (c *Cleaner) Run(ctx context.Context) {
+	ticker := time.NewTicker(cleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := c.service.DeleteExpired(ctx, time.Now()); err != nil {
+			c.logger.Entry.Errorf("failed to delete expired tokens: %s", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package database
+
+import (
+	"errors"
+	uerrors "githib.com/dkischenko/company-api/internal/errors"
+	"githib.com/dkischenko/company-api/internal/token"
+	"githib.com/dkischenko/company-api/models"
+	"githib.com/dkischenko/company-api/pkg/logger"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type postgres struct {
+	logger *logger.Logger
+	db     *gorm.DB
+}
+
+func NewStorage(db *gorm.DB, logger *logger.Logger) token.Repository {
+	return &postgres{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (p postgres) Transaction(fn func(r token.Repository) error) error {
+	return p.db.Transaction(func(tx *gorm.DB) error {
+		return fn(postgres{db: tx, logger: p.logger})
+	})
+}
+
+func (p postgres) CreateRefreshToken(t *models.RefreshToken) (err error) {
+	return p.db.Create(t).Error
+}
+
+func (p postgres) GetRefreshToken(hash string) (t models.RefreshToken, err error) {
+	err = p.db.Where("token_hash = ?", hash).First(&t).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return t, uerrors.ErrInvalidRefreshToken
+	}
+	return
+}
+
+func (p postgres) UseRefreshToken(id uuid.UUID, at time.Time) (err error) {
+	// the token is only marked by the first of concurrent exchanges
+	res := p.db.Model(&models.RefreshToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", at)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return uerrors.ErrRefreshTokenReused
+	}
+	return nil
+}
+
+func (p postgres) RevokeFamily(familyId uuid.UUID, at time.Time) (err error) {
+	return p.db.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyId).
+		Update("revoked_at", at).Error
+}
+
+func (p postgres) RevokeAccessToken(jti string, expiresAt time.Time) (err error) {
+	return p.db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.RevokedToken{Jti: jti, ExpiresAt: expiresAt}).Error
+}
+
+func (p postgres) IsAccessTokenRevoked(jti string) (revoked bool, err error) {
+	var count int64
+	err = p.db.Model(&models.RevokedToken{}).Where("jti = ?", jti).Count(&count).Error
+	return count > 0, err
+}
+
+func (p postgres) GetUser(userId uint) (u models.User, err error) {
+	err = p.db.Where("id = ?", userId).First(&u).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return u, uerrors.ErrGetUser
+	}
+	return
+}
+
+func (p postgres) ClientExists(id uuid.UUID) (exists bool, err error) {
+	var count int64
+	err = p.db.Model(&models.OAuthClient{}).Where("id = ?", id).Count(&count).Error
+	return count > 0, err
+}
+
+func (p postgres) DeleteExpired(now time.Time) (deleted int64, err error) {
+	err = p.db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Where("expires_at <= ?", now).Delete(&models.RefreshToken{})
+		if res.Error != nil {
+			return res.Error
+		}
+		deleted = res.RowsAffected
+		res = tx.Where("expires_at <= ?", now).Delete(&models.RevokedToken{})
+		deleted += res.RowsAffected
+		return res.Error
+	})
+	return
+}
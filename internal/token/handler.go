@@ -0,0 +1,123 @@
+package token
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	uerrors "githib.com/dkischenko/company-api/internal/errors"
+	"githib.com/dkischenko/company-api/internal/middleware"
+	"githib.com/dkischenko/company-api/pkg/auth"
+	"githib.com/dkischenko/company-api/pkg/logger"
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+	"net/http"
+	"time"
+)
+
+const (
+	tokenRefresh           = "/v1/token/refresh"
+	logout                 = "/v1/logout"
+	jwks                   = "/.well-known/jwks.json"
+	headerContentType      = "Content-Type"
+	headerCacheControl     = "Cache-Control"
+	headerXExpiresAfter    = "X-Expires-After"
+	headerValueContentType = "application/json"
+)
+
+type handler struct {
+	logger    *logger.Logger
+	service   IService
+	keys      *auth.KeySet
+	accessTTL time.Duration
+}
+
+func NewHandler(logger *logger.Logger, service IService, keys *auth.KeySet, accessTTL time.Duration) *handler {
+	return &handler{
+		logger:    logger,
+		service:   service,
+		keys:      keys,
+		accessTTL: accessTTL,
+	}
+}
+
+// Register adds the routes of tokens. They are public, the refresh token is the credential.
+func (h handler) Register(router *mux.Router) {
+	middleware.HandleRoutes(router, []middleware.Route{
+		{Method: http.MethodPost, Path: tokenRefresh, Handler: h.RefreshHandler},
+		{Method: http.MethodPost, Path: logout, Handler: h.LogoutHandler},
+		{Method: http.MethodGet, Path: jwks, Handler: h.JWKSHandler},
+	})
+}
+
+// JWKSHandler publishes the public keys access tokens are verified with,
+// so other services can verify them without sharing a secret.
+func (h handler) JWKSHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set(headerCacheControl, "public, max-age=300")
+	h.writeJSON(w, http.StatusOK, h.keys.JWKS())
+}
+
+func (h handler) RefreshHandler(w http.ResponseWriter, r *http.Request) {
+	req, ok := h.decodeRequest(w, r)
+	if !ok {
+		return
+	}
+
+	tokens, err := h.service.Refresh(r.Context(), req.RefreshToken)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Add(headerXExpiresAfter, time.Now().Local().Add(h.accessTTL).String())
+	h.writeJSON(w, http.StatusOK, TokenResponse{Hash: tokens.AccessToken, RefreshToken: tokens.RefreshToken})
+}
+
+// LogoutHandler revokes the family of the refresh token, and the access token
+// the request is authorized with, if any.
+func (h handler) LogoutHandler(w http.ResponseWriter, r *http.Request) {
+	req, ok := h.decodeRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.service.Logout(r.Context(), req.RefreshToken); err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h handler) decodeRequest(w http.ResponseWriter, r *http.Request) (req RefreshRequest, ok bool) {
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, "wrong json format")
+		return req, false
+	}
+	if err := validator.New().Struct(req); err != nil {
+		h.writeError(w, http.StatusBadRequest, fmt.Sprintf("got wrong token data: %+v", err))
+		return req, false
+	}
+	return req, true
+}
+
+func (h handler) writeServiceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, uerrors.ErrInvalidRefreshToken), errors.Is(err, uerrors.ErrRefreshTokenReused):
+		h.writeError(w, http.StatusUnauthorized, "refresh token is invalid, expired or revoked")
+	default:
+		h.logger.Entry.Errorf("token request failed: %+v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func (h handler) writeError(w http.ResponseWriter, code int, message string) {
+	h.writeJSON(w, code, uerrors.ErrorResponse{Code: code, Message: message})
+}
+
+func (h handler) writeJSON(w http.ResponseWriter, code int, body interface{}) {
+	w.Header().Add(headerContentType, headerValueContentType)
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		h.logger.Entry.Errorf("problems with encoding data: %+v", err)
+	}
+}
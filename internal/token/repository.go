@@ -0,0 +1,28 @@
+package token
+
+import (
+	"githib.com/dkischenko/company-api/models"
+	"github.com/google/uuid"
+	"time"
+)
+
+//go:generate mockgen -source=repository.go -destination=mocks/repository_mock.go
+type Repository interface {
+	// Transaction runs fn with a Repository bound to a single database transaction,
+	// which is committed when fn returns nil and rolled back otherwise.
+	Transaction(fn func(r Repository) error) error
+	CreateRefreshToken(t *models.RefreshToken) (err error)
+	// GetRefreshToken returns uerrors.ErrInvalidRefreshToken for unknown hashes.
+	GetRefreshToken(hash string) (t models.RefreshToken, err error)
+	// UseRefreshToken marks the token as exchanged, it returns
+	// uerrors.ErrRefreshTokenReused when the token was exchanged already.
+	UseRefreshToken(id uuid.UUID, at time.Time) (err error)
+	RevokeFamily(familyId uuid.UUID, at time.Time) (err error)
+	RevokeAccessToken(jti string, expiresAt time.Time) (err error)
+	IsAccessTokenRevoked(jti string) (revoked bool, err error)
+	// GetUser returns uerrors.ErrGetUser for unknown users.
+	GetUser(userId uint) (u models.User, err error)
+	ClientExists(id uuid.UUID) (exists bool, err error)
+	// DeleteExpired deletes refresh tokens and revoked access tokens expired at now.
+	DeleteExpired(now time.Time) (deleted int64, err error)
+}
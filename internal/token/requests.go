@@ -0,0 +1,10 @@
+package token
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refreshToken" validate:"required"`
+}
+
+type TokenResponse struct {
+	Hash         string `json:"hash"`
+	RefreshToken string `json:"refreshToken"`
+}
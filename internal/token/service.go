@@ -0,0 +1,216 @@
+package token
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	uerrors "githib.com/dkischenko/company-api/internal/errors"
+	"githib.com/dkischenko/company-api/models"
+	"githib.com/dkischenko/company-api/pkg/auth"
+	"githib.com/dkischenko/company-api/pkg/hasher"
+	"githib.com/dkischenko/company-api/pkg/logger"
+	"github.com/google/uuid"
+	"strconv"
+	"time"
+)
+
+// refreshTokenSize is the number of random bytes of a refresh token.
+const refreshTokenSize = 32
+
+// Tokens is a pair of an access token and the refresh token to get the next one.
+type Tokens struct {
+	AccessToken  string
+	RefreshToken string
+}
+
+type Service struct {
+	logger       *logger.Logger
+	storage      Repository
+	tokenManager *auth.Manager
+	accessTTL    time.Duration
+	refreshTTL   time.Duration
+}
+
+//go:generate mockgen -source=service.go -destination=mocks/service_mock.go
+type IService interface {
+	// IssueRefreshToken starts a new family of refresh tokens of the user.
+	IssueRefreshToken(ctx context.Context, userId uint) (refreshToken string, err error)
+	// Refresh exchanges the refresh token for new tokens. A refresh token can be
+	// exchanged once, a reuse revokes all tokens of its family.
+	Refresh(ctx context.Context, refreshToken string) (tokens Tokens, err error)
+	// Logout revokes the family of the refresh token and the access token of the request.
+	Logout(ctx context.Context, refreshToken string) (err error)
+	// RevokeAccessToken rejects the access token until it expires at expiresAt.
+	RevokeAccessToken(ctx context.Context, jti string, expiresAt time.Time) (err error)
+	IsRevoked(ctx context.Context, jti string) (revoked bool, err error)
+	// IsUserDisabled reports whether the user was disabled or doesn't exist anymore.
+	IsUserDisabled(ctx context.Context, userId string) (disabled bool, err error)
+	// IsClientDeleted reports whether the OAuth client of a token doesn't exist anymore.
+	IsClientDeleted(ctx context.Context, clientId string) (deleted bool, err error)
+	DeleteExpired(ctx context.Context, now time.Time) (deleted int64, err error)
+}
+
+func NewService(logger *logger.Logger, storage Repository, accessTTL, refreshTTL time.Duration) IService {
+	tm, err := auth.NewManager(accessTTL)
+	if err != nil {
+		logger.Entry.Errorf("error with token manager: %s", err)
+	}
+
+	return &Service{
+		logger:       logger,
+		storage:      storage,
+		tokenManager: tm,
+		accessTTL:    accessTTL,
+		refreshTTL:   refreshTTL,
+	}
+}
+
+func (s Service) IssueRefreshToken(ctx context.Context, userId uint) (refreshToken string, err error) {
+	refreshToken, err = s.issue(s.storage, userId, uuid.New(), time.Now())
+	if err != nil {
+		s.logger.Entry.Errorf("failed to issue refresh token: %s", err)
+		return "", fmt.Errorf("error occurs: %w", uerrors.ErrIssueToken)
+	}
+	return
+}
+
+func (s Service) Refresh(ctx context.Context, refreshToken string) (tokens Tokens, err error) {
+	now := time.Now()
+	var reused *models.RefreshToken
+	err = s.storage.Transaction(func(r Repository) error {
+		t, err := r.GetRefreshToken(hasher.HashToken(refreshToken))
+		if err != nil {
+			return err
+		}
+		if t.UsedAt != nil || t.RevokedAt != nil {
+			reused = &t
+			return uerrors.ErrRefreshTokenReused
+		}
+		if !t.ExpiresAt.After(now) {
+			return uerrors.ErrInvalidRefreshToken
+		}
+		if err = r.UseRefreshToken(t.Id, now); err != nil {
+			if errors.Is(err, uerrors.ErrRefreshTokenReused) {
+				reused = &t
+			}
+			return err
+		}
+
+		u, err := r.GetUser(t.UserId)
+		if err != nil {
+			return err
+		}
+		if u.Disabled() {
+			return uerrors.ErrInvalidRefreshToken
+		}
+		if tokens.AccessToken, err = s.tokenManager.CreateJWT(strconv.FormatUint(uint64(u.Id), 10), string(u.Role)); err != nil {
+			return err
+		}
+		tokens.RefreshToken, err = s.issue(r, u.Id, t.FamilyId, now)
+		return err
+	})
+
+	switch {
+	case reused != nil:
+		// the token was stolen or the client is broken, either way
+		// no token of the family can be trusted anymore
+		s.logger.Entry.Warningf("reuse of refresh token of user %d, revoking family %s", reused.UserId, reused.FamilyId)
+		if err := s.storage.RevokeFamily(reused.FamilyId, now); err != nil {
+			s.logger.Entry.Errorf("failed to revoke refresh token family %s: %s", reused.FamilyId, err)
+		}
+		return Tokens{}, fmt.Errorf("error occurs: %w", uerrors.ErrRefreshTokenReused)
+	case errors.Is(err, uerrors.ErrInvalidRefreshToken), errors.Is(err, uerrors.ErrGetUser):
+		return Tokens{}, fmt.Errorf("error occurs: %w", uerrors.ErrInvalidRefreshToken)
+	case err != nil:
+		s.logger.Entry.Errorf("failed to refresh token: %s", err)
+		return Tokens{}, fmt.Errorf("error occurs: %w", uerrors.ErrIssueToken)
+	}
+	return
+}
+
+func (s Service) Logout(ctx context.Context, refreshToken string) (err error) {
+	now := time.Now()
+	t, err := s.storage.GetRefreshToken(hasher.HashToken(refreshToken))
+	if errors.Is(err, uerrors.ErrInvalidRefreshToken) {
+		return fmt.Errorf("error occurs: %w", err)
+	}
+	if err != nil {
+		s.logger.Entry.Errorf("failed to get refresh token: %s", err)
+		return fmt.Errorf("error occurs: %w", uerrors.ErrRevokeToken)
+	}
+	if err = s.storage.RevokeFamily(t.FamilyId, now); err != nil {
+		s.logger.Entry.Errorf("failed to revoke refresh token family %s: %s", t.FamilyId, err)
+		return fmt.Errorf("error occurs: %w", uerrors.ErrRevokeToken)
+	}
+
+	// the access token is remembered until it would have expired anyway
+	if jti := auth.TokenIdFromContext(ctx); jti != "" {
+		if err = s.storage.RevokeAccessToken(jti, now.Add(s.accessTTL)); err != nil {
+			s.logger.Entry.Errorf("failed to revoke access token %s: %s", jti, err)
+			return fmt.Errorf("error occurs: %w", uerrors.ErrRevokeToken)
+		}
+	}
+	return nil
+}
+
+func (s Service) RevokeAccessToken(ctx context.Context, jti string, expiresAt time.Time) (err error) {
+	if err = s.storage.RevokeAccessToken(jti, expiresAt); err != nil {
+		s.logger.Entry.Errorf("failed to revoke access token %s: %s", jti, err)
+		return fmt.Errorf("error occurs: %w", uerrors.ErrRevokeToken)
+	}
+	return nil
+}
+
+func (s Service) IsRevoked(ctx context.Context, jti string) (revoked bool, err error) {
+	return s.storage.IsAccessTokenRevoked(jti)
+}
+
+func (s Service) IsUserDisabled(ctx context.Context, userId string) (disabled bool, err error) {
+	id, err := strconv.ParseUint(userId, 10, 32)
+	if err != nil {
+		return true, nil
+	}
+	u, err := s.storage.GetUser(uint(id))
+	if errors.Is(err, uerrors.ErrGetUser) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return u.Disabled(), nil
+}
+
+func (s Service) IsClientDeleted(ctx context.Context, clientId string) (deleted bool, err error) {
+	id, err := uuid.Parse(clientId)
+	if err != nil {
+		return true, nil
+	}
+	exists, err := s.storage.ClientExists(id)
+	return !exists, err
+}
+
+func (s Service) DeleteExpired(ctx context.Context, now time.Time) (deleted int64, err error) {
+	return s.storage.DeleteExpired(now)
+}
+
+// issue stores the hash of a new refresh token of the family and returns the token.
+func (s Service) issue(r Repository, userId uint, familyId uuid.UUID, now time.Time) (string, error) {
+	b := make([]byte, refreshTokenSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	refreshToken := base64.RawURLEncoding.EncodeToString(b)
+
+	t := &models.RefreshToken{
+		FamilyId:  familyId,
+		UserId:    userId,
+		TokenHash: hasher.HashToken(refreshToken),
+		ExpiresAt: now.Add(s.refreshTTL),
+	}
+	if err := r.CreateRefreshToken(t); err != nil {
+		return "", err
+	}
+	return refreshToken, nil
+}
@@ -0,0 +1,262 @@
+package token_test
+
+import (
+	"context"
+	"errors"
+	uerrors "githib.com/dkischenko/company-api/internal/errors"
+	"githib.com/dkischenko/company-api/internal/token"
+	mock_token "githib.com/dkischenko/company-api/internal/token/mocks"
+	"githib.com/dkischenko/company-api/models"
+	"githib.com/dkischenko/company-api/pkg/auth"
+	"githib.com/dkischenko/company-api/pkg/logger"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// inTransaction makes the transaction of the mock run on the mock itself.
+func inTransaction(repo *mock_token.MockRepository) {
+	repo.EXPECT().Transaction(gomock.Any()).DoAndReturn(func(fn func(r token.Repository) error) error {
+		return fn(repo)
+	})
+}
+
+func TestService_IssueRefreshToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var stored models.RefreshToken
+	mockRepo := mock_token.NewMockRepository(ctrl)
+	mockRepo.EXPECT().CreateRefreshToken(gomock.Any()).DoAndReturn(func(rt *models.RefreshToken) error {
+		stored = *rt
+		return nil
+	})
+
+	l, _ := logger.GetLogger()
+	s := token.NewService(l, mockRepo, time.Minute, time.Hour)
+	refreshToken, err := s.IssueRefreshToken(context.Background(), 7)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, refreshToken)
+	assert.Equal(t, uint(7), stored.UserId)
+	assert.NotEqual(t, uuid.Nil, stored.FamilyId)
+	assert.Len(t, stored.TokenHash, 64)
+	assert.NotContains(t, stored.TokenHash, refreshToken)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), stored.ExpiresAt, time.Minute)
+}
+
+func TestService_Refresh(t *testing.T) {
+	familyId := uuid.New()
+	used := time.Now().Add(-time.Minute)
+	tests := []struct {
+		name     string
+		stored   models.RefreshToken
+		getErr   error
+		useErr   error
+		disabled bool
+		revoked  bool
+		wantErr  error
+	}{
+		{
+			name:   "Rotated",
+			stored: models.RefreshToken{Id: uuid.New(), FamilyId: familyId, UserId: 7, ExpiresAt: time.Now().Add(time.Hour)},
+		},
+		{
+			name:    "Unknown token",
+			getErr:  uerrors.ErrInvalidRefreshToken,
+			wantErr: uerrors.ErrInvalidRefreshToken,
+		},
+		{
+			name:    "Expired token",
+			stored:  models.RefreshToken{Id: uuid.New(), FamilyId: familyId, UserId: 7, ExpiresAt: time.Now().Add(-time.Hour)},
+			wantErr: uerrors.ErrInvalidRefreshToken,
+		},
+		{
+			name:    "Reused token",
+			stored:  models.RefreshToken{Id: uuid.New(), FamilyId: familyId, UserId: 7, ExpiresAt: time.Now().Add(time.Hour), UsedAt: &used},
+			revoked: true,
+			wantErr: uerrors.ErrRefreshTokenReused,
+		},
+		{
+			name:    "Token of revoked family",
+			stored:  models.RefreshToken{Id: uuid.New(), FamilyId: familyId, UserId: 7, ExpiresAt: time.Now().Add(time.Hour), RevokedAt: &used},
+			revoked: true,
+			wantErr: uerrors.ErrRefreshTokenReused,
+		},
+		{
+			name:    "Concurrent reuse",
+			stored:  models.RefreshToken{Id: uuid.New(), FamilyId: familyId, UserId: 7, ExpiresAt: time.Now().Add(time.Hour)},
+			useErr:  uerrors.ErrRefreshTokenReused,
+			revoked: true,
+			wantErr: uerrors.ErrRefreshTokenReused,
+		},
+		{
+			name:     "Token of disabled user",
+			stored:   models.RefreshToken{Id: uuid.New(), FamilyId: familyId, UserId: 7, ExpiresAt: time.Now().Add(time.Hour)},
+			disabled: true,
+			wantErr:  uerrors.ErrInvalidRefreshToken,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mock_token.NewMockRepository(ctrl)
+			inTransaction(mockRepo)
+			mockRepo.EXPECT().GetRefreshToken(gomock.Any()).Return(tc.stored, tc.getErr)
+			if tc.wantErr == nil || tc.useErr != nil || tc.disabled {
+				mockRepo.EXPECT().UseRefreshToken(tc.stored.Id, gomock.Any()).Return(tc.useErr)
+			}
+			if tc.disabled {
+				mockRepo.EXPECT().GetUser(uint(7)).Return(models.User{Id: 7, DisabledAt: &used}, nil)
+			}
+			if tc.wantErr == nil {
+				mockRepo.EXPECT().GetUser(uint(7)).Return(models.User{Id: 7, Role: models.RoleViewer}, nil)
+				mockRepo.EXPECT().CreateRefreshToken(gomock.Any()).DoAndReturn(func(rt *models.RefreshToken) error {
+					assert.Equal(t, familyId, rt.FamilyId)
+					return nil
+				})
+			}
+			if tc.revoked {
+				mockRepo.EXPECT().RevokeFamily(familyId, gomock.Any()).Return(nil)
+			}
+
+			l, _ := logger.GetLogger()
+			s := token.NewService(l, mockRepo, time.Minute, time.Hour)
+			tokens, err := s.Refresh(context.Background(), "refresh")
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.NotEmpty(t, tokens.AccessToken)
+			assert.NotEmpty(t, tokens.RefreshToken)
+		})
+	}
+}
+
+func TestService_Logout(t *testing.T) {
+	familyId := uuid.New()
+	tests := []struct {
+		name      string
+		jti       string
+		getErr    error
+		revokeErr error
+		wantErr   error
+	}{
+		{name: "With access token", jti: "jti"},
+		{name: "Without access token"},
+		{name: "Unknown token", getErr: uerrors.ErrInvalidRefreshToken, wantErr: uerrors.ErrInvalidRefreshToken},
+		{name: "Family can't be revoked", revokeErr: errors.New("connection refused"), wantErr: uerrors.ErrRevokeToken},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mock_token.NewMockRepository(ctrl)
+			mockRepo.EXPECT().GetRefreshToken(gomock.Any()).Return(models.RefreshToken{FamilyId: familyId}, tc.getErr)
+			if tc.getErr == nil {
+				mockRepo.EXPECT().RevokeFamily(familyId, gomock.Any()).Return(tc.revokeErr)
+			}
+			if tc.jti != "" {
+				mockRepo.EXPECT().RevokeAccessToken(tc.jti, gomock.Any()).DoAndReturn(func(jti string, expiresAt time.Time) error {
+					assert.WithinDuration(t, time.Now().Add(time.Minute), expiresAt, time.Second)
+					return nil
+				})
+			}
+
+			l, _ := logger.GetLogger()
+			s := token.NewService(l, mockRepo, time.Minute, time.Hour)
+			err := s.Logout(auth.WithTokenId(context.Background(), tc.jti), "refresh")
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestService_IsUserDisabled(t *testing.T) {
+	disabledAt := time.Now()
+	tests := []struct {
+		name         string
+		userId       string
+		user         models.User
+		getErr       error
+		wantDisabled bool
+		wantErr      bool
+	}{
+		{name: "Active user", userId: "7", user: models.User{Id: 7}},
+		{name: "Disabled user", userId: "7", user: models.User{Id: 7, DisabledAt: &disabledAt}, wantDisabled: true},
+		{name: "Deleted user", userId: "7", getErr: uerrors.ErrGetUser, wantDisabled: true},
+		{name: "Malformed user id", userId: "bob", wantDisabled: true},
+		{name: "Database error", userId: "7", getErr: errors.New("connection refused"), wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mock_token.NewMockRepository(ctrl)
+			if tc.userId == "7" {
+				mockRepo.EXPECT().GetUser(uint(7)).Return(tc.user, tc.getErr)
+			}
+
+			l, _ := logger.GetLogger()
+			s := token.NewService(l, mockRepo, time.Minute, time.Hour)
+			disabled, err := s.IsUserDisabled(context.Background(), tc.userId)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.wantDisabled, disabled)
+		})
+	}
+}
+
+func TestService_IsClientDeleted(t *testing.T) {
+	clientId := uuid.New()
+	tests := []struct {
+		name        string
+		clientId    string
+		exists      bool
+		existsErr   error
+		wantDeleted bool
+		wantErr     bool
+	}{
+		{name: "Existing client", clientId: clientId.String(), exists: true},
+		{name: "Deleted client", clientId: clientId.String(), wantDeleted: true},
+		{name: "Malformed client id", clientId: "client", wantDeleted: true},
+		{name: "Database error", clientId: clientId.String(), existsErr: errors.New("connection refused"), wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mock_token.NewMockRepository(ctrl)
+			if tc.clientId == clientId.String() {
+				mockRepo.EXPECT().ClientExists(clientId).Return(tc.exists, tc.existsErr)
+			}
+
+			l, _ := logger.GetLogger()
+			s := token.NewService(l, mockRepo, time.Minute, time.Hour)
+			deleted, err := s.IsClientDeleted(context.Background(), tc.clientId)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.wantDeleted, deleted)
+		})
+	}
+}
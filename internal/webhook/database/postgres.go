@@ -0,0 +1,96 @@
+package database
+
+import (
+	"errors"
+	uerrors "githib.com/dkischenko/company-api/internal/errors"
+	"githib.com/dkischenko/company-api/internal/webhook"
+	"githib.com/dkischenko/company-api/models"
+	"githib.com/dkischenko/company-api/pkg/logger"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type postgres struct {
+	logger *logger.Logger
+	db     *gorm.DB
+}
+
+func NewStorage(db *gorm.DB, logger *logger.Logger) webhook.Repository {
+	return &postgres{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (p postgres) Create(sub *models.WebhookSubscription) (err error) {
+	return p.db.Create(sub).Error
+}
+
+func (p postgres) Get(id uuid.UUID) (sub models.WebhookSubscription, err error) {
+	err = p.db.Where("id = ?", id).First(&sub).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return sub, uerrors.ErrWebhookNotFound
+	}
+	return
+}
+
+func (p postgres) List(ownerId string) (subs []models.WebhookSubscription, err error) {
+	err = p.db.Where("owner_id = ?", ownerId).Order("created_at").Find(&subs).Error
+	return
+}
+
+func (p postgres) ListActive() (subs []models.WebhookSubscription, err error) {
+	err = p.db.Where("active").Find(&subs).Error
+	return
+}
+
+func (p postgres) Update(sub *models.WebhookSubscription) (err error) {
+	return p.db.Save(sub).Error
+}
+
+func (p postgres) Delete(id uuid.UUID) (err error) {
+	return p.db.Delete(&models.WebhookSubscription{Id: id}).Error
+}
+
+func (p postgres) CreateDeliveries(deliveries []models.WebhookDelivery) (err error) {
+	return p.db.Omit(clause.Associations).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&deliveries).Error
+}
+
+func (p postgres) FetchDueDeliveries(limit int, now time.Time) (deliveries []models.WebhookDelivery, err error) {
+	err = p.db.Preload("Subscription").
+		Where("status = ? AND next_attempt_at <= ?", models.DeliveryPending, now).
+		Order("id").
+		Limit(limit).
+		Find(&deliveries).Error
+	return
+}
+
+func (p postgres) SaveAttempt(delivery *models.WebhookDelivery, attempt models.WebhookAttempt) (err error) {
+	return p.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.WebhookDelivery{Id: delivery.Id}).Updates(map[string]interface{}{
+			"status":          delivery.Status,
+			"attempts":        delivery.Attempts,
+			"next_attempt_at": delivery.NextAttemptAt,
+		}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(&attempt).Error
+	})
+}
+
+func (p postgres) ListDeliveries(subscriptionId uuid.UUID, limit, offset int) (deliveries []models.WebhookDelivery, total int64, err error) {
+	q := p.db.Model(&models.WebhookDelivery{}).Where("subscription_id = ?", subscriptionId)
+	if err = q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err = q.Preload("Log", func(db *gorm.DB) *gorm.DB {
+		return db.Order("attempted_at")
+	}).Order("id DESC").Limit(limit).Offset(offset).Find(&deliveries).Error
+	return
+}
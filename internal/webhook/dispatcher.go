@@ -0,0 +1,228 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"githib.com/dkischenko/company-api/internal/events"
+	"githib.com/dkischenko/company-api/models"
+	"githib.com/dkischenko/company-api/pkg/logger"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	HeaderSignature = "X-Signature"
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderAttempt   = "X-Webhook-Attempt"
+
+	dispatchBatchSize = 100
+	minRetryDelay     = 2 * time.Second
+	maxRetryDelay     = time.Hour
+	maxErrorBodyLen   = 512
+)
+
+// Dispatcher implements events.Publisher by queueing deliveries of the event
+// to the matching subscriptions, and delivers queued events in background.
+type Dispatcher struct {
+	logger      *logger.Logger
+	storage     Repository
+	client      *http.Client
+	interval    time.Duration
+	maxAttempts int
+}
+
+func NewDispatcher(logger *logger.Logger, storage Repository, client *http.Client, interval time.Duration, maxAttempts int) *Dispatcher {
+	return &Dispatcher{
+		logger:      logger,
+		storage:     storage,
+		client:      client,
+		interval:    interval,
+		maxAttempts: maxAttempts,
+	}
+}
+
+// Sign returns the value of the X-Signature header for the body:
+// hex encoded HMAC-SHA256 of the body with the subscription secret.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Matches tells whether the event passes the filters of the subscription.
+func Matches(sub models.WebhookSubscription, e events.Event) bool {
+	if !sub.Active {
+		return false
+	}
+
+	if len(sub.EventTypes) > 0 {
+		found := false
+		for _, t := range sub.EventTypes {
+			found = found || t == string(e.Type)
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if len(sub.CompanyTypes) > 0 {
+		c := e.After
+		if c == nil {
+			c = e.Before
+		}
+		found := false
+		for _, t := range sub.CompanyTypes {
+			found = found || (c != nil && t == c.Type)
+		}
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (d *Dispatcher) Publish(ctx context.Context, e events.Event) error {
+	subs, err := d.storage.ListActive()
+	if err != nil {
+		return fmt.Errorf("cannot list webhook subscriptions: %w", err)
+	}
+
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("cannot marshal event: %w", err)
+	}
+
+	deliveries := []models.WebhookDelivery{}
+	for _, sub := range subs {
+		if !Matches(sub, e) {
+			continue
+		}
+		deliveries = append(deliveries, models.WebhookDelivery{
+			SubscriptionId: sub.Id,
+			EventId:        e.Id,
+			EventType:      string(e.Type),
+			Payload:        payload,
+			Status:         models.DeliveryPending,
+			NextAttemptAt:  time.Now(),
+		})
+	}
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	if err = d.storage.CreateDeliveries(deliveries); err != nil {
+		return fmt.Errorf("cannot queue webhook deliveries: %w", err)
+	}
+	return nil
+}
+
+// Run delivers queued events every interval until ctx is done.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := d.Process(ctx); err != nil {
+			d.logger.Entry.Errorf("failed to deliver webhooks: %s", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Process makes one attempt for every due delivery and returns their number.
+func (d *Dispatcher) Process(ctx context.Context) (int, error) {
+	due, err := d.storage.FetchDueDeliveries(dispatchBatchSize, time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("cannot fetch webhook deliveries: %w", err)
+	}
+
+	for i := range due {
+		if ctx.Err() != nil {
+			return len(due), ctx.Err()
+		}
+		d.deliver(ctx, &due[i])
+	}
+
+	return len(due), nil
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, delivery *models.WebhookDelivery) {
+	delivery.Attempts++
+	attempt := models.WebhookAttempt{DeliveryId: delivery.Id, AttemptedAt: time.Now()}
+
+	if delivery.Subscription == nil || !delivery.Subscription.Active {
+		attempt.Error = "subscription is not active"
+		delivery.Status = models.DeliveryFailed
+	} else {
+		attempt.StatusCode, attempt.Error = d.send(ctx, delivery)
+		attempt.DurationMs = time.Since(attempt.AttemptedAt).Milliseconds()
+
+		switch {
+		case attempt.Error == "":
+			delivery.Status = models.DeliverySucceeded
+		case delivery.Attempts >= d.maxAttempts:
+			delivery.Status = models.DeliveryFailed
+		default:
+			delivery.NextAttemptAt = time.Now().Add(retryDelay(delivery.Attempts))
+		}
+	}
+
+	if attempt.Error != "" {
+		d.logger.Entry.Warningf("failed to deliver webhook %d (attempt %d): %s", delivery.Id, delivery.Attempts, attempt.Error)
+	}
+	if err := d.storage.SaveAttempt(delivery, attempt); err != nil {
+		d.logger.Entry.Errorf("failed to save webhook attempt of delivery %d: %s", delivery.Id, err)
+	}
+}
+
+// send posts the event and returns the status code of the response
+// with the description of the failure if the event wasn't accepted.
+func (d *Dispatcher) send(ctx context.Context, delivery *models.WebhookDelivery) (int, string) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.Subscription.Url, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err.Error()
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderSignature, Sign(delivery.Subscription.Secret, delivery.Payload))
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderDelivery, strconv.FormatUint(delivery.Id, 10))
+	req.Header.Set(HeaderAttempt, strconv.Itoa(delivery.Attempts))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err.Error()
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyLen))
+		return resp.StatusCode, fmt.Sprintf("unexpected status %s: %s", resp.Status, body)
+	}
+	return resp.StatusCode, ""
+}
+
+// retryDelay doubles the delay after every failed attempt up to maxRetryDelay.
+func retryDelay(attempts int) time.Duration {
+	delay := minRetryDelay
+	for i := 1; i < attempts && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	return delay
+}
@@ -0,0 +1,173 @@
+package webhook_test
+
+import (
+	"context"
+	"githib.com/dkischenko/company-api/internal/events"
+	"githib.com/dkischenko/company-api/internal/webhook"
+	mock_webhook "githib.com/dkischenko/company-api/internal/webhook/mocks"
+	"githib.com/dkischenko/company-api/models"
+	"githib.com/dkischenko/company-api/pkg/logger"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestMatches(t *testing.T) {
+	e := events.New(events.CompanyDeleted, "1", &models.Company{Id: uuid.New(), Type: models.NonProfit}, nil)
+
+	testCases := []struct {
+		name string
+		sub  models.WebhookSubscription
+		want bool
+	}{
+		{
+			name: "No filters",
+			sub:  models.WebhookSubscription{Active: true},
+			want: true,
+		},
+		{
+			name: "Inactive",
+			sub:  models.WebhookSubscription{Active: false},
+			want: false,
+		},
+		{
+			name: "Matching filters",
+			sub: models.WebhookSubscription{
+				Active:       true,
+				EventTypes:   []string{"company.created", "company.deleted"},
+				CompanyTypes: []models.TypeAllowed{models.NonProfit},
+			},
+			want: true,
+		},
+		{
+			name: "Other event type",
+			sub:  models.WebhookSubscription{Active: true, EventTypes: []string{"company.created"}},
+			want: false,
+		},
+		{
+			name: "Other company type",
+			sub:  models.WebhookSubscription{Active: true, CompanyTypes: []models.TypeAllowed{models.Cooperative}},
+			want: false,
+		},
+	}
+
+	for _, tcase := range testCases {
+		t.Run(tcase.name, func(t *testing.T) {
+			assert.Equal(t, tcase.want, webhook.Matches(tcase.sub, e))
+		})
+	}
+}
+
+func TestDispatcher_Publish(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	matching := models.WebhookSubscription{Id: uuid.New(), Active: true, EventTypes: []string{"company.created"}}
+	other := models.WebhookSubscription{Id: uuid.New(), Active: true, EventTypes: []string{"company.deleted"}}
+	e := events.New(events.CompanyCreated, "1", nil, &models.Company{Id: uuid.New()})
+
+	mockRepo := mock_webhook.NewMockRepository(ctrl)
+	mockRepo.EXPECT().ListActive().Return([]models.WebhookSubscription{matching, other}, nil)
+	mockRepo.EXPECT().CreateDeliveries(gomock.Any()).DoAndReturn(func(deliveries []models.WebhookDelivery) error {
+		if assert.Len(t, deliveries, 1) {
+			assert.Equal(t, matching.Id, deliveries[0].SubscriptionId)
+			assert.Equal(t, e.Id, deliveries[0].EventId)
+			assert.Equal(t, models.DeliveryPending, deliveries[0].Status)
+		}
+		return nil
+	})
+
+	l, _ := logger.GetLogger()
+	d := webhook.NewDispatcher(l, mockRepo, http.DefaultClient, time.Second, 3)
+	assert.NoError(t, d.Publish(context.Background(), e))
+}
+
+func TestDispatcher_Process(t *testing.T) {
+	testCases := []struct {
+		name         string
+		status       int
+		attempts     int
+		wantStatus   models.DeliveryStatus
+		wantRetryGap time.Duration
+	}{
+		{
+			name:       "Delivered",
+			status:     http.StatusNoContent,
+			wantStatus: models.DeliverySucceeded,
+		},
+		{
+			name:         "Retried with backoff",
+			status:       http.StatusServiceUnavailable,
+			attempts:     1,
+			wantStatus:   models.DeliveryPending,
+			wantRetryGap: 4 * time.Second,
+		},
+		{
+			name:       "Out of attempts",
+			status:     http.StatusInternalServerError,
+			attempts:   2,
+			wantStatus: models.DeliveryFailed,
+		},
+	}
+
+	for _, tcase := range testCases {
+		t.Run(tcase.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			payload := []byte(`{"type":"company.created"}`)
+			var gotSignature string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				assert.Equal(t, payload, body)
+				gotSignature = r.Header.Get(webhook.HeaderSignature)
+				w.WriteHeader(tcase.status)
+			}))
+			defer server.Close()
+
+			sub := &models.WebhookSubscription{Id: uuid.New(), Url: server.URL, Secret: "secret", Active: true}
+			mockRepo := mock_webhook.NewMockRepository(ctrl)
+			mockRepo.EXPECT().FetchDueDeliveries(gomock.Any(), gomock.Any()).Return([]models.WebhookDelivery{{
+				Id:             5,
+				SubscriptionId: sub.Id,
+				EventType:      "company.created",
+				Payload:        payload,
+				Status:         models.DeliveryPending,
+				Attempts:       tcase.attempts,
+				Subscription:   sub,
+			}}, nil)
+			mockRepo.EXPECT().SaveAttempt(gomock.Any(), gomock.Any()).DoAndReturn(
+				func(d *models.WebhookDelivery, a models.WebhookAttempt) error {
+					assert.Equal(t, tcase.wantStatus, d.Status)
+					assert.Equal(t, tcase.attempts+1, d.Attempts)
+					assert.Equal(t, tcase.status, a.StatusCode)
+					if tcase.wantRetryGap > 0 {
+						assert.WithinDuration(t, time.Now().Add(tcase.wantRetryGap), d.NextAttemptAt, time.Second)
+					}
+					return nil
+				})
+
+			l, _ := logger.GetLogger()
+			d := webhook.NewDispatcher(l, mockRepo, server.Client(), time.Second, 3)
+			n, err := d.Process(context.Background())
+			assert.NoError(t, err)
+			assert.Equal(t, 1, n)
+			assert.Equal(t, webhook.Sign("secret", payload), gotSignature)
+		})
+	}
+}
+
+func TestNewClient_RejectsLoopback(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Unexpected delivery to a loopback address")
+	}))
+	defer server.Close()
+
+	_, err := webhook.NewClient(time.Second).Get(server.URL)
+	assert.ErrorContains(t, err, "is not allowed")
+}
@@ -0,0 +1,223 @@
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	uerrors "githib.com/dkischenko/company-api/internal/errors"
+	"githib.com/dkischenko/company-api/internal/middleware"
+	"githib.com/dkischenko/company-api/models"
+	"githib.com/dkischenko/company-api/pkg/logger"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"net/http"
+	"strconv"
+)
+
+const (
+	webhooks               = "/v1/webhooks"
+	webhookWithId          = "/v1/webhooks/{id}"
+	webhookDeliveries      = "/v1/webhooks/{id}/deliveries"
+	headerContentType      = "Content-Type"
+	headerValueContentType = "application/json"
+
+	defaultListLimit = 20
+	maxListLimit     = 100
+)
+
+type handler struct {
+	logger  *logger.Logger
+	service IService
+}
+
+func NewHandler(logger *logger.Logger, service IService) *handler {
+	return &handler{
+		logger:  logger,
+		service: service,
+	}
+}
+
+func (h handler) Register(router *mux.Router) {
+	middleware.HandleRoutes(router, []middleware.Route{
+		{Method: http.MethodPost, Path: webhooks, Role: models.RoleViewer, Scope: models.ScopeWebhooks,
+			Handler: h.CreateSubscriptionHandler},
+		{Method: http.MethodGet, Path: webhooks, Role: models.RoleViewer, Scope: models.ScopeWebhooks,
+			Handler: h.ListSubscriptionsHandler},
+		{Method: http.MethodGet, Path: webhookWithId, Role: models.RoleViewer, Scope: models.ScopeWebhooks,
+			Handler: h.GetSubscriptionHandler},
+		{Method: http.MethodPut, Path: webhookWithId, Role: models.RoleViewer, Scope: models.ScopeWebhooks,
+			Handler: h.UpdateSubscriptionHandler},
+		{Method: http.MethodDelete, Path: webhookWithId, Role: models.RoleViewer, Scope: models.ScopeWebhooks,
+			Handler: h.DeleteSubscriptionHandler},
+		{Method: http.MethodGet, Path: webhookDeliveries, Role: models.RoleViewer, Scope: models.ScopeWebhooks,
+			Handler: h.ListDeliveriesHandler},
+	})
+}
+
+func (h handler) CreateSubscriptionHandler(w http.ResponseWriter, r *http.Request) {
+	req, ok := h.decodeRequest(w, r)
+	if !ok {
+		return
+	}
+
+	sub, err := h.service.CreateSubscription(r.Context(), req)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusCreated, SubscriptionCreateResponse{WebhookSubscription: sub, Secret: sub.Secret})
+}
+
+func (h handler) ListSubscriptionsHandler(w http.ResponseWriter, r *http.Request) {
+	subs, err := h.service.ListSubscriptions(r.Context())
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+	if subs == nil {
+		subs = []models.WebhookSubscription{}
+	}
+
+	h.writeJSON(w, http.StatusOK, subs)
+}
+
+func (h handler) GetSubscriptionHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.parseId(w, r)
+	if !ok {
+		return
+	}
+
+	sub, err := h.service.GetSubscription(r.Context(), id)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, sub)
+}
+
+func (h handler) UpdateSubscriptionHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.parseId(w, r)
+	if !ok {
+		return
+	}
+	req, ok := h.decodeRequest(w, r)
+	if !ok {
+		return
+	}
+
+	sub, err := h.service.UpdateSubscription(r.Context(), id, req)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, sub)
+}
+
+func (h handler) DeleteSubscriptionHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.parseId(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteSubscription(r.Context(), id); err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h handler) ListDeliveriesHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.parseId(w, r)
+	if !ok {
+		return
+	}
+
+	limit, offset := defaultListLimit, 0
+	q := r.URL.Query()
+	if v := q.Get("limit"); v != "" {
+		var err error
+		if limit, err = strconv.Atoi(v); err != nil || limit < 1 || limit > maxListLimit {
+			h.writeError(w, http.StatusBadRequest, fmt.Sprintf("limit must be an integer between 1 and %d", maxListLimit))
+			return
+		}
+	}
+	if v := q.Get("offset"); v != "" {
+		var err error
+		if offset, err = strconv.Atoi(v); err != nil || offset < 0 {
+			h.writeError(w, http.StatusBadRequest, "offset must be a non-negative integer")
+			return
+		}
+	}
+
+	deliveries, total, err := h.service.ListDeliveries(r.Context(), id, limit, offset)
+	if err != nil {
+		h.writeServiceError(w, err)
+		return
+	}
+	if deliveries == nil {
+		deliveries = []models.WebhookDelivery{}
+	}
+
+	h.writeJSON(w, http.StatusOK, DeliveryListResponse{
+		Data:   deliveries,
+		Total:  total,
+		Limit:  limit,
+		Offset: offset,
+	})
+}
+
+func (h handler) decodeRequest(w http.ResponseWriter, r *http.Request) (req SubscriptionRequest, ok bool) {
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Entry.Error("wrong json format")
+		h.writeError(w, http.StatusBadRequest, "wrong json format")
+		return req, false
+	}
+
+	v := validator.New()
+	if err := v.Struct(req); err != nil {
+		h.logger.Entry.Errorf("got wrong webhook data: %+v", err)
+		h.writeError(w, http.StatusBadRequest, fmt.Sprintf("got wrong webhook data: %+v", err))
+		return req, false
+	}
+
+	return req, true
+}
+
+func (h handler) parseId(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		h.logger.Entry.Errorf("can't parse UUID: %+v", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return id, false
+	}
+	return id, true
+}
+
+func (h handler) writeServiceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, uerrors.ErrWebhookNotFound):
+		h.writeError(w, http.StatusNotFound, uerrors.ErrWebhookNotFound.Error())
+	case errors.Is(err, uerrors.ErrInvalidWebhook):
+		h.writeError(w, http.StatusBadRequest, err.Error())
+	default:
+		h.logger.Entry.Errorf("webhook request failed: %+v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func (h handler) writeError(w http.ResponseWriter, code int, message string) {
+	h.writeJSON(w, code, uerrors.ErrorResponse{Code: code, Message: message})
+}
+
+func (h handler) writeJSON(w http.ResponseWriter, code int, body interface{}) {
+	w.Header().Add(headerContentType, headerValueContentType)
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		h.logger.Entry.Errorf("problems with encoding data: %+v", err)
+	}
+}
@@ -0,0 +1,24 @@
+package webhook
+
+import (
+	"githib.com/dkischenko/company-api/models"
+	"github.com/google/uuid"
+	"time"
+)
+
+//go:generate mockgen -source=repository.go -destination=mocks/repository_mock.go
+type Repository interface {
+	Create(sub *models.WebhookSubscription) (err error)
+	Get(id uuid.UUID) (sub models.WebhookSubscription, err error)
+	List(ownerId string) (subs []models.WebhookSubscription, err error)
+	ListActive() (subs []models.WebhookSubscription, err error)
+	Update(sub *models.WebhookSubscription) (err error)
+	Delete(id uuid.UUID) (err error)
+	// CreateDeliveries skips deliveries of an event already queued for the subscription.
+	CreateDeliveries(deliveries []models.WebhookDelivery) (err error)
+	// FetchDueDeliveries returns pending deliveries due at now along with their subscriptions.
+	FetchDueDeliveries(limit int, now time.Time) (deliveries []models.WebhookDelivery, err error)
+	// SaveAttempt stores the state of the delivery together with the attempt made.
+	SaveAttempt(delivery *models.WebhookDelivery, attempt models.WebhookAttempt) (err error)
+	ListDeliveries(subscriptionId uuid.UUID, limit, offset int) (deliveries []models.WebhookDelivery, total int64, err error)
+}
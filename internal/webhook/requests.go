@@ -0,0 +1,25 @@
+package webhook
+
+import "githib.com/dkischenko/company-api/models"
+
+type SubscriptionRequest struct {
+	Url          string               `json:"url" validate:"required,url,max=2048"`
+	Secret       string               `json:"secret" validate:"omitempty,min=16,max=256"`
+	EventTypes   []string             `json:"eventTypes" validate:"dive,oneof=company.created company.updated company.deleted company.restored"`
+	CompanyTypes []models.TypeAllowed `json:"companyTypes" validate:"dive,oneof=Corporations NonProfit Cooperative 'Sole Proprietorship'"`
+	Active       *bool                `json:"active"`
+}
+
+// SubscriptionCreateResponse reveals the secret of the subscription,
+// it's the only time the secret is returned by the API.
+type SubscriptionCreateResponse struct {
+	models.WebhookSubscription
+	Secret string `json:"secret"`
+}
+
+type DeliveryListResponse struct {
+	Data   []models.WebhookDelivery `json:"data"`
+	Total  int64                    `json:"total"`
+	Limit  int                      `json:"limit"`
+	Offset int                      `json:"offset"`
+}
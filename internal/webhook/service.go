@@ -0,0 +1,185 @@
+package webhook
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	uerrors "githib.com/dkischenko/company-api/internal/errors"
+	"githib.com/dkischenko/company-api/models"
+	"githib.com/dkischenko/company-api/pkg/auth"
+	"githib.com/dkischenko/company-api/pkg/logger"
+	"github.com/google/uuid"
+	"net"
+	"net/url"
+)
+
+const secretLength = 32
+
+type Service struct {
+	logger   *logger.Logger
+	storage  Repository
+	resolver Resolver
+}
+
+//go:generate mockgen -source=service.go -destination=mocks/service_mock.go
+type IService interface {
+	CreateSubscription(ctx context.Context, req SubscriptionRequest) (sub models.WebhookSubscription, err error)
+	ListSubscriptions(ctx context.Context) (subs []models.WebhookSubscription, err error)
+	GetSubscription(ctx context.Context, id uuid.UUID) (sub models.WebhookSubscription, err error)
+	UpdateSubscription(ctx context.Context, id uuid.UUID, req SubscriptionRequest) (sub models.WebhookSubscription, err error)
+	DeleteSubscription(ctx context.Context, id uuid.UUID) (err error)
+	ListDeliveries(ctx context.Context, id uuid.UUID, limit, offset int) (deliveries []models.WebhookDelivery, total int64, err error)
+}
+
+func NewService(logger *logger.Logger, storage Repository, resolver Resolver) IService {
+	return &Service{
+		logger:   logger,
+		storage:  storage,
+		resolver: resolver,
+	}
+}
+
+func (s Service) CreateSubscription(ctx context.Context, req SubscriptionRequest) (sub models.WebhookSubscription, err error) {
+	if err = s.checkUrl(ctx, req.Url); err != nil {
+		return sub, err
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		if secret, err = generateSecret(); err != nil {
+			s.logger.Entry.Errorf("failed to generate webhook secret: %s", err)
+			return sub, fmt.Errorf("error occurs: %w", uerrors.ErrCreateWebhook)
+		}
+	}
+
+	sub = models.WebhookSubscription{
+		OwnerId:      auth.UserIdFromContext(ctx),
+		Url:          req.Url,
+		Secret:       secret,
+		EventTypes:   nonNil(req.EventTypes),
+		CompanyTypes: nonNilTypes(req.CompanyTypes),
+		Active:       req.Active == nil || *req.Active,
+	}
+	if err = s.storage.Create(&sub); err != nil {
+		s.logger.Entry.Errorf("failed to create webhook subscription: %s", err)
+		return models.WebhookSubscription{}, fmt.Errorf("error occurs: %w", uerrors.ErrCreateWebhook)
+	}
+	return
+}
+
+func (s Service) ListSubscriptions(ctx context.Context) (subs []models.WebhookSubscription, err error) {
+	subs, err = s.storage.List(auth.UserIdFromContext(ctx))
+	if err != nil {
+		s.logger.Entry.Errorf("failed to list webhook subscriptions: %s", err)
+		return nil, fmt.Errorf("error occurs: %w", uerrors.ErrListWebhooks)
+	}
+	return
+}
+
+// GetSubscription returns the subscription if it belongs to the user of the request.
+func (s Service) GetSubscription(ctx context.Context, id uuid.UUID) (sub models.WebhookSubscription, err error) {
+	sub, err = s.storage.Get(id)
+	if err != nil {
+		if !errors.Is(err, uerrors.ErrWebhookNotFound) {
+			s.logger.Entry.Errorf("failed to get webhook subscription: %s", err)
+		}
+		return models.WebhookSubscription{}, fmt.Errorf("error occurs: %w", uerrors.ErrWebhookNotFound)
+	}
+	if sub.OwnerId != auth.UserIdFromContext(ctx) {
+		return models.WebhookSubscription{}, fmt.Errorf("error occurs: %w", uerrors.ErrWebhookNotFound)
+	}
+	return
+}
+
+func (s Service) UpdateSubscription(ctx context.Context, id uuid.UUID, req SubscriptionRequest) (sub models.WebhookSubscription, err error) {
+	if err = s.checkUrl(ctx, req.Url); err != nil {
+		return sub, err
+	}
+	if sub, err = s.GetSubscription(ctx, id); err != nil {
+		return sub, err
+	}
+
+	sub.Url = req.Url
+	sub.EventTypes = nonNil(req.EventTypes)
+	sub.CompanyTypes = nonNilTypes(req.CompanyTypes)
+	if req.Secret != "" {
+		sub.Secret = req.Secret
+	}
+	if req.Active != nil {
+		sub.Active = *req.Active
+	}
+	if err = s.storage.Update(&sub); err != nil {
+		s.logger.Entry.Errorf("failed to update webhook subscription: %s", err)
+		return models.WebhookSubscription{}, fmt.Errorf("error occurs: %w", uerrors.ErrUpdateWebhook)
+	}
+	return
+}
+
+func (s Service) DeleteSubscription(ctx context.Context, id uuid.UUID) (err error) {
+	if _, err = s.GetSubscription(ctx, id); err != nil {
+		return err
+	}
+	if err = s.storage.Delete(id); err != nil {
+		s.logger.Entry.Errorf("failed to delete webhook subscription: %s", err)
+		return fmt.Errorf("error occurs: %w", uerrors.ErrDeleteWebhook)
+	}
+	return
+}
+
+func (s Service) ListDeliveries(ctx context.Context, id uuid.UUID, limit, offset int) (deliveries []models.WebhookDelivery, total int64, err error) {
+	if _, err = s.GetSubscription(ctx, id); err != nil {
+		return nil, 0, err
+	}
+	deliveries, total, err = s.storage.ListDeliveries(id, limit, offset)
+	if err != nil {
+		s.logger.Entry.Errorf("failed to list webhook deliveries: %s", err)
+		return nil, 0, fmt.Errorf("error occurs: %w", uerrors.ErrListWebhooks)
+	}
+	return
+}
+
+// checkUrl allows only absolute http and https URLs of hosts that resolve
+// to public addresses only.
+func (s Service) checkUrl(ctx context.Context, rawUrl string) error {
+	u, err := url.Parse(rawUrl)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return fmt.Errorf("%w: url must be an absolute http or https url", uerrors.ErrInvalidWebhook)
+	}
+
+	var addrs []net.IPAddr
+	if ip := net.ParseIP(u.Hostname()); ip != nil {
+		addrs = []net.IPAddr{{IP: ip}}
+	} else if addrs, err = s.resolver.LookupIPAddr(ctx, u.Hostname()); err != nil || len(addrs) == 0 {
+		return fmt.Errorf("%w: host of the url cannot be resolved", uerrors.ErrInvalidWebhook)
+	}
+	for _, addr := range addrs {
+		if !isPublic(addr.IP) {
+			return fmt.Errorf("%w: url must not point to a private, loopback or link-local address", uerrors.ErrInvalidWebhook)
+		}
+	}
+	return nil
+}
+
+func generateSecret() (string, error) {
+	b := make([]byte, secretLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
+
+func nonNilTypes(s []models.TypeAllowed) []models.TypeAllowed {
+	if s == nil {
+		return []models.TypeAllowed{}
+	}
+	return s
+}
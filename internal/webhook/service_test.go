@@ -0,0 +1,98 @@
+package webhook_test
+
+import (
+	"context"
+	uerrors "githib.com/dkischenko/company-api/internal/errors"
+	"githib.com/dkischenko/company-api/internal/webhook"
+	mock_webhook "githib.com/dkischenko/company-api/internal/webhook/mocks"
+	"githib.com/dkischenko/company-api/models"
+	"githib.com/dkischenko/company-api/pkg/auth"
+	"githib.com/dkischenko/company-api/pkg/logger"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"testing"
+)
+
+func TestService_CreateSubscription(t *testing.T) {
+	t.Run("Create subscription", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mock_webhook.NewMockRepository(ctrl)
+		mockRepo.EXPECT().Create(gomock.Any()).Return(nil)
+
+		l, _ := logger.GetLogger()
+		s := webhook.NewService(l, mockRepo, resolver{})
+		ctx := auth.WithUserId(context.Background(), "7")
+		sub, err := s.CreateSubscription(ctx, webhook.SubscriptionRequest{Url: "https://partner.example/hooks"})
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		assert.Equal(t, "7", sub.OwnerId)
+		assert.True(t, sub.Active)
+		assert.Len(t, sub.Secret, 64)
+		assert.Equal(t, []string{}, sub.EventTypes)
+	})
+
+	t.Run("Not an http url", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		l, _ := logger.GetLogger()
+		s := webhook.NewService(l, mock_webhook.NewMockRepository(ctrl), resolver{})
+		_, err := s.CreateSubscription(context.Background(), webhook.SubscriptionRequest{Url: "file:///etc/passwd"})
+		assert.ErrorIs(t, err, uerrors.ErrInvalidWebhook)
+	})
+
+	for _, url := range []string{
+		"http://127.0.0.1:8080/hooks",
+		"http://10.1.2.3/hooks",
+		"http://[::1]/hooks",
+		"http://169.254.169.254/latest/meta-data",
+		"http://localhost/hooks",
+		"https://internal.example/hooks",
+		"https://unknown.example/hooks",
+	} {
+		t.Run("Rejected "+url, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			l, _ := logger.GetLogger()
+			s := webhook.NewService(l, mock_webhook.NewMockRepository(ctrl), resolver{})
+			_, err := s.CreateSubscription(context.Background(), webhook.SubscriptionRequest{Url: url})
+			assert.ErrorIs(t, err, uerrors.ErrInvalidWebhook)
+		})
+	}
+}
+
+// resolver resolves the hosts used in the tests without DNS.
+type resolver struct{}
+
+func (resolver) LookupIPAddr(_ context.Context, host string) ([]net.IPAddr, error) {
+	switch host {
+	case "partner.example":
+		return []net.IPAddr{{IP: net.ParseIP("93.184.216.34")}}, nil
+	case "localhost":
+		return []net.IPAddr{{IP: net.ParseIP("127.0.0.1")}, {IP: net.ParseIP("::1")}}, nil
+	case "internal.example":
+		return []net.IPAddr{{IP: net.ParseIP("93.184.216.34")}, {IP: net.ParseIP("192.168.0.10")}}, nil
+	}
+	return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+}
+
+func TestService_GetSubscriptionOfAnotherUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	id := uuid.New()
+	mockRepo := mock_webhook.NewMockRepository(ctrl)
+	mockRepo.EXPECT().Get(id).Return(models.WebhookSubscription{Id: id, OwnerId: "7"}, nil).Times(2)
+
+	l, _ := logger.GetLogger()
+	s := webhook.NewService(l, mockRepo, resolver{})
+	_, err := s.GetSubscription(auth.WithUserId(context.Background(), "8"), id)
+	assert.ErrorIs(t, err, uerrors.ErrWebhookNotFound)
+	assert.ErrorIs(t, s.DeleteSubscription(auth.WithUserId(context.Background(), "8"), id), uerrors.ErrWebhookNotFound)
+}
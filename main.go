@@ -1,18 +1,50 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"githib.com/dkischenko/company-api/configs"
+	"githib.com/dkischenko/company-api/internal/account"
+	accountdb "githib.com/dkischenko/company-api/internal/account/database"
+	"githib.com/dkischenko/company-api/internal/apikey"
+	apikeydb "githib.com/dkischenko/company-api/internal/apikey/database"
 	"githib.com/dkischenko/company-api/internal/app"
+	"githib.com/dkischenko/company-api/internal/commands"
 	"githib.com/dkischenko/company-api/internal/company"
 	"githib.com/dkischenko/company-api/internal/company/database"
+	"githib.com/dkischenko/company-api/internal/events"
+	"githib.com/dkischenko/company-api/internal/group"
+	groupdb "githib.com/dkischenko/company-api/internal/group/database"
+	"githib.com/dkischenko/company-api/internal/idempotency"
+	idempotencydb "githib.com/dkischenko/company-api/internal/idempotency/database"
+	"githib.com/dkischenko/company-api/internal/imports"
+	importsdb "githib.com/dkischenko/company-api/internal/imports/database"
+	"githib.com/dkischenko/company-api/internal/lockout"
+	lockoutdb "githib.com/dkischenko/company-api/internal/lockout/database"
+	"githib.com/dkischenko/company-api/internal/mfa"
+	mfadb "githib.com/dkischenko/company-api/internal/mfa/database"
+	"githib.com/dkischenko/company-api/internal/notify"
+	"githib.com/dkischenko/company-api/internal/oauth"
+	oauthdb "githib.com/dkischenko/company-api/internal/oauth/database"
+	"githib.com/dkischenko/company-api/internal/outbox"
+	outboxdb "githib.com/dkischenko/company-api/internal/outbox/database"
+	"githib.com/dkischenko/company-api/internal/token"
+	tokendb "githib.com/dkischenko/company-api/internal/token/database"
+	"githib.com/dkischenko/company-api/internal/webhook"
+	webhookdb "githib.com/dkischenko/company-api/internal/webhook/database"
 	"githib.com/dkischenko/company-api/models"
+	"githib.com/dkischenko/company-api/pkg/auth"
 	"githib.com/dkischenko/company-api/pkg/logger"
+	"githib.com/dkischenko/company-api/pkg/password"
 	"github.com/caarlos0/env"
 	"github.com/gorilla/mux"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"log"
+	"net"
+	"os"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -39,21 +71,153 @@ func run() error {
 		return fmt.Errorf("cannot connect to database: %w", err)
 	}
 
-	err = db.AutoMigrate(models.Company{}, models.User{})
+	err = db.AutoMigrate(models.Company{}, models.User{}, models.OutboxEvent{}, models.CompanyRevision{},
+		models.IdempotencyKey{}, models.ImportJob{}, models.RefreshToken{}, models.RevokedToken{},
+		models.APIKey{}, models.LoginFailure{}, models.AuditEvent{},
+		models.PasswordResetToken{}, models.MFASecret{}, models.RecoveryCode{}, models.MFAChallenge{},
+		models.OAuthClient{}, models.Group{}, models.GroupMember{}, models.ACLEntry{},
+		models.WebhookSubscription{}, models.WebhookDelivery{}, models.WebhookAttempt{})
 	if err != nil {
 		return fmt.Errorf("cannot migrate database: %w", err)
 	}
+	if err = database.Migrate(db); err != nil {
+		return fmt.Errorf("cannot migrate database: %w", err)
+	}
 
 	accessTokenTTL, err := time.ParseDuration(cfg.AccessTokenTTL)
 	if err != nil {
 		return fmt.Errorf("cannot parse token: %w", err)
 	}
+	keys, err := auth.Keys()
+	if err != nil {
+		return fmt.Errorf("cannot load signing keys: %w", err)
+	}
+	keysReloadInterval, err := time.ParseDuration(cfg.KeysReloadInterval)
+	if err != nil {
+		return fmt.Errorf("cannot parse keys reload interval: %w", err)
+	}
+	go keys.Watch(context.Background(), keysReloadInterval, l)
+	refreshTokenTTL, err := time.ParseDuration(cfg.RefreshTokenTTL)
+	if err != nil {
+		return fmt.Errorf("cannot parse refresh token ttl: %w", err)
+	}
+	tokens := token.NewService(l, tokendb.NewStorage(db, l), accessTokenTTL, refreshTokenTTL)
+	go token.NewCleaner(l, tokens).Run(context.Background())
+
+	idempotencyTTL, err := time.ParseDuration(cfg.IdempotencyTTL)
+	if err != nil {
+		return fmt.Errorf("cannot parse idempotency ttl: %w", err)
+	}
+	keeper := idempotency.NewKeeper(l, idempotencydb.NewStorage(db, l), idempotencyTTL)
+	go keeper.Run(context.Background())
+
+	if !models.Role(cfg.DefaultUserRole).Valid() {
+		return fmt.Errorf("cannot use default user role %q", cfg.DefaultUserRole)
+	}
+
+	passwords, err := password.NewPolicy(cfg.PasswordMinLength, cfg.PasswordMinClasses, cfg.PasswordBreachedFile)
+	if err != nil {
+		return fmt.Errorf("cannot load password policy: %w", err)
+	}
+	passwordResetTTL, err := time.ParseDuration(cfg.PasswordResetTTL)
+	if err != nil {
+		return fmt.Errorf("cannot parse password reset ttl: %w", err)
+	}
+	notifyOut := os.Stdout
+	if cfg.NotifyFile != "" {
+		notifyOut, err = os.OpenFile(cfg.NotifyFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+		if err != nil {
+			return fmt.Errorf("cannot open notify file: %w", err)
+		}
+	}
+	notifier := notify.NewWriterNotifier(notifyOut)
+
+	mfaChallengeTTL, err := time.ParseDuration(cfg.MFAChallengeTTL)
+	if err != nil {
+		return fmt.Errorf("cannot parse MFA challenge ttl: %w", err)
+	}
+	mfaService := mfa.NewService(l, mfadb.NewStorage(db, l), cfg.MFAIssuer, mfaChallengeTTL)
 
 	storage := database.NewStorage(db, l)
-	service := company.NewService(l, storage, accessTokenTTL)
-	handler := company.NewHandler(l, service, &cfg)
+	service := company.NewService(l, storage, accessTokenTTL, events.NewBroker(cfg.EventsBufferSize), passwords,
+		mfaService)
+	grantAdmins(l, service, cfg.AdminUserIds)
+	loginLockout, err := time.ParseDuration(cfg.LoginLockout)
+	if err != nil {
+		return fmt.Errorf("cannot parse login lockout: %w", err)
+	}
+	guard := lockout.NewGuard(l, lockoutdb.NewStorage(db, l), lockout.Policy{
+		FreeAttempts:  cfg.LoginFreeAttempts,
+		MaxAttempts:   cfg.LoginMaxAttempts,
+		MaxIPAttempts: cfg.LoginMaxIPAttempts,
+		Lockout:       loginLockout,
+	})
+
+	apiKeys := apikey.NewService(l, apikeydb.NewStorage(db, l))
+	handler := company.NewHandler(l, service, &cfg, keeper, tokens, apiKeys, guard)
 	handler.Register(router)
+	lockout.NewHandler(l, guard).Register(router)
+	apikey.NewHandler(l, apiKeys).Register(router)
+	mfa.NewHandler(l, mfaService).Register(router)
+	group.NewHandler(l, group.NewService(l, groupdb.NewStorage(db, l))).Register(router)
+	oauth.NewHandler(l, oauth.NewService(l, oauthdb.NewStorage(db, l), tokens, accessTokenTTL), guard,
+		cfg.ClientIPHeader).Register(router)
+	account.NewHandler(l, account.NewService(l, accountdb.NewStorage(db, l), notifier, passwords, passwordResetTTL)).
+		Register(router)
+	token.NewHandler(l, tokens, keys, accessTokenTTL).Register(router)
+	imports.NewHandler(l, imports.NewService(l, service, importsdb.NewStorage(db, l), cfg.ImportBatchSize)).
+		Register(router)
+
+	companyRetention, err := time.ParseDuration(cfg.CompanyRetention)
+	if err != nil {
+		return fmt.Errorf("cannot parse company retention: %w", err)
+	}
+	companyPurgeInterval, err := time.ParseDuration(cfg.CompanyPurgeInterval)
+	if err != nil {
+		return fmt.Errorf("cannot parse company purge interval: %w", err)
+	}
+	go company.NewPurger(l, service, companyPurgeInterval, companyRetention).Run(context.Background())
+
+	webhookInterval, err := time.ParseDuration(cfg.WebhookInterval)
+	if err != nil {
+		return fmt.Errorf("cannot parse webhook poll interval: %w", err)
+	}
+	webhookTimeout, err := time.ParseDuration(cfg.WebhookTimeout)
+	if err != nil {
+		return fmt.Errorf("cannot parse webhook timeout: %w", err)
+	}
+	webhookStorage := webhookdb.NewStorage(db, l)
+	dispatcher := webhook.NewDispatcher(l, webhookStorage, webhook.NewClient(webhookTimeout),
+		webhookInterval, cfg.WebhookMaxAttempts)
+	go dispatcher.Run(context.Background())
+	webhook.NewHandler(l, webhook.NewService(l, webhookStorage, net.DefaultResolver)).Register(router)
+
+	outboxPollInterval, err := time.ParseDuration(cfg.OutboxPollInterval)
+	if err != nil {
+		return fmt.Errorf("cannot parse outbox poll interval: %w", err)
+	}
+	publisher := events.MultiPublisher{events.NewKafkaPublisher(&cfg), dispatcher}
+	relay := outbox.NewRelay(l, outboxdb.NewStorage(db, l), publisher, outboxPollInterval, cfg.OutboxBatchSize)
+	go relay.Run(context.Background())
+	outbox.NewHandler(l, relay).Register(router)
+
+	consumer := commands.NewKafkaConsumer(l, service, &cfg)
+	go consumer.Run(context.Background())
 	app.RunServer(router, l, &cfg)
 
 	return nil
 }
+
+// grantAdmins makes the users admins, so there's someone to assign roles to others.
+func grantAdmins(l *logger.Logger, service company.IService, userIds []string) {
+	for _, id := range userIds {
+		userId, err := strconv.ParseUint(strings.TrimSpace(id), 10, 32)
+		if err != nil {
+			l.Entry.Errorf("cannot parse admin user id %q: %s", id, err)
+			continue
+		}
+		if _, err = service.SetUserRole(context.Background(), uint(userId), models.RoleAdmin); err != nil {
+			l.Entry.Errorf("cannot make user %d admin: %s", userId, err)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// Permission is the access to a company granted by an ACL entry.
+type Permission string
+
+const (
+	// PermissionRead allows to see who else has access to the company.
+	PermissionRead Permission = "read"
+	// PermissionWrite allows to update, delete and restore the company.
+	PermissionWrite Permission = "write"
+	// PermissionAdmin allows to share and unshare the company as well.
+	PermissionAdmin Permission = "admin"
+)
+
+// permissionLevels orders the permissions, every permission includes the ones below it.
+var permissionLevels = map[Permission]int{
+	PermissionRead:  1,
+	PermissionWrite: 2,
+	PermissionAdmin: 3,
+}
+
+// Valid reports whether the permission is one of the known permissions.
+func (p Permission) Valid() bool {
+	_, ok := permissionLevels[p]
+	return ok
+}
+
+// Includes reports whether the permission grants the other permission as well.
+func (p Permission) Includes(other Permission) bool {
+	return p.Valid() && permissionLevels[p] >= permissionLevels[other]
+}
+
+// ACLEntry grants the permission on the company to either a user or a group.
+// The owner of a company has admin permission without an entry.
+type ACLEntry struct {
+	Id         uuid.UUID  `json:"id" gorm:"type:uuid;default:uuid_generate_v4()"`
+	CompanyId  uuid.UUID  `json:"companyId" gorm:"type:uuid;not null;index"`
+	UserId     *uint      `json:"userId,omitempty" gorm:"index"`
+	GroupId    *uuid.UUID `json:"groupId,omitempty" gorm:"type:uuid;index"`
+	Permission Permission `json:"permission" gorm:"type:varchar(16);not null"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
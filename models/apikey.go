@@ -0,0 +1,32 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// Scope is a permission granted to an API key or OAuth client. Requests made with a key
+// reach only the routes of its scopes and only as far as the role of its user allows.
+type Scope string
+
+const (
+	ScopeCompaniesRead  Scope = "companies:read"
+	ScopeCompaniesWrite Scope = "companies:write"
+	ScopeWebhooks       Scope = "webhooks"
+	ScopeAdmin          Scope = "admin"
+)
+
+// APIKey authorizes requests of services on behalf of the user, without a login.
+// Only the hash of the key is stored, Prefix is kept to tell keys apart.
+type APIKey struct {
+	Id         uuid.UUID  `json:"id" gorm:"type:uuid;default:uuid_generate_v4()"`
+	UserId     uint       `json:"userId" gorm:"not null;index"`
+	Name       string     `json:"name" gorm:"type:varchar(255);not null"`
+	Prefix     string     `json:"prefix" gorm:"type:varchar(16);not null"`
+	KeyHash    string     `json:"-" gorm:"type:char(64);not null;uniqueIndex"`
+	Scopes     []Scope    `json:"scopes" gorm:"serializer:json;type:jsonb;not null"`
+	ExpiresAt  *time.Time `json:"expiresAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+	RevokedAt  *time.Time `json:"revokedAt"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
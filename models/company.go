@@ -2,6 +2,7 @@ package models
 
 import (
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type TypeAllowed string
@@ -16,9 +17,17 @@ const (
 // Company defines the structure for an API company
 type Company struct {
 	Id                uuid.UUID   `json:"id" gorm:"type:uuid;default:uuid_generate_v4()"`
-	Name              string      `json:"name" gorm:"not null;unique;type:varchar(255);index"`
-	Description       string      `json:"description" gorm:"type:varchar(3000);index"`
-	AmountOfEmployees int         `json:"amountOfEmployees" gorm:"not null;type:int;index"`
+	Name              string      `json:"name" gorm:"not null;type:varchar(255);index" validate:"required,max=255"`
+	Description       string      `json:"description" gorm:"type:varchar(3000);index" validate:"max=3000"`
+	AmountOfEmployees int         `json:"amountOfEmployees" gorm:"not null;type:int;index" validate:"gte=0"`
 	Registered        bool        `json:"registered" gorm:"not null;type:bool;index"`
-	Type              TypeAllowed `json:"type" gorm:"type:company_type;not null;index"`
+	Type              TypeAllowed `json:"type" gorm:"type:company_type;not null;index" validate:"required,oneof=Corporations NonProfit Cooperative 'Sole Proprietorship'"`
+	// OwnerId is the user who created the company, it has admin permission on it.
+	// Companies created before ownership have no owner.
+	OwnerId *uint `json:"ownerId,omitempty" gorm:"index"`
+	// Version is incremented by every update of the company and exposed as its ETag.
+	Version int `json:"version" gorm:"not null;default:1"`
+	// DeletedAt marks a deleted company, which can be restored until it's purged.
+	// Deleted companies are excluded from all queries but unscoped ones.
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
 }
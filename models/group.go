@@ -0,0 +1,18 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// Group is a named set of users, companies can be shared with all of them at once.
+type Group struct {
+	Id        uuid.UUID `json:"id" gorm:"type:uuid;default:uuid_generate_v4()"`
+	Name      string    `json:"name" gorm:"not null;unique"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type GroupMember struct {
+	GroupId uuid.UUID `json:"groupId" gorm:"type:uuid;primaryKey"`
+	UserId  uint      `json:"userId" gorm:"primaryKey;autoIncrement:false;index"`
+}
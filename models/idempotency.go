@@ -0,0 +1,20 @@
+package models
+
+import (
+	"net/http"
+	"time"
+)
+
+// IdempotencyKey remembers the response to a request made with the Idempotency-Key header,
+// so retries of the request get the same response. StatusCode is zero
+// while the original request is being processed.
+type IdempotencyKey struct {
+	Key         string      `json:"key" gorm:"primaryKey;type:varchar(255)"`
+	UserId      string      `json:"userId" gorm:"primaryKey;type:varchar(64)"`
+	RequestHash string      `json:"requestHash" gorm:"type:varchar(64);not null"`
+	StatusCode  int         `json:"statusCode" gorm:"not null;default:0"`
+	Header      http.Header `json:"header" gorm:"serializer:json;type:jsonb"`
+	Body        []byte      `json:"body" gorm:"type:bytea"`
+	CreatedAt   time.Time   `json:"createdAt" gorm:"not null"`
+	ExpiresAt   time.Time   `json:"expiresAt" gorm:"not null;index"`
+}
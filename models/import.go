@@ -0,0 +1,58 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+type ImportStatus string
+
+const (
+	ImportPending   ImportStatus = "pending"
+	ImportRunning   ImportStatus = "running"
+	ImportSucceeded ImportStatus = "succeeded"
+	ImportFailed    ImportStatus = "failed"
+)
+
+type ImportRowStatus string
+
+const (
+	// RowCreated is a company created by the import, RowValid is a company
+	// which would have been created if the import was not a dry run.
+	RowCreated   ImportRowStatus = "created"
+	RowValid     ImportRowStatus = "valid"
+	RowInvalid   ImportRowStatus = "invalid"
+	RowDuplicate ImportRowStatus = "duplicate"
+	RowFailed    ImportRowStatus = "failed"
+)
+
+// ImportJob is a bulk import of companies and the report on its rows.
+// Error explains why the import as a whole failed.
+type ImportJob struct {
+	Id         uuid.UUID    `json:"id" gorm:"type:uuid;default:uuid_generate_v4()"`
+	OwnerId    string       `json:"ownerId" gorm:"not null;index"`
+	Format     string       `json:"format" gorm:"type:varchar(16);not null"`
+	DryRun     bool         `json:"dryRun" gorm:"not null"`
+	Status     ImportStatus `json:"status" gorm:"type:varchar(16);not null"`
+	Total      int          `json:"total" gorm:"not null;default:0"`
+	Created    int          `json:"created" gorm:"not null;default:0"`
+	Valid      int          `json:"valid" gorm:"not null;default:0"`
+	Invalid    int          `json:"invalid" gorm:"not null;default:0"`
+	Duplicates int          `json:"duplicates" gorm:"not null;default:0"`
+	Failed     int          `json:"failed" gorm:"not null;default:0"`
+	Error      string       `json:"error,omitempty" gorm:"type:text"`
+	Rows       []ImportRow  `json:"rows" gorm:"serializer:json;type:jsonb;not null"`
+	CreatedAt  time.Time    `json:"createdAt"`
+	UpdatedAt  time.Time    `json:"updatedAt"`
+	FinishedAt *time.Time   `json:"finishedAt,omitempty"`
+}
+
+// ImportRow reports the outcome of a single row of an import,
+// Line is the number of the line the row starts at.
+type ImportRow struct {
+	Line      int             `json:"line"`
+	Status    ImportRowStatus `json:"status"`
+	Name      string          `json:"name,omitempty"`
+	CompanyId *uuid.UUID      `json:"companyId,omitempty"`
+	Error     string          `json:"error,omitempty"`
+}
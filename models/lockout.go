@@ -0,0 +1,33 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// LoginFailure counts the failed logins of a user name or an IP address,
+// identified by Key. The count starts over once the failures are old enough.
+type LoginFailure struct {
+	Key          string     `json:"key" gorm:"primaryKey;type:varchar(255)"`
+	Failures     int        `json:"failures" gorm:"not null;default:0"`
+	LastFailedAt time.Time  `json:"lastFailedAt" gorm:"not null"`
+	LockedUntil  *time.Time `json:"lockedUntil"`
+}
+
+type AuditType string
+
+const (
+	AuditLoginLocked   AuditType = "login.locked"
+	AuditLoginUnlocked AuditType = "login.unlocked"
+)
+
+// AuditEvent records an event relevant to the security of accounts. Subject is
+// what the event is about, such as the key of a login failure.
+type AuditEvent struct {
+	Id         uuid.UUID `json:"id" gorm:"type:uuid;default:uuid_generate_v4()"`
+	Type       AuditType `json:"type" gorm:"type:varchar(64);not null;index"`
+	ActorId    string    `json:"actorId" gorm:"not null"`
+	Subject    string    `json:"subject" gorm:"type:varchar(255);not null;index"`
+	Ip         string    `json:"ip" gorm:"type:varchar(64)"`
+	OccurredAt time.Time `json:"occurredAt" gorm:"not null;index"`
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// MFASecret is the TOTP secret of the user. The secret is enrolled unconfirmed
+// and turns on MFA once the user proves to have it with a code.
+type MFASecret struct {
+	UserId      uint       `json:"userId" gorm:"primaryKey;autoIncrement:false"`
+	Secret      string     `json:"-" gorm:"not null"`
+	ConfirmedAt *time.Time `json:"confirmedAt"`
+	// LastStep is the time step of the last accepted code, so a code can't be used twice.
+	LastStep  int64     `json:"-" gorm:"not null;default:0"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// RecoveryCode replaces a TOTP code once, when the user lost the authenticator.
+// Only the hash of the code is stored.
+type RecoveryCode struct {
+	Id       uuid.UUID  `json:"id" gorm:"type:uuid;default:uuid_generate_v4()"`
+	UserId   uint       `json:"userId" gorm:"not null;index"`
+	CodeHash string     `json:"-" gorm:"type:char(64);not null;uniqueIndex"`
+	UsedAt   *time.Time `json:"usedAt"`
+}
+
+// MFAChallenge is issued at login to users with MFA after the password
+// was checked, it's exchanged with a code for the access token.
+type MFAChallenge struct {
+	Id        uuid.UUID `json:"id" gorm:"type:uuid;default:uuid_generate_v4()"`
+	UserId    uint      `json:"userId" gorm:"not null;index"`
+	TokenHash string    `json:"-" gorm:"type:char(64);not null;uniqueIndex"`
+	Attempts  int       `json:"attempts" gorm:"not null;default:0"`
+	ExpiresAt time.Time `json:"expiresAt" gorm:"not null;index"`
+	CreatedAt time.Time `json:"createdAt"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// GrantType is a way of an OAuth client to get tokens.
+type GrantType string
+
+const (
+	// GrantClientCredentials issues tokens of the user of the client with the client secret alone.
+	GrantClientCredentials GrantType = "client_credentials"
+	// GrantPassword issues tokens of the user whose name and password the client sends.
+	GrantPassword GrantType = "password"
+)
+
+// OAuthClient is a service registered to get tokens at /oauth/token. Tokens are
+// limited to the scopes of the client, those of the client credentials grant are
+// issued on behalf of the user of the client. Only the hash of the secret is stored.
+type OAuthClient struct {
+	Id         uuid.UUID   `json:"id" gorm:"type:uuid;default:uuid_generate_v4()"`
+	UserId     uint        `json:"userId" gorm:"not null;index"`
+	Name       string      `json:"name" gorm:"type:varchar(255);not null"`
+	SecretHash string      `json:"-" gorm:"type:char(64);not null"`
+	Scopes     []Scope     `json:"scopes" gorm:"serializer:json;type:jsonb;not null"`
+	Grants     []GrantType `json:"grants" gorm:"serializer:json;type:jsonb;not null"`
+	CreatedAt  time.Time   `json:"createdAt"`
+}
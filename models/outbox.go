@@ -0,0 +1,25 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// OutboxEvent is a company event stored in the same transaction as the change
+// of the company, waiting to be delivered to the event publisher.
+// Events of a company are delivered in the order of Id.
+type OutboxEvent struct {
+	Id            uint64    `json:"id" gorm:"primaryKey;autoIncrement"`
+	EventId       uuid.UUID `json:"eventId" gorm:"type:uuid;not null;uniqueIndex"`
+	CompanyId     uuid.UUID `json:"companyId" gorm:"type:uuid;not null;index"`
+	Type          string    `json:"type" gorm:"type:varchar(64);not null"`
+	Payload       []byte    `json:"payload" gorm:"type:jsonb;not null"`
+	Attempts      int       `json:"attempts" gorm:"not null;default:0"`
+	LastError     string    `json:"lastError" gorm:"type:text"`
+	CreatedAt     time.Time `json:"createdAt" gorm:"not null"`
+	NextAttemptAt time.Time `json:"nextAttemptAt" gorm:"not null;index"`
+}
+
+func (OutboxEvent) TableName() string {
+	return "outbox"
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// CompanyRevision records a change of a company. Revisions of a company are
+// numbered from 1 in the order of changes. Snapshot is the state of the company
+// after the change, or right before it for deletions.
+type CompanyRevision struct {
+	Id        uint64        `json:"-" gorm:"primaryKey;autoIncrement"`
+	CompanyId uuid.UUID     `json:"companyId" gorm:"type:uuid;not null;uniqueIndex:idx_company_revisions_revision"`
+	Revision  int           `json:"revision" gorm:"not null;uniqueIndex:idx_company_revisions_revision"`
+	Action    string        `json:"action" gorm:"type:varchar(64);not null"`
+	ActorId   string        `json:"actorId" gorm:"type:varchar(64)"`
+	Diff      []FieldChange `json:"diff" gorm:"serializer:json;type:jsonb;not null"`
+	Snapshot  Company       `json:"snapshot" gorm:"serializer:json;type:jsonb;not null"`
+	CreatedAt time.Time     `json:"createdAt" gorm:"not null"`
+}
+
+// FieldChange is a change of a single field of a company, by its JSON name.
+// Old is empty for created companies and New is empty for deleted ones.
+type FieldChange struct {
+	Field string      `json:"field"`
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// RefreshToken can be exchanged once for a new access token and the next refresh token
+// of the same family. A family starts at login, only hashes of tokens are stored.
+type RefreshToken struct {
+	Id        uuid.UUID  `json:"id" gorm:"type:uuid;default:uuid_generate_v4()"`
+	FamilyId  uuid.UUID  `json:"familyId" gorm:"type:uuid;not null;index"`
+	UserId    uint       `json:"userId" gorm:"not null;index"`
+	TokenHash string     `json:"-" gorm:"type:char(64);not null;uniqueIndex"`
+	ExpiresAt time.Time  `json:"expiresAt" gorm:"not null;index"`
+	UsedAt    *time.Time `json:"usedAt"`
+	RevokedAt *time.Time `json:"revokedAt"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+// RevokedToken is an access token revoked before it expires, identified by its jti.
+// It's kept until the token expires anyway.
+type RevokedToken struct {
+	Jti       string    `json:"jti" gorm:"primaryKey;type:varchar(64)"`
+	ExpiresAt time.Time `json:"expiresAt" gorm:"not null;index"`
+}
+
+// PasswordResetToken allows to set the password of the user once, without the current password.
+// Only the hash of the token is stored.
+type PasswordResetToken struct {
+	Id        uuid.UUID  `json:"id" gorm:"type:uuid;default:uuid_generate_v4()"`
+	UserId    uint       `json:"userId" gorm:"not null;index"`
+	TokenHash string     `json:"-" gorm:"type:char(64);not null;uniqueIndex"`
+	ExpiresAt time.Time  `json:"expiresAt" gorm:"not null"`
+	UsedAt    *time.Time `json:"usedAt"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
@@ -1,7 +1,47 @@
 package models
 
+import "time"
+
+type Role string
+
+const (
+	RoleViewer Role = "viewer"
+	RoleEditor Role = "editor"
+	RoleAdmin  Role = "admin"
+)
+
+// roleLevels orders the roles, every role is granted all permissions of the roles below it.
+var roleLevels = map[Role]int{
+	RoleViewer: 1,
+	RoleEditor: 2,
+	RoleAdmin:  3,
+}
+
+// Valid reports whether the role is one of the known roles.
+func (r Role) Valid() bool {
+	_, ok := roleLevels[r]
+	return ok
+}
+
+// Includes reports whether the role is granted the permissions of the other role.
+func (r Role) Includes(other Role) bool {
+	return r.Valid() && roleLevels[r] >= roleLevels[other]
+}
+
 type User struct {
 	Id           uint   `json:"id"`
 	Name         string `json:"name" gorm:"not null;unique"`
-	PasswordHash string `json:"passwordHash"`
+	PasswordHash string `json:"-"`
+	// Role defaults to editor, so users created before roles keep their access.
+	Role Role `json:"role" gorm:"type:varchar(16);not null;default:editor"`
+	// MFAEnabled requires a TOTP or recovery code after the password at login.
+	MFAEnabled bool `json:"mfaEnabled" gorm:"not null;default:false"`
+	// DisabledAt is set while the user is disabled by an admin, disabled users
+	// can't log in and their tokens and API keys are rejected.
+	DisabledAt *time.Time `json:"disabledAt,omitempty"`
+}
+
+// Disabled reports whether the user was disabled by an admin.
+func (u User) Disabled() bool {
+	return u.DisabledAt != nil
 }
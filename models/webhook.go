@@ -0,0 +1,56 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliverySucceeded DeliveryStatus = "succeeded"
+	DeliveryFailed    DeliveryStatus = "failed"
+)
+
+// WebhookSubscription asks to push company events to Url. Empty EventTypes
+// or CompanyTypes mean events of any type or companies of any type.
+type WebhookSubscription struct {
+	Id           uuid.UUID     `json:"id" gorm:"type:uuid;default:uuid_generate_v4()"`
+	OwnerId      string        `json:"ownerId" gorm:"not null;index"`
+	Url          string        `json:"url" gorm:"not null;type:varchar(2048)"`
+	Secret       string        `json:"-" gorm:"not null"`
+	EventTypes   []string      `json:"eventTypes" gorm:"serializer:json;type:jsonb;not null"`
+	CompanyTypes []TypeAllowed `json:"companyTypes" gorm:"serializer:json;type:jsonb;not null"`
+	Active       bool          `json:"active" gorm:"not null;index"`
+	CreatedAt    time.Time     `json:"createdAt"`
+	UpdatedAt    time.Time     `json:"updatedAt"`
+}
+
+// WebhookDelivery is a company event to be delivered to a subscription.
+type WebhookDelivery struct {
+	Id             uint64           `json:"id" gorm:"primaryKey;autoIncrement"`
+	SubscriptionId uuid.UUID        `json:"subscriptionId" gorm:"type:uuid;not null;uniqueIndex:idx_webhook_deliveries_event"`
+	EventId        uuid.UUID        `json:"eventId" gorm:"type:uuid;not null;uniqueIndex:idx_webhook_deliveries_event"`
+	EventType      string           `json:"eventType" gorm:"type:varchar(64);not null"`
+	Payload        []byte           `json:"-" gorm:"type:jsonb;not null"`
+	Status         DeliveryStatus   `json:"status" gorm:"type:varchar(16);not null;index"`
+	Attempts       int              `json:"attempts" gorm:"not null;default:0"`
+	NextAttemptAt  time.Time        `json:"nextAttemptAt" gorm:"not null;index"`
+	CreatedAt      time.Time        `json:"createdAt"`
+	UpdatedAt      time.Time        `json:"updatedAt"`
+	Log            []WebhookAttempt `json:"log" gorm:"foreignKey:DeliveryId;constraint:OnDelete:CASCADE"`
+
+	Subscription *WebhookSubscription `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+}
+
+// WebhookAttempt records a single try to deliver an event.
+// StatusCode is zero when no response was received.
+type WebhookAttempt struct {
+	Id          uint64    `json:"-" gorm:"primaryKey;autoIncrement"`
+	DeliveryId  uint64    `json:"-" gorm:"not null;index"`
+	StatusCode  int       `json:"statusCode"`
+	Error       string    `json:"error,omitempty" gorm:"type:text"`
+	DurationMs  int64     `json:"durationMs"`
+	AttemptedAt time.Time `json:"attemptedAt" gorm:"not null"`
+}
@@ -0,0 +1,61 @@
+package auth
+
+import "context"
+
+type contextKey int
+
+const (
+	userIdKey contextKey = iota
+	roleKey
+	tokenIdKey
+	scopesKey
+)
+
+// WithUserId returns a copy of ctx carrying the id of the authorized user.
+func WithUserId(ctx context.Context, userId string) context.Context {
+	return context.WithValue(ctx, userIdKey, userId)
+}
+
+// UserIdFromContext returns the id of the authorized user stored by WithUserId
+// or an empty string for anonymous requests.
+func UserIdFromContext(ctx context.Context) string {
+	userId, _ := ctx.Value(userIdKey).(string)
+	return userId
+}
+
+// WithRole returns a copy of ctx carrying the role of the authorized user.
+func WithRole(ctx context.Context, role string) context.Context {
+	return context.WithValue(ctx, roleKey, role)
+}
+
+// RoleFromContext returns the role stored by WithRole
+// or an empty string for anonymous requests.
+func RoleFromContext(ctx context.Context) string {
+	role, _ := ctx.Value(roleKey).(string)
+	return role
+}
+
+// WithTokenId returns a copy of ctx carrying the jti of the access token of the request.
+func WithTokenId(ctx context.Context, jti string) context.Context {
+	return context.WithValue(ctx, tokenIdKey, jti)
+}
+
+// TokenIdFromContext returns the jti stored by WithTokenId
+// or an empty string for requests without a token.
+func TokenIdFromContext(ctx context.Context) string {
+	jti, _ := ctx.Value(tokenIdKey).(string)
+	return jti
+}
+
+// WithScopes returns a copy of ctx of a request authorized by an API key
+// or an OAuth token, which is limited to the scopes of the key or token.
+func WithScopes(ctx context.Context, scopes []string) context.Context {
+	return context.WithValue(ctx, scopesKey, scopes)
+}
+
+// ScopesFromContext returns the scopes stored by WithScopes,
+// limited is false for requests not authorized by an API key or OAuth token.
+func ScopesFromContext(ctx context.Context) (scopes []string, limited bool) {
+	scopes, limited = ctx.Value(scopesKey).([]string)
+	return
+}
@@ -0,0 +1,284 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"githib.com/dkischenko/company-api/pkg/logger"
+	"github.com/golang-jwt/jwt/v4"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const keyFileExt = ".pem"
+
+// Key is a key tokens are signed or verified with. Keys loaded
+// from public keys only verify tokens.
+type Key struct {
+	Id      string
+	Method  jwt.SigningMethod
+	private crypto.PrivateKey
+	public  crypto.PublicKey
+}
+
+// KeySet holds the keys of tokens. Tokens are signed with the signing key
+// and accepted when signed with any key of the set, so a key is rotated by
+// adding the new key, making it the signing key and removing the old one
+// once the tokens signed with it have expired.
+//
+// Keys are loaded from the PEM files of a directory, the id of a key is the
+// name of its file without the .pem extension. The signing key is the one
+// chosen by id, or the private key with the greatest id. Without the directory
+// tokens are signed with the HS256 secret, which can't be published.
+type KeySet struct {
+	dir       string
+	signingId string
+
+	mu      sync.RWMutex
+	keys    map[string]Key
+	signing Key
+}
+
+var (
+	defaultKeys    *KeySet
+	defaultKeysErr error
+	defaultOnce    sync.Once
+)
+
+// Keys returns the key set of the process loaded on the first call from
+// the directory of JWT_KEYS_DIR, with the signing key JWT_SIGNING_KEY_ID,
+// or from the secret of SIGNINKEY when the directory isn't set.
+func Keys() (*KeySet, error) {
+	defaultOnce.Do(func() {
+		if dir := os.Getenv("JWT_KEYS_DIR"); dir != "" {
+			defaultKeys, defaultKeysErr = LoadKeySet(dir, os.Getenv("JWT_SIGNING_KEY_ID"))
+			return
+		}
+		defaultKeys, defaultKeysErr = NewSecretKeySet([]byte(os.Getenv("SIGNINKEY")))
+	})
+	return defaultKeys, defaultKeysErr
+}
+
+// NewSecretKeySet returns the set signing tokens with the HS256 secret.
+func NewSecretKeySet(secret []byte) (*KeySet, error) {
+	if len(secret) == 0 {
+		return nil, errors.New("empty signin key passed")
+	}
+	key := Key{Method: jwt.SigningMethodHS256, private: secret, public: secret}
+	return &KeySet{keys: map[string]Key{"": key}, signing: key}, nil
+}
+
+// LoadKeySet loads the keys from the directory, signingId chooses the signing key.
+func LoadKeySet(dir, signingId string) (*KeySet, error) {
+	s := &KeySet{dir: dir, signingId: signingId}
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Reload loads the keys from the directory again. The keys in use are kept
+// when the directory can't be loaded. Sets of the secret don't change.
+func (s *KeySet) Reload() error {
+	if s.dir == "" {
+		return nil
+	}
+	keys, signing, err := loadKeys(s.dir, s.signingId)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys, s.signing = keys, signing
+	return nil
+}
+
+// Watch reloads the keys at the interval until ctx is done.
+func (s *KeySet) Watch(ctx context.Context, interval time.Duration, logger *logger.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := s.Reload(); err != nil {
+			logger.Entry.Errorf("failed to reload signing keys: %s", err)
+		}
+	}
+}
+
+// Sign returns the token of the claims signed with the signing key.
+func (s *KeySet) Sign(claims jwt.Claims) (string, error) {
+	s.mu.RLock()
+	key := s.signing
+	s.mu.RUnlock()
+
+	token := jwt.NewWithClaims(key.Method, claims)
+	if key.Id != "" {
+		token.Header["kid"] = key.Id
+	}
+	return token.SignedString(key.private)
+}
+
+// Keyfunc returns the key of the kid of the token for jwt.Parse,
+// as long as the token is signed with the algorithm of the key.
+func (s *KeySet) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	s.mu.RLock()
+	key, ok := s.keys[kid]
+	s.mu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key: %q", kid)
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return key.public, nil
+}
+
+// JWK is a public key in the JSON Web Key format.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Crv string `json:"crv,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public keys of the set ordered by id. The secret isn't published.
+func (s *KeySet) JWKS() JWKS {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	set := JWKS{Keys: []JWK{}}
+	for _, key := range s.keys {
+		if jwk, ok := key.jwk(); ok {
+			set.Keys = append(set.Keys, jwk)
+		}
+	}
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].Kid < set.Keys[j].Kid })
+	return set
+}
+
+func (k Key) jwk() (JWK, bool) {
+	b64 := base64.RawURLEncoding.EncodeToString
+	jwk := JWK{Kid: k.Id, Use: "sig", Alg: k.Method.Alg()}
+	switch pub := k.public.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty, jwk.N, jwk.E = "RSA", b64(pub.N.Bytes()), b64(big.NewInt(int64(pub.E)).Bytes())
+	case *ecdsa.PublicKey:
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		jwk.Kty, jwk.Crv = "EC", pub.Curve.Params().Name
+		jwk.X, jwk.Y = b64(pub.X.FillBytes(make([]byte, size))), b64(pub.Y.FillBytes(make([]byte, size)))
+	case ed25519.PublicKey:
+		jwk.Kty, jwk.Crv, jwk.X = "OKP", "Ed25519", b64(pub)
+	default:
+		return jwk, false
+	}
+	return jwk, true
+}
+
+func loadKeys(dir, signingId string) (keys map[string]Key, signing Key, err error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, signing, fmt.Errorf("can't read keys: %w", err)
+	}
+
+	keys = make(map[string]Key)
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != keyFileExt {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, signing, fmt.Errorf("can't read key %s: %w", entry.Name(), err)
+		}
+		key, err := parseKey(strings.TrimSuffix(entry.Name(), keyFileExt), data)
+		if err != nil {
+			return nil, signing, fmt.Errorf("can't parse key %s: %w", entry.Name(), err)
+		}
+		keys[key.Id] = key
+		if key.private != nil && signingId == "" && key.Id > signing.Id {
+			signing = key
+		}
+	}
+
+	if signingId != "" {
+		signing = keys[signingId]
+	}
+	if signing.private == nil {
+		return nil, signing, fmt.Errorf("no private signing key %q in %s", signingId, dir)
+	}
+	return keys, signing, nil
+}
+
+// parseKey parses the PEM encoded PKCS #8, PKCS #1 or SEC 1 private key
+// or PKIX public key. The algorithm of the key follows from its type.
+func parseKey(id string, data []byte) (key Key, err error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return key, errors.New("no PEM data")
+	}
+
+	key.Id = id
+	switch block.Type {
+	case "PRIVATE KEY":
+		key.private, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key.private, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key.private, err = x509.ParseECPrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		key.public, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return key, fmt.Errorf("unsupported PEM type %s", block.Type)
+	}
+	if err != nil {
+		return key, err
+	}
+	if signer, ok := key.private.(crypto.Signer); ok {
+		key.public = signer.Public()
+	}
+
+	switch pub := key.public.(type) {
+	case *rsa.PublicKey:
+		key.Method = jwt.SigningMethodRS256
+	case *ecdsa.PublicKey:
+		if pub.Curve != elliptic.P256() {
+			return key, fmt.Errorf("unsupported curve %s, use P-256", pub.Curve.Params().Name)
+		}
+		key.Method = jwt.SigningMethodES256
+	case ed25519.PublicKey:
+		key.Method = jwt.SigningMethodEdDSA
+	default:
+		return key, fmt.Errorf("unsupported key type %T", key.public)
+	}
+	return key, nil
+}
@@ -0,0 +1,157 @@
+package auth_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"githib.com/dkischenko/company-api/pkg/auth"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeKey(t *testing.T, dir, id string, key crypto.PrivateKey) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	if err = os.WriteFile(filepath.Join(dir, id+".pem"), data, 0600); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+}
+
+func sign(t *testing.T, keys *auth.KeySet) string {
+	token, err := keys.Sign(jwt.MapClaims{"user_id": "7"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	return token
+}
+
+func kidOf(t *testing.T, token string) string {
+	parsed, _, err := new(jwt.Parser).ParseUnverified(token, jwt.MapClaims{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	return parsed.Header["kid"].(string)
+}
+
+func TestKeySet(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+
+	testCases := []struct {
+		name string
+		key  crypto.PrivateKey
+		alg  string
+		kty  string
+	}{
+		{name: "RS256", key: rsaKey, alg: "RS256", kty: "RSA"},
+		{name: "ES256", key: ecKey, alg: "ES256", kty: "EC"},
+		{name: "EdDSA", key: edKey, alg: "EdDSA", kty: "OKP"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeKey(t, dir, "2024-01", tc.key)
+
+			keys, err := auth.LoadKeySet(dir, "")
+			assert.NoError(t, err)
+			token := sign(t, keys)
+			assert.Equal(t, "2024-01", kidOf(t, token))
+
+			_, err = jwt.Parse(token, keys.Keyfunc)
+			assert.NoError(t, err)
+
+			jwks := keys.JWKS()
+			assert.Len(t, jwks.Keys, 1)
+			assert.Equal(t, "2024-01", jwks.Keys[0].Kid)
+			assert.Equal(t, tc.alg, jwks.Keys[0].Alg)
+			assert.Equal(t, tc.kty, jwks.Keys[0].Kty)
+		})
+	}
+}
+
+func TestKeySet_Rotation(t *testing.T) {
+	dir := t.TempDir()
+	oldKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	writeKey(t, dir, "2024-01", oldKey)
+	keys, err := auth.LoadKeySet(dir, "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	oldToken := sign(t, keys)
+
+	_, newKey, _ := ed25519.GenerateKey(rand.Reader)
+	writeKey(t, dir, "2024-02", newKey)
+	assert.NoError(t, keys.Reload())
+	newToken := sign(t, keys)
+	assert.Equal(t, "2024-02", kidOf(t, newToken))
+	assert.Len(t, keys.JWKS().Keys, 2)
+
+	// tokens of the old key are accepted until the key is retired
+	_, err = jwt.Parse(oldToken, keys.Keyfunc)
+	assert.NoError(t, err)
+
+	assert.NoError(t, os.Remove(filepath.Join(dir, "2024-01.pem")))
+	assert.NoError(t, keys.Reload())
+	_, err = jwt.Parse(oldToken, keys.Keyfunc)
+	assert.Error(t, err)
+	_, err = jwt.Parse(newToken, keys.Keyfunc)
+	assert.NoError(t, err)
+}
+
+func TestKeySet_Errors(t *testing.T) {
+	t.Run("Unknown signing key", func(t *testing.T) {
+		dir := t.TempDir()
+		key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		writeKey(t, dir, "2024-01", key)
+		_, err := auth.LoadKeySet(dir, "2023-12")
+		assert.Error(t, err)
+	})
+
+	t.Run("Unsupported curve", func(t *testing.T) {
+		dir := t.TempDir()
+		key, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+		writeKey(t, dir, "2024-01", key)
+		_, err := auth.LoadKeySet(dir, "")
+		assert.Error(t, err)
+	})
+
+	t.Run("Algorithm of another key", func(t *testing.T) {
+		dir := t.TempDir()
+		key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		writeKey(t, dir, "2024-01", key)
+		keys, err := auth.LoadKeySet(dir, "")
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"user_id": "7"})
+		token.Header["kid"] = "2024-01"
+		signed, _ := token.SignedString([]byte("secret"))
+		_, err = jwt.Parse(signed, keys.Keyfunc)
+		assert.Error(t, err)
+	})
+
+	t.Run("Secret isn't published", func(t *testing.T) {
+		keys, err := auth.NewSecretKeySet([]byte("secret"))
+		assert.NoError(t, err)
+		_, err = jwt.Parse(sign(t, keys), keys.Keyfunc)
+		assert.NoError(t, err)
+		assert.Empty(t, keys.JWKS().Keys)
+
+		_, err = auth.NewSecretKeySet(nil)
+		assert.Error(t, err)
+	})
+}
@@ -1,31 +1,96 @@
 package auth
 
 import (
-	"errors"
+	"fmt"
 	"github.com/golang-jwt/jwt/v4"
-	"os"
+	"github.com/google/uuid"
+	"strings"
 	"time"
 )
 
 type Manager struct {
-	signinKey []byte
-	tokenTTL  time.Duration
+	keys     *KeySet
+	tokenTTL time.Duration
 }
 
+// Claims are the claims of a token issued by the manager.
+type Claims struct {
+	Id        string
+	UserId    string
+	Role      string
+	ClientId  string
+	Scopes    []string
+	Scoped    bool
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
+
+// NewManager creates the manager signing tokens with the key set of the process.
 func NewManager(tokenTTL time.Duration) (*Manager, error) {
-	var key string
-	if key = os.Getenv("SIGNINKEY"); key == "" {
-		return nil, errors.New("empty signin key passed")
+	keys, err := Keys()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Manager{keys: keys, tokenTTL: tokenTTL}, nil
+}
+
+// CreateJWT issues the token of the user with the role the user has now,
+// a change of the role applies to tokens issued after it.
+// Every token gets a unique jti, so it can be revoked before it expires.
+func (m *Manager) CreateJWT(userId, role string) (string, error) {
+	return m.keys.Sign(m.claims(userId, role))
+}
+
+// CreateClientJWT issues the token of the user to the OAuth client,
+// it's limited to the scopes like an API key.
+func (m *Manager) CreateClientJWT(userId, role, clientId string, scopes []string) (string, error) {
+	claims := m.claims(userId, role)
+	claims["client_id"] = clientId
+	claims["scope"] = strings.Join(scopes, " ")
+	return m.keys.Sign(claims)
+}
+
+// ParseJWT verifies the token and returns its claims.
+func (m *Manager) ParseJWT(tokenString string) (claims Claims, err error) {
+	token, err := jwt.Parse(tokenString, m.keys.Keyfunc)
+	if err != nil {
+		return claims, err
+	}
+	mapClaims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return claims, fmt.Errorf("error get user claims from token")
+	}
+	if claims.UserId, ok = mapClaims["user_id"].(string); !ok {
+		return claims, fmt.Errorf("error get user claims from token")
 	}
+	claims.Role, _ = mapClaims["role"].(string)
+	claims.Id, _ = mapClaims["jti"].(string)
+	claims.ClientId, _ = mapClaims["client_id"].(string)
+	var scope string
+	if scope, claims.Scoped = mapClaims["scope"].(string); claims.Scoped {
+		claims.Scopes = strings.Fields(scope)
+	}
+	if iat, ok := mapClaims["iss_at"].(float64); ok {
+		claims.IssuedAt = time.Unix(int64(iat), 0)
+	}
+	if exp, ok := mapClaims["exp"].(float64); ok {
+		claims.ExpiresAt = time.Unix(int64(exp), 0)
+	}
+	return claims, nil
+}
 
-	return &Manager{signinKey: []byte(key), tokenTTL: tokenTTL}, nil
+// TokenTTL returns the time the issued tokens are valid for.
+func (m *Manager) TokenTTL() time.Duration {
+	return m.tokenTTL
 }
 
-func (m *Manager) CreateJWT(userId string) (string, error) {
+func (m *Manager) claims(userId, role string) jwt.MapClaims {
 	claims := jwt.MapClaims{}
 	claims["exp"] = time.Now().Add(m.tokenTTL).Unix()
 	claims["iss_at"] = time.Now().Unix()
+	claims["jti"] = uuid.NewString()
 	claims["user_id"] = userId
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(m.signinKey)
+	claims["role"] = role
+	return claims
 }
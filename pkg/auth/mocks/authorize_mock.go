@@ -34,18 +34,18 @@ func (m *MockAuthorize) EXPECT() *MockAuthorizeMockRecorder {
 }
 
 // CreateJWT mocks base method.
-func (m *MockAuthorize) CreateJWT(userId string) (string, error) {
+func (m *MockAuthorize) CreateJWT(userId, role string) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateJWT", userId)
+	ret := m.ctrl.Call(m, "CreateJWT", userId, role)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateJWT indicates an expected call of CreateJWT.
-func (mr *MockAuthorizeMockRecorder) CreateJWT(userId interface{}) *gomock.Call {
+func (mr *MockAuthorizeMockRecorder) CreateJWT(userId, role interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateJWT", reflect.TypeOf((*MockAuthorize)(nil).CreateJWT), userId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateJWT", reflect.TypeOf((*MockAuthorize)(nil).CreateJWT), userId, role)
 }
 
 // ParseJWT mocks base method.
@@ -0,0 +1,76 @@
+// Package cursor implements opaque pagination tokens
+// signed with HMAC-SHA256, so clients can't forge them.
+package cursor
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+)
+
+var (
+	ErrMalformedToken = errors.New("malformed cursor token")
+	ErrSignature      = errors.New("cursor token signature mismatch")
+)
+
+type Signer struct {
+	signinKey []byte
+}
+
+func NewSigner() (*Signer, error) {
+	var key string
+	if key = os.Getenv("SIGNINKEY"); key == "" {
+		return nil, errors.New("empty signin key passed")
+	}
+
+	return &Signer{signinKey: []byte(key)}, nil
+}
+
+// Encode marshals v to JSON and returns it as URL-safe token
+// with the signature appended after a dot.
+func (s *Signer) Encode(v interface{}) (string, error) {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(payload) + "." +
+		base64.RawURLEncoding.EncodeToString(s.sign(payload)), nil
+}
+
+// Decode verifies the signature of the token created by Encode
+// and unmarshals its payload into v.
+func (s *Signer) Decode(token string, v interface{}) error {
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 {
+		return ErrMalformedToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return ErrMalformedToken
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return ErrMalformedToken
+	}
+	if !hmac.Equal(signature, s.sign(payload)) {
+		return ErrSignature
+	}
+
+	if err := json.Unmarshal(payload, v); err != nil {
+		return ErrMalformedToken
+	}
+
+	return nil
+}
+
+func (s *Signer) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, s.signinKey)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
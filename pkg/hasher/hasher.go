@@ -4,6 +4,8 @@ package hasher
 // hashing passwords
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"golang.org/x/crypto/bcrypt"
 )
@@ -26,3 +28,11 @@ func CheckPasswordHash(hash string, password string) bool {
 
 	return err == nil
 }
+
+// HashToken returns the hex encoded SHA-256 hash of the token. Unlike passwords,
+// generated tokens have enough entropy to be hashed without a salt, so they
+// can be looked up by the hash and checked on every request.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,112 @@
+package password
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var ErrWeakPassword = errors.New("password is too weak")
+
+// Policy is the strength policy of passwords. A password needs at least
+// MinLength characters of at least MinClasses of the classes of lower case
+// letters, upper case letters, digits and other characters, and must not be
+// on the list of breached passwords. A nil policy only rejects empty passwords.
+type Policy struct {
+	MinLength  int
+	MinClasses int
+	breached   map[[sha1.Size]byte]struct{}
+}
+
+// NewPolicy returns the policy with the breached passwords loaded from the file,
+// which is skipped when the path is empty.
+func NewPolicy(minLength, minClasses int, breachedFile string) (*Policy, error) {
+	p := &Policy{MinLength: minLength, MinClasses: minClasses}
+	if breachedFile == "" {
+		return p, nil
+	}
+	if err := p.loadBreached(breachedFile); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// loadBreached reads a password per line. Lines of 40 hex digits, optionally
+// followed by a colon and a count as published by breach databases, are taken
+// as SHA-1 hashes of passwords, other lines as the passwords themselves.
+func (p *Policy) loadBreached(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("can't open breached passwords: %w", err)
+	}
+	defer f.Close()
+
+	p.breached = make(map[[sha1.Size]byte]struct{})
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+		p.breached[breachedKey(line)] = struct{}{}
+	}
+	if err = scanner.Err(); err != nil {
+		return fmt.Errorf("can't read breached passwords: %w", err)
+	}
+	return nil
+}
+
+func breachedKey(line string) (key [sha1.Size]byte) {
+	hash, _, _ := strings.Cut(line, ":")
+	if len(hash) == 2*sha1.Size {
+		if b, err := hex.DecodeString(hash); err == nil {
+			copy(key[:], b)
+			return key
+		}
+	}
+	return sha1.Sum([]byte(line))
+}
+
+// Check returns ErrWeakPassword explaining the first rule the password breaks.
+func (p *Policy) Check(password string) error {
+	if password == "" {
+		return fmt.Errorf("%w: it must not be empty", ErrWeakPassword)
+	}
+	if p == nil {
+		return nil
+	}
+	if utf8.RuneCountInString(password) < p.MinLength {
+		return fmt.Errorf("%w: it must be at least %d characters long", ErrWeakPassword, p.MinLength)
+	}
+	if classes(password) < p.MinClasses {
+		return fmt.Errorf("%w: it must contain at least %d of lower case letters, upper case letters, digits and other characters",
+			ErrWeakPassword, p.MinClasses)
+	}
+	if _, ok := p.breached[sha1.Sum([]byte(password))]; ok {
+		return fmt.Errorf("%w: it is known from a data breach", ErrWeakPassword)
+	}
+	return nil
+}
+
+func classes(password string) int {
+	var lower, upper, digit, other int
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = 1
+		case unicode.IsUpper(r):
+			upper = 1
+		case unicode.IsDigit(r):
+			digit = 1
+		default:
+			other = 1
+		}
+	}
+	return lower + upper + digit + other
+}
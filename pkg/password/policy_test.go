@@ -0,0 +1,57 @@
+package password_test
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"githib.com/dkischenko/company-api/pkg/password"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPolicy_Check(t *testing.T) {
+	sum := sha1.Sum([]byte("Summer2024!"))
+	breached := "Password123\n" + strings.ToUpper(hex.EncodeToString(sum[:])) + ":1024\n"
+	file := filepath.Join(t.TempDir(), "breached.txt")
+	if err := os.WriteFile(file, []byte(breached), 0600); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	policy, err := password.NewPolicy(8, 3, file)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	testCases := []struct {
+		name     string
+		policy   *password.Policy
+		password string
+		wantErr  bool
+	}{
+		{name: "Strong password", policy: policy, password: "correct-Horse-battery"},
+		{name: "Empty password", policy: policy, password: "", wantErr: true},
+		{name: "Too short", policy: policy, password: "aB3!", wantErr: true},
+		{name: "Too few classes", policy: policy, password: "lowercaseonly1", wantErr: true},
+		{name: "Breached password", policy: policy, password: "Password123", wantErr: true},
+		{name: "Breached password hash", policy: policy, password: "Summer2024!", wantErr: true},
+		{name: "Nil policy", password: "x"},
+		{name: "Nil policy empty password", password: "", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.policy.Check(tc.password)
+			if tc.wantErr {
+				assert.ErrorIs(t, err, password.ErrWeakPassword)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestNewPolicy_MissingFile(t *testing.T) {
+	_, err := password.NewPolicy(8, 2, filepath.Join(t.TempDir(), "missing.txt"))
+	assert.Error(t, err)
+}
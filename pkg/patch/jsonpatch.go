@@ -0,0 +1,289 @@
+package patch
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Operation is a single operation of JSON Patch.
+type Operation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// JSONPatch is a sequence of operations applied to the document in order,
+// the document is left untouched unless all of them succeed.
+type JSONPatch struct {
+	ops []operation
+}
+
+type operation struct {
+	op    string
+	path  []string
+	from  []string
+	value interface{}
+}
+
+func ParseJSONPatch(data []byte) (*JSONPatch, error) {
+	var ops []Operation
+	if err := json.Unmarshal(data, &ops); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrMalformedPatch, err)
+	}
+
+	p := &JSONPatch{ops: make([]operation, 0, len(ops))}
+	for i, o := range ops {
+		parsed, err := parseOperation(o)
+		if err != nil {
+			return nil, fmt.Errorf("%w: operation %d: %s", ErrMalformedPatch, i, err)
+		}
+		p.ops = append(p.ops, parsed)
+	}
+	return p, nil
+}
+
+func parseOperation(o Operation) (op operation, err error) {
+	op.op = o.Op
+	if op.path, err = parsePointer(o.Path); err != nil {
+		return op, err
+	}
+
+	switch o.Op {
+	case "add", "replace", "test":
+		if o.Value == nil {
+			return op, fmt.Errorf("%s requires a value", o.Op)
+		}
+		if err = json.Unmarshal(o.Value, &op.value); err != nil {
+			return op, err
+		}
+	case "move", "copy":
+		if op.from, err = parsePointer(o.From); err != nil {
+			return op, err
+		}
+		if o.Op == "move" && isPrefix(op.from, op.path) && len(op.from) < len(op.path) {
+			return op, fmt.Errorf("can't move %q into itself", o.From)
+		}
+	case "remove":
+	default:
+		return op, fmt.Errorf("unknown op %q", o.Op)
+	}
+
+	return op, nil
+}
+
+// parsePointer splits JSON Pointer (RFC 6901) into unescaped reference tokens.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return []string{}, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("path %q must start with /", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(t, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+func isPrefix(prefix, path []string) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *JSONPatch) Apply(doc []byte) ([]byte, error) {
+	var target interface{}
+	if err := json.Unmarshal(doc, &target); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrApplyPatch, err)
+	}
+
+	for i, op := range p.ops {
+		var err error
+		if target, err = op.apply(target); err != nil {
+			return nil, fmt.Errorf("%w: operation %d: %s", ErrApplyPatch, i, err)
+		}
+	}
+
+	return json.Marshal(target)
+}
+
+func (op operation) apply(doc interface{}) (interface{}, error) {
+	switch op.op {
+	case "add":
+		return add(doc, op.path, deepCopy(op.value))
+	case "remove":
+		return remove(doc, op.path)
+	case "replace":
+		if len(op.path) == 0 {
+			return deepCopy(op.value), nil
+		}
+		doc, err := remove(doc, op.path)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, op.path, deepCopy(op.value))
+	case "move":
+		v, err := get(doc, op.from)
+		if err != nil {
+			return nil, err
+		}
+		if doc, err = remove(doc, op.from); err != nil {
+			return nil, err
+		}
+		return add(doc, op.path, v)
+	case "copy":
+		v, err := get(doc, op.from)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, op.path, deepCopy(v))
+	case "test":
+		v, err := get(doc, op.path)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(v, op.value) {
+			return nil, fmt.Errorf("test of /%s failed", strings.Join(op.path, "/"))
+		}
+		return doc, nil
+	}
+	return nil, fmt.Errorf("unknown op %q", op.op)
+}
+
+func get(node interface{}, path []string) (interface{}, error) {
+	for _, key := range path {
+		switch n := node.(type) {
+		case map[string]interface{}:
+			v, ok := n[key]
+			if !ok {
+				return nil, fmt.Errorf("member %q does not exist", key)
+			}
+			node = v
+		case []interface{}:
+			i, err := index(key, len(n)-1)
+			if err != nil {
+				return nil, err
+			}
+			node = n[i]
+		default:
+			return nil, fmt.Errorf("member %q does not exist", key)
+		}
+	}
+	return node, nil
+}
+
+// add returns the node with the value added at the path.
+func add(node interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	key, last := path[0], len(path) == 1
+
+	switch n := node.(type) {
+	case map[string]interface{}:
+		if last {
+			n[key] = value
+			return n, nil
+		}
+		child, ok := n[key]
+		if !ok {
+			return nil, fmt.Errorf("member %q does not exist", key)
+		}
+		child, err := add(child, path[1:], value)
+		n[key] = child
+		return n, err
+	case []interface{}:
+		if last {
+			i := len(n)
+			if key != "-" {
+				var err error
+				if i, err = index(key, len(n)); err != nil {
+					return nil, err
+				}
+			}
+			n = append(n, nil)
+			copy(n[i+1:], n[i:])
+			n[i] = value
+			return n, nil
+		}
+		i, err := index(key, len(n)-1)
+		if err != nil {
+			return nil, err
+		}
+		n[i], err = add(n[i], path[1:], value)
+		return n, err
+	}
+	return nil, fmt.Errorf("member %q does not exist", key)
+}
+
+// remove returns the node without the value at the path.
+func remove(node interface{}, path []string) (interface{}, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf("the whole document can't be removed")
+	}
+	key, last := path[0], len(path) == 1
+
+	switch n := node.(type) {
+	case map[string]interface{}:
+		child, ok := n[key]
+		if !ok {
+			return nil, fmt.Errorf("member %q does not exist", key)
+		}
+		if last {
+			delete(n, key)
+			return n, nil
+		}
+		child, err := remove(child, path[1:])
+		n[key] = child
+		return n, err
+	case []interface{}:
+		i, err := index(key, len(n)-1)
+		if err != nil {
+			return nil, err
+		}
+		if last {
+			return append(n[:i], n[i+1:]...), nil
+		}
+		n[i], err = remove(n[i], path[1:])
+		return n, err
+	}
+	return nil, fmt.Errorf("member %q does not exist", key)
+}
+
+// index parses the array index of the token, which must not be greater than max.
+func index(token string, max int) (int, error) {
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || i > max || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("index %q is out of bounds", token)
+	}
+	return i, nil
+}
+
+func deepCopy(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		c := make(map[string]interface{}, len(t))
+		for k, v := range t {
+			c[k] = deepCopy(v)
+		}
+		return c
+	case []interface{}:
+		c := make([]interface{}, len(t))
+		for i, v := range t {
+			c[i] = deepCopy(v)
+		}
+		return c
+	}
+	return v
+}
@@ -0,0 +1,67 @@
+// Package patch applies JSON Merge Patch (RFC 7386) and JSON Patch (RFC 6902)
+// documents to JSON documents.
+package patch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+const (
+	MergePatchContentType = "application/merge-patch+json"
+	JSONPatchContentType  = "application/json-patch+json"
+)
+
+var (
+	// ErrMalformedPatch means the patch document itself is wrong.
+	ErrMalformedPatch = errors.New("malformed patch")
+	// ErrApplyPatch means the patch is valid but does not fit the document.
+	ErrApplyPatch = errors.New("patch can't be applied")
+)
+
+// Patch changes a JSON document.
+type Patch interface {
+	Apply(doc []byte) ([]byte, error)
+}
+
+// MergePatch replaces the members of the document present in the patch
+// and removes the members which are null in the patch.
+type MergePatch struct {
+	patch interface{}
+}
+
+func ParseMergePatch(data []byte) (*MergePatch, error) {
+	p := &MergePatch{}
+	if err := json.Unmarshal(data, &p.patch); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrMalformedPatch, err)
+	}
+	return p, nil
+}
+
+func (p *MergePatch) Apply(doc []byte) ([]byte, error) {
+	var target interface{}
+	if err := json.Unmarshal(doc, &target); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrApplyPatch, err)
+	}
+	return json.Marshal(merge(target, p.patch))
+}
+
+func merge(target, patch interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	t, ok := target.(map[string]interface{})
+	if !ok {
+		t = map[string]interface{}{}
+	}
+	for k, v := range p {
+		if v == nil {
+			delete(t, k)
+		} else {
+			t[k] = merge(t[k], v)
+		}
+	}
+	return t
+}
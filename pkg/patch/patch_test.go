@@ -0,0 +1,95 @@
+package patch_test
+
+import (
+	"githib.com/dkischenko/company-api/pkg/patch"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+const doc = `{"name":"Big company","registered":true,"tags":["a","b"],"address":{"city":"Riga","zip":"1010"}}`
+
+func TestMergePatch(t *testing.T) {
+	p, err := patch.ParseMergePatch([]byte(`{"registered":false,"address":{"zip":null},"tags":["c"]}`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	patched, err := p.Apply([]byte(doc))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"name":"Big company","registered":false,"tags":["c"],"address":{"city":"Riga"}}`, string(patched))
+
+	_, err = patch.ParseMergePatch([]byte(`{"registered":`))
+	assert.ErrorIs(t, err, patch.ErrMalformedPatch)
+}
+
+func TestJSONPatch(t *testing.T) {
+	testCases := []struct {
+		name  string
+		patch string
+		want  string
+		err   error
+	}{
+		{
+			name:  "Replace and remove",
+			patch: `[{"op":"replace","path":"/registered","value":false},{"op":"remove","path":"/address/zip"}]`,
+			want:  `{"name":"Big company","registered":false,"tags":["a","b"],"address":{"city":"Riga"}}`,
+		},
+		{
+			name:  "Add to arrays",
+			patch: `[{"op":"add","path":"/tags/0","value":"z"},{"op":"add","path":"/tags/-","value":"y"}]`,
+			want:  `{"name":"Big company","registered":true,"tags":["z","a","b","y"],"address":{"city":"Riga","zip":"1010"}}`,
+		},
+		{
+			name:  "Move and copy",
+			patch: `[{"op":"move","from":"/address/city","path":"/city"},{"op":"copy","from":"/tags","path":"/labels"}]`,
+			want:  `{"name":"Big company","registered":true,"tags":["a","b"],"labels":["a","b"],"city":"Riga","address":{"zip":"1010"}}`,
+		},
+		{
+			name:  "Passed test",
+			patch: `[{"op":"test","path":"/tags/1","value":"b"},{"op":"remove","path":"/tags"}]`,
+			want:  `{"name":"Big company","registered":true,"address":{"city":"Riga","zip":"1010"}}`,
+		},
+		{
+			name:  "Failed test",
+			patch: `[{"op":"test","path":"/name","value":"Small company"}]`,
+			err:   patch.ErrApplyPatch,
+		},
+		{
+			name:  "Missing member",
+			patch: `[{"op":"replace","path":"/description","value":"Makes things"}]`,
+			err:   patch.ErrApplyPatch,
+		},
+		{
+			name:  "Index out of bounds",
+			patch: `[{"op":"add","path":"/tags/3","value":"c"}]`,
+			err:   patch.ErrApplyPatch,
+		},
+		{
+			name:  "Unknown op",
+			patch: `[{"op":"merge","path":"/name","value":"Small company"}]`,
+			err:   patch.ErrMalformedPatch,
+		},
+		{
+			name:  "Missing value",
+			patch: `[{"op":"add","path":"/name"}]`,
+			err:   patch.ErrMalformedPatch,
+		},
+	}
+
+	for _, tcase := range testCases {
+		t.Run(tcase.name, func(t *testing.T) {
+			p, err := patch.ParseJSONPatch([]byte(tcase.patch))
+			if err == nil {
+				var patched []byte
+				patched, err = p.Apply([]byte(doc))
+				if tcase.err == nil {
+					assert.JSONEq(t, tcase.want, string(patched))
+				}
+			}
+			if tcase.err != nil {
+				assert.ErrorIs(t, err, tcase.err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package totp_test
+
+import (
+	"encoding/base32"
+	"githib.com/dkischenko/company-api/pkg/totp"
+	"github.com/stretchr/testify/assert"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// secret is the SHA-1 key of the test vectors of RFC 6238.
+var secret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestCode(t *testing.T) {
+	testCases := []struct {
+		unix int64
+		code string
+	}{
+		{unix: 59, code: "287082"},
+		{unix: 1111111109, code: "081804"},
+		{unix: 1111111111, code: "050471"},
+		{unix: 1234567890, code: "005924"},
+		{unix: 2000000000, code: "279037"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.code, func(t *testing.T) {
+			code, err := totp.Code(secret, totp.Step(time.Unix(tc.unix, 0)))
+			assert.NoError(t, err)
+			assert.Equal(t, tc.code, code)
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	code, _ := totp.Code(secret, totp.Step(now))
+
+	step, ok, err := totp.Validate(secret, code, now.Add(totp.Period), 1)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, totp.Step(now), step)
+
+	_, ok, _ = totp.Validate(secret, code, now.Add(2*totp.Period), 1)
+	assert.False(t, ok)
+	_, ok, _ = totp.Validate(secret, "12345", now, 1)
+	assert.False(t, ok)
+
+	_, _, err = totp.Validate("not base32!", code, now, 1)
+	assert.ErrorIs(t, err, totp.ErrMalformedSecret)
+}
+
+func TestGenerateSecret(t *testing.T) {
+	s, err := totp.GenerateSecret()
+	assert.NoError(t, err)
+	assert.Len(t, s, 32)
+	_, err = totp.Code(s, 1)
+	assert.NoError(t, err)
+}
+
+func TestURI(t *testing.T) {
+	u, err := url.Parse(totp.URI("company-api", "bob", secret))
+	assert.NoError(t, err)
+	assert.Equal(t, "otpauth", u.Scheme)
+	assert.Equal(t, "totp", u.Host)
+	assert.Equal(t, "/company-api:bob", u.Path)
+	assert.Equal(t, secret, u.Query().Get("secret"))
+	assert.Equal(t, "company-api", u.Query().Get("issuer"))
+}